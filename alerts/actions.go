@@ -0,0 +1,144 @@
+package alerts
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/parser"
+)
+
+const (
+	// builtin action that logs matched rule
+	ACTION_LOG = "log"
+)
+
+var (
+	ErrActionAlreadyRegistered = errors.New("alert action already registered")
+	ErrActionNotFound          = errors.New("alert action not found")
+)
+
+var (
+	Actions = NewActionRegistry()
+)
+
+func init() {
+	RegisterAction(ACTION_LOG, "Writes alert to log", func() Action { return &LogAction{} })
+}
+
+/*
+Notification is passed to actions when alert rule matches
+*/
+type Notification struct {
+	Rule       *models.AlertRule
+	Action     *models.AlertAction
+	Raw        *parser.RawEvent
+	Event      *models.Event
+	EventGroup *models.EventGroup
+}
+
+/*
+Action is performed when alert rule matches
+*/
+type Action interface {
+	// validates options of action when alert rule is saved
+	Validate(options map[string]string) error
+
+	// performs action
+	Perform(context *context.Context, notification *Notification) error
+}
+
+/*
+action constructor function
+*/
+type ActionFunc func() Action
+
+// registers action to default registry
+func RegisterAction(id, description string, f ActionFunc) error {
+	return Actions.Register(id, description, f)
+}
+
+/*
+ActionRegistry holds all available actions
+*/
+func NewActionRegistry() *ActionRegistry {
+	return &ActionRegistry{
+		actions: map[string]*ActionRegistryItem{},
+	}
+}
+
+type ActionRegistry struct {
+	actions map[string]*ActionRegistryItem
+	mutex   sync.RWMutex
+}
+
+type ActionRegistryItem struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	f           ActionFunc
+}
+
+func (a *ActionRegistry) Register(id, description string, f ActionFunc) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if _, ok := a.actions[id]; ok {
+		return ErrActionAlreadyRegistered
+	}
+	a.actions[id] = &ActionRegistryItem{ID: id, Description: description, f: f}
+	return nil
+}
+
+// returns new action instance
+func (a *ActionRegistry) Get(id string) (Action, error) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	item, ok := a.actions[id]
+	if !ok {
+		return nil, ErrActionNotFound
+	}
+	return item.f(), nil
+}
+
+// returns registered actions sorted by id
+func (a *ActionRegistry) Items() (result []*ActionRegistryItem) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	result = make([]*ActionRegistryItem, 0, len(a.actions))
+	for _, item := range a.actions {
+		result = append(result, item)
+	}
+	sort.Sort(actionRegistryItems(result))
+	return
+}
+
+/*
+Validates action, action must be registered and options valid
+*/
+func (a *ActionRegistry) Validate(action *models.AlertAction) (err error) {
+	var instance Action
+	if instance, err = a.Get(action.Type); err != nil {
+		return
+	}
+	return instance.Validate(action.Options)
+}
+
+type actionRegistryItems []*ActionRegistryItem
+
+func (a actionRegistryItems) Len() int           { return len(a) }
+func (a actionRegistryItems) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a actionRegistryItems) Less(i, j int) bool { return a[i].ID < a[j].ID }
+
+/*
+LogAction writes matched alert rule to log
+*/
+type LogAction struct{}
+
+func (l *LogAction) Validate(options map[string]string) error { return nil }
+func (l *LogAction) Perform(context *context.Context, notification *Notification) error {
+	glog.Infof("alerts: rule %s matched eventgroup %s (event %s).",
+		notification.Rule.Name, notification.EventGroup.ID, notification.Event.EventID)
+	return nil
+}
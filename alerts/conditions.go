@@ -0,0 +1,70 @@
+package alerts
+
+import (
+	"time"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/parser"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+ConditionFunc returns whether condition matches notification
+*/
+type ConditionFunc func(context *context.Context, condition *models.AlertCondition, notification *Notification) (bool, error)
+
+var (
+	Conditions = map[string]ConditionFunc{
+		models.ALERT_CONDITION_NEW_EVENTGROUP:  ConditionNewEventGroup,
+		models.ALERT_CONDITION_REGRESSION:      ConditionRegression,
+		models.ALERT_CONDITION_LEVEL:           ConditionLevel,
+		models.ALERT_CONDITION_TAG:             ConditionTag,
+		models.ALERT_CONDITION_EVENT_FREQUENCY: ConditionEventFrequency,
+		models.ALERT_CONDITION_USER_FREQUENCY:  ConditionUserFrequency,
+	}
+)
+
+// first event of eventgroup
+func ConditionNewEventGroup(context *context.Context, condition *models.AlertCondition, notification *Notification) (bool, error) {
+	return notification.EventGroup.IsNew, nil
+}
+
+// resolved eventgroup occured again
+func ConditionRegression(context *context.Context, condition *models.AlertCondition, notification *Notification) (bool, error) {
+	return notification.EventGroup.IsRegression, nil
+}
+
+// level of event is at or above condition level
+func ConditionLevel(context *context.Context, condition *models.AlertCondition, notification *Notification) (bool, error) {
+	return parser.LevelValue(notification.Raw.Level) >= condition.Level, nil
+}
+
+// event has tag with value matching glob pattern (blank value matches any value)
+func ConditionTag(context *context.Context, condition *models.AlertCondition, notification *Notification) (bool, error) {
+	value, ok := notification.Raw.Tags[condition.Key]
+	if !ok {
+		return false, nil
+	}
+	return condition.Value == "" || utils.GlobMatch(condition.Value, value), nil
+}
+
+// eventgroup has more than count events in last minutes
+func ConditionEventFrequency(context *context.Context, condition *models.AlertCondition, notification *Notification) (bool, error) {
+	since := utils.NowTruncated().Add(-time.Duration(condition.Minutes) * time.Minute)
+	count, err := models.NewEventManager(context).CountSince(notification.EventGroup, since)
+	if err != nil {
+		return false, err
+	}
+	return count > int64(condition.Count), nil
+}
+
+// eventgroup affected more than count users in last minutes
+func ConditionUserFrequency(context *context.Context, condition *models.AlertCondition, notification *Notification) (bool, error) {
+	since := utils.NowTruncated().Add(-time.Duration(condition.Minutes) * time.Minute)
+	count, err := models.NewEventTagManager(context).CountValuesSince(notification.EventGroup, parser.USER_TAG, since)
+	if err != nil {
+		return false, err
+	}
+	return count > int64(condition.Count), nil
+}
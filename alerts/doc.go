@@ -0,0 +1,19 @@
+/*
+Package alerts provides rules engine for alert rules.
+
+Every processed event is evaluated against enabled alert rules of project.
+When rule conditions match, rule actions are performed. Actions are registered
+in action registry so plugins can add their own actions, e.g.
+
+	type PagerAction struct{}
+
+	func (p *PagerAction) Validate(options map[string]string) error { return nil }
+
+	func (p *PagerAction) Perform(ctx *context.Context, n *alerts.Notification) error {
+		// call pager api
+		return nil
+	}
+
+	alerts.RegisterAction("pager", "Sends page", func() alerts.Action { return &PagerAction{} })
+*/
+package alerts
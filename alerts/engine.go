@@ -0,0 +1,121 @@
+package alerts
+
+import (
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/parser"
+)
+
+/*
+Engine evaluates alert rules for processed events
+*/
+func NewEngine(context *context.Context) *Engine {
+	return &Engine{context: context, actions: Actions}
+}
+
+type Engine struct {
+	context *context.Context
+	actions *ActionRegistry
+}
+
+/*
+Evaluates all enabled rules of event project and performs actions of matched
+rules, muted eventgroups are skipped. Errors of single rule are logged so
+other rules are still evaluated.
+*/
+func (e *Engine) Evaluate(raw *parser.RawEvent, event *models.Event, eventgroup *models.EventGroup) (err error) {
+	// muted eventgroups never alert
+	if eventgroup.Status == models.EVENT_GROUP_STATUS_MUTED {
+		return
+	}
+
+	manager := models.NewAlertRuleManager(e.context)
+	rules := manager.NewAlertRuleList()
+
+	if err = manager.FilterEnabled(&rules, eventgroup.ProjectID); err != nil {
+		return
+	}
+
+	for _, rule := range rules {
+		notification := &Notification{
+			Rule:       rule,
+			Raw:        raw,
+			Event:      event,
+			EventGroup: eventgroup,
+		}
+
+		var matched bool
+		if matched, err = e.Match(notification); err != nil {
+			glog.Errorf("alerts: rule %s evaluation failed: %s.", rule, err)
+			continue
+		}
+		if !matched {
+			continue
+		}
+
+		// rule already notified about this eventgroup recently
+		var throttled bool
+		if throttled, err = manager.IsThrottled(rule, eventgroup); err != nil {
+			glog.Errorf("alerts: rule %s throttle failed: %s.", rule, err)
+			continue
+		}
+		if throttled {
+			glog.V(2).Infof("alerts: rule %s throttled for %s.", rule, eventgroup)
+			continue
+		}
+
+		e.Perform(notification)
+	}
+
+	return nil
+}
+
+/*
+Returns whether rule conditions match notification
+*/
+func (e *Engine) Match(notification *Notification) (matched bool, err error) {
+	rule := notification.Rule
+	if len(rule.Conditions) == 0 {
+		return false, nil
+	}
+
+	for _, condition := range rule.Conditions {
+		f, ok := Conditions[condition.Type]
+		if !ok {
+			glog.Warningf("alerts: unknown condition %s in rule %s.", condition.Type, rule)
+			matched = false
+		} else if matched, err = f(e.context, condition, notification); err != nil {
+			return
+		}
+
+		if rule.MatchAll && !matched {
+			return false, nil
+		}
+		if !rule.MatchAll && matched {
+			return true, nil
+		}
+	}
+
+	return rule.MatchAll, nil
+}
+
+/*
+Performs all actions of rule, failed action does not stop other actions
+*/
+func (e *Engine) Perform(notification *Notification) {
+	for _, item := range notification.Rule.Actions {
+		action, err := e.actions.Get(item.Type)
+		if err != nil {
+			glog.Errorf("alerts: rule %s action %s: %s.", notification.Rule, item.Type, err)
+			continue
+		}
+
+		n := *notification
+		n.Action = item
+
+		if err = action.Perform(e.context, &n); err != nil {
+			glog.Errorf("alerts: rule %s action %s failed: %s.", notification.Rule, item.Type, err)
+		}
+	}
+}
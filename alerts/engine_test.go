@@ -0,0 +1,83 @@
+package alerts
+
+import (
+	"testing"
+
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/parser"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEngine(t *testing.T) {
+
+	newNotification := func(rule *models.AlertRule) *Notification {
+		raw := parser.NewRawEvent()
+		raw.Level = "error"
+		raw.Tags["browser.name"] = "Chrome"
+		return &Notification{
+			Rule:       rule,
+			Raw:        raw,
+			Event:      models.NewEvent(),
+			EventGroup: models.NewEventGroup(func(eg *models.EventGroup) { eg.IsNew = true }),
+		}
+	}
+
+	Convey("Test match all conditions", t, func() {
+		engine := NewEngine(nil)
+		rule := models.NewAlertRule(func(ar *models.AlertRule) {
+			ar.Conditions = models.AlertConditionList{
+				{Type: models.ALERT_CONDITION_NEW_EVENTGROUP},
+				{Type: models.ALERT_CONDITION_LEVEL, Level: parser.LEVEL_WARNING},
+				{Type: models.ALERT_CONDITION_TAG, Key: "browser.name", Value: "Chr*"},
+			}
+		})
+
+		matched, err := engine.Match(newNotification(rule))
+		So(err, ShouldBeNil)
+		So(matched, ShouldBeTrue)
+
+		rule.Conditions = append(rule.Conditions, &models.AlertCondition{Type: models.ALERT_CONDITION_REGRESSION})
+		matched, err = engine.Match(newNotification(rule))
+		So(err, ShouldBeNil)
+		So(matched, ShouldBeFalse)
+	})
+
+	Convey("Test match any condition", t, func() {
+		engine := NewEngine(nil)
+		rule := models.NewAlertRule(func(ar *models.AlertRule) {
+			ar.MatchAll = false
+			ar.Conditions = models.AlertConditionList{
+				{Type: models.ALERT_CONDITION_REGRESSION},
+				{Type: models.ALERT_CONDITION_LEVEL, Level: parser.LEVEL_FATAL},
+			}
+		})
+
+		matched, err := engine.Match(newNotification(rule))
+		So(err, ShouldBeNil)
+		So(matched, ShouldBeFalse)
+
+		rule.Conditions = append(rule.Conditions, &models.AlertCondition{Type: models.ALERT_CONDITION_TAG, Key: "browser.name"})
+		matched, err = engine.Match(newNotification(rule))
+		So(err, ShouldBeNil)
+		So(matched, ShouldBeTrue)
+	})
+
+	Convey("Test rule without conditions does not match", t, func() {
+		matched, err := NewEngine(nil).Match(newNotification(models.NewAlertRule()))
+		So(err, ShouldBeNil)
+		So(matched, ShouldBeFalse)
+	})
+
+	Convey("Test action registry", t, func() {
+		registry := NewActionRegistry()
+		So(registry.Register("log", "", func() Action { return &LogAction{} }), ShouldBeNil)
+		So(registry.Register("log", "", func() Action { return &LogAction{} }), ShouldEqual, ErrActionAlreadyRegistered)
+
+		_, err := registry.Get("unknown")
+		So(err, ShouldEqual, ErrActionNotFound)
+
+		So(registry.Validate(&models.AlertAction{Type: "log"}), ShouldBeNil)
+		So(registry.Validate(&models.AlertAction{Type: "unknown"}), ShouldEqual, ErrActionNotFound)
+		So(len(registry.Items()), ShouldEqual, 1)
+	})
+}
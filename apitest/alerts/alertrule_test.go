@@ -0,0 +1,98 @@
+package alerts
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/alerts"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAlertRule(t *testing.T) {
+	apitest.Setup()
+
+	user, erruser := apitest.CreateUser(patrol.Context)
+	if erruser != nil {
+		t.FailNow()
+	}
+	project, errproject := apitest.CreateProject(patrol.Context, user)
+	if errproject != nil {
+		t.FailNow()
+	}
+
+	team := models.NewTeam()
+	if err := project.Team(team, patrol.Context); err != nil {
+		t.FailNow()
+	}
+
+	tmm := models.NewTeamMemberManager(patrol.Context)
+	if _, err := tmm.SetTeamMemberType(team, user, models.MEMBER_TYPE_ADMIN); err != nil {
+		t.FailNow()
+	}
+
+	serializer := serializers.AlertsAlertRuleSerializer{
+		Name:      "new errors",
+		Enabled:   true,
+		MatchAll:  true,
+		Frequency: 10,
+		Conditions: models.AlertConditionList{
+			{Type: models.ALERT_CONDITION_NEW_EVENTGROUP},
+		},
+		Actions: models.AlertActionList{
+			{Type: alerts.ACTION_LOG},
+		},
+	}
+
+	Convey("Alert rules - unauthenticated user", t, func() {
+		session := apitest.NewSession()
+		request := session.Request("GET", settings.ROUTE_ALERTS_ALERTRULE_LIST, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Alert rules - invalid action", t, func() {
+		invalid := serializer
+		invalid.Actions = models.AlertActionList{{Type: "unknown"}}
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("POST", settings.ROUTE_ALERTS_ALERTRULE_LIST, "project_id", project.ID.String())
+		So(request.JSONBody(invalid).Do().Response().Code, ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Alert rules - create, update and delete", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("POST", settings.ROUTE_ALERTS_ALERTRULE_LIST, "project_id", project.ID.String())
+		So(request.JSONBody(serializer).Do().Response().Code, ShouldEqual, http.StatusCreated)
+
+		response := struct {
+			Result *models.AlertRule `json:"result"`
+		}{}
+		request.Scan(&response)
+		So(response.Result.Name, ShouldEqual, "new errors")
+
+		rules := []*models.AlertRule{}
+		So(models.NewAlertRuleManager(patrol.Context).FilterEnabled(&rules, project.ID.ToForeignKey()), ShouldBeNil)
+		So(len(rules), ShouldEqual, 1)
+
+		updated := serializer
+		updated.Enabled = false
+		request = session.Request("POST", settings.ROUTE_ALERTS_ALERTRULE_DETAIL, "project_id", project.ID.String(), "alertrule_id", response.Result.ID.String())
+		So(request.JSONBody(updated).Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		rules = []*models.AlertRule{}
+		So(models.NewAlertRuleManager(patrol.Context).FilterEnabled(&rules, project.ID.ToForeignKey()), ShouldBeNil)
+		So(len(rules), ShouldEqual, 0)
+
+		request = session.Request("DELETE", settings.ROUTE_ALERTS_ALERTRULE_DETAIL, "project_id", project.ID.String(), "alertrule_id", response.Result.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+	})
+
+	Convey("Alert actions - list", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("GET", settings.ROUTE_ALERTS_ACTION_LIST)
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+	})
+}
@@ -0,0 +1,182 @@
+package audit
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAuditLog(t *testing.T) {
+	apitest.Setup()
+
+	superuser := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+		user.IsSuperuser = true
+	})
+
+	// returns audit log entries filtered by given query params
+	auditlog := func(session *apitest.Session, code int, params ...string) []*models.AuditLog {
+		request := session.Request("GET", settings.ROUTE_AUDIT_AUDITLOG_LIST)
+		for i := 0; i+1 < len(params); i += 2 {
+			request.SetValue(params[i], params[i+1])
+		}
+		request.Do()
+		So(request.Response().Code, ShouldEqual, code)
+
+		response := struct {
+			Result []*models.AuditLog `json:"result"`
+		}{}
+		if code == http.StatusOK {
+			So(request.Scan(&response).Error(), ShouldBeNil)
+		}
+		return response.Result
+	}
+
+	Convey("Test superuser flag change is recorded", t, func() {
+		user := apitest.NewSession(patrol.Context).WithNewUser().User()
+
+		request := superuser.Request("POST", settings.ROUTE_AUTH_USER_DETAIL, "user_id", user.ID.String()).JSONBody(map[string]interface{}{
+			"email":        user.Email,
+			"name":         user.Name,
+			"is_active":    user.IsActive,
+			"is_superuser": true,
+		}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		result := auditlog(superuser, http.StatusOK,
+			"target_type", models.AUTH_USER_DB_TABLE,
+			"target_id", user.ID.String(),
+		)
+		So(len(result), ShouldEqual, 1)
+		So(result[0].ActorID, ShouldEqual, superuser.User().ID.ToForeignKey())
+		So(result[0].Action, ShouldEqual, models.AUDIT_ACTION_UPDATE)
+		So(result[0].Changes["is_superuser"].Before, ShouldEqual, false)
+		So(result[0].Changes["is_superuser"].After, ShouldEqual, true)
+		So(result[0].TeamID.Valid, ShouldBeFalse)
+
+		// audit log cannot be changed
+		So(result[0].Delete(patrol.Context), ShouldEqual, models.ErrAuditLogAppendOnly)
+	})
+
+	Convey("Test team member removal is recorded and visible to team admin", t, func() {
+		owner := apitest.NewSession(patrol.Context).WithNewUser()
+		member := apitest.NewSession(patrol.Context).WithNewUser()
+
+		team, err := apitest.CreateTeam(patrol.Context, owner.User())
+		So(err, ShouldBeNil)
+
+		membermanager := models.NewTeamMemberManager(patrol.Context)
+		_, err = membermanager.SetTeamMemberType(team, owner.User(), models.MEMBER_TYPE_ADMIN)
+		So(err, ShouldBeNil)
+		teammember, err := membermanager.SetTeamMemberType(team, member.User(), models.MEMBER_TYPE_MEMBER)
+		So(err, ShouldBeNil)
+
+		request := owner.Request("DELETE", settings.ROUTE_TEAMS_TEAMMEMBER_DETAIL, "team_id", team.ID.String(), "teammember_id", teammember.ID.String()).
+			RemoteAddr("192.0.2.1:1234").Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		result := auditlog(owner, http.StatusOK, "team_id", team.ID.String())
+		So(len(result), ShouldEqual, 1)
+		So(result[0].ActorID, ShouldEqual, owner.User().ID.ToForeignKey())
+		So(result[0].Action, ShouldEqual, models.AUDIT_ACTION_DELETE)
+		So(result[0].TargetType, ShouldEqual, models.TEAMS_TEAMMEMBER_DB_TABLE)
+		So(result[0].TargetID, ShouldEqual, teammember.ID.ToForeignKey())
+		So(result[0].IP, ShouldEqual, "192.0.2.1")
+		So(result[0].Changes, ShouldContainKey, "user_id")
+
+		So(len(auditlog(owner, http.StatusOK, "team_id", team.ID.String(), "action", models.AUDIT_ACTION_CREATE)), ShouldEqual, 0)
+		So(len(auditlog(superuser, http.StatusOK, "team_id", team.ID.String())), ShouldEqual, 1)
+
+		// invalid filter
+		auditlog(owner, http.StatusBadRequest, "team_id", team.ID.String(), "action", "unknown")
+		auditlog(owner, http.StatusBadRequest, "team_id", team.ID.String(), "since", "yesterday")
+
+		// removed member cannot read audit log of team
+		auditlog(member, http.StatusForbidden, "team_id", team.ID.String())
+	})
+
+	Convey("Test project settings update is recorded", t, func() {
+		owner := apitest.NewSession(patrol.Context).WithNewUser()
+		project, err := apitest.CreateProject(patrol.Context, owner.User())
+		So(err, ShouldBeNil)
+
+		team := models.NewTeam()
+		So(project.Team(team, patrol.Context), ShouldBeNil)
+		_, err = models.NewTeamMemberManager(patrol.Context).SetTeamMemberType(team, owner.User(), models.MEMBER_TYPE_ADMIN)
+		So(err, ShouldBeNil)
+
+		request := owner.Request("POST", settings.ROUTE_PROJECTS_PROJECT_SCRUBBING, "project_id", project.ID.String()).
+			JSONBody(serializers.ProjectsProjectScrubbingUpdateSerializer{Enabled: true, ScrubIPAddresses: true}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		result := auditlog(owner, http.StatusOK,
+			"team_id", team.ID.String(),
+			"target_type", models.PROJECTS_PROJECTSCRUBBING_DB_TABLE,
+		)
+		So(len(result), ShouldEqual, 1)
+		So(result[0].Action, ShouldEqual, models.AUDIT_ACTION_UPDATE)
+		So(result[0].Changes["scrub_ip_addresses"].After, ShouldEqual, true)
+	})
+
+	Convey("Test personal tokens are recorded", t, func() {
+		user := apitest.NewSession(patrol.Context).WithNewUser()
+
+		request := user.Request("POST", settings.ROUTE_AUTH_PERSONALTOKEN_LIST).
+			JSONBody(serializers.AuthPersonalTokenSerializer{Name: "ci", Scopes: types.StringSlice{models.TOKEN_SCOPE_EVENT_READ}}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusCreated)
+
+		response := struct {
+			Result models.PersonalToken `json:"result"`
+		}{}
+		So(request.Scan(&response).Error(), ShouldBeNil)
+		tokenID := response.Result.ID.String()
+
+		request = user.Request("DELETE", settings.ROUTE_AUTH_PERSONALTOKEN_DETAIL, "personaltoken_id", tokenID).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		result := auditlog(superuser, http.StatusOK,
+			"actor_id", user.User().ID.String(),
+			"target_type", models.AUTH_PERSONALTOKEN_DB_TABLE,
+			"target_id", tokenID,
+		)
+		So(len(result), ShouldEqual, 2)
+
+		actions := []string{result[0].Action, result[1].Action}
+		So(actions, ShouldContain, models.AUDIT_ACTION_CREATE)
+		So(actions, ShouldContain, models.AUDIT_ACTION_DELETE)
+		for _, entry := range result {
+			So(entry.Changes, ShouldContainKey, "token_hash")
+			for _, value := range []interface{}{entry.Changes["token_hash"].Before, entry.Changes["token_hash"].After} {
+				if value != nil {
+					So(value, ShouldEqual, models.AUDIT_REDACTED_VALUE)
+				}
+			}
+		}
+	})
+
+	Convey("Test audit log permissions", t, func() {
+		owner := apitest.NewSession(patrol.Context).WithNewUser()
+		member := apitest.NewSession(patrol.Context).WithNewUser()
+
+		team, err := apitest.CreateTeam(patrol.Context, owner.User())
+		So(err, ShouldBeNil)
+		_, err = models.NewTeamMemberManager(patrol.Context).SetTeamMemberType(team, member.User(), models.MEMBER_TYPE_MEMBER)
+		So(err, ShouldBeNil)
+
+		So(apitest.NewSession(patrol.Context).Request("GET", settings.ROUTE_AUDIT_AUDITLOG_LIST).Do().Response().Code, ShouldEqual, http.StatusUnauthorized)
+
+		// only superuser can read whole audit log
+		auditlog(member, http.StatusForbidden)
+		auditlog(superuser, http.StatusOK)
+
+		// member of team is not admin
+		auditlog(member, http.StatusForbidden, "team_id", team.ID.String())
+		auditlog(member, http.StatusForbidden, "team_id", "0")
+	})
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/utils"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+/*
+Tests ldap backend against local ldap server, test is skipped when LDAP_URL
+is not set. Other variables:
+
+	LDAP_BASE_DN, LDAP_BIND_DN, LDAP_BIND_PASSWORD - directory settings
+	LDAP_USERNAME, LDAP_PASSWORD - existing user in directory
+	LDAP_GROUP - (optional) group dn of user for team mapping
+*/
+func TestAuthLDAPBackend(t *testing.T) {
+	apitest.Setup()
+
+	if os.Getenv("LDAP_URL") == "" {
+		t.Skip("LDAP_URL not set")
+	}
+
+	username := os.Getenv("LDAP_USERNAME")
+	password := os.Getenv("LDAP_PASSWORD")
+	group := os.Getenv("LDAP_GROUP")
+
+	configure := func(backend *models.LDAPAuthBackend) {
+		backend.URL = os.Getenv("LDAP_URL")
+		backend.BaseDN = os.Getenv("LDAP_BASE_DN")
+		backend.BindDN = os.Getenv("LDAP_BIND_DN")
+		backend.BindPassword = os.Getenv("LDAP_BIND_PASSWORD")
+	}
+
+	Convey("Test ldap authenticate", t, func() {
+		backend := models.NewLDAPAuthBackend(patrol.Context, configure)
+
+		_, err := backend.Authenticate(username, "invalid password")
+		So(err, ShouldEqual, models.ErrInvalidCredentials)
+
+		_, err = backend.Authenticate(username, "")
+		So(err, ShouldEqual, models.ErrInvalidCredentials)
+
+		_, err = backend.Authenticate("nonexisting-ldap-user", password)
+		So(err, ShouldEqual, models.ErrInvalidCredentials)
+
+		user, err := backend.Authenticate(username, password)
+		So(err, ShouldBeNil)
+		So(user.ID, ShouldNotEqual, 0)
+		So(user.Username, ShouldEqual, username)
+		So(user.Email, ShouldNotEqual, "")
+		So(user.EmailVerified, ShouldBeTrue)
+
+		// second login finds same user
+		again, err := backend.Authenticate(username, password)
+		So(err, ShouldBeNil)
+		So(again.ID, ShouldEqual, user.ID)
+
+		// local password of directory user is not known
+		_, err = models.NewPasswordAuthBackend(patrol.Context).Authenticate(username, password)
+		So(err, ShouldEqual, models.ErrInvalidCredentials)
+	})
+
+	Convey("Test ldap team mapping", t, func() {
+		if group == "" {
+			SkipSo("LDAP_GROUP not set")
+			return
+		}
+
+		owner := apitest.NewSession(patrol.Context).WithNewUser().User()
+		team, err := apitest.CreateTeam(patrol.Context, owner)
+		So(err, ShouldBeNil)
+
+		other, err := apitest.CreateTeam(patrol.Context, owner)
+		So(err, ShouldBeNil)
+
+		backend := models.NewLDAPAuthBackend(patrol.Context, configure, func(backend *models.LDAPAuthBackend) {
+			backend.TeamMapping = team.ID.String() + ":member:" + group + ";" +
+				team.ID.String() + ":admin:" + group + ";" +
+				other.ID.String() + ":member:cn=nonexisting"
+		})
+
+		user, err := backend.Authenticate(username, password)
+		So(err, ShouldBeNil)
+
+		membermanager := models.NewTeamMemberManager(patrol.Context)
+		mt, err := membermanager.MemberType(team, user)
+		So(err, ShouldBeNil)
+		So(mt, ShouldEqual, models.MEMBER_TYPE_ADMIN)
+
+		_, err = membermanager.MemberType(other, user)
+		So(err, ShouldEqual, models.ErrObjectDoesNotExists)
+	})
+
+	Convey("Test ldap login", t, func() {
+		backends, url := settings.SETTINGS_AUTH_BACKENDS, settings.SETTINGS_LDAP_URL
+		baseDN, bindDN, bindPassword := settings.SETTINGS_LDAP_BASE_DN, settings.SETTINGS_LDAP_BIND_DN, settings.SETTINGS_LDAP_BIND_PASSWORD
+		defer func() {
+			settings.SETTINGS_AUTH_BACKENDS, settings.SETTINGS_LDAP_URL = backends, url
+			settings.SETTINGS_LDAP_BASE_DN, settings.SETTINGS_LDAP_BIND_DN, settings.SETTINGS_LDAP_BIND_PASSWORD = baseDN, bindDN, bindPassword
+		}()
+
+		settings.SETTINGS_AUTH_BACKENDS = models.AUTH_BACKEND_LDAP + "," + models.AUTH_BACKEND_PASSWORD
+		settings.SETTINGS_LDAP_URL = os.Getenv("LDAP_URL")
+		settings.SETTINGS_LDAP_BASE_DN = os.Getenv("LDAP_BASE_DN")
+		settings.SETTINGS_LDAP_BIND_DN = os.Getenv("LDAP_BIND_DN")
+		settings.SETTINGS_LDAP_BIND_PASSWORD = os.Getenv("LDAP_BIND_PASSWORD")
+
+		loginCode := func(username, password string) int {
+			return apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_LOGIN).JSONBody(map[string]string{
+				"username": username,
+				"password": password,
+			}).RemoteAddr(utils.RandomString(8)).Do().Response().Code
+		}
+
+		So(loginCode(username, "invalid password"), ShouldEqual, http.StatusUnauthorized)
+		So(loginCode(username, password), ShouldEqual, http.StatusOK)
+
+		// local users still can log in with password backend
+		localPassword := "password"
+		local := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+			user.IsActive = true
+			user.SetPassword(localPassword)
+		}).User()
+		So(loginCode(local.Username, localPassword), ShouldEqual, http.StatusOK)
+	})
+}
@@ -2,12 +2,14 @@ package auth
 
 import (
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/phonkee/patrol"
 	"github.com/phonkee/patrol/apitest"
 	"github.com/phonkee/patrol/models"
 	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/utils"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -15,9 +17,12 @@ func TestAuthLogin(t *testing.T) {
 	apitest.Setup()
 
 	Convey("Test non existing Login", t, func() {
+		// failed logins are throttled, so use random username and address
 		session := apitest.NewSession(patrol.Context)
-		body := `{"username":"nonexisting", "password": "also"}`
-		request := session.Request("POST", settings.ROUTE_AUTH_LOGIN).StringBody(body)
+		request := session.Request("POST", settings.ROUTE_AUTH_LOGIN).RemoteAddr(utils.RandomString(8)).JSONBody(map[string]string{
+			"username": "nonexisting" + strings.ToLower(utils.RandomString(8)),
+			"password": "also",
+		})
 		So(request.Do().Response().Code, ShouldEqual, http.StatusUnauthorized)
 	})
 
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/utils"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAuthLoginThrottle(t *testing.T) {
+	apitest.Setup()
+
+	password := "password"
+
+	newUser := func() *models.User {
+		return apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+			user.IsActive = true
+			user.SetPassword(password)
+		}).User()
+	}
+
+	login := func(username, password, remote string) *apitest.SessionRequest {
+		return apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_LOGIN).JSONBody(map[string]string{
+			"username": username,
+			"password": password,
+		}).RemoteAddr(remote).Do()
+	}
+
+	throttle := models.NewLoginThrottle(patrol.Context)
+
+	Convey("Test failed logins are delayed", t, func() {
+		user := newUser()
+
+		for i := 0; i < settings.AUTH_LOGIN_THROTTLE_FREE_ATTEMPTS; i++ {
+			So(login(user.Username, "invalid", utils.RandomString(8)).Response().Code, ShouldEqual, http.StatusUnauthorized)
+		}
+		So(login(user.Username, "invalid", utils.RandomString(8)).Response().Code, ShouldEqual, http.StatusUnauthorized)
+
+		// next attempt is delayed even with valid password
+		request := login(user.Username, password, utils.RandomString(8))
+		So(request.Response().Code, ShouldEqual, http.StatusTooManyRequests)
+		So(request.Response().Header().Get("Retry-After"), ShouldNotEqual, "")
+		So(throttle.Failures(user.Username), ShouldEqual, settings.AUTH_LOGIN_THROTTLE_FREE_ATTEMPTS+1)
+
+		// successful login resets failures
+		So(throttle.Succeeded(user.Username), ShouldBeNil)
+		So(login(user.Username, password, utils.RandomString(8)).Response().Code, ShouldEqual, http.StatusOK)
+		So(throttle.Failures(user.Username), ShouldEqual, 0)
+	})
+
+	Convey("Test failed logins are delayed per ip", t, func() {
+		remote := utils.RandomString(8)
+		for i := 0; i <= settings.AUTH_LOGIN_THROTTLE_FREE_ATTEMPTS_PER_IP; i++ {
+			So(login(utils.RandomString(10), "invalid", remote).Response().Code, ShouldEqual, http.StatusUnauthorized)
+		}
+
+		user := newUser()
+		So(login(user.Username, password, remote).Response().Code, ShouldEqual, http.StatusTooManyRequests)
+		So(login(user.Username, password, utils.RandomString(8)).Response().Code, ShouldEqual, http.StatusOK)
+	})
+
+	Convey("Test account lockout and unlock", t, func() {
+		user := newUser()
+
+		// record failures directly, so test does not wait for delays
+		for i := 0; i < settings.SETTINGS_AUTH_LOCKOUT_THRESHOLD; i++ {
+			_, err := throttle.Failed(user.Username, utils.RandomString(8))
+			So(err, ShouldBeNil)
+		}
+		So(throttle.IsLocked(user.Username), ShouldBeTrue)
+
+		request := login(user.Username, password, utils.RandomString(8))
+		So(request.Response().Code, ShouldEqual, http.StatusForbidden)
+
+		// only superuser can unlock
+		request = apitest.NewSession(patrol.Context).WithNewUser().
+			Request("DELETE", settings.ROUTE_AUTH_USER_LOCKOUT, "user_id", user.ID.String()).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusForbidden)
+
+		superuser := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+			user.IsSuperuser = true
+		})
+
+		request = superuser.Request("GET", settings.ROUTE_AUTH_USER_LOCKOUT, "user_id", user.ID.String()).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		response := struct {
+			Result struct {
+				Locked   bool `json:"locked"`
+				Failures int  `json:"failures"`
+			} `json:"result"`
+		}{}
+		So(request.Scan(&response).Error(), ShouldBeNil)
+		So(response.Result.Locked, ShouldBeTrue)
+		So(response.Result.Failures, ShouldEqual, settings.SETTINGS_AUTH_LOCKOUT_THRESHOLD)
+
+		request = superuser.Request("DELETE", settings.ROUTE_AUTH_USER_LOCKOUT, "user_id", user.ID.String()).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+		So(throttle.IsLocked(user.Username), ShouldBeFalse)
+
+		So(login(user.Username, password, utils.RandomString(8)).Response().Code, ShouldEqual, http.StatusOK)
+	})
+}
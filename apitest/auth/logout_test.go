@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// logs in user and returns tokens
+func login(user *models.User, password string) serializers.AuthTokenSerializer {
+	request := apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_LOGIN).JSONBody(map[string]string{
+		"username": user.Username,
+		"password": password,
+	}).Do()
+	So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+	response := struct {
+		Result serializers.AuthTokenSerializer `json:"result"`
+	}{}
+	So(request.Scan(&response).Error(), ShouldBeNil)
+	So(response.Result.Token, ShouldNotEqual, "")
+	So(response.Result.RefreshToken, ShouldNotEqual, "")
+	return response.Result
+}
+
+// refreshes tokens and returns response code and tokens
+func refresh(refreshToken string) (int, serializers.AuthTokenSerializer) {
+	request := apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_REFRESH).JSONBody(map[string]string{
+		"refresh_token": refreshToken,
+	}).Do()
+
+	response := struct {
+		Result serializers.AuthTokenSerializer `json:"result"`
+	}{}
+	request.Scan(&response)
+	return request.Response().Code, response.Result
+}
+
+// returns response code of me view called with token
+func me(token string) int {
+	return apitest.NewSession(patrol.Context).Token(token).Request("GET", settings.ROUTE_AUTH_ME).Do().Response().Code
+}
+
+func TestAuthLogout(t *testing.T) {
+	apitest.Setup()
+
+	password := "password"
+	session := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+		user.IsActive = true
+		user.SetPassword(password)
+	})
+	user := session.User()
+
+	Convey("Test refresh token rotation", t, func() {
+		tokens := login(user, password)
+
+		code, rotated := refresh(tokens.RefreshToken)
+		So(code, ShouldEqual, http.StatusOK)
+		So(rotated.RefreshToken, ShouldNotEqual, tokens.RefreshToken)
+		So(me(rotated.Token), ShouldEqual, http.StatusOK)
+
+		// reused refresh token revokes whole session
+		code, _ = refresh(tokens.RefreshToken)
+		So(code, ShouldEqual, http.StatusUnauthorized)
+		code, _ = refresh(rotated.RefreshToken)
+		So(code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Test invalid refresh token", t, func() {
+		code, _ := refresh("invalid")
+		So(code, ShouldEqual, http.StatusUnauthorized)
+		code, _ = refresh("")
+		So(code, ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Test logout", t, func() {
+		tokens := login(user, password)
+		other := login(user, password)
+
+		request := apitest.NewSession(patrol.Context).Token(tokens.Token).Request("POST", settings.ROUTE_AUTH_LOGOUT).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		So(me(tokens.Token), ShouldEqual, http.StatusUnauthorized)
+		code, _ := refresh(tokens.RefreshToken)
+		So(code, ShouldEqual, http.StatusUnauthorized)
+
+		// other sessions are still valid
+		So(me(other.Token), ShouldEqual, http.StatusOK)
+	})
+
+	Convey("Test logout everywhere", t, func() {
+		tokens := login(user, password)
+		other := login(user, password)
+
+		request := apitest.NewSession(patrol.Context).Token(tokens.Token).Request("POST", settings.ROUTE_AUTH_LOGOUT_ALL).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		So(me(tokens.Token), ShouldEqual, http.StatusUnauthorized)
+		So(me(other.Token), ShouldEqual, http.StatusUnauthorized)
+		code, _ := refresh(other.RefreshToken)
+		So(code, ShouldEqual, http.StatusUnauthorized)
+
+		// new login works
+		So(me(login(user, password).Token), ShouldEqual, http.StatusOK)
+	})
+}
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/oidc/oidctest"
+	"github.com/phonkee/patrol/plugins"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/utils"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAuthOIDC(t *testing.T) {
+	apitest.Setup()
+
+	server := oidctest.NewServer("patrol", "secret")
+	defer server.Close()
+
+	plugin, err := patrol.Plugin(settings.OIDC_PLUGIN_ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := plugin.(*plugins.OIDCPlugin).Provider
+
+	// restore provider settings after test
+	issuer, clientID, clientSecret, domains := provider.Issuer, provider.ClientID, provider.ClientSecret, provider.AllowedDomains
+	defer func() {
+		provider.Issuer, provider.ClientID, provider.ClientSecret = issuer, clientID, clientSecret
+		provider.AllowedDomains = domains
+	}()
+
+	// returns login request and parsed query of final redirect to frontend
+	signIn := func(claims map[string]interface{}, withCookie bool) (*apitest.SessionRequest, url.Values) {
+		login := apitest.NewSession(patrol.Context).Request("GET", settings.ROUTE_AUTH_OIDC_LOGIN).Do()
+		So(login.Response().Code, ShouldEqual, http.StatusFound)
+
+		callback, err := server.Authorize(login.Response().Header().Get("Location"), claims)
+		So(err, ShouldBeNil)
+
+		request := apitest.NewSession(patrol.Context).Request("GET", settings.ROUTE_AUTH_OIDC_CALLBACK)
+		for key := range callback.Query() {
+			request.SetValue(key, callback.Query().Get(key))
+		}
+		if withCookie {
+			for _, cookie := range login.Response().Result().Cookies() {
+				request.Cookie(cookie)
+			}
+		}
+		request.Do()
+		So(request.Response().Code, ShouldEqual, http.StatusFound)
+
+		// values are in query of fragment
+		location := request.Response().Header().Get("Location")
+		So(location, ShouldStartWith, strings.TrimRight(settings.SETTINGS_BASE_URL, "/")+settings.AUTH_OIDC_LOGIN_LINK+"?")
+
+		values, err := url.ParseQuery(location[strings.Index(location, "?")+1:])
+		So(err, ShouldBeNil)
+		return request, values
+	}
+
+	// returns id of user with token
+	meID := func(token string) int64 {
+		request := apitest.NewSession(patrol.Context).Token(token).Request("GET", settings.ROUTE_AUTH_ME).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		response := struct {
+			Result models.User `json:"result"`
+		}{}
+		So(request.Scan(&response).Error(), ShouldBeNil)
+		return response.Result.ID.Int64()
+	}
+
+	Convey("Test oidc not configured", t, func() {
+		provider.Issuer = ""
+		request := apitest.NewSession(patrol.Context).Request("GET", settings.ROUTE_AUTH_OIDC_LOGIN).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusNotFound)
+	})
+
+	provider.Issuer, provider.ClientID, provider.ClientSecret = server.URL, "patrol", "secret"
+	provider.AllowedDomains = []string{}
+
+	Convey("Test oidc creates user", t, func() {
+		email := strings.ToLower(utils.RandomString(10)) + "@example.com"
+		_, values := signIn(map[string]interface{}{
+			"email":          email,
+			"email_verified": true,
+			"name":           "Single Sign-On",
+		}, true)
+		So(values.Get("error"), ShouldEqual, "")
+		So(values.Get("refresh_token"), ShouldNotEqual, "")
+
+		manager := models.NewUserManager(patrol.Context)
+		user := manager.NewUser()
+		So(manager.Get(user, manager.QueryFilterEmail(email)), ShouldBeNil)
+		So(user.Name, ShouldEqual, "Single Sign-On")
+		So(user.EmailVerified, ShouldBeTrue)
+		So(meID(values.Get("token")), ShouldEqual, user.ID.Int64())
+
+		// second sign in links same user
+		_, values = signIn(map[string]interface{}{"email": email, "email_verified": true}, true)
+		So(meID(values.Get("token")), ShouldEqual, user.ID.Int64())
+	})
+
+	Convey("Test oidc links existing user", t, func() {
+		user := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+			user.Email = utils.RandomString(10) + "@example.com"
+			user.EmailVerified = false
+		}).User()
+
+		_, values := signIn(map[string]interface{}{
+			"email":          strings.ToUpper(user.Email),
+			"email_verified": true,
+		}, true)
+		So(meID(values.Get("token")), ShouldEqual, user.ID.Int64())
+	})
+
+	Convey("Test oidc refuses unverified e-mail", t, func() {
+		_, values := signIn(map[string]interface{}{
+			"email":          utils.RandomString(10) + "@example.com",
+			"email_verified": false,
+		}, true)
+		So(values.Get("error"), ShouldEqual, models.ErrEmailNotVerified.Error())
+		So(values.Get("token"), ShouldEqual, "")
+	})
+
+	Convey("Test oidc allowed domains", t, func() {
+		provider.AllowedDomains = []string{"example.org"}
+		defer func() { provider.AllowedDomains = []string{} }()
+
+		_, values := signIn(map[string]interface{}{
+			"email":          utils.RandomString(10) + "@example.com",
+			"email_verified": true,
+		}, true)
+		So(values.Get("error"), ShouldEqual, "email_domain_not_allowed")
+
+		_, values = signIn(map[string]interface{}{
+			"email":          utils.RandomString(10) + "@example.org",
+			"email_verified": true,
+		}, true)
+		So(values.Get("error"), ShouldEqual, "")
+		So(values.Get("token"), ShouldNotEqual, "")
+	})
+
+	Convey("Test oidc asks second factor when required by team", t, func() {
+		owner := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+			user.Email = strings.ToLower(utils.RandomString(10)) + "@example.com"
+		}).User()
+		team, err := apitest.CreateTeam(patrol.Context, owner)
+		So(err, ShouldBeNil)
+
+		superuser := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+			user.IsSuperuser = true
+		})
+		request := superuser.Request("PUT", settings.ROUTE_TEAMS_TEAM_TWOFACTOR, "team_id", team.ID.String()).
+			JSONBody(map[string]bool{"require_two_factor": true}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		_, values := signIn(map[string]interface{}{
+			"email":          owner.Email,
+			"email_verified": true,
+		}, true)
+		So(values.Get("error"), ShouldEqual, "")
+		So(values.Get("token"), ShouldEqual, "")
+		So(values.Get("refresh_token"), ShouldEqual, "")
+		So(values.Get("two_factor_token"), ShouldNotEqual, "")
+		So(values.Get("enroll"), ShouldEqual, "true")
+
+		// challenge continues in second step of login
+		request = apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_LOGIN_TWOFACTOR_ENROLL).
+			JSONBody(map[string]string{"token": values.Get("two_factor_token")}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+	})
+
+	Convey("Test oidc state", t, func() {
+		claims := map[string]interface{}{
+			"email":          utils.RandomString(10) + "@example.com",
+			"email_verified": true,
+		}
+
+		// state must be bound to browser by cookie
+		_, values := signIn(claims, false)
+		So(values.Get("error"), ShouldEqual, "invalid_state")
+
+		// state can be used only once
+		request, values := signIn(claims, true)
+		So(values.Get("token"), ShouldNotEqual, "")
+
+		request.Do()
+		location := request.Response().Header().Get("Location")
+		So(location, ShouldContainSubstring, "error=invalid_state")
+	})
+}
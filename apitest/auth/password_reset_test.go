@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/utils"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAuthPasswordReset(t *testing.T) {
+	apitest.Setup()
+
+	password := "password"
+	session := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+		user.IsActive = true
+		user.SetPassword(password)
+	})
+	user := session.User()
+	manager := models.NewUserManager(patrol.Context)
+
+	// every test run uses different client address so ip rate limits are not shared
+	remote := utils.RandomString(10) + ":1234"
+
+	// requests password reset and returns response code
+	reset := func(email string) int {
+		return apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_PASSWORD_RESET).JSONBody(map[string]string{
+			"email": email,
+		}).RemoteAddr(remote).Do().Response().Code
+	}
+
+	// confirms password reset and returns response code
+	confirm := func(token, newPassword string) int {
+		return apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_PASSWORD_RESET_CONFIRM).JSONBody(map[string]string{
+			"token":    token,
+			"password": newPassword,
+			"retype":   newPassword,
+		}).RemoteAddr(remote).Do().Response().Code
+	}
+
+	Convey("Test password reset request", t, func() {
+		So(reset("invalid"), ShouldEqual, http.StatusBadRequest)
+
+		// unknown and known addresses have same response
+		So(reset(utils.RandomString(10)+"@example.com"), ShouldEqual, http.StatusAccepted)
+		So(reset(user.Email), ShouldEqual, http.StatusAccepted)
+	})
+
+	Convey("Test password reset rate limit", t, func() {
+		email := utils.RandomString(10) + "@example.com"
+		for i := 0; i < settings.AUTH_MAIL_RATE_LIMIT_PER_EMAIL; i++ {
+			So(reset(email), ShouldEqual, http.StatusAccepted)
+		}
+		So(reset(email), ShouldEqual, http.StatusTooManyRequests)
+	})
+
+	Convey("Test password reset confirm", t, func() {
+		tokens := login(user, password)
+
+		So(confirm("invalid", "new password"), ShouldEqual, http.StatusBadRequest)
+
+		// token of other purpose
+		other := manager.NewSignedToken(user, models.SIGNED_TOKEN_EMAIL_VERIFY, settings.AUTH_EMAIL_VERIFY_TOKEN_TTL)
+		So(confirm(other, "new password"), ShouldEqual, http.StatusBadRequest)
+
+		// expired token
+		expired := manager.NewSignedToken(user, models.SIGNED_TOKEN_PASSWORD_RESET, -settings.AUTH_PASSWORD_RESET_TOKEN_TTL)
+		So(confirm(expired, "new password"), ShouldEqual, http.StatusBadRequest)
+
+		token := manager.NewSignedToken(user, models.SIGNED_TOKEN_PASSWORD_RESET, settings.AUTH_PASSWORD_RESET_TOKEN_TTL)
+		So(confirm(token, "new password"), ShouldEqual, http.StatusOK)
+
+		// token can be used only once
+		So(confirm(token, "other password"), ShouldEqual, http.StatusBadRequest)
+
+		// existing tokens are revoked
+		So(me(tokens.Token), ShouldEqual, http.StatusUnauthorized)
+		So(me(login(user, "new password").Token), ShouldEqual, http.StatusOK)
+	})
+}
+
+func TestAuthEmailVerify(t *testing.T) {
+	apitest.Setup()
+
+	password := "password"
+	session := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+		user.IsActive = true
+		user.EmailVerified = false
+		user.SetPassword(password)
+	})
+	user := session.User()
+	manager := models.NewUserManager(patrol.Context)
+
+	remote := utils.RandomString(10) + ":1234"
+
+	// verifies e-mail and returns response code
+	verify := func(token string) int {
+		return apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_EMAIL_VERIFY).JSONBody(map[string]string{
+			"token": token,
+		}).RemoteAddr(remote).Do().Response().Code
+	}
+
+	// returns response code of login
+	loginCode := func() int {
+		return apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_LOGIN).JSONBody(map[string]string{
+			"username": user.Username,
+			"password": password,
+		}).Do().Response().Code
+	}
+
+	Convey("Test resend verification", t, func() {
+		request := apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_EMAIL_VERIFY_RESEND).JSONBody(map[string]string{
+			"email": user.Email,
+		}).RemoteAddr(remote).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusAccepted)
+	})
+
+	Convey("Test e-mail verification", t, func() {
+		So(loginCode(), ShouldEqual, http.StatusForbidden)
+
+		So(verify("invalid"), ShouldEqual, http.StatusBadRequest)
+
+		token := manager.NewSignedToken(user, models.SIGNED_TOKEN_EMAIL_VERIFY, settings.AUTH_EMAIL_VERIFY_TOKEN_TTL)
+		So(verify(token), ShouldEqual, http.StatusOK)
+		So(verify(token), ShouldEqual, http.StatusBadRequest)
+
+		So(loginCode(), ShouldEqual, http.StatusOK)
+	})
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAuthPersonalToken(t *testing.T) {
+	apitest.Setup()
+
+	session := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+		user.IsActive = true
+	})
+	user := session.User()
+
+	project, errproject := apitest.CreateProject(patrol.Context, user)
+	if errproject != nil {
+		t.FailNow()
+	}
+
+	team := models.NewTeam()
+	if err := project.Team(team, patrol.Context); err != nil {
+		t.FailNow()
+	}
+
+	tmm := models.NewTeamMemberManager(patrol.Context)
+	if _, err := tmm.SetTeamMemberType(team, user, models.MEMBER_TYPE_ADMIN); err != nil {
+		t.FailNow()
+	}
+
+	// creates personal token and returns response code and result
+	create := func(body map[string]interface{}) (int, map[string]interface{}) {
+		request := session.Request("POST", settings.ROUTE_AUTH_PERSONALTOKEN_LIST).JSONBody(body).Do()
+		response := struct {
+			Result map[string]interface{} `json:"result"`
+		}{}
+		request.Scan(&response)
+		return request.Response().Code, response.Result
+	}
+
+	// returns response code of project detail called with token
+	projectDetail := func(method, token string) int {
+		request := apitest.NewSession(patrol.Context).Token(token).Request(method, settings.ROUTE_PROJECTS_PROJECT_DETAIL, "project_id", project.ID.String())
+		if method == "POST" {
+			request.JSONBody(map[string]interface{}{"name": project.Name, "platform": project.Platform})
+		}
+		return request.Do().Response().Code
+	}
+
+	Convey("Test create personal token - invalid data", t, func() {
+		code, _ := create(map[string]interface{}{"name": "", "scopes": []string{models.TOKEN_SCOPE_PROJECT_READ}})
+		So(code, ShouldEqual, http.StatusBadRequest)
+
+		code, _ = create(map[string]interface{}{"name": "ci", "scopes": []string{}})
+		So(code, ShouldEqual, http.StatusBadRequest)
+
+		code, _ = create(map[string]interface{}{"name": "ci", "scopes": []string{"unknown"}})
+		So(code, ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Test personal token scopes", t, func() {
+		code, result := create(map[string]interface{}{"name": "ci", "scopes": []string{models.TOKEN_SCOPE_PROJECT_READ}})
+		So(code, ShouldEqual, http.StatusCreated)
+		token, _ := result["token"].(string)
+		So(models.IsPersonalToken(token), ShouldBeTrue)
+		So(result, ShouldNotContainKey, "token_hash")
+
+		So(projectDetail("GET", token), ShouldEqual, http.StatusOK)
+		So(projectDetail("POST", token), ShouldEqual, http.StatusForbidden)
+
+		// personal tokens cannot manage tokens
+		code = apitest.NewSession(patrol.Context).Token(token).Request("GET", settings.ROUTE_AUTH_PERSONALTOKEN_LIST).Do().Response().Code
+		So(code, ShouldBeIn, http.StatusUnauthorized, http.StatusForbidden)
+
+		// list does not return token
+		request := session.Request("GET", settings.ROUTE_AUTH_PERSONALTOKEN_LIST).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+		So(request.Response().Body.String(), ShouldNotContainSubstring, token)
+
+		// revoked token cannot be used anymore
+		id := result["id"].(float64)
+		request = session.Request("DELETE", settings.ROUTE_AUTH_PERSONALTOKEN_DETAIL, "personaltoken_id", types.PrimaryKey(int64(id)).String()).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+		So(projectDetail("GET", token), ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Test invalid personal token", t, func() {
+		So(projectDetail("GET", settings.AUTH_PERSONAL_TOKEN_PREFIX+"invalid"), ShouldEqual, http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/utils"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAuthTwoFactor(t *testing.T) {
+	apitest.Setup()
+
+	password := "password"
+
+	// returns totp code of secret, offset is number of periods from now
+	code := func(secret string, offset int64) string {
+		result, err := utils.HOTP(secret, time.Now().Unix()/int64(settings.AUTH_TOTP_PERIOD/time.Second)+offset, settings.AUTH_TOTP_DIGITS)
+		So(err, ShouldBeNil)
+		return result
+	}
+
+	newUser := func() *models.User {
+		return apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+			user.IsActive = true
+			user.SetPassword(password)
+		}).User()
+	}
+
+	// returns status code of first step of login
+	loginStatus := func(user *models.User) int {
+		request := apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_LOGIN).JSONBody(map[string]string{
+			"username": user.Username,
+			"password": password,
+		}).Do()
+		return request.Response().Code
+	}
+
+	// first step of login, returns challenge
+	login := func(user *models.User) (challenge serializers.AuthTwoFactorChallengeSerializer) {
+		request := apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_LOGIN).JSONBody(map[string]string{
+			"username": user.Username,
+			"password": password,
+		}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusAccepted)
+		So(request.Response().Header().Get(settings.AUTH_TOKEN_HEADER_NAME), ShouldEqual, "")
+
+		response := struct {
+			Result serializers.AuthTwoFactorChallengeSerializer `json:"result"`
+		}{}
+		So(request.Scan(&response).Error(), ShouldBeNil)
+		So(response.Result.TwoFactorToken, ShouldNotEqual, "")
+		return response.Result
+	}
+
+	// second step of login
+	loginCode := func(token, code string) *apitest.SessionRequest {
+		return apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_LOGIN_TWOFACTOR).
+			RemoteAddr(utils.RandomString(8)).
+			JSONBody(map[string]string{"token": token, "code": code}).
+			Do()
+	}
+
+	// enrolls user by self-service endpoints, returns secret and recovery codes
+	enroll := func(user *models.User) (secret string, codes []string) {
+		session := apitest.NewSession(patrol.Context).WithUser(user)
+
+		request := session.Request("POST", settings.ROUTE_AUTH_TWOFACTOR).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		enrollment := struct {
+			Result serializers.AuthTwoFactorEnrollSerializer `json:"result"`
+		}{}
+		So(request.Scan(&enrollment).Error(), ShouldBeNil)
+		So(enrollment.Result.ProvisioningURI, ShouldStartWith, "otpauth://totp/")
+		secret = enrollment.Result.Secret
+
+		// enrollment is not active until confirmed
+		So(loginStatus(user), ShouldEqual, http.StatusOK)
+
+		request = session.Request("POST", settings.ROUTE_AUTH_TWOFACTOR_CONFIRM).JSONBody(map[string]string{"code": "000000"}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusBadRequest)
+
+		// use code of previous period, so current code can be used to login
+		request = session.Request("POST", settings.ROUTE_AUTH_TWOFACTOR_CONFIRM).JSONBody(map[string]string{"code": code(secret, -1)}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		confirmed := struct {
+			Result serializers.AuthTwoFactorRecoveryCodesSerializer `json:"result"`
+		}{}
+		So(request.Scan(&confirmed).Error(), ShouldBeNil)
+		So(len(confirmed.Result.RecoveryCodes), ShouldEqual, settings.AUTH_RECOVERY_CODES_COUNT)
+		return secret, confirmed.Result.RecoveryCodes
+	}
+
+	Convey("Test two-factor login", t, func() {
+		user := newUser()
+		secret, codes := enroll(user)
+
+		challenge := login(user)
+		So(challenge.Enroll, ShouldBeFalse)
+
+		// invalid code and invalid token
+		So(loginCode(challenge.TwoFactorToken, "000000").Response().Code, ShouldEqual, http.StatusBadRequest)
+		So(loginCode("invalid", code(secret, 0)).Response().Code, ShouldEqual, http.StatusBadRequest)
+
+		current := code(secret, 0)
+		request := loginCode(challenge.TwoFactorToken, current)
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+		So(request.Response().Header().Get(settings.AUTH_TOKEN_HEADER_NAME), ShouldNotEqual, "")
+
+		// token is valid only once
+		So(loginCode(challenge.TwoFactorToken, code(secret, 1)).Response().Code, ShouldEqual, http.StatusBadRequest)
+
+		// code cannot be reused
+		challenge = login(user)
+		So(loginCode(challenge.TwoFactorToken, current).Response().Code, ShouldEqual, http.StatusBadRequest)
+
+		// recovery code can be used only once
+		So(loginCode(challenge.TwoFactorToken, codes[0]).Response().Code, ShouldEqual, http.StatusOK)
+		challenge = login(user)
+		So(loginCode(challenge.TwoFactorToken, codes[0]).Response().Code, ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Test two-factor status, recovery codes and disable", t, func() {
+		user := newUser()
+		secret, codes := enroll(user)
+		session := apitest.NewSession(patrol.Context).WithUser(user)
+
+		status := func() serializers.AuthTwoFactorSerializer {
+			request := session.Request("GET", settings.ROUTE_AUTH_TWOFACTOR).Do()
+			So(request.Response().Code, ShouldEqual, http.StatusOK)
+			response := struct {
+				Result serializers.AuthTwoFactorSerializer `json:"result"`
+			}{}
+			So(request.Scan(&response).Error(), ShouldBeNil)
+			return response.Result
+		}
+
+		So(status().Enabled, ShouldBeTrue)
+		So(status().RecoveryCodesLeft, ShouldEqual, settings.AUTH_RECOVERY_CODES_COUNT)
+
+		// already enabled
+		request := session.Request("POST", settings.ROUTE_AUTH_TWOFACTOR).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusBadRequest)
+
+		// new recovery codes replace old ones
+		request = session.Request("POST", settings.ROUTE_AUTH_TWOFACTOR_RECOVERY).JSONBody(map[string]string{"code": codes[0]}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+		So(status().RecoveryCodesLeft, ShouldEqual, settings.AUTH_RECOVERY_CODES_COUNT)
+
+		request = session.Request("DELETE", settings.ROUTE_AUTH_TWOFACTOR).JSONBody(map[string]string{"code": codes[1]}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusBadRequest)
+
+		request = session.Request("DELETE", settings.ROUTE_AUTH_TWOFACTOR).JSONBody(map[string]string{"code": code(secret, 0)}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+		So(status().Enabled, ShouldBeFalse)
+		So(loginStatus(user), ShouldEqual, http.StatusOK)
+	})
+
+	Convey("Test team requires two-factor authentication", t, func() {
+		owner := newUser()
+		member := newUser()
+
+		team, err := apitest.CreateTeam(patrol.Context, owner)
+		So(err, ShouldBeNil)
+		_, err = models.NewTeamMemberManager(patrol.Context).SetTeamMemberType(team, member, models.MEMBER_TYPE_MEMBER)
+		So(err, ShouldBeNil)
+
+		// only superuser can require two-factor authentication
+		request := apitest.NewSession(patrol.Context).WithUser(owner).
+			Request("PUT", settings.ROUTE_TEAMS_TEAM_TWOFACTOR, "team_id", team.ID.String()).
+			JSONBody(map[string]bool{"require_two_factor": true}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusForbidden)
+
+		superuser := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+			user.IsSuperuser = true
+		})
+		request = superuser.Request("PUT", settings.ROUTE_TEAMS_TEAM_TWOFACTOR, "team_id", team.ID.String()).
+			JSONBody(map[string]bool{"require_two_factor": true}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+		defer superuser.Request("PUT", settings.ROUTE_TEAMS_TEAM_TWOFACTOR, "team_id", team.ID.String()).
+			JSONBody(map[string]bool{"require_two_factor": false}).Do()
+
+		// member must enroll during login
+		challenge := login(member)
+		So(challenge.Enroll, ShouldBeTrue)
+
+		So(loginCode(challenge.TwoFactorToken, "000000").Response().Code, ShouldEqual, http.StatusBadRequest)
+
+		request = apitest.NewSession(patrol.Context).Request("POST", settings.ROUTE_AUTH_LOGIN_TWOFACTOR_ENROLL).
+			JSONBody(map[string]string{"token": challenge.TwoFactorToken}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		enrollment := struct {
+			Result serializers.AuthTwoFactorEnrollSerializer `json:"result"`
+		}{}
+		So(request.Scan(&enrollment).Error(), ShouldBeNil)
+
+		// first code confirms enrollment and returns recovery codes
+		request = loginCode(challenge.TwoFactorToken, code(enrollment.Result.Secret, 0))
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		result := struct {
+			Result serializers.AuthLoginTwoFactorResultSerializer `json:"result"`
+		}{}
+		So(request.Scan(&result).Error(), ShouldBeNil)
+		So(result.Result.Token, ShouldNotEqual, "")
+		So(len(result.Result.RecoveryCodes), ShouldEqual, settings.AUTH_RECOVERY_CODES_COUNT)
+
+		// cannot be disabled while required
+		request = apitest.NewSession(patrol.Context).WithUser(member).Request("DELETE", settings.ROUTE_AUTH_TWOFACTOR).
+			JSONBody(map[string]string{"code": result.Result.RecoveryCodes[0]}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusForbidden)
+
+		// owner is required too
+		So(login(owner).Enroll, ShouldBeTrue)
+	})
+}
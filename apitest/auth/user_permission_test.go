@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/utils"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUserPermission(t *testing.T) {
+	apitest.Setup()
+
+	superuser := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+		user.IsActive = true
+		user.IsSuperuser = true
+	})
+	session := apitest.NewSession(patrol.Context).WithNewUser(func(user *models.User) {
+		user.IsActive = true
+		user.IsSuperuser = false
+	})
+	userid := session.User().ID.String()
+
+	// returns response code of team create
+	createTeam := func() int {
+		serializer := serializers.TeamsTeamCreateSerializer{
+			Name: "test team" + utils.RandomString(10),
+		}
+		return session.Request("POST", settings.ROUTE_TEAMS_TEAM_LIST).JSONBody(serializer).Do().Response().Code
+	}
+
+	Convey("Test grant permission by non superuser", t, func() {
+		request := session.Request("POST", settings.ROUTE_AUTH_USER_PERMISSION_LIST, "user_id", userid).JSONBody(map[string]string{
+			"codename": settings.PERMISSION_TEAMS_TEAM_ADD,
+		}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("Test grant unknown permission", t, func() {
+		request := superuser.Request("POST", settings.ROUTE_AUTH_USER_PERMISSION_LIST, "user_id", userid).JSONBody(map[string]string{
+			"codename": "unknown:permission",
+		}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Test grant and revoke permission", t, func() {
+		So(createTeam(), ShouldEqual, http.StatusForbidden)
+
+		request := superuser.Request("POST", settings.ROUTE_AUTH_USER_PERMISSION_LIST, "user_id", userid).JSONBody(map[string]string{
+			"codename": settings.PERMISSION_TEAMS_TEAM_ADD,
+		}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		response := struct {
+			Result []string `json:"result"`
+		}{}
+		So(request.Scan(&response).Error(), ShouldBeNil)
+		So(response.Result, ShouldContain, settings.PERMISSION_TEAMS_TEAM_ADD)
+
+		So(createTeam(), ShouldEqual, http.StatusCreated)
+
+		request = superuser.Request("DELETE", settings.ROUTE_AUTH_USER_PERMISSION_DETAIL, "user_id", userid, "codename", settings.PERMISSION_TEAMS_TEAM_ADD).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		So(createTeam(), ShouldEqual, http.StatusForbidden)
+
+		request = superuser.Request("DELETE", settings.ROUTE_AUTH_USER_PERMISSION_DETAIL, "user_id", userid, "codename", settings.PERMISSION_TEAMS_TEAM_ADD).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusNotFound)
+	})
+}
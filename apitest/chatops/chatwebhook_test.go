@@ -0,0 +1,134 @@
+package chatops
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/chatops"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestChatWebhook(t *testing.T) {
+	apitest.Setup()
+
+	user, erruser := apitest.CreateUser(patrol.Context)
+	if erruser != nil {
+		t.FailNow()
+	}
+	project, errproject := apitest.CreateProject(patrol.Context, user)
+	if errproject != nil {
+		t.FailNow()
+	}
+
+	team := models.NewTeam()
+	if err := project.Team(team, patrol.Context); err != nil {
+		t.FailNow()
+	}
+
+	tmm := models.NewTeamMemberManager(patrol.Context)
+	if _, err := tmm.SetTeamMemberType(team, user, models.MEMBER_TYPE_ADMIN); err != nil {
+		t.FailNow()
+	}
+
+	serializer := serializers.ChatOpsChatWebhookSerializer{
+		URL:     "https://chat.example.com/hooks/abc",
+		Channel: "alerts",
+		Enabled: true,
+	}
+
+	Convey("Chat webhooks - unauthenticated user", t, func() {
+		session := apitest.NewSession()
+		request := session.Request("GET", settings.ROUTE_CHATOPS_CHATWEBHOOK_LIST, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Chat webhooks - invalid url", t, func() {
+		session := apitest.NewSession().WithUser(user)
+
+		invalid := serializer
+		invalid.URL = "ftp://example.com"
+		request := session.Request("POST", settings.ROUTE_CHATOPS_CHATWEBHOOK_LIST, "project_id", project.ID.String())
+		So(request.JSONBody(invalid).Do().Response().Code, ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Chat webhooks - create and delete", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("POST", settings.ROUTE_CHATOPS_CHATWEBHOOK_LIST, "project_id", project.ID.String())
+		So(request.JSONBody(serializer).Do().Response().Code, ShouldEqual, http.StatusCreated)
+
+		response := struct {
+			Result *models.ChatWebhook `json:"result"`
+		}{}
+		request.Scan(&response)
+		So(response.Result.Channel, ShouldEqual, "alerts")
+
+		webhooks := []*models.ChatWebhook{}
+		So(models.NewChatWebhookManager(patrol.Context).FilterEnabled(&webhooks, project.ID.ToForeignKey()), ShouldBeNil)
+		So(len(webhooks), ShouldEqual, 1)
+
+		webhookID := response.Result.ID.String()
+		request = session.Request("DELETE", settings.ROUTE_CHATOPS_CHATWEBHOOK_DETAIL, "project_id", project.ID.String(), "chatwebhook_id", webhookID)
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		request = session.Request("GET", settings.ROUTE_CHATOPS_CHATWEBHOOK_DETAIL, "project_id", project.ID.String(), "chatwebhook_id", webhookID)
+		So(request.Do().Response().Code, ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("Chat actions - signed links", t, func() {
+		eventgroup, err := apitest.CreateEventGroup(patrol.Context, project)
+		So(err, ShouldBeNil)
+
+		secret := settings.SETTINGS_SECRET_KEY
+		id := eventgroup.ID.String()
+		expires := time.Now().Add(time.Hour).Unix()
+
+		// anonymous session, link is authorized by signature
+		session := apitest.NewSession()
+
+		request := session.Request("GET", settings.ROUTE_CHATOPS_ACTION, "action", chatops.ACTION_MUTE, "eventgroup_id", id)
+		request.SetValue("expires", strconv.FormatInt(expires, 10)).SetValue("signature", "invalid")
+		So(request.Do().Response().Code, ShouldEqual, http.StatusForbidden)
+
+		expired := time.Now().Add(-time.Hour).Unix()
+		request = session.Request("GET", settings.ROUTE_CHATOPS_ACTION, "action", chatops.ACTION_MUTE, "eventgroup_id", id)
+		request.SetValue("expires", strconv.FormatInt(expired, 10))
+		request.SetValue("signature", chatops.SignAction(secret, chatops.ACTION_MUTE, eventgroup.ID.Int64(), expired))
+		So(request.Do().Response().Code, ShouldEqual, http.StatusForbidden)
+
+		// GET only shows confirmation, link unfurlers must not change state
+		muteRequest := func(method string) *apitest.SessionRequest {
+			request := session.Request(method, settings.ROUTE_CHATOPS_ACTION, "action", chatops.ACTION_MUTE, "eventgroup_id", id)
+			request.SetValue("expires", strconv.FormatInt(expires, 10))
+			return request.SetValue("signature", chatops.SignAction(secret, chatops.ACTION_MUTE, eventgroup.ID.Int64(), expires))
+		}
+		request = muteRequest("GET").Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+		So(request.Response().Body.String(), ShouldContainSubstring, `method="post"`)
+
+		muted := models.NewEventGroup()
+		So(models.NewEventGroupManager(patrol.Context).GetByID(muted, eventgroup.ID), ShouldBeNil)
+		So(muted.Status, ShouldEqual, eventgroup.Status)
+
+		So(muteRequest("POST").Do().Response().Code, ShouldEqual, http.StatusOK)
+		So(models.NewEventGroupManager(patrol.Context).GetByID(muted, eventgroup.ID), ShouldBeNil)
+		So(muted.Status, ShouldEqual, models.EVENT_GROUP_STATUS_MUTED)
+
+		// repeated submission is idempotent
+		So(muteRequest("POST").Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		request = session.Request("POST", settings.ROUTE_CHATOPS_ACTION, "action", chatops.ACTION_RESOLVE, "eventgroup_id", id)
+		request.SetValue("expires", strconv.FormatInt(expires, 10))
+		request.SetValue("signature", chatops.SignAction(secret, chatops.ACTION_RESOLVE, eventgroup.ID.Int64(), expires))
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		So(models.NewEventGroupManager(patrol.Context).GetByID(muted, eventgroup.ID), ShouldBeNil)
+		So(muted.Status, ShouldEqual, models.EVENT_GROUP_STATUS_RESOLVED)
+	})
+}
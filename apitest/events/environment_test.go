@@ -0,0 +1,109 @@
+package events
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEnvironment(t *testing.T) {
+
+	apitest.Setup()
+
+	user, erruser := apitest.CreateUser(patrol.Context)
+	if erruser != nil {
+		t.FailNow()
+	}
+	project, errproject := apitest.CreateProject(patrol.Context, user)
+	if errproject != nil {
+		t.FailNow()
+	}
+
+	team := models.NewTeam()
+	if err := project.Team(team, patrol.Context); err != nil {
+		t.FailNow()
+	}
+
+	tmm := models.NewTeamMemberManager(patrol.Context)
+	if _, err := tmm.SetTeamMemberType(team, user, models.MEMBER_TYPE_ADMIN); err != nil {
+		t.FailNow()
+	}
+
+	eventgroup, erreg := apitest.CreateEventGroup(patrol.Context, project)
+	if erreg != nil {
+		t.FailNow()
+	}
+
+	events, errevents := apitest.CreateEvents(patrol.Context, eventgroup, 3)
+	if errevents != nil {
+		t.FailNow()
+	}
+
+	// first event is in production
+	production := models.NewEnvironment()
+	if err := models.NewEnvironmentManager(patrol.Context).GetOrCreate(production, project.ID.ToForeignKey(), " Production "); err != nil {
+		t.FailNow()
+	}
+	events[0].EnvironmentID = production.ToNullForeignKey()
+	if _, err := events[0].Update(patrol.Context, "environment_id"); err != nil {
+		t.FailNow()
+	}
+	if err := models.NewEventGroupManager(patrol.Context).IncrementCounters(eventgroup, events[0]); err != nil {
+		t.FailNow()
+	}
+
+	Convey("List environments", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("GET", settings.ROUTE_EVENTS_ENVIRONMENT_LIST, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+		response := struct {
+			Result []*models.Environment `json:"result"`
+		}{}
+		request.Scan(&response)
+		So(len(response.Result), ShouldEqual, 1)
+		So(response.Result[0].Name, ShouldEqual, "production")
+	})
+
+	Convey("List events - filter by environment", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("GET", settings.ROUTE_EVENTS_EVENT_LIST, "project_id", project.ID.String(), "eventgroup_id", eventgroup.ID.String())
+		So(request.SetValue("environment", "production").Do().Response().Code, ShouldEqual, http.StatusOK)
+		response := struct {
+			Result []*models.Event `json:"result"`
+		}{}
+		request.Scan(&response)
+		So(len(response.Result), ShouldEqual, 1)
+		So(response.Result[0].ID, ShouldEqual, events[0].ID)
+	})
+
+	Convey("List eventgroups - filter by environment", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("GET", settings.ROUTE_EVENTS_EVENTGROUP_LIST, "project_id", project.ID.String())
+		So(request.SetValue("environment", "staging").Do().Response().Code, ShouldEqual, http.StatusOK)
+		response := struct {
+			Result []*models.EventGroup `json:"result"`
+		}{}
+		request.Scan(&response)
+		So(len(response.Result), ShouldEqual, 0)
+	})
+
+	Convey("Eventgroup environments", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("GET", settings.ROUTE_EVENTS_EVENTGROUP_ENVIRONMENTS, "project_id", project.ID.String(), "eventgroup_id", eventgroup.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+		response := struct {
+			Result []*models.EventGroupEnvironmentStat `json:"result"`
+		}{}
+		request.Scan(&response)
+		So(len(response.Result), ShouldEqual, 1)
+		So(response.Result[0].Name, ShouldEqual, "production")
+		So(response.Result[0].TimesSeen, ShouldEqual, 1)
+		So(response.Result[0].EnvironmentID, ShouldEqual, types.ForeignKey(production.ID.Int64()))
+	})
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/utils"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEventIDUnique(t *testing.T) {
+
+	apitest.Setup()
+
+	user, erruser := apitest.CreateUser(patrol.Context)
+	if erruser != nil {
+		t.FailNow()
+	}
+	project, errproject := apitest.CreateProject(patrol.Context, user)
+	if errproject != nil {
+		t.FailNow()
+	}
+	eventgroup, erreg := apitest.CreateEventGroup(patrol.Context, project)
+	if erreg != nil {
+		t.FailNow()
+	}
+
+	manager := models.NewEventManager(patrol.Context)
+
+	Convey("Test repeated event_id is duplicate until forgotten", t, func() {
+		eventID := utils.RandomString(32)
+
+		duplicate, err := manager.IsDuplicateEventID(project.ID, eventID)
+		So(err, ShouldBeNil)
+		So(duplicate, ShouldBeFalse)
+
+		duplicate, err = manager.IsDuplicateEventID(project.ID, eventID)
+		So(err, ShouldBeNil)
+		So(duplicate, ShouldBeTrue)
+
+		// event that was not queued can be retried
+		So(manager.ForgetEventID(project.ID, eventID), ShouldBeNil)
+		duplicate, err = manager.IsDuplicateEventID(project.ID, eventID)
+		So(err, ShouldBeNil)
+		So(duplicate, ShouldBeFalse)
+	})
+
+	Convey("Test event_id is unique in project", t, func() {
+		events, err := apitest.CreateEvents(patrol.Context, eventgroup, 1)
+		So(err, ShouldBeNil)
+
+		event := models.NewEvent(func(e *models.Event) {
+			e.EventGroupID = eventgroup.ID.ToForeignKey()
+			e.EventID = events[0].EventID
+			e.ProjectID = eventgroup.ProjectID
+			e.Message = "message"
+			e.Platform = eventgroup.Platform
+			e.Datetime = utils.NowTruncated()
+		})
+		err = event.Insert(patrol.Context)
+		So(models.IsUniqueViolation(err), ShouldBeTrue)
+	})
+}
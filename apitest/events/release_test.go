@@ -0,0 +1,90 @@
+package events
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRelease(t *testing.T) {
+
+	apitest.Setup()
+
+	user, erruser := apitest.CreateUser(patrol.Context)
+	if erruser != nil {
+		t.FailNow()
+	}
+	project, errproject := apitest.CreateProject(patrol.Context, user)
+	if errproject != nil {
+		t.FailNow()
+	}
+
+	team := models.NewTeam()
+	if err := project.Team(team, patrol.Context); err != nil {
+		t.FailNow()
+	}
+
+	tmm := models.NewTeamMemberManager(patrol.Context)
+	if _, err := tmm.SetTeamMemberType(team, user, models.MEMBER_TYPE_ADMIN); err != nil {
+		t.FailNow()
+	}
+
+	eventgroup, erreg := apitest.CreateEventGroup(patrol.Context, project)
+	if erreg != nil {
+		t.FailNow()
+	}
+
+	Convey("Resolve in next release - no release", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("POST", settings.ROUTE_EVENTS_EVENTGROUP_RESOLVE, "project_id", project.ID.String(), "eventgroup_id", eventgroup.ID.String())
+		request.JSONBody(serializers.EventsEventGroupResolveSerializer{InNextRelease: true}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusBadRequest)
+	})
+
+	release := models.NewRelease()
+	if err := models.NewReleaseManager(patrol.Context).GetOrCreate(release, project.ID.ToForeignKey(), "1.0.0"); err != nil {
+		t.FailNow()
+	}
+
+	Convey("List releases - unauthenticated user", t, func() {
+		session := apitest.NewSession()
+		request := session.Request("GET", settings.ROUTE_EVENTS_RELEASE_LIST, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("List releases - member", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("GET", settings.ROUTE_EVENTS_RELEASE_LIST, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+		response := struct {
+			Result []*models.Release `json:"result"`
+		}{}
+		request.Scan(&response)
+		So(len(response.Result), ShouldEqual, 1)
+		So(response.Result[0].Version, ShouldEqual, "1.0.0")
+	})
+
+	Convey("Release detail - member", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("GET", settings.ROUTE_EVENTS_RELEASE_DETAIL, "project_id", project.ID.String(), "release_id", release.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+	})
+
+	Convey("Resolve in next release", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("POST", settings.ROUTE_EVENTS_EVENTGROUP_RESOLVE, "project_id", project.ID.String(), "eventgroup_id", eventgroup.ID.String())
+		request.JSONBody(serializers.EventsEventGroupResolveSerializer{InNextRelease: true}).Do()
+		So(request.Response().Code, ShouldEqual, http.StatusOK)
+
+		updated := models.NewEventGroup()
+		So(models.NewEventGroupManager(patrol.Context).GetByID(updated, eventgroup.ID), ShouldBeNil)
+		So(updated.Status, ShouldEqual, models.EVENT_GROUP_STATUS_RESOLVED)
+		So(updated.ResolvedInReleaseID.Int64, ShouldEqual, release.ID.Int64())
+	})
+}
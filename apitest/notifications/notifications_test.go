@@ -0,0 +1,128 @@
+package notifications
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNotificationSettings(t *testing.T) {
+	apitest.Setup()
+
+	user, erruser := apitest.CreateUser(patrol.Context)
+	if erruser != nil {
+		t.FailNow()
+	}
+	project, errproject := apitest.CreateProject(patrol.Context, user)
+	if errproject != nil {
+		t.FailNow()
+	}
+
+	team := models.NewTeam()
+	if err := project.Team(team, patrol.Context); err != nil {
+		t.FailNow()
+	}
+
+	tmm := models.NewTeamMemberManager(patrol.Context)
+	if _, err := tmm.SetTeamMemberType(team, user, models.MEMBER_TYPE_MEMBER); err != nil {
+		t.FailNow()
+	}
+
+	eventgroup, erreventgroup := apitest.CreateEventGroup(patrol.Context, project)
+	if erreventgroup != nil {
+		t.FailNow()
+	}
+
+	Convey("Notification settings - unauthenticated user", t, func() {
+		session := apitest.NewSession()
+		request := session.Request("GET", settings.ROUTE_NOTIFICATIONS_SETTING)
+		So(request.Do().Response().Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Notification settings - defaults and update", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("GET", settings.ROUTE_NOTIFICATIONS_SETTING)
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		response := struct {
+			Result *models.NotificationSetting `json:"result"`
+		}{}
+		request.Scan(&response)
+		So(response.Result.Email, ShouldBeTrue)
+		So(response.Result.Webhook, ShouldBeFalse)
+
+		// webhook channel requires url
+		request = session.Request("POST", settings.ROUTE_NOTIFICATIONS_SETTING)
+		request.JSONBody(serializers.NotificationsSettingSerializer{Email: true, Webhook: true})
+		So(request.Do().Response().Code, ShouldEqual, http.StatusBadRequest)
+
+		// webhook must not target internal addresses
+		for _, internal := range []string{"http://127.0.0.1/me", "http://localhost:8080/me", "http://169.254.169.254/latest", "http://[::1]/me"} {
+			request = session.Request("POST", settings.ROUTE_NOTIFICATIONS_SETTING)
+			request.JSONBody(serializers.NotificationsSettingSerializer{Webhook: true, WebhookURL: internal})
+			So(request.Do().Response().Code, ShouldEqual, http.StatusBadRequest)
+		}
+
+		request = session.Request("POST", settings.ROUTE_NOTIFICATIONS_SETTING)
+		request.JSONBody(serializers.NotificationsSettingSerializer{Webhook: true, WebhookURL: "https://example.com/me", OwnActions: true})
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		setting := models.NewNotificationSetting()
+		So(models.NewNotificationSettingManager(patrol.Context).GetByUser(setting, user), ShouldBeNil)
+		So(setting.Email, ShouldBeFalse)
+		So(setting.WebhookEnabled(), ShouldBeTrue)
+		So(setting.OwnActions, ShouldBeTrue)
+	})
+
+	Convey("Notification settings - project mode", t, func() {
+		session := apitest.NewSession().WithUser(user)
+
+		request := session.Request("POST", settings.ROUTE_NOTIFICATIONS_PROJECTSETTING, "project_id", project.ID.String())
+		request.JSONBody(serializers.NotificationsProjectSettingSerializer{Mode: "sometimes"})
+		So(request.Do().Response().Code, ShouldEqual, http.StatusBadRequest)
+
+		request = session.Request("POST", settings.ROUTE_NOTIFICATIONS_PROJECTSETTING, "project_id", project.ID.String())
+		request.JSONBody(serializers.NotificationsProjectSettingSerializer{Mode: models.NOTIFICATION_MODE_NONE})
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		setting := models.NewProjectNotificationSetting()
+		So(models.NewProjectNotificationSettingManager(patrol.Context).GetByUserProject(setting, user, project), ShouldBeNil)
+		So(setting.Mode, ShouldEqual, models.NOTIFICATION_MODE_NONE)
+	})
+
+	Convey("Notification settings - eventgroup subscription", t, func() {
+		session := apitest.NewSession().WithUser(user)
+
+		request := session.Request("GET", settings.ROUTE_NOTIFICATIONS_SUBSCRIPTION, "eventgroup_id", eventgroup.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusNotFound)
+
+		request = session.Request("POST", settings.ROUTE_NOTIFICATIONS_SUBSCRIPTION, "eventgroup_id", eventgroup.ID.String())
+		request.JSONBody(serializers.NotificationsSubscriptionSerializer{Subscribed: true})
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		response := struct {
+			Result *models.EventGroupSubscription `json:"result"`
+		}{}
+		request.Scan(&response)
+		So(response.Result.Subscribed, ShouldBeTrue)
+		So(response.Result.Reason, ShouldEqual, models.SUBSCRIPTION_REASON_MANUAL)
+
+		request = session.Request("DELETE", settings.ROUTE_NOTIFICATIONS_SUBSCRIPTION, "eventgroup_id", eventgroup.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		// explicit unsubscription is not overridden by automatic subscription
+		manager := models.NewEventGroupSubscriptionManager(patrol.Context)
+		So(manager.Unsubscribe(user, eventgroup), ShouldBeNil)
+		So(manager.Subscribe(user, eventgroup, models.SUBSCRIPTION_REASON_ASSIGNED), ShouldBeNil)
+
+		subscription := manager.NewEventGroupSubscription()
+		So(manager.GetByUserEventGroup(subscription, user, eventgroup), ShouldBeNil)
+		So(subscription.Subscribed, ShouldBeFalse)
+	})
+}
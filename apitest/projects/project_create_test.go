@@ -7,6 +7,7 @@ import (
 	"github.com/phonkee/patrol"
 	"github.com/phonkee/patrol/apitest"
 	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/plugins"
 	"github.com/phonkee/patrol/serializers"
 	"github.com/phonkee/patrol/settings"
 	"github.com/phonkee/patrol/utils"
@@ -47,6 +48,52 @@ func TestProjectCreate(t *testing.T) {
 		So(request.Response().Code, ShouldEqual, http.StatusForbidden)
 	})
 
+	Convey("Test existing team admin can create project after upgrade", t, func() {
+		admin, err := apitest.CreateUser(patrol.Context)
+		So(err, ShouldBeNil)
+		member, err := apitest.CreateUser(patrol.Context)
+		So(err, ShouldBeNil)
+
+		tmm := models.NewTeamMemberManager(patrol.Context)
+		_, err = tmm.SetTeamMemberType(team, admin, models.MEMBER_TYPE_ADMIN)
+		So(err, ShouldBeNil)
+		_, err = tmm.SetTeamMemberType(team, member, models.MEMBER_TYPE_MEMBER)
+		So(err, ShouldBeNil)
+
+		serializer := serializers.ProjectsProjectCreateSerializer{
+			Name:     "test project " + utils.RandomString(10),
+			Platform: "any",
+			TeamID:   team.ID.ToForeignKey(),
+		}
+
+		// admin without permission (user is cached by request)
+		session := apitest.NewSession().WithUser(admin)
+		So(session.Request("POST", settings.ROUTE_PROJECTS_PROJECT_LIST).JSONBody(serializer).Do().Response().Code, ShouldEqual, http.StatusForbidden)
+
+		// run migration twice, permission is granted only once
+		plugin := plugins.NewProjectsPlugin(patrol.Context).(*plugins.ProjectsPlugin)
+		for i := 0; i < 2; i++ {
+			_, err = patrol.Context.DB.Exec(models.MIGRATION_PROJECT_GRANT_ADD_PERMISSION)
+			So(err, ShouldBeNil)
+			So(plugin.PostGrantAddPermissionMigration(patrol.Context), ShouldBeNil)
+		}
+
+		manager := models.NewUserManager(patrol.Context)
+		upgraded := manager.NewUser()
+		So(manager.GetByID(upgraded, admin.ID), ShouldBeNil)
+		So(len(upgraded.Permissions), ShouldEqual, 1)
+		So(upgraded.Permissions.Has(settings.PERMISSION_PROJECTS_PROJECT_ADD), ShouldBeTrue)
+
+		notupgraded := manager.NewUser()
+		So(manager.GetByID(notupgraded, member.ID), ShouldBeNil)
+		So(notupgraded.Permissions.Has(settings.PERMISSION_PROJECTS_PROJECT_ADD), ShouldBeFalse)
+
+		So(session.Request("POST", settings.ROUTE_PROJECTS_PROJECT_LIST).JSONBody(serializer).Do().Response().Code, ShouldEqual, http.StatusCreated)
+
+		memberSession := apitest.NewSession().WithUser(member)
+		So(memberSession.Request("POST", settings.ROUTE_PROJECTS_PROJECT_LIST).JSONBody(serializer).Do().Response().Code, ShouldEqual, http.StatusForbidden)
+	})
+
 	Convey("Test create project for authenticated superuser", t, func() {
 		session := apitest.NewSession().WithNewUser(func(user *models.User) {
 			user.IsSuperuser = true
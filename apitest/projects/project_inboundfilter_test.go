@@ -0,0 +1,76 @@
+package projects
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/parser"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProjectInboundFilter(t *testing.T) {
+	apitest.Setup()
+
+	user, erruser := apitest.CreateUser(patrol.Context)
+	if erruser != nil {
+		t.FailNow()
+	}
+	project, errproject := apitest.CreateProject(patrol.Context, user)
+	if errproject != nil {
+		t.FailNow()
+	}
+
+	team := models.NewTeam()
+	if err := project.Team(team, patrol.Context); err != nil {
+		t.FailNow()
+	}
+
+	tmm := models.NewTeamMemberManager(patrol.Context)
+	if _, err := tmm.SetTeamMemberType(team, user, models.MEMBER_TYPE_ADMIN); err != nil {
+		t.FailNow()
+	}
+
+	Convey("Inbound filters - unauthenticated user", t, func() {
+		session := apitest.NewSession()
+		request := session.Request("GET", settings.ROUTE_PROJECTS_PROJECT_FILTERS, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Inbound filters - update", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		serializer := serializers.ProjectsProjectInboundFilterUpdateSerializer{
+			Localhost:     true,
+			ErrorMessages: types.StringSlice{"Script error."},
+		}
+		request := session.Request("POST", settings.ROUTE_PROJECTS_PROJECT_FILTERS, "project_id", project.ID.String())
+		So(request.JSONBody(serializer).Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		filter := models.NewProjectInboundFilter()
+		err := models.NewProjectInboundFilterManager(patrol.Context).GetByProject(filter, project)
+		So(err, ShouldBeNil)
+		So(filter.Localhost, ShouldBeTrue)
+		So(filter.ErrorMessages, ShouldResemble, types.StringSlice{"Script error."})
+	})
+
+	Convey("Inbound filters - stats", t, func() {
+		manager := models.NewProjectFilterStatManager(patrol.Context)
+		So(manager.Increment(project, parser.FILTER_REASON_LOCALHOST), ShouldBeNil)
+		So(manager.Increment(project, parser.FILTER_REASON_LOCALHOST), ShouldBeNil)
+
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("GET", settings.ROUTE_PROJECTS_PROJECT_FILTER_STATS, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+		response := struct {
+			Result map[string]*models.ProjectFilterStat `json:"result"`
+		}{}
+		request.Scan(&response)
+		So(response.Result[parser.FILTER_REASON_LOCALHOST].Count, ShouldEqual, 2)
+		So(response.Result[parser.FILTER_REASON_WEB_CRAWLERS].Count, ShouldEqual, 0)
+	})
+}
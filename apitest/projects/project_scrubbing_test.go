@@ -0,0 +1,73 @@
+package projects
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProjectScrubbing(t *testing.T) {
+	apitest.Setup()
+
+	user, erruser := apitest.CreateUser(patrol.Context)
+	if erruser != nil {
+		t.FailNow()
+	}
+	project, errproject := apitest.CreateProject(patrol.Context, user)
+	if errproject != nil {
+		t.FailNow()
+	}
+
+	team := models.NewTeam()
+	if err := project.Team(team, patrol.Context); err != nil {
+		t.FailNow()
+	}
+
+	tmm := models.NewTeamMemberManager(patrol.Context)
+	if _, err := tmm.SetTeamMemberType(team, user, models.MEMBER_TYPE_ADMIN); err != nil {
+		t.FailNow()
+	}
+
+	Convey("Scrubbing settings - unauthenticated user", t, func() {
+		session := apitest.NewSession()
+		request := session.Request("GET", settings.ROUTE_PROJECTS_PROJECT_SCRUBBING, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Scrubbing settings - non member", t, func() {
+		session := apitest.NewSession().WithNewUser()
+		request := session.Request("GET", settings.ROUTE_PROJECTS_PROJECT_SCRUBBING, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("Scrubbing settings - defaults", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("GET", settings.ROUTE_PROJECTS_PROJECT_SCRUBBING, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+	})
+
+	Convey("Scrubbing settings - update", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		serializer := serializers.ProjectsProjectScrubbingUpdateSerializer{
+			Enabled:       true,
+			ScrubDefaults: true,
+			ExtraFields:   types.StringSlice{"iban", " iban "},
+			SafeFields:    types.StringSlice{"session_id"},
+		}
+		request := session.Request("POST", settings.ROUTE_PROJECTS_PROJECT_SCRUBBING, "project_id", project.ID.String())
+		So(request.JSONBody(serializer).Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		scrubbing := models.NewProjectScrubbing()
+		err := models.NewProjectScrubbingManager(patrol.Context).GetByProject(scrubbing, project)
+		So(err, ShouldBeNil)
+		So(scrubbing.ExtraFields, ShouldResemble, types.StringSlice{"iban"})
+		So(scrubbing.ScrubCreditCards, ShouldBeFalse)
+	})
+}
@@ -0,0 +1,76 @@
+package realtime
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/settings"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEventGroupStream(t *testing.T) {
+	apitest.Setup()
+
+	user, erruser := apitest.CreateUser(patrol.Context)
+	if erruser != nil {
+		t.FailNow()
+	}
+	project, errproject := apitest.CreateProject(patrol.Context, user)
+	if errproject != nil {
+		t.FailNow()
+	}
+
+	Convey("Eventgroup stream - unauthenticated user", t, func() {
+		session := apitest.NewSession()
+		request := session.Request("GET", settings.ROUTE_REALTIME_EVENTGROUP_STREAM, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Eventgroup stream - user which is not project member", t, func() {
+		session := apitest.NewSession().WithNewUser()
+		request := session.Request("GET", settings.ROUTE_REALTIME_EVENTGROUP_STREAM, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Eventgroup stream - token in query parameter", t, func() {
+		server := httptest.NewServer(patrol.Context.Router)
+		defer server.Close()
+
+		member, token, err := apitest.CreateUserWithToken(patrol.Context)
+		So(err, ShouldBeNil)
+		memberProject, err := apitest.CreateProject(patrol.Context, member)
+		So(err, ShouldBeNil)
+
+		path, _ := patrol.Context.Router.Get(settings.ROUTE_REALTIME_EVENTGROUP_STREAM).URL("project_id", memberProject.ID.String())
+		query := url.Values{settings.REALTIME_TOKEN_PARAM_NAME: {token}}
+
+		// EventSource sends no Authorization header
+		response, err := http.Get(server.URL + path.Path + "?" + query.Encode())
+		So(err, ShouldBeNil)
+		So(response.StatusCode, ShouldEqual, http.StatusOK)
+		So(response.Header.Get("Content-Type"), ShouldEqual, "text/event-stream")
+
+		line, err := bufio.NewReader(response.Body).ReadString('\n')
+		So(err, ShouldBeNil)
+		So(line, ShouldStartWith, "retry:")
+		response.Body.Close()
+
+		// token of user which is not member of project
+		path, _ = patrol.Context.Router.Get(settings.ROUTE_REALTIME_EVENTGROUP_STREAM).URL("project_id", project.ID.String())
+		response, err = http.Get(server.URL + path.Path + "?" + query.Encode())
+		So(err, ShouldBeNil)
+		response.Body.Close()
+		So(response.StatusCode, ShouldEqual, http.StatusUnauthorized)
+
+		query.Set(settings.REALTIME_TOKEN_PARAM_NAME, "invalid")
+		response, err = http.Get(server.URL + path.Path + "?" + query.Encode())
+		So(err, ShouldBeNil)
+		response.Body.Close()
+		So(response.StatusCode, ShouldEqual, http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,180 @@
+package realtime
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/plugins"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/views/realtime"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWebsocket(t *testing.T) {
+	apitest.Setup()
+
+	user, token, erruser := apitest.CreateUserWithToken(patrol.Context)
+	if erruser != nil {
+		t.FailNow()
+	}
+	project, errproject := apitest.CreateProject(patrol.Context, user)
+	if errproject != nil {
+		t.FailNow()
+	}
+
+	// project of other team
+	other, errother := apitest.CreateUser(patrol.Context)
+	if errother != nil {
+		t.FailNow()
+	}
+	otherProject, errotherproject := apitest.CreateProject(patrol.Context, other)
+	if errotherproject != nil {
+		t.FailNow()
+	}
+	otherEventGroup, erreg := apitest.CreateEventGroup(patrol.Context, otherProject)
+	if erreg != nil {
+		t.FailNow()
+	}
+
+	server := httptest.NewServer(patrol.Context.Router)
+	defer server.Close()
+
+	path, _ := patrol.Context.Router.Get(plugins.ROUTE_REALTIME).URL()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + path.Path
+
+	dial := func(query url.Values, header http.Header) *websocket.Conn {
+		target := wsURL
+		if query != nil {
+			target += "?" + query.Encode()
+		}
+		ws, _, err := websocket.DefaultDialer.Dial(target, header)
+		So(err, ShouldBeNil)
+		return ws
+	}
+
+	// sends request and returns ack
+	request := func(ws *websocket.Conn, r *realtime.Request) *realtime.Ack {
+		So(ws.WriteJSON(r), ShouldBeNil)
+		ack := &realtime.Ack{}
+		So(ws.ReadJSON(ack), ShouldBeNil)
+		return ack
+	}
+
+	// asserts that server closed websocket with policy violation
+	shouldBeClosed := func(ws *websocket.Conn) {
+		_, _, err := ws.ReadMessage()
+		So(websocket.IsCloseError(err, websocket.ClosePolicyViolation), ShouldBeTrue)
+	}
+
+	Convey("Websocket - unauthenticated socket is closed", t, func() {
+		ws := dial(nil, nil)
+		defer ws.Close()
+
+		So(ws.WriteJSON(&realtime.Request{Type: realtime.PROTOCOL_PING, ID: "1"}), ShouldBeNil)
+		shouldBeClosed(ws)
+	})
+
+	Convey("Websocket - invalid token is closed", t, func() {
+		ws := dial(url.Values{settings.REALTIME_TOKEN_PARAM_NAME: {"invalid"}}, nil)
+		defer ws.Close()
+		shouldBeClosed(ws)
+
+		ws = dial(nil, nil)
+		defer ws.Close()
+		So(ws.WriteJSON(&realtime.Request{Type: realtime.PROTOCOL_AUTH, Token: "invalid"}), ShouldBeNil)
+		shouldBeClosed(ws)
+	})
+
+	Convey("Websocket - token in header, query parameter and auth request", t, func() {
+		ws := dial(nil, http.Header{"Authorization": {"Bearer " + token}})
+		defer ws.Close()
+		So(request(ws, &realtime.Request{Type: realtime.PROTOCOL_PING, ID: "1"}).Error, ShouldBeEmpty)
+
+		ws = dial(url.Values{settings.REALTIME_TOKEN_PARAM_NAME: {token}}, nil)
+		defer ws.Close()
+		So(request(ws, &realtime.Request{Type: realtime.PROTOCOL_PING, ID: "1"}).Error, ShouldBeEmpty)
+
+		ws = dial(nil, nil)
+		defer ws.Close()
+		So(ws.WriteJSON(&realtime.Request{Type: realtime.PROTOCOL_AUTH, Token: token}), ShouldBeNil)
+		So(request(ws, &realtime.Request{Type: realtime.PROTOCOL_PING, ID: "1"}).Error, ShouldBeEmpty)
+	})
+
+	Convey("Websocket - subscribe permissions", t, func() {
+		ws := dial(url.Values{settings.REALTIME_TOKEN_PARAM_NAME: {token}}, nil)
+		defer ws.Close()
+
+		ack := request(ws, &realtime.Request{Type: realtime.PROTOCOL_SUBSCRIBE, ID: "1", ProjectID: project.ID})
+		So(ack.Error, ShouldBeEmpty)
+		So(ack.ID, ShouldEqual, "1")
+
+		ack = request(ws, &realtime.Request{Type: realtime.PROTOCOL_UNSUBSCRIBE, ID: "2", ProjectID: project.ID})
+		So(ack.Error, ShouldBeEmpty)
+
+		// non member cannot subscribe project or eventgroup of other team
+		ack = request(ws, &realtime.Request{Type: realtime.PROTOCOL_SUBSCRIBE, ID: "3", ProjectID: otherProject.ID})
+		So(ack.Error, ShouldEqual, realtime.ErrProtocolForbidden.Error())
+
+		ack = request(ws, &realtime.Request{Type: realtime.PROTOCOL_SUBSCRIBE, ID: "4", EventGroupID: otherEventGroup.ID})
+		So(ack.Error, ShouldEqual, realtime.ErrProtocolForbidden.Error())
+
+		// nonexisting objects are forbidden too
+		ack = request(ws, &realtime.Request{Type: realtime.PROTOCOL_SUBSCRIBE, ID: "5", ProjectID: types.PrimaryKey(1 << 40)})
+		So(ack.Error, ShouldEqual, realtime.ErrProtocolForbidden.Error())
+	})
+
+	Convey("Websocket - revoked token, inactive user and removed member are closed on ping", t, func() {
+		ping := &realtime.Request{Type: realtime.PROTOCOL_PING, ID: "1"}
+		manager := models.NewUserManager(patrol.Context)
+
+		// revoked token
+		revoked, revokedToken, err := apitest.CreateUserWithToken(patrol.Context)
+		So(err, ShouldBeNil)
+		ws := dial(url.Values{settings.REALTIME_TOKEN_PARAM_NAME: {revokedToken}}, nil)
+		defer ws.Close()
+		So(request(ws, ping).Error, ShouldBeEmpty)
+
+		So(manager.RevokeTokens(revoked), ShouldBeNil)
+		So(ws.WriteJSON(ping), ShouldBeNil)
+		shouldBeClosed(ws)
+
+		// deactivated user
+		inactive, inactiveToken, err := apitest.CreateUserWithToken(patrol.Context)
+		So(err, ShouldBeNil)
+		ws = dial(url.Values{settings.REALTIME_TOKEN_PARAM_NAME: {inactiveToken}}, nil)
+		defer ws.Close()
+		So(request(ws, ping).Error, ShouldBeEmpty)
+
+		inactive.IsActive = false
+		_, err = inactive.Update(patrol.Context, "is_active")
+		So(err, ShouldBeNil)
+		So(ws.WriteJSON(ping), ShouldBeNil)
+		shouldBeClosed(ws)
+
+		// member removed from team of subscribed project
+		member, memberToken, err := apitest.CreateUserWithToken(patrol.Context)
+		So(err, ShouldBeNil)
+		team := models.NewTeam()
+		So(models.NewTeamManager(patrol.Context).GetByID(team, otherProject.TeamID), ShouldBeNil)
+		tmm := models.NewTeamMemberManager(patrol.Context)
+		_, err = tmm.SetTeamMemberType(team, member, models.MEMBER_TYPE_MEMBER)
+		So(err, ShouldBeNil)
+
+		ws = dial(url.Values{settings.REALTIME_TOKEN_PARAM_NAME: {memberToken}}, nil)
+		defer ws.Close()
+		So(request(ws, &realtime.Request{Type: realtime.PROTOCOL_SUBSCRIBE, ID: "2", EventGroupID: otherEventGroup.ID}).Error, ShouldBeEmpty)
+		So(request(ws, ping).Error, ShouldBeEmpty)
+
+		So(tmm.RemoveTeamMember(team, member), ShouldBeNil)
+		So(ws.WriteJSON(ping), ShouldBeNil)
+		shouldBeClosed(ws)
+	})
+}
@@ -139,6 +139,8 @@ type SessionRequest struct {
 	err      error
 	token    string
 	values   url.Values
+	remote   string
+	cookies  []*http.Cookie
 }
 
 func (r *SessionRequest) Error() error {
@@ -175,6 +177,18 @@ func (r *SessionRequest) JSONBody(what interface{}) *SessionRequest {
 	return r
 }
 
+// Sets remote address (host:port) of request
+func (r *SessionRequest) RemoteAddr(remote string) *SessionRequest {
+	r.remote = remote
+	return r
+}
+
+// Adds cookie to request
+func (r *SessionRequest) Cookie(cookie *http.Cookie) *SessionRequest {
+	r.cookies = append(r.cookies, cookie)
+	return r
+}
+
 func (r *SessionRequest) Body(body io.Reader) *SessionRequest {
 	r.body = body
 	return r
@@ -204,6 +218,12 @@ func (r *SessionRequest) prepareRequest() {
 	req, _ := http.NewRequest(r.method, r.path+ev, r.body)
 	r.request = req
 	r.request.Header.Set("Authorization", "Bearer "+r.token)
+	if r.remote != "" {
+		r.request.RemoteAddr = r.remote
+	}
+	for _, cookie := range r.cookies {
+		r.request.AddCookie(cookie)
+	}
 	r.response = httptest.NewRecorder()
 	r.err = nil
 }
@@ -0,0 +1,105 @@
+package webhooks
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/phonkee/patrol"
+	"github.com/phonkee/patrol/apitest"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWebhook(t *testing.T) {
+	apitest.Setup()
+
+	user, erruser := apitest.CreateUser(patrol.Context)
+	if erruser != nil {
+		t.FailNow()
+	}
+	project, errproject := apitest.CreateProject(patrol.Context, user)
+	if errproject != nil {
+		t.FailNow()
+	}
+
+	team := models.NewTeam()
+	if err := project.Team(team, patrol.Context); err != nil {
+		t.FailNow()
+	}
+
+	tmm := models.NewTeamMemberManager(patrol.Context)
+	if _, err := tmm.SetTeamMemberType(team, user, models.MEMBER_TYPE_ADMIN); err != nil {
+		t.FailNow()
+	}
+
+	serializer := serializers.WebhooksWebhookSerializer{
+		URL:     "https://example.com/hook",
+		Events:  types.StringSlice{models.WEBHOOK_EVENT_EVENTGROUP_CREATED},
+		Enabled: true,
+	}
+
+	Convey("Webhooks - unauthenticated user", t, func() {
+		session := apitest.NewSession()
+		request := session.Request("GET", settings.ROUTE_WEBHOOKS_WEBHOOK_LIST, "project_id", project.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("Webhooks - invalid url and event", t, func() {
+		session := apitest.NewSession().WithUser(user)
+
+		invalid := serializer
+		invalid.URL = "ftp://example.com"
+		request := session.Request("POST", settings.ROUTE_WEBHOOKS_WEBHOOK_LIST, "project_id", project.ID.String())
+		So(request.JSONBody(invalid).Do().Response().Code, ShouldEqual, http.StatusBadRequest)
+
+		invalid = serializer
+		invalid.Events = types.StringSlice{"unknown"}
+		request = session.Request("POST", settings.ROUTE_WEBHOOKS_WEBHOOK_LIST, "project_id", project.ID.String())
+		So(request.JSONBody(invalid).Do().Response().Code, ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Webhooks - create, list deliveries, redeliver and delete", t, func() {
+		session := apitest.NewSession().WithUser(user)
+		request := session.Request("POST", settings.ROUTE_WEBHOOKS_WEBHOOK_LIST, "project_id", project.ID.String())
+		So(request.JSONBody(serializer).Do().Response().Code, ShouldEqual, http.StatusCreated)
+
+		response := struct {
+			Result *models.Webhook `json:"result"`
+		}{}
+		request.Scan(&response)
+		So(response.Result.URL, ShouldEqual, "https://example.com/hook")
+		So(response.Result.Secret, ShouldNotBeEmpty)
+
+		webhooks := []*models.Webhook{}
+		manager := models.NewWebhookManager(patrol.Context)
+		So(manager.FilterSubscribed(&webhooks, project.ID.ToForeignKey(), models.WEBHOOK_EVENT_EVENTGROUP_CREATED), ShouldBeNil)
+		So(len(webhooks), ShouldEqual, 1)
+		So(manager.FilterSubscribed(&webhooks, project.ID.ToForeignKey(), models.WEBHOOK_EVENT_EVENT_CREATED), ShouldBeNil)
+		So(len(webhooks), ShouldEqual, 0)
+
+		delivery := models.NewWebhookDelivery(func(wd *models.WebhookDelivery) {
+			wd.WebhookID = response.Result.ID.ToForeignKey()
+			wd.GUID = "abc"
+			wd.EventType = models.WEBHOOK_EVENT_EVENTGROUP_CREATED
+			wd.Payload = "{}"
+			wd.StatusCode = http.StatusInternalServerError
+		})
+		So(delivery.Insert(patrol.Context), ShouldBeNil)
+
+		webhookID := response.Result.ID.String()
+		request = session.Request("GET", settings.ROUTE_WEBHOOKS_DELIVERY_LIST, "project_id", project.ID.String(), "webhook_id", webhookID)
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		request = session.Request("POST", settings.ROUTE_WEBHOOKS_DELIVERY_REDELIVER, "project_id", project.ID.String(), "webhook_id", webhookID, "delivery_id", delivery.ID.String())
+		So(request.Do().Response().Code, ShouldEqual, http.StatusAccepted)
+
+		request = session.Request("DELETE", settings.ROUTE_WEBHOOKS_WEBHOOK_DETAIL, "project_id", project.ID.String(), "webhook_id", webhookID)
+		So(request.Do().Response().Code, ShouldEqual, http.StatusOK)
+
+		request = session.Request("GET", settings.ROUTE_WEBHOOKS_WEBHOOK_DETAIL, "project_id", project.ID.String(), "webhook_id", webhookID)
+		So(request.Do().Response().Code, ShouldEqual, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,25 @@
+package chatops
+
+import (
+	"github.com/phonkee/patrol/alerts"
+	"github.com/phonkee/patrol/context"
+)
+
+const (
+	// alert action that posts eventgroup to chat webhooks of project
+	ALERT_ACTION_CHAT = "chat"
+)
+
+func init() {
+	alerts.RegisterAction(ALERT_ACTION_CHAT, "Posts alert to chat webhooks of project", func() alerts.Action { return &ChatAction{} })
+}
+
+/*
+ChatAction posts matched eventgroup to chat webhooks
+*/
+type ChatAction struct{}
+
+func (c *ChatAction) Validate(options map[string]string) error { return nil }
+func (c *ChatAction) Perform(context *context.Context, notification *alerts.Notification) error {
+	return NewNotifier(context).Notify(notification.Event, notification.EventGroup)
+}
@@ -0,0 +1,123 @@
+package chatops
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/parser"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestChatOps(t *testing.T) {
+
+	Convey("Test sign and verify action", t, func() {
+		now := time.Now()
+		expires := now.Add(time.Hour).Unix()
+		signature := SignAction("secret", ACTION_RESOLVE, 1, expires)
+
+		So(VerifyAction("secret", ACTION_RESOLVE, 1, expires, signature, now), ShouldBeNil)
+		So(VerifyAction("other", ACTION_RESOLVE, 1, expires, signature, now), ShouldEqual, ErrActionLinkInvalid)
+		So(VerifyAction("secret", ACTION_MUTE, 1, expires, signature, now), ShouldEqual, ErrActionLinkInvalid)
+		So(VerifyAction("secret", ACTION_RESOLVE, 2, expires, signature, now), ShouldEqual, ErrActionLinkInvalid)
+		So(VerifyAction("secret", ACTION_RESOLVE, 1, expires+1, signature, now), ShouldEqual, ErrActionLinkInvalid)
+		So(VerifyAction("secret", "delete", 1, expires, signature, now), ShouldEqual, ErrActionUnknown)
+		So(VerifyAction("secret", ACTION_RESOLVE, 1, expires, signature, now.Add(2*time.Hour)), ShouldEqual, ErrActionLinkExpired)
+	})
+
+	Convey("Test action url", t, func() {
+		now := time.Now()
+		eventgroup := models.NewEventGroup(func(eg *models.EventGroup) {
+			eg.ID = 42
+		})
+		u, err := url.Parse(ActionURL("secret", ACTION_MUTE, eventgroup, now))
+		So(err, ShouldBeNil)
+		So(u.Path, ShouldEqual, "/api/chatops/action/mute/42")
+
+		expires, err := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+		So(err, ShouldBeNil)
+		So(VerifyAction("secret", ACTION_MUTE, 42, expires, u.Query().Get("signature"), now), ShouldBeNil)
+	})
+
+	Convey("Test confirmation page", t, func() {
+		eventgroup := models.NewEventGroup(func(eg *models.EventGroup) {
+			eg.Message = "<script>"
+		})
+		body, err := RenderConfirmation(ACTION_RESOLVE, eventgroup, "/api/chatops/action/resolve/1?expires=1&signature=abc")
+		So(err, ShouldBeNil)
+		So(string(body), ShouldContainSubstring, `<form method="post" action="/api/chatops/action/resolve/1?expires=1&amp;signature=abc">`)
+		So(string(body), ShouldNotContainSubstring, "<script>")
+	})
+
+	Convey("Test level color", t, func() {
+		So(LevelColor(parser.LEVEL_FATAL), ShouldNotEqual, LevelColor(parser.LEVEL_ERROR))
+		So(LevelColor(parser.LEVEL_ERROR), ShouldNotEqual, LevelColor(parser.LEVEL_WARNING))
+		So(LevelColor(parser.LEVEL_WARNING), ShouldNotEqual, LevelColor(parser.LEVEL_INFO))
+		So(LevelColor(parser.LEVEL_INFO), ShouldNotEqual, LevelColor(parser.LEVEL_DEBUG))
+	})
+
+	Convey("Test eventgroup message", t, func() {
+		project := models.NewProject(func(p *models.Project) {
+			p.Name = "project"
+		})
+		eventgroup := models.NewEventGroup(func(eg *models.EventGroup) {
+			eg.ID = 1
+			eg.Message = "error"
+			eg.Culprit = "main.go"
+			eg.Level = parser.LEVEL_ERROR
+			eg.TimesSeen = 3
+		})
+
+		message := NewEventGroupMessage("secret", project, eventgroup, "production", "http://link")
+		So(len(message.Attachments), ShouldEqual, 1)
+
+		attachment := message.Attachments[0]
+		So(attachment.Color, ShouldEqual, LevelColor(parser.LEVEL_ERROR))
+		So(attachment.TitleLink, ShouldEqual, "http://link")
+		So(len(attachment.Fields), ShouldEqual, 3)
+		So(attachment.Fields[1].Value, ShouldEqual, "3")
+		So(attachment.Fields[2].Value, ShouldEqual, "production")
+		So(len(attachment.Actions), ShouldEqual, 2)
+
+		without := NewEventGroupMessage("secret", project, eventgroup, "", "http://link")
+		So(len(without.Attachments[0].Fields), ShouldEqual, 2)
+	})
+
+	Convey("Test post message", t, func() {
+		bodies := make(chan []byte, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			bodies <- body
+			w.Write([]byte("ok"))
+		}))
+		defer server.Close()
+
+		// default client refuses loopback address of test server
+		So(Post(server.URL, &Message{Text: "hello"}), ShouldNotBeNil)
+
+		public := client
+		client = server.Client()
+		defer func() { client = public }()
+
+		err := Post(server.URL, &Message{Text: "hello", Channel: "alerts"})
+		So(err, ShouldBeNil)
+
+		message := &Message{}
+		So(json.Unmarshal(<-bodies, message), ShouldBeNil)
+		So(message.Text, ShouldEqual, "hello")
+		So(message.Channel, ShouldEqual, "alerts")
+
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer failing.Close()
+
+		So(Post(failing.URL, &Message{Text: "hello"}), ShouldNotBeNil)
+	})
+}
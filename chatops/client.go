@@ -0,0 +1,108 @@
+package chatops
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/notifications"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/utils"
+)
+
+var (
+	ErrPostFailed = errors.New("chat webhook post failed")
+
+	// client refuses internal addresses, chat webhook urls are set by users
+	client = utils.NewPublicHTTPClient(settings.CHATOPS_REQUEST_TIMEOUT)
+)
+
+/*
+Posts message to incoming webhook url
+*/
+func Post(url string, message *Message) (err error) {
+	var body []byte
+	if body, err = json.Marshal(message); err != nil {
+		return
+	}
+
+	var resp *http.Response
+	if resp, err = client.Post(url, "application/json", bytes.NewReader(body)); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: status %d", ErrPostFailed, resp.StatusCode)
+	}
+	return
+}
+
+/*
+Notifier posts eventgroup messages to enabled chat webhooks of project
+*/
+func NewNotifier(context *context.Context) *Notifier {
+	return &Notifier{context: context}
+}
+
+type Notifier struct {
+	context *context.Context
+}
+
+/*
+Notify posts message about eventgroup to all enabled chat webhooks of project.
+Posting itself is done in background so signal handlers are not blocked.
+*/
+func (n *Notifier) Notify(event *models.Event, eventgroup *models.EventGroup) (err error) {
+	manager := models.NewChatWebhookManager(n.context)
+	webhooks := manager.NewChatWebhookList()
+	if err = manager.FilterEnabled(&webhooks, eventgroup.ProjectID); err != nil {
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	project := models.NewProject()
+	if err = models.NewProjectManager(n.context).GetByID(project, eventgroup.ProjectID); err != nil {
+		return
+	}
+
+	secret := n.context.Get(context.SECRET_KEY).(string)
+	message := NewEventGroupMessage(secret, project, eventgroup, n.Environment(event), notifications.EventGroupLink(eventgroup))
+
+	for _, webhook := range webhooks {
+		// every webhook can override channel
+		m := *message
+		m.Channel = webhook.Channel
+
+		go func(webhook *models.ChatWebhook, message *Message) {
+			if err := Post(webhook.URL, message); err != nil {
+				glog.Errorf("chatops: cannot post %s to %s: %s.", eventgroup, webhook, err)
+			}
+		}(webhook, &m)
+	}
+
+	return
+}
+
+// returns environment name of event or empty string
+func (n *Notifier) Environment(event *models.Event) string {
+	if event == nil || !event.EnvironmentID.Valid {
+		return ""
+	}
+	manager := models.NewEnvironmentManager(n.context)
+	environment := manager.NewEnvironment()
+	if err := manager.Get(environment, manager.QueryFilterWhere("id = ?", event.EnvironmentID.Int64)); err != nil {
+		return ""
+	}
+	return environment.Name
+}
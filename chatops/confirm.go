@@ -0,0 +1,42 @@
+package chatops
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+
+	"github.com/phonkee/patrol/models"
+)
+
+var (
+	confirmationTemplate = htmltemplate.Must(htmltemplate.New("confirmation").Parse(
+		`<html>
+<body>
+<p>Do you really want to {{ .Action }} issue?</p>
+<h2>{{ .EventGroup.Message }}</h2>
+{{ if .EventGroup.Culprit }}<p>Culprit: <code>{{ .EventGroup.Culprit }}</code></p>
+{{ end }}<form method="post" action="{{ .URL }}">
+<button type="submit">{{ .Action }}</button>
+</form>
+</body>
+</html>
+`))
+)
+
+/*
+Renders confirmation page for action link. Chat clients prefetch links
+(unfurling), so GET only shows this page and form posts to the same url
+to perform the action.
+*/
+func RenderConfirmation(action string, eventgroup *models.EventGroup, url string) (result []byte, err error) {
+	buffer := &bytes.Buffer{}
+	data := map[string]interface{}{
+		"Action":     action,
+		"EventGroup": eventgroup,
+		"URL":        url,
+	}
+	if err = confirmationTemplate.Execute(buffer, data); err != nil {
+		return
+	}
+	result = buffer.Bytes()
+	return
+}
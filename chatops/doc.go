@@ -0,0 +1,10 @@
+/*
+Package chatops posts eventgroup alerts to Slack or Mattermost compatible
+incoming webhooks.
+
+Messages carry attachment colored by level with culprit, count and
+environment. Attachments have action links to resolve or mute eventgroup,
+links are signed with secret key and valid for CHATOPS_ACTION_LINK_TIMEOUT,
+so they can be used without patrol login directly from chat.
+*/
+package chatops
@@ -0,0 +1,78 @@
+package chatops
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/settings"
+)
+
+/*
+Actions available through signed links
+*/
+const (
+	ACTION_RESOLVE = "resolve"
+	ACTION_MUTE    = "mute"
+)
+
+var (
+	ErrActionLinkInvalid = errors.New("invalid_action_link")
+	ErrActionLinkExpired = errors.New("expired_action_link")
+	ErrActionUnknown     = errors.New("unknown_action")
+)
+
+// returns whether action is known
+func IsAction(action string) bool {
+	return action == ACTION_RESOLVE || action == ACTION_MUTE
+}
+
+/*
+Returns signature of action for eventgroup valid until expires (unix time)
+*/
+func SignAction(secret, action string, eventgroupID int64, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s:%d:%d", action, eventgroupID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+/*
+Verifies signature of action link, returns ErrActionLinkExpired when link
+is valid but too old.
+*/
+func VerifyAction(secret, action string, eventgroupID int64, expires int64, signature string, now time.Time) error {
+	if !IsAction(action) {
+		return ErrActionUnknown
+	}
+	expected := SignAction(secret, action, eventgroupID, expires)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrActionLinkInvalid
+	}
+	if now.Unix() > expires {
+		return ErrActionLinkExpired
+	}
+	return nil
+}
+
+/*
+Returns absolute signed url of action for eventgroup
+*/
+func ActionURL(secret, action string, eventgroup *models.EventGroup, now time.Time) string {
+	expires := now.Add(settings.CHATOPS_ACTION_LINK_TIMEOUT).Unix()
+	id := eventgroup.ID.Int64()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", SignAction(secret, action, id, expires))
+
+	return fmt.Sprintf("%s/api/chatops/action/%s/%d?%s",
+		strings.TrimRight(settings.SETTINGS_BASE_URL, "/"), action, id, query.Encode(),
+	)
+}
@@ -0,0 +1,97 @@
+package chatops
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/parser"
+)
+
+/*
+Message in incoming webhook format (common for Slack and Mattermost)
+*/
+type Message struct {
+	Text        string        `json:"text,omitempty"`
+	Channel     string        `json:"channel,omitempty"`
+	Username    string        `json:"username,omitempty"`
+	Attachments []*Attachment `json:"attachments,omitempty"`
+}
+
+type Attachment struct {
+	Fallback  string              `json:"fallback"`
+	Color     string              `json:"color,omitempty"`
+	Title     string              `json:"title"`
+	TitleLink string              `json:"title_link,omitempty"`
+	Text      string              `json:"text,omitempty"`
+	Fields    []*AttachmentField  `json:"fields,omitempty"`
+	Actions   []*AttachmentAction `json:"actions,omitempty"`
+}
+
+type AttachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// link button, opened in browser
+type AttachmentAction struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	URL   string `json:"url"`
+	Style string `json:"style,omitempty"`
+}
+
+/*
+Returns attachment color for event level
+*/
+func LevelColor(level int) string {
+	switch {
+	case level >= parser.LEVEL_FATAL:
+		return "#7a0000"
+	case level >= parser.LEVEL_ERROR:
+		return "#e03e2f"
+	case level >= parser.LEVEL_WARNING:
+		return "#f5a623"
+	case level >= parser.LEVEL_INFO:
+		return "#2788ce"
+	}
+	return "#9b9b9b"
+}
+
+/*
+Returns message about eventgroup with signed resolve and mute links
+*/
+func NewEventGroupMessage(secret string, project *models.Project, eventgroup *models.EventGroup, environment string, link string) (message *Message) {
+	title := "New issue"
+	if eventgroup.IsRegression {
+		title = "Regression"
+	}
+
+	attachment := &Attachment{
+		Fallback:  fmt.Sprintf("[%s] %s: %s", project.Name, title, eventgroup.Message),
+		Color:     LevelColor(eventgroup.Level),
+		Title:     eventgroup.Message,
+		TitleLink: link,
+		Text:      fmt.Sprintf("%s in project %s", title, project.Name),
+		Fields: []*AttachmentField{
+			{Title: "Culprit", Value: eventgroup.Culprit, Short: false},
+			{Title: "Count", Value: strconv.FormatInt(eventgroup.TimesSeen, 10), Short: true},
+		},
+	}
+	if environment != "" {
+		attachment.Fields = append(attachment.Fields, &AttachmentField{Title: "Environment", Value: environment, Short: true})
+	}
+
+	now := time.Now()
+	attachment.Actions = []*AttachmentAction{
+		{Type: "button", Text: "Resolve", URL: ActionURL(secret, ACTION_RESOLVE, eventgroup, now), Style: "primary"},
+		{Type: "button", Text: "Mute", URL: ActionURL(secret, ACTION_MUTE, eventgroup, now)},
+	}
+
+	return &Message{
+		Username:    "patrol",
+		Attachments: []*Attachment{attachment},
+	}
+}
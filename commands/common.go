@@ -33,6 +33,15 @@ var (
 	// colors
 	sectioncolor = ansi.ColorFunc("yellow+h:black")
 	itemcolor    = ansi.ColorFunc("green+h:black")
+
+	// colors of event levels
+	levelcolors = map[string]func(string) string{
+		"fatal":   ansi.ColorFunc("red+b:black"),
+		"error":   ansi.ColorFunc("red+h:black"),
+		"warning": ansi.ColorFunc("yellow+h:black"),
+		"info":    ansi.ColorFunc("cyan+h:black"),
+		"debug":   ansi.ColorFunc("white:black"),
+	}
 )
 
 /*
@@ -9,6 +9,7 @@ import (
 	"github.com/phonkee/patrol/models"
 
 	"github.com/golang/glog"
+	"github.com/phonkee/patrol/alerts"
 	"github.com/phonkee/patrol/core"
 	"github.com/phonkee/patrol/parser"
 	"github.com/phonkee/patrol/settings"
@@ -140,15 +141,25 @@ func (e *EventWorker) ProcessEvent(re *parser.RawEvent) (err error) {
 
 	// some error occured
 	if event, eventgroup, err = eventManager.NewEventFromRaw(re); err != nil {
+		// duplicate event is not an error, message will be acked
+		if err == models.ErrEventAlreadyExists {
+			glog.V(2).Infof("event worker-%d: duplicate event %s dropped.", e.id, re.EventID)
+			return nil
+		}
 		return
 	}
 
 	// increment counters
-	err = eventgroupManager.IncrementCounters(eventgroup)
+	err = eventgroupManager.IncrementCounters(eventgroup, event)
 	if err != nil {
 		glog.Errorf("Increment counters returned error %+v", err)
 	}
 
+	// evaluate alert rules, failed evaluation does not fail processing
+	if errAlerts := alerts.NewEngine(e.context).Evaluate(re, event, eventgroup); errAlerts != nil {
+		glog.Errorf("event worker-%d: alert rules evaluation failed: %s.", e.id, errAlerts)
+	}
+
 	// send signal
 	e.onevent(event, eventgroup)
 
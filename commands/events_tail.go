@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/core"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/parser"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+var (
+	ErrTailNoProjects         = errors.New("no projects to tail")
+	ErrTailUnexpectedArgument = errors.New("unexpected argument")
+)
+
+/*
+Event tail command
+
+	prints newly processed events to stdout as they arrive
+*/
+func NewEventTailCommand(context *context.Context) core.Commander {
+	return &EventTailCommand{
+		context: context,
+		out:     os.Stdout,
+	}
+}
+
+type EventTailCommand struct {
+	core.Command
+	context *context.Context
+
+	// cli args settings
+	project string
+	level   int
+	filter  string
+	json    bool
+
+	out io.Writer
+}
+
+func (et *EventTailCommand) ID() string { return "tail" }
+func (et *EventTailCommand) Description() string {
+	return `Prints newly processed events
+patrol event:tail [project] [--level=warning] [--filter=text] [--json]`
+}
+
+/*
+Parses arguments, project is optional id or name of project. Flags can be
+given in any position, values of flags either as --level=warning or
+--level warning.
+*/
+func (et *EventTailCommand) ParseArgs(args []string) (err error) {
+	var (
+		level string
+		flags = flag.NewFlagSet(settings.EVENTS_PLUGIN_ID+":"+et.ID(), flag.ContinueOnError)
+	)
+	flags.StringVar(&level, "level", "", "minimal level of events")
+	flags.StringVar(&et.filter, "filter", "", "text contained in message or culprit")
+	flags.BoolVar(&et.json, "json", false, "print events as json (one per line)")
+
+	// flag set stops at first positional argument, so parsing continues
+	// after it
+	for {
+		if err = flags.Parse(args); err != nil {
+			return
+		}
+		if args = flags.Args(); len(args) == 0 {
+			break
+		}
+		if et.project != "" {
+			return fmt.Errorf("%s %s", ErrTailUnexpectedArgument, args[0])
+		}
+		et.project, args = args[0], args[1:]
+	}
+
+	if level != "" {
+		if _, ok := parser.LEVELS[strings.ToLower(level)]; !ok {
+			return fmt.Errorf("unknown level %s", level)
+		}
+		et.level = parser.LevelValue(level)
+	}
+	et.filter = strings.ToLower(et.filter)
+
+	return nil
+}
+
+func (et *EventTailCommand) Run() (err error) {
+	var projects []*models.Project
+	if projects, err = et.projects(); err != nil {
+		return
+	}
+
+	names := map[types.ForeignKey]string{}
+	channels := []string{}
+	for _, project := range projects {
+		names[project.ID.ToForeignKey()] = project.Name
+		channels = append(channels, models.RealtimeProjectChannel(project.ID))
+	}
+
+	messages, errs := models.NewRealtimeManager(et.context).Subscribe(et.context.Quit, channels...)
+
+	for {
+		select {
+		case <-et.context.Quit:
+			return nil
+		case err, ok := <-errs:
+			if !ok {
+				return nil
+			}
+			return err
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			envelope := &models.RealtimeEnvelope{}
+			if err = json.Unmarshal(message.Message, envelope); err != nil || envelope.Type != models.MESSAGE_TYPE_EVENT_CREATED {
+				continue
+			}
+			event := &models.EventCreatedRealtimeMessage{}
+			if err = json.Unmarshal(envelope.Data, event); err != nil || !et.matches(event) {
+				continue
+			}
+
+			fmt.Fprintln(et.out, et.output(envelope.Data, event, names[event.ProjectID]))
+		}
+	}
+}
+
+// returns projects to tail (all projects when project is not given)
+func (et *EventTailCommand) projects() (result []*models.Project, err error) {
+	manager := models.NewProjectManager(et.context)
+	result = manager.NewProjectList()
+
+	if et.project == "" {
+		err = manager.Filter(&result)
+	} else if id, errid := strconv.ParseInt(et.project, 10, 64); errid == nil {
+		err = manager.Filter(&result, manager.QueryFilterWhere("id = ?", id))
+	} else {
+		err = manager.Filter(&result, manager.QueryFilterWhere("name ILIKE ?", et.project))
+	}
+
+	if err == nil && len(result) == 0 {
+		err = ErrTailNoProjects
+	}
+	return
+}
+
+// returns whether event passes level and text filters
+func (et *EventTailCommand) matches(event *models.EventCreatedRealtimeMessage) bool {
+	if event.Level < et.level {
+		return false
+	}
+	if et.filter == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(event.Message), et.filter) ||
+		strings.Contains(strings.ToLower(event.Culprit), et.filter)
+}
+
+// returns line printed for event, raw json data with --json
+func (et *EventTailCommand) output(data json.RawMessage, event *models.EventCreatedRealtimeMessage, project string) string {
+	if et.json {
+		return string(data)
+	}
+	return et.format(event, project)
+}
+
+// returns coloured one line representation of event
+func (et *EventTailCommand) format(event *models.EventCreatedRealtimeMessage, project string) string {
+	level := parser.LevelName(event.Level)
+	line := fmt.Sprintf("%s %s %s %s",
+		event.Datetime.Local().Format("2006-01-02 15:04:05"),
+		levelcolors[level](utils.StringPadRight("["+level+"]", " ", 9)),
+		sectioncolor("["+printable(project)+"]"),
+		printable(event.Message),
+	)
+	if event.Culprit != "" {
+		line = line + " " + itemcolor("("+printable(event.Culprit)+")")
+	}
+	return line
+}
+
+/*
+Returns text safe to print to terminal. Events come from clients, so escape
+and other control characters (which could inject terminal sequences) are
+escaped, whitespace is printed as single space.
+*/
+func printable(text string) string {
+	result := &bytes.Buffer{}
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			result.WriteByte(' ')
+		case unicode.IsControl(r):
+			fmt.Fprintf(result, "\\x%02x", r)
+		default:
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
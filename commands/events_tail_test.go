@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/parser"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEventTailCommand(t *testing.T) {
+
+	Convey("Test ParseArgs", t, func() {
+		tests := []struct {
+			args    []string
+			project string
+			level   int
+			filter  string
+			json    bool
+			err     bool
+		}{
+			{args: []string{}},
+			{args: []string{"web"}, project: "web"},
+			{args: []string{"web", "--level=warning"}, project: "web", level: parser.LEVEL_WARNING},
+			{args: []string{"--level=warning", "web"}, project: "web", level: parser.LEVEL_WARNING},
+			{args: []string{"--level", "warning", "web"}, project: "web", level: parser.LEVEL_WARNING},
+			{args: []string{"web", "--level", "ERROR"}, project: "web", level: parser.LEVEL_ERROR},
+			{args: []string{"--json", "12", "--filter", "Timeout"}, project: "12", filter: "timeout", json: true},
+			{args: []string{"--filter=db", "--json"}, filter: "db", json: true},
+			{args: []string{"--level=unknown"}, err: true},
+			{args: []string{"--level"}, err: true},
+			{args: []string{"--unknown"}, err: true},
+			{args: []string{"web", "api"}, err: true},
+		}
+
+		for _, test := range tests {
+			command := &EventTailCommand{}
+			err := command.ParseArgs(test.args)
+			if test.err {
+				So(err, ShouldNotBeNil)
+				continue
+			}
+			So(err, ShouldBeNil)
+			So(command.project, ShouldEqual, test.project)
+			So(command.level, ShouldEqual, test.level)
+			So(command.filter, ShouldEqual, test.filter)
+			So(command.json, ShouldEqual, test.json)
+		}
+	})
+
+	Convey("Test matches", t, func() {
+		event := &models.EventCreatedRealtimeMessage{
+			Message: "Connection Timeout",
+			Culprit: "db.Connect",
+			Level:   parser.LEVEL_ERROR,
+		}
+
+		tests := []struct {
+			level   string
+			filter  string
+			matches bool
+		}{
+			{matches: true},
+			{level: "warning", matches: true},
+			{level: "error", matches: true},
+			{level: "fatal", matches: false},
+			{filter: "timeout", matches: true},
+			{filter: "TIMEOUT", matches: true},
+			{filter: "DB.connect", matches: true},
+			{filter: "cache", matches: false},
+			{level: "fatal", filter: "timeout", matches: false},
+		}
+
+		for _, test := range tests {
+			args := []string{}
+			if test.level != "" {
+				args = append(args, "--level="+test.level)
+			}
+			if test.filter != "" {
+				args = append(args, "--filter="+test.filter)
+			}
+			command := &EventTailCommand{}
+			So(command.ParseArgs(args), ShouldBeNil)
+			So(command.matches(event), ShouldEqual, test.matches)
+		}
+	})
+
+	Convey("Test output", t, func() {
+		event := &models.EventCreatedRealtimeMessage{
+			ID:       1,
+			Message:  "first line\nsecond line",
+			Culprit:  "main.go",
+			Level:    parser.LEVEL_WARNING,
+			Datetime: time.Now(),
+		}
+		data, err := json.Marshal(event)
+		So(err, ShouldBeNil)
+
+		command := &EventTailCommand{}
+		line := command.output(data, event, "web")
+		So(line, ShouldContainSubstring, "[warning]")
+		So(line, ShouldContainSubstring, "[web]")
+		So(line, ShouldContainSubstring, "first line second line")
+		So(line, ShouldContainSubstring, "(main.go)")
+
+		command.json = true
+		line = command.output(data, event, "web")
+		So(line, ShouldEqual, string(data))
+
+		decoded := &models.EventCreatedRealtimeMessage{}
+		So(json.Unmarshal([]byte(line), decoded), ShouldBeNil)
+		So(decoded.Message, ShouldEqual, event.Message)
+	})
+
+	Convey("Test output escapes control characters", t, func() {
+		tests := []struct {
+			text     string
+			expected string
+		}{
+			{"plain text", "plain text"},
+			{"first\nsecond\r\tthird", "first second  third"},
+			{"\x1b[2J\x1b]0;title\x07cleared", "\\x1b[2J\\x1b]0;title\\x07cleared"},
+			{"csi \u009b31m", "csi \\x9b31m"},
+			{"unicode žluťoučký", "unicode žluťoučký"},
+		}
+		for _, test := range tests {
+			So(printable(test.text), ShouldEqual, test.expected)
+		}
+
+		event := &models.EventCreatedRealtimeMessage{
+			Message:  "\x1b[31mred",
+			Culprit:  "\x1b]8;;http://example.com\x07link",
+			Level:    parser.LEVEL_ERROR,
+			Datetime: time.Now(),
+		}
+		line := (&EventTailCommand{}).format(event, "\x1b[0mweb")
+		So(line, ShouldContainSubstring, "\\x1b[31mred")
+		So(line, ShouldContainSubstring, "\\x1b]8;;http://example.com\\x07link")
+		So(line, ShouldContainSubstring, "\\x1b[0mweb")
+	})
+}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/core"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/webhooks"
+)
+
+/*
+Webhook commands
+*/
+
+func NewWebhookWorkerCommand(context *context.Context) core.Commander {
+	return &WebhookWorkerCommand{
+		context: context,
+	}
+}
+
+type WebhookWorkerCommand struct {
+	core.Command
+	context *context.Context
+
+	// cli args settings
+	goroutinesCount int
+}
+
+func (ww *WebhookWorkerCommand) ID() string { return "worker" }
+func (ww *WebhookWorkerCommand) Description() string {
+	return `Runs webhook delivery worker
+patrol webhooks:worker [goroutines=2]`
+}
+func (ww *WebhookWorkerCommand) Run() error {
+	glog.Infof("webhook worker: running %d workers (goroutines).", ww.goroutinesCount)
+	var wg sync.WaitGroup
+
+	wg.Add(ww.goroutinesCount)
+	for i := 0; i < ww.goroutinesCount; i++ {
+		worker := NewWebhookWorker(ww.context, i)
+		go func() {
+			defer wg.Done()
+			worker.Run()
+		}()
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+func (ww *WebhookWorkerCommand) ParseArgs(args []string) (err error) {
+	// Parse goroutines count
+	ww.goroutinesCount = settings.WEBHOOK_WORKER_DEFAULT_GOROUTINES_COUNT
+	if len(args) > 0 {
+		ww.goroutinesCount, err = strconv.Atoi(args[0])
+		if err != nil {
+			return
+		} else if ww.goroutinesCount <= 0 {
+			ww.goroutinesCount = settings.WEBHOOK_WORKER_DEFAULT_GOROUTINES_COUNT
+		}
+	}
+	return nil
+}
+
+/*
+Webhook background worker
+*/
+func NewWebhookWorker(context *context.Context, id int) *WebhookWorker {
+	return &WebhookWorker{
+		context:    context,
+		id:         id,
+		dispatcher: webhooks.NewDispatcher(context),
+	}
+}
+
+type WebhookWorker struct {
+	context    *context.Context
+	id         int
+	dispatcher *webhooks.Dispatcher
+}
+
+/*
+Runs webhook worker, every second delivers all due tasks from queue. Tasks
+waiting for retry are pushed back to queue.
+*/
+func (w *WebhookWorker) Run() error {
+	for {
+		select {
+		case <-w.context.Quit:
+			return nil
+		case <-time.After(time.Second):
+			w.process()
+		}
+	}
+}
+
+// processes queue until it's empty or only postponed tasks remain
+func (w *WebhookWorker) process() {
+	postponed := map[string]struct{}{}
+
+	for {
+		task, message, err := w.dispatcher.Pop()
+		if err != nil {
+			if message == nil {
+				glog.V(2).Infof("webhook worker-%d: %s.", w.id, err)
+				return
+			}
+			glog.Errorf("webhook worker-%d: invalid task dropped: %s.", w.id, err)
+			message.Ack()
+			continue
+		}
+
+		key := task.GUID + ":" + strconv.Itoa(task.Attempt)
+
+		switch err = w.dispatcher.Deliver(task); err {
+		case nil:
+		case webhooks.ErrTaskNotDue:
+			if errPush := w.dispatcher.Push(task); errPush != nil {
+				glog.Errorf("webhook worker-%d: cannot postpone delivery %s: %s.", w.id, task.GUID, errPush)
+				continue
+			}
+		default:
+			glog.Errorf("webhook worker-%d: delivery %s failed: %s.", w.id, task.GUID, err)
+			continue
+		}
+
+		if errAck := message.Ack(); errAck != nil {
+			glog.Errorf("webhook worker-%d: message ack failed with %s.", w.id, errAck)
+		}
+
+		// whole queue was already seen in this run
+		if err == webhooks.ErrTaskNotDue {
+			if _, seen := postponed[key]; seen {
+				return
+			}
+			postponed[key] = struct{}{}
+		}
+	}
+}
@@ -42,6 +42,15 @@ type Migrationer interface {
 	PostMigrate(context *context.Context) error
 }
 
+/* PostMigrater
+plugins that implement this interface are called after every migrate (even
+when there are no pending migrations), so they can seed data that depends on
+registered plugins (e.g. permissions).
+*/
+type PostMigrater interface {
+	PostMigrate(context *context.Context) error
+}
+
 // Simple migration
 type simpleMigration struct {
 	id             string
@@ -97,6 +106,9 @@ func (s *SchemaEditor) Migrate() (err error) {
 		return
 	}
 	if count == 0 {
+		if err = s.PostMigratePlugins(); err != nil {
+			return
+		}
 		return fmt.Errorf("no pending migrations")
 	}
 
@@ -137,7 +149,21 @@ func (s *SchemaEditor) Migrate() (err error) {
 		fmt.Println(green("... OK"))
 	}
 
-	return nil
+	return s.PostMigratePlugins()
+}
+
+/* Runs PostMigrate of all plugins that implement PostMigrater interface
+ */
+func (s *SchemaEditor) PostMigratePlugins() (err error) {
+	return s.pr.Do(func(plugin Pluginer) error {
+		if pm, ok := plugin.(PostMigrater); ok {
+			glog.V(2).Infof("patrol: post migrate plugin %s.", plugin.ID())
+			if err := pm.PostMigrate(s.context); err != nil {
+				return fmt.Errorf("post migrate %s failed with: %s", plugin.ID(), err)
+			}
+		}
+		return nil
+	})
 }
 
 /* Process migration runs migration in transaction and inserts into schema table
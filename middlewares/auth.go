@@ -6,7 +6,9 @@ import (
 	"github.com/justinas/alice"
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest"
 	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/types"
 )
 
 /*
@@ -25,6 +27,11 @@ func AuthTokenValidMiddleware() alice.Constructor {
 
 			user := manager.NewUser()
 			if err = manager.GetAuthUser(user, r); err != nil {
+				// personal token without required scope
+				if err == models.ErrTokenScope {
+					response.New(http.StatusForbidden).Error(err).Write(w, r)
+					return
+				}
 				response.New(http.StatusUnauthorized).Error(err).Write(w, r)
 				return
 			}
@@ -41,3 +48,101 @@ func AuthTokenValidMiddleware() alice.Constructor {
 		})
 	}
 }
+
+/*
+QueryTokenMiddleware copies auth token from query parameter to Authorization
+header when header is not set. Browsers cannot set headers on EventSource
+(and websocket) requests. It must be used before other auth middlewares.
+*/
+func QueryTokenMiddleware(param string) alice.Constructor {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token := r.URL.Query().Get(param); token != "" && r.Header.Get("Authorization") == "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			// serve next
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+/*
+TokenScopeMiddleware sets scope which personal token needs for request: read
+scope for safe methods and write scope for others. It must be used before
+AuthTokenValidMiddleware.
+*/
+func TokenScopeMiddleware(read, write string) alice.Constructor {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, _ := context.Get(r)
+
+			switch r.Method {
+			case "GET", "HEAD", "OPTIONS":
+				ctx.Set(models.AUTH_SCOPE_CONTEXT_KEY, read)
+			default:
+				ctx.Set(models.AUTH_SCOPE_CONTEXT_KEY, write)
+			}
+
+			// serve next
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+/*
+RequirePermission checks whether authenticated user has permission (see
+UserManager.HasPermission). When route has team_id variable, user must be
+admin of given team. If methods are given, permission is checked only for
+these methods. It must be used after AuthTokenValidMiddleware.
+*/
+func RequirePermission(codename string, methods ...string) alice.Constructor {
+	checked := types.StringSlice(methods)
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var (
+				err    error
+				result bool
+			)
+
+			if len(checked) > 0 && !checked.Has(r.Method) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, _ := context.Get(r)
+
+			manager := models.NewUserManager(ctx)
+			user := manager.NewUser()
+			if err = manager.GetAuthUser(user, r); err != nil {
+				response.New(http.StatusUnauthorized).Write(w, r)
+				return
+			}
+
+			teams := []*models.Team{}
+			if pk, errpk := rest.GetMuxVarPrimaryKey(r, "team_id"); errpk == nil {
+				team := models.NewTeam()
+				if err = models.NewTeamManager(ctx).GetByID(team, pk); err != nil {
+					if err == models.ErrObjectDoesNotExists {
+						response.New(http.StatusNotFound).Write(w, r)
+					} else {
+						response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+					}
+					return
+				}
+				teams = append(teams, team)
+			}
+
+			if result, err = manager.HasPermission(user, codename, teams...); err != nil {
+				response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+				return
+			} else if !result {
+				response.New(http.StatusForbidden).Write(w, r)
+				return
+			}
+
+			// serve next
+			h.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/rest/paginator"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+var (
+	ErrAlertRuleBadValue = errors.New("alert rule: bad value")
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_ALERTS_ALERTRULE_INITIAL_ID = "alerts-alertrule-initial"
+	MIGRATION_ALERTS_ALERTRULE_INITIAL    = `CREATE TABLE ` + ALERTS_ALERTRULE_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		project_id bigint NOT NULL REFERENCES ` + PROJECTS_PROJECT_DB_TABLE + ` ON DELETE CASCADE,
+		name character varying (` + strconv.Itoa(MAX_ALERT_RULE_NAME_LENGTH) + `) NOT NULL,
+		enabled boolean NOT NULL DEFAULT true,
+		match_all boolean NOT NULL DEFAULT true,
+		conditions text NOT NULL,
+		actions text NOT NULL,
+		frequency integer NOT NULL,
+		date_created timestamp with time zone NOT NULL
+	)`
+	MIGRATION_ALERTS_ALERTRULE_INITIAL_DEPENDENCIES = []string{
+		settings.PROJECTS_PLUGIN_ID + ":" + MIGRATION_PROJECT_INITIAL_ID,
+	}
+)
+
+/*
+Alert condition types
+*/
+const (
+	// first event of eventgroup
+	ALERT_CONDITION_NEW_EVENTGROUP = "new_eventgroup"
+	// resolved eventgroup occured again
+	ALERT_CONDITION_REGRESSION = "regression"
+	// event level is at or above level
+	ALERT_CONDITION_LEVEL = "level"
+	// event has tag key with value matching glob pattern
+	ALERT_CONDITION_TAG = "tag"
+	// eventgroup has more than count events in last minutes
+	ALERT_CONDITION_EVENT_FREQUENCY = "event_frequency"
+	// eventgroup affected more than count users in last minutes
+	ALERT_CONDITION_USER_FREQUENCY = "user_frequency"
+)
+
+var (
+	ALERT_CONDITION_TYPES = []string{
+		ALERT_CONDITION_NEW_EVENTGROUP,
+		ALERT_CONDITION_REGRESSION,
+		ALERT_CONDITION_LEVEL,
+		ALERT_CONDITION_TAG,
+		ALERT_CONDITION_EVENT_FREQUENCY,
+		ALERT_CONDITION_USER_FREQUENCY,
+	}
+)
+
+/*
+AlertCondition
+
+	single condition of alert rule, used params depend on type
+*/
+type AlertCondition struct {
+	Type    string `json:"type"`
+	Level   int    `json:"level,omitempty"`
+	Key     string `json:"key,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Count   int    `json:"count,omitempty"`
+	Minutes int    `json:"minutes,omitempty"`
+}
+
+// returns whether condition has known type and valid params
+func (a *AlertCondition) IsValid() bool {
+	switch a.Type {
+	case ALERT_CONDITION_NEW_EVENTGROUP, ALERT_CONDITION_REGRESSION:
+		return true
+	case ALERT_CONDITION_LEVEL:
+		return a.Level > 0
+	case ALERT_CONDITION_TAG:
+		return a.Key != "" && len(a.Key) <= MAX_TAG_KEY_LENGTH && len(a.Value) <= MAX_TAG_VALUE_LENGTH
+	case ALERT_CONDITION_EVENT_FREQUENCY, ALERT_CONDITION_USER_FREQUENCY:
+		return a.Count > 0 && a.Minutes > 0 && a.Minutes <= MAX_ALERT_CONDITION_MINUTES
+	}
+	return false
+}
+
+/*
+AlertConditionList is stored to database as json
+*/
+type AlertConditionList []*AlertCondition
+
+func (a *AlertConditionList) Scan(value interface{}) error {
+	return scanJSON(value, a)
+}
+
+func (a AlertConditionList) Value() (driver.Value, error) {
+	if a == nil {
+		a = AlertConditionList{}
+	}
+	return valueJSON(a)
+}
+
+/*
+AlertAction
+
+	action performed when alert rule matches, type is id of action registered
+	in alerts action registry
+*/
+type AlertAction struct {
+	Type    string            `json:"type"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+/*
+AlertActionList is stored to database as json
+*/
+type AlertActionList []*AlertAction
+
+func (a *AlertActionList) Scan(value interface{}) error {
+	return scanJSON(value, a)
+}
+
+func (a AlertActionList) Value() (driver.Value, error) {
+	if a == nil {
+		a = AlertActionList{}
+	}
+	return valueJSON(a)
+}
+
+// scans json stored in text column
+func scanJSON(value interface{}, target interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, target)
+	case string:
+		return json.Unmarshal([]byte(v), target)
+	}
+	return ErrAlertRuleBadValue
+}
+
+// returns json representation for text column
+func valueJSON(value interface{}) (driver.Value, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return string(body), nil
+}
+
+/*
+AlertRule model
+
+	rule is evaluated for every processed event of project. When conditions
+	match (all or any of them), actions are performed. Actions are performed at
+	most once per Frequency minutes for single eventgroup.
+*/
+type AlertRule struct {
+	Model
+	ProjectID   types.ForeignKey   `db:"project_id" json:"project_id"`
+	Name        string             `db:"name" json:"name"`
+	Enabled     bool               `db:"enabled" json:"enabled"`
+	MatchAll    bool               `db:"match_all" json:"match_all"`
+	Conditions  AlertConditionList `db:"conditions" json:"conditions"`
+	Actions     AlertActionList    `db:"actions" json:"actions"`
+	Frequency   int                `db:"frequency" json:"frequency"`
+	DateCreated time.Time          `db:"date_created" json:"date_created"`
+}
+
+// returns all columns except of primary key
+func (a *AlertRule) Columns() []string {
+	return []string{
+		"project_id", "name", "enabled", "match_all", "conditions",
+		"actions", "frequency", "date_created",
+	}
+}
+func (a *AlertRule) Values() []interface{} {
+	return []interface{}{
+		a.ProjectID, a.Name, a.Enabled, a.MatchAll, a.Conditions,
+		a.Actions, a.Frequency, a.DateCreated,
+	}
+}
+func (a *AlertRule) String() string { return "alerts:alertrule:" + a.PrimaryKey().String() }
+func (a *AlertRule) Table() string  { return ALERTS_ALERTRULE_DB_TABLE }
+
+// enabled rules are cached by project
+func alertRuleProjectCacheKey(projectID types.ForeignKey) string {
+	return "alerts:alertrule:project:" + strconv.FormatInt(projectID.Int64(), 10)
+}
+
+/*
+CRUD
+*/
+func (a *AlertRule) Insert(ctx *context.Context) (err error) {
+	if err = DBInsert(ctx, a); err != nil {
+		return
+	}
+	return RemoveCached(ctx, alertRuleProjectCacheKey(a.ProjectID))
+}
+
+func (a *AlertRule) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	if changed, err = DBUpdate(ctx, a, fields...); err != nil {
+		return
+	}
+	err = RemoveCached(ctx, alertRuleProjectCacheKey(a.ProjectID))
+	return
+}
+
+func (a *AlertRule) Delete(ctx *context.Context) (err error) {
+	if err = DBDelete(ctx, a); err != nil {
+		return
+	}
+	return RemoveCached(ctx, alertRuleProjectCacheKey(a.ProjectID))
+}
+
+/*
+AlertRuleManager
+*/
+type AlertRuleManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewAlertRuleManager(context *context.Context) *AlertRuleManager {
+	return &AlertRuleManager{context: context}
+}
+
+// returns new model instance with default values
+func NewAlertRule(funcs ...func(*AlertRule)) (rule *AlertRule) {
+	rule = &AlertRule{
+		Enabled:     true,
+		MatchAll:    true,
+		Conditions:  AlertConditionList{},
+		Actions:     AlertActionList{},
+		Frequency:   settings.ALERT_RULE_DEFAULT_FREQUENCY,
+		DateCreated: utils.NowTruncated(),
+	}
+	for _, f := range funcs {
+		f(rule)
+	}
+	return
+}
+
+func (a *AlertRuleManager) NewAlertRule(funcs ...func(*AlertRule)) *AlertRule {
+	return NewAlertRule(funcs...)
+}
+func (a *AlertRuleManager) NewAlertRuleList() []*AlertRule { return []*AlertRule{} }
+
+// Filter results without paging
+func (a *AlertRuleManager) Filter(target interface{}, qfs ...utils.QueryFunc) error {
+	_, safe := target.([]*AlertRule)
+	return DBFilter(a.context, ALERTS_ALERTRULE_DB_TABLE+".*", ALERTS_ALERTRULE_DB_TABLE, !safe, target, qfs...)
+}
+
+// Filter results with paging
+func (a *AlertRuleManager) FilterPaged(target interface{}, paging *paginator.Paginator, qfs ...utils.QueryFunc) (err error) {
+	if err = DBFilterCount(a.context, ALERTS_ALERTRULE_DB_TABLE, paging, qfs...); err != nil {
+		return
+	}
+
+	// add paging query filter
+	qfs = append(qfs, a.QueryFilterPaging(paging))
+
+	_, safe := target.([]*AlertRule)
+
+	return DBFilter(a.context, ALERTS_ALERTRULE_DB_TABLE+".*", ALERTS_ALERTRULE_DB_TABLE, !safe, target, qfs...)
+}
+
+// get from database
+func (a *AlertRuleManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*AlertRule)
+	return DBGet(a.context, "*", ALERTS_ALERTRULE_DB_TABLE, !safe, target, qfs...)
+}
+
+// returns by id and possibly other queryFuncs
+func (a *AlertRuleManager) GetByID(target interface{}, id types.Keyer, qfs ...utils.QueryFunc) (err error) {
+	qfs = append(qfs, a.QueryFilterWhere("id = ?", id.Int64()))
+	return a.Get(target, qfs...)
+}
+
+/*
+Returns enabled rules of project, rules are cached until some rule of project
+is changed.
+*/
+func (a *AlertRuleManager) FilterEnabled(target *[]*AlertRule, projectID types.ForeignKey) (err error) {
+	cacheKey := alertRuleProjectCacheKey(projectID)
+	if err = GetCached(a.context, cacheKey, target); err == nil {
+		return
+	}
+
+	if err = a.Filter(target,
+		a.QueryFilterWhere(ALERTS_ALERTRULE_DB_TABLE+".project_id = ? AND "+ALERTS_ALERTRULE_DB_TABLE+".enabled = ?", projectID, true),
+		a.QueryFilterOrderID(),
+	); err != nil {
+		return
+	}
+
+	return Cache(a.context, cacheKey, target)
+}
+
+/*
+Returns whether rule already performed actions for eventgroup in last
+rule.Frequency minutes. First call in period returns false.
+*/
+func (a *AlertRuleManager) IsThrottled(rule *AlertRule, eventgroup *EventGroup) (throttled bool, err error) {
+	handleNilPointer(rule)
+	handleNilPointer(eventgroup)
+
+	if rule.Frequency <= 0 {
+		return false, nil
+	}
+
+	cacheKey := rule.String() + ":eventgroup:" + eventgroup.PrimaryKey().String()
+
+	var count int
+	if count, err = IncrTimeout(a.context, cacheKey, time.Duration(rule.Frequency)*time.Minute); err != nil {
+		return
+	}
+
+	return count > 1, nil
+}
+
+// filters rules by project
+func (a *AlertRuleManager) QueryFilterProject(project *Project) utils.QueryFunc {
+	handleNilPointer(project)
+	return a.QueryFilterWhere(ALERTS_ALERTRULE_DB_TABLE+".project_id = ?", project.ID)
+}
+
+// orders rules by id
+func (a *AlertRuleManager) QueryFilterOrderID() utils.QueryFunc {
+	return func(builder squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return builder.OrderBy(ALERTS_ALERTRULE_DB_TABLE + ".id ASC")
+	}
+}
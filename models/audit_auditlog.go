@@ -0,0 +1,330 @@
+package models
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/rest/paginator"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+
+	audit log has no foreign keys, so entries survive deletion of actor or
+	target. Rules make table append-only.
+*/
+var (
+	MIGRATION_AUDIT_AUDITLOG_INITIAL_ID = "audit-auditlog-initial"
+	MIGRATION_AUDIT_AUDITLOG_INITIAL    = `CREATE TABLE ` + AUDIT_AUDITLOG_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		actor_id bigint NOT NULL,
+		action character varying (` + strconv.Itoa(MAX_AUDIT_ACTION_LENGTH) + `) NOT NULL,
+		target_type character varying (` + strconv.Itoa(MAX_AUDIT_TARGET_TYPE_LENGTH) + `) NOT NULL,
+		target_id bigint NOT NULL,
+		team_id bigint NULL,
+		ip character varying (` + strconv.Itoa(MAX_AUDIT_IP_LENGTH) + `) NOT NULL DEFAULT '',
+		user_agent character varying (` + strconv.Itoa(MAX_AUDIT_USER_AGENT_LENGTH) + `) NOT NULL DEFAULT '',
+		changes text NOT NULL,
+		date_created timestamp with time zone NOT NULL
+	)`
+	MIGRATION_AUDIT_AUDITLOG_INDEX = `CREATE INDEX ` + AUDIT_AUDITLOG_DB_TABLE + `_team_id ON ` +
+		AUDIT_AUDITLOG_DB_TABLE + ` (team_id, date_created DESC)`
+	MIGRATION_AUDIT_AUDITLOG_TARGET_INDEX = `CREATE INDEX ` + AUDIT_AUDITLOG_DB_TABLE + `_target ON ` +
+		AUDIT_AUDITLOG_DB_TABLE + ` (target_type, target_id)`
+	MIGRATION_AUDIT_AUDITLOG_NO_UPDATE = `CREATE RULE ` + AUDIT_AUDITLOG_DB_TABLE + `_no_update AS ON UPDATE TO ` +
+		AUDIT_AUDITLOG_DB_TABLE + ` DO INSTEAD NOTHING`
+	MIGRATION_AUDIT_AUDITLOG_NO_DELETE = `CREATE RULE ` + AUDIT_AUDITLOG_DB_TABLE + `_no_delete AS ON DELETE TO ` +
+		AUDIT_AUDITLOG_DB_TABLE + ` DO INSTEAD NOTHING`
+)
+
+/*
+Audit actions
+*/
+const (
+	AUDIT_ACTION_CREATE = "create"
+	AUDIT_ACTION_UPDATE = "update"
+	AUDIT_ACTION_DELETE = "delete"
+	AUDIT_ACTION_GRANT  = "grant"
+	AUDIT_ACTION_REVOKE = "revoke"
+	AUDIT_ACTION_UNLOCK = "unlock"
+
+	// value stored instead of sensitive columns
+	AUDIT_REDACTED_VALUE = "[redacted]"
+)
+
+var (
+	AUDIT_ACTION_LIST = types.StringSlice{
+		AUDIT_ACTION_CREATE, AUDIT_ACTION_UPDATE, AUDIT_ACTION_DELETE,
+		AUDIT_ACTION_GRANT, AUDIT_ACTION_REVOKE, AUDIT_ACTION_UNLOCK,
+	}
+
+	// columns whose values are never stored in audit log
+	AUDIT_REDACTED_COLUMNS = []string{"password", "secret", "secret_key", "token_hash", "recovery_codes"}
+
+	ErrAuditLogAppendOnly = errors.New("audit log is append only")
+)
+
+/*
+AuditChange is value of column before and after change
+*/
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+/*
+AuditChanges maps column name to its change, stored as json
+*/
+type AuditChanges map[string]AuditChange
+
+func (a *AuditChanges) Scan(value interface{}) error {
+	return scanJSON(value, a)
+}
+
+func (a AuditChanges) Value() (driver.Value, error) {
+	if a == nil {
+		a = AuditChanges{}
+	}
+	return valueJSON(a)
+}
+
+/*
+Returns changes between before and after. When before is nil (created) or
+after is nil (deleted), all columns are returned. Values of sensitive columns
+are redacted.
+*/
+func NewAuditChanges(before, after Modeler) (changes AuditChanges, err error) {
+	changes = AuditChanges{}
+
+	switch {
+	case before == nil && after == nil:
+		return
+	case before == nil:
+		values := after.Values()
+		for i, column := range after.Columns() {
+			changes[column] = AuditChange{After: values[i]}
+		}
+	case after == nil:
+		values := before.Values()
+		for i, column := range before.Columns() {
+			changes[column] = AuditChange{Before: values[i]}
+		}
+	default:
+		var fields []string
+		if fields, err = ChangedModelFields(before, after); err != nil {
+			return
+		}
+
+		columns := before.Columns()
+		beforeValues, afterValues := before.Values(), after.Values()
+		for _, field := range fields {
+			for i, column := range columns {
+				if column == field {
+					changes[column] = AuditChange{Before: beforeValues[i], After: afterValues[i]}
+					break
+				}
+			}
+		}
+	}
+
+	for _, column := range AUDIT_REDACTED_COLUMNS {
+		if change, ok := changes[column]; ok {
+			if change.Before != nil {
+				change.Before = AUDIT_REDACTED_VALUE
+			}
+			if change.After != nil {
+				change.After = AUDIT_REDACTED_VALUE
+			}
+			changes[column] = change
+		}
+	}
+
+	return
+}
+
+/*
+AuditLog model
+
+	single administrative action performed by actor on target (any model).
+	Team is set for targets that belong to team, so team admins can read
+	audit log of their team.
+*/
+type AuditLog struct {
+	Model
+	ActorID     types.ForeignKey     `db:"actor_id" json:"actor_id"`
+	Action      string               `db:"action" json:"action"`
+	TargetType  string               `db:"target_type" json:"target_type"`
+	TargetID    types.ForeignKey     `db:"target_id" json:"target_id"`
+	TeamID      types.NullForeignKey `db:"team_id" json:"team_id"`
+	IP          string               `db:"ip" json:"ip"`
+	UserAgent   string               `db:"user_agent" json:"user_agent"`
+	Changes     AuditChanges         `db:"changes" json:"changes"`
+	DateCreated time.Time            `db:"date_created" json:"date_created"`
+}
+
+// returns all columns except of primary key
+func (a *AuditLog) Columns() []string {
+	return []string{
+		"actor_id", "action", "target_type", "target_id", "team_id",
+		"ip", "user_agent", "changes", "date_created",
+	}
+}
+func (a *AuditLog) Values() []interface{} {
+	return []interface{}{
+		a.ActorID, a.Action, a.TargetType, a.TargetID, a.TeamID,
+		a.IP, a.UserAgent, a.Changes, a.DateCreated,
+	}
+}
+func (a *AuditLog) String() string { return "audit:auditlog:" + a.PrimaryKey().String() }
+func (a *AuditLog) Table() string  { return AUDIT_AUDITLOG_DB_TABLE }
+
+/*
+Sets target and changes from state of target before and after action (one of
+them can be nil)
+*/
+func (a *AuditLog) SetTarget(before, after Modeler) (err error) {
+	target := after
+	if target == nil {
+		target = before
+	}
+	handleNilPointer(target)
+
+	if a.Changes, err = NewAuditChanges(before, after); err != nil {
+		return
+	}
+
+	a.TargetType = target.Table()
+	a.TargetID = target.PrimaryKey().ToForeignKey()
+	return
+}
+
+/*
+Sets team of audit log
+*/
+func (a *AuditLog) SetTeam(teamID types.ForeignKey) {
+	a.TeamID = types.NullForeignKey{NullInt64: sql.NullInt64{Int64: teamID.Int64(), Valid: true}}
+}
+
+/*
+CRUD
+
+	audit log is append only, entries cannot be updated nor deleted
+*/
+func (a *AuditLog) Insert(ctx *context.Context) (err error) {
+	a.IP = utils.StringTruncate(a.IP, MAX_AUDIT_IP_LENGTH)
+	a.UserAgent = utils.StringTruncate(a.UserAgent, MAX_AUDIT_USER_AGENT_LENGTH)
+	return DBInsert(ctx, a)
+}
+
+func (a *AuditLog) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return false, ErrAuditLogAppendOnly
+}
+
+func (a *AuditLog) Delete(ctx *context.Context) (err error) {
+	return ErrAuditLogAppendOnly
+}
+
+/*
+AuditLogManager
+*/
+type AuditLogManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewAuditLogManager(context *context.Context) *AuditLogManager {
+	return &AuditLogManager{context: context}
+}
+
+// returns new model instance
+func NewAuditLog(funcs ...func(*AuditLog)) (auditlog *AuditLog) {
+	auditlog = &AuditLog{
+		Changes:     AuditChanges{},
+		DateCreated: utils.NowTruncated(),
+	}
+	for _, f := range funcs {
+		f(auditlog)
+	}
+	return
+}
+
+func (a *AuditLogManager) NewAuditLog(funcs ...func(*AuditLog)) *AuditLog {
+	return NewAuditLog(funcs...)
+}
+func (a *AuditLogManager) NewAuditLogList() []*AuditLog { return []*AuditLog{} }
+
+// Filter results with paging
+func (a *AuditLogManager) FilterPaged(target interface{}, paging *paginator.Paginator, qfs ...utils.QueryFunc) (err error) {
+	if err = DBFilterCount(a.context, AUDIT_AUDITLOG_DB_TABLE, paging, qfs...); err != nil {
+		return
+	}
+
+	// add paging query filter
+	qfs = append(qfs, a.QueryFilterPaging(paging))
+
+	_, safe := target.([]*AuditLog)
+
+	return DBFilter(a.context, AUDIT_AUDITLOG_DB_TABLE+".*", AUDIT_AUDITLOG_DB_TABLE, !safe, target, qfs...)
+}
+
+// get from database
+func (a *AuditLogManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*AuditLog)
+	return DBGet(a.context, "*", AUDIT_AUDITLOG_DB_TABLE, !safe, target, qfs...)
+}
+
+// returns by id and possibly other queryFuncs
+func (a *AuditLogManager) GetByID(target interface{}, id types.Keyer, qfs ...utils.QueryFunc) (err error) {
+	qfs = append(qfs, a.QueryFilterWhere("id = ?", id.Int64()))
+	return a.Get(target, qfs...)
+}
+
+// filters audit log by actor
+func (a *AuditLogManager) QueryFilterActor(actorID types.Keyer) utils.QueryFunc {
+	return a.QueryFilterWhere(AUDIT_AUDITLOG_DB_TABLE+".actor_id = ?", actorID.Int64())
+}
+
+// filters audit log by action
+func (a *AuditLogManager) QueryFilterAction(action string) utils.QueryFunc {
+	return a.QueryFilterWhere(AUDIT_AUDITLOG_DB_TABLE+".action = ?", action)
+}
+
+// filters audit log by target type (db table of target)
+func (a *AuditLogManager) QueryFilterTargetType(targetType string) utils.QueryFunc {
+	return a.QueryFilterWhere(AUDIT_AUDITLOG_DB_TABLE+".target_type = ?", targetType)
+}
+
+// filters audit log by target id
+func (a *AuditLogManager) QueryFilterTargetID(targetID types.Keyer) utils.QueryFunc {
+	return a.QueryFilterWhere(AUDIT_AUDITLOG_DB_TABLE+".target_id = ?", targetID.Int64())
+}
+
+// filters audit log by team
+func (a *AuditLogManager) QueryFilterTeam(teamID types.Keyer) utils.QueryFunc {
+	return a.QueryFilterWhere(AUDIT_AUDITLOG_DB_TABLE+".team_id = ?", teamID.Int64())
+}
+
+// filters audit log created since given time
+func (a *AuditLogManager) QueryFilterSince(since time.Time) utils.QueryFunc {
+	return a.QueryFilterWhere(AUDIT_AUDITLOG_DB_TABLE+".date_created >= ?", since)
+}
+
+// filters audit log created before given time
+func (a *AuditLogManager) QueryFilterUntil(until time.Time) utils.QueryFunc {
+	return a.QueryFilterWhere(AUDIT_AUDITLOG_DB_TABLE+".date_created < ?", until)
+}
+
+// orders audit log from latest
+func (a *AuditLogManager) QueryFilterOrderLatest() utils.QueryFunc {
+	return func(builder squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return builder.OrderBy(AUDIT_AUDITLOG_DB_TABLE+".date_created DESC", AUDIT_AUDITLOG_DB_TABLE+".id DESC")
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/phonkee/patrol/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewAuditChanges(t *testing.T) {
+	Convey("Test audit changes of updated model", t, func() {
+		before := NewUser(func(user *User) {
+			user.ID = 1
+			user.Username = "username"
+			user.Password = "old"
+		})
+		after := *before
+		after.IsSuperuser = true
+		after.Password = "new"
+		after.Permissions = types.StringSlice{"permission"}
+
+		changes, err := NewAuditChanges(before, &after)
+		So(err, ShouldBeNil)
+		So(len(changes), ShouldEqual, 3)
+		So(changes["is_superuser"], ShouldResemble, AuditChange{Before: false, After: true})
+		So(changes["password"], ShouldResemble, AuditChange{Before: AUDIT_REDACTED_VALUE, After: AUDIT_REDACTED_VALUE})
+		So(changes["permissions"].After, ShouldResemble, types.StringSlice{"permission"})
+
+		_, ok := changes["username"]
+		So(ok, ShouldBeFalse)
+
+		changes, err = NewAuditChanges(before, before)
+		So(err, ShouldBeNil)
+		So(len(changes), ShouldEqual, 0)
+
+		_, err = NewAuditChanges(before, NewTeam())
+		So(err, ShouldEqual, ErrIncorrectModel)
+	})
+
+	Convey("Test audit changes of created and deleted model", t, func() {
+		projectkey := NewProjectKey(func(pk *ProjectKey) {
+			pk.ProjectID = 1
+		})
+
+		changes, err := NewAuditChanges(nil, projectkey)
+		So(err, ShouldBeNil)
+		So(len(changes), ShouldEqual, len(projectkey.Columns()))
+		So(changes["project_id"], ShouldResemble, AuditChange{After: types.ForeignKey(1)})
+		So(changes["secret_key"], ShouldResemble, AuditChange{After: AUDIT_REDACTED_VALUE})
+
+		changes, err = NewAuditChanges(projectkey, nil)
+		So(err, ShouldBeNil)
+		So(changes["project_id"], ShouldResemble, AuditChange{Before: types.ForeignKey(1)})
+		So(changes["secret_key"], ShouldResemble, AuditChange{Before: AUDIT_REDACTED_VALUE})
+	})
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+)
+
+const (
+	// builtin authentication backends
+	AUTH_BACKEND_PASSWORD = "password"
+	AUTH_BACKEND_LDAP     = "ldap"
+)
+
+var (
+	AuthBackends = NewAuthBackendRegistry()
+)
+
+func init() {
+	RegisterAuthBackend(AUTH_BACKEND_PASSWORD, func(context *context.Context) AuthBackend {
+		return NewPasswordAuthBackend(context)
+	})
+	RegisterAuthBackend(AUTH_BACKEND_LDAP, func(context *context.Context) AuthBackend {
+		return NewLDAPAuthBackend(context)
+	})
+}
+
+/*
+AuthBackend authenticates user by username and password. When credentials
+are not valid for backend ErrInvalidCredentials must be returned, so next
+backend can be tried.
+*/
+type AuthBackend interface {
+	Authenticate(username, password string) (*User, error)
+}
+
+/*
+auth backend constructor function
+*/
+type AuthBackendFunc func(context *context.Context) AuthBackend
+
+// registers auth backend to default registry
+func RegisterAuthBackend(id string, f AuthBackendFunc) error {
+	return AuthBackends.Register(id, f)
+}
+
+/*
+Authenticates user with backends given by ids (in given order), first backend
+that authenticates user wins. When all backends refuse credentials
+ErrInvalidCredentials is returned, when some backend failed otherwise (e.g.
+ldap server is down) its error is returned.
+*/
+func Authenticate(context *context.Context, ids []string, username, password string) (user *User, err error) {
+	var backends []AuthBackend
+	if backends, err = AuthBackends.Get(context, ids...); err != nil {
+		return
+	}
+
+	var failure error
+	for _, backend := range backends {
+		if user, err = backend.Authenticate(username, password); err == nil {
+			return
+		}
+		if err != ErrInvalidCredentials {
+			glog.Errorf("auth: backend %T failed: %v", backend, err)
+			failure = err
+		}
+	}
+
+	user = nil
+	if err = failure; err == nil {
+		err = ErrInvalidCredentials
+	}
+	return
+}
+
+/*
+AuthBackendRegistry holds all available auth backends
+*/
+func NewAuthBackendRegistry() *AuthBackendRegistry {
+	return &AuthBackendRegistry{
+		backends: map[string]AuthBackendFunc{},
+	}
+}
+
+type AuthBackendRegistry struct {
+	backends map[string]AuthBackendFunc
+	mutex    sync.RWMutex
+}
+
+func (a *AuthBackendRegistry) Register(id string, f AuthBackendFunc) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if _, ok := a.backends[id]; ok {
+		return ErrAuthBackendAlreadyRegistered
+	}
+	a.backends[id] = f
+	return nil
+}
+
+// returns new backend instances for given ids, blank ids are skipped
+func (a *AuthBackendRegistry) Get(context *context.Context, ids ...string) (result []AuthBackend, err error) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	result = make([]AuthBackend, 0, len(ids))
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		f, ok := a.backends[id]
+		if !ok {
+			return nil, ErrAuthBackendNotFound
+		}
+		result = append(result, f(context))
+	}
+
+	if len(result) == 0 {
+		return nil, ErrAuthBackendNotFound
+	}
+	return
+}
+
+/*
+PasswordAuthBackend verifies password stored in database
+*/
+func NewPasswordAuthBackend(context *context.Context) *PasswordAuthBackend {
+	return &PasswordAuthBackend{context: context}
+}
+
+type PasswordAuthBackend struct {
+	context *context.Context
+}
+
+func (p *PasswordAuthBackend) Authenticate(username, password string) (user *User, err error) {
+	manager := NewUserManager(p.context)
+
+	// find user in db by username
+	user = manager.NewUser()
+	if err = manager.Get(user, manager.QueryFilterUsername(username)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	// check password
+	var ok bool
+	if ok, err = user.VerifyPassword(password); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	return
+}
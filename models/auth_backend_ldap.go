@@ -0,0 +1,262 @@
+package models
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"gopkg.in/ldap.v3"
+)
+
+/*
+LDAPAuthBackend authenticates users against ldap directory. User entry is
+searched under BaseDN by UserFilter (service account BindDN is used for search
+if given) and then bound with given password.
+
+Users are created on first login, name and e-mail are synced from directory
+on every login. Membership in mapped groups sets team member types.
+*/
+func NewLDAPAuthBackend(context *context.Context, funcs ...func(*LDAPAuthBackend)) (backend *LDAPAuthBackend) {
+	backend = &LDAPAuthBackend{
+		URL:            settings.SETTINGS_LDAP_URL,
+		StartTLS:       settings.SETTINGS_LDAP_START_TLS,
+		BindDN:         settings.SETTINGS_LDAP_BIND_DN,
+		BindPassword:   settings.SETTINGS_LDAP_BIND_PASSWORD,
+		BaseDN:         settings.SETTINGS_LDAP_BASE_DN,
+		UserFilter:     settings.SETTINGS_LDAP_USER_FILTER,
+		NameAttribute:  settings.SETTINGS_LDAP_NAME_ATTRIBUTE,
+		EmailAttribute: settings.SETTINGS_LDAP_EMAIL_ATTRIBUTE,
+		GroupAttribute: settings.SETTINGS_LDAP_GROUP_ATTRIBUTE,
+		TeamMapping:    settings.SETTINGS_LDAP_TEAM_MAPPING,
+		context:        context,
+	}
+	for _, f := range funcs {
+		f(backend)
+	}
+	return
+}
+
+type LDAPAuthBackend struct {
+	URL            string
+	StartTLS       bool
+	BindDN         string
+	BindPassword   string
+	BaseDN         string
+	UserFilter     string
+	NameAttribute  string
+	EmailAttribute string
+	GroupAttribute string
+	TeamMapping    string
+
+	context *context.Context
+}
+
+func (l *LDAPAuthBackend) Authenticate(username, password string) (user *User, err error) {
+	// ldap servers treat bind with empty password as unauthenticated bind
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	var mappings []*LDAPTeamMapping
+	if mappings, err = ParseLDAPTeamMapping(l.TeamMapping); err != nil {
+		return
+	}
+
+	var entry *ldap.Entry
+	if entry, err = l.bind(username, password); err != nil {
+		return
+	}
+
+	manager := NewUserManager(l.context)
+	user = manager.NewUser()
+
+	var created bool
+	if err = manager.Get(user, manager.QueryFilterUsername(username)); err != nil {
+		if err != ErrObjectDoesNotExists {
+			return nil, err
+		}
+		created = true
+	}
+
+	user.Name = entry.GetAttributeValue(l.NameAttribute)
+	user.Email = entry.GetAttributeValue(l.EmailAttribute)
+
+	if created {
+		user.Username = username
+		user.IsActive = true
+
+		// directory users never log in with local password
+		if err = user.SetUnusablePassword(); err != nil {
+			return nil, err
+		}
+		if err = user.Insert(l.context); err != nil {
+			return nil, err
+		}
+	} else if _, err = user.Update(l.context, "name", "email"); err != nil {
+		return nil, err
+	}
+
+	if err = l.syncTeams(user, mappings, entry.GetAttributeValues(l.GroupAttribute)); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+/*
+Finds user entry in directory and binds it with password
+*/
+func (l *LDAPAuthBackend) bind(username, password string) (entry *ldap.Entry, err error) {
+	var conn *ldap.Conn
+	if conn, err = ldap.DialURL(l.URL); err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetTimeout(settings.AUTH_LDAP_TIMEOUT)
+
+	if l.StartTLS {
+		var u *url.URL
+		if u, err = url.Parse(l.URL); err != nil {
+			return
+		}
+		if err = conn.StartTLS(&tls.Config{ServerName: u.Hostname()}); err != nil {
+			return
+		}
+	}
+
+	if l.BindDN != "" {
+		if err = conn.Bind(l.BindDN, l.BindPassword); err != nil {
+			return
+		}
+	}
+
+	request := ldap.NewSearchRequest(
+		l.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(l.UserFilter, ldap.EscapeFilter(username)),
+		[]string{l.NameAttribute, l.EmailAttribute, l.GroupAttribute},
+		nil,
+	)
+
+	var result *ldap.SearchResult
+	if result, err = conn.Search(request); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			err = ErrInvalidCredentials
+		}
+		return
+	}
+
+	// username must identify exactly one entry
+	if len(result.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	entry = result.Entries[0]
+	if err = conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			err = ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	return
+}
+
+/*
+Sets member types of mapped teams by groups of user, user is removed from
+mapped teams when not member of any mapped group. When user is member of
+multiple groups mapped to same team, admin wins.
+*/
+func (l *LDAPAuthBackend) syncTeams(user *User, mappings []*LDAPTeamMapping, groups []string) (err error) {
+	if len(mappings) == 0 {
+		return
+	}
+
+	teams := map[types.PrimaryKey]MemberType{}
+	for _, mapping := range mappings {
+		if _, ok := teams[mapping.TeamID]; !ok {
+			teams[mapping.TeamID] = 0
+		}
+		for _, group := range groups {
+			if !strings.EqualFold(group, mapping.Group) {
+				continue
+			}
+			if mt := teams[mapping.TeamID]; mt == 0 || mapping.Type == MEMBER_TYPE_ADMIN {
+				teams[mapping.TeamID] = mapping.Type
+			}
+		}
+	}
+
+	teammanager := NewTeamManager(l.context)
+	membermanager := NewTeamMemberManager(l.context)
+	for id, mt := range teams {
+		team := teammanager.NewTeam()
+		if err = teammanager.GetByID(team, id); err != nil {
+			return
+		}
+
+		if mt == 0 {
+			err = membermanager.RemoveTeamMember(team, user)
+		} else {
+			_, err = membermanager.SetTeamMemberType(team, user, mt)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+/*
+LDAPTeamMapping maps ldap group to team member type
+*/
+type LDAPTeamMapping struct {
+	TeamID types.PrimaryKey
+	Type   MemberType
+	Group  string
+}
+
+/*
+Parses team mapping in format "team_id:member_type:group_dn" separated by
+semicolons, e.g. "1:admin:cn=admins,ou=groups,dc=example,dc=org"
+*/
+func ParseLDAPTeamMapping(value string) (result []*LDAPTeamMapping, err error) {
+	result = []*LDAPTeamMapping{}
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, ":", 3)
+		if len(parts) != 3 {
+			return nil, ErrInvalidLDAPTeamMapping
+		}
+
+		mapping := &LDAPTeamMapping{
+			Group: strings.TrimSpace(parts[2]),
+		}
+
+		var id int64
+		if id, err = strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64); err != nil || id <= 0 {
+			return nil, ErrInvalidLDAPTeamMapping
+		}
+		mapping.TeamID = types.PrimaryKey(id)
+
+		for mt, name := range MEMBER_TYPE_MAPPING {
+			if name == strings.TrimSpace(parts[1]) {
+				mapping.Type = mt
+			}
+		}
+
+		if !mapping.Type.IsValid() || mapping.Group == "" {
+			return nil, ErrInvalidLDAPTeamMapping
+		}
+		result = append(result, mapping)
+	}
+	return
+}
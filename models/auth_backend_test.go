@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testAuthBackend struct {
+	user *User
+	err  error
+}
+
+func (t *testAuthBackend) Authenticate(username, password string) (*User, error) {
+	return t.user, t.err
+}
+
+func TestAuthenticate(t *testing.T) {
+	errDown := errors.New("server down")
+	user := NewUser(func(u *User) { u.Username = "user" })
+
+	registry := map[string]*testAuthBackend{
+		"test-refuse": {err: ErrInvalidCredentials},
+		"test-down":   {err: errDown},
+		"test-user":   {user: user},
+	}
+	for id, backend := range registry {
+		b := backend
+		RegisterAuthBackend(id, func(*context.Context) AuthBackend { return b })
+	}
+
+	Convey("Test register auth backend", t, func() {
+		So(RegisterAuthBackend(AUTH_BACKEND_PASSWORD, nil), ShouldEqual, ErrAuthBackendAlreadyRegistered)
+
+		_, err := AuthBackends.Get(nil, "unknown")
+		So(err, ShouldEqual, ErrAuthBackendNotFound)
+
+		_, err = AuthBackends.Get(nil, " ", "")
+		So(err, ShouldEqual, ErrAuthBackendNotFound)
+
+		backends, err := AuthBackends.Get(nil, "ldap", " password")
+		So(err, ShouldBeNil)
+		So(len(backends), ShouldEqual, 2)
+	})
+
+	Convey("Test authenticate with backends", t, func() {
+		result, err := Authenticate(nil, []string{"test-refuse", "test-user"}, "user", "password")
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, user)
+
+		// backend failure does not prevent other backends
+		result, err = Authenticate(nil, []string{"test-down", "test-user"}, "user", "password")
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, user)
+
+		result, err = Authenticate(nil, []string{"test-refuse"}, "user", "password")
+		So(err, ShouldEqual, ErrInvalidCredentials)
+		So(result, ShouldBeNil)
+
+		result, err = Authenticate(nil, []string{"test-down", "test-refuse"}, "user", "password")
+		So(err, ShouldEqual, errDown)
+		So(result, ShouldBeNil)
+	})
+}
+
+func TestParseLDAPTeamMapping(t *testing.T) {
+	Convey("Test parse ldap team mapping", t, func() {
+		mappings, err := ParseLDAPTeamMapping("")
+		So(err, ShouldBeNil)
+		So(len(mappings), ShouldEqual, 0)
+
+		mappings, err = ParseLDAPTeamMapping(" 1:admin:cn=admins,dc=example,dc=org; 2:member:cn=a:b,dc=example,dc=org ;")
+		So(err, ShouldBeNil)
+		So(len(mappings), ShouldEqual, 2)
+		So(mappings[0].TeamID, ShouldEqual, types.PrimaryKey(1))
+		So(mappings[0].Type, ShouldEqual, MEMBER_TYPE_ADMIN)
+		So(mappings[0].Group, ShouldEqual, "cn=admins,dc=example,dc=org")
+		So(mappings[1].TeamID, ShouldEqual, types.PrimaryKey(2))
+		So(mappings[1].Type, ShouldEqual, MEMBER_TYPE_MEMBER)
+		So(mappings[1].Group, ShouldEqual, "cn=a:b,dc=example,dc=org")
+
+		for _, value := range []string{
+			"1:admin",
+			"x:admin:cn=admins",
+			"0:admin:cn=admins",
+			"1:owner:cn=admins",
+			"1:admin: ",
+		} {
+			_, err = ParseLDAPTeamMapping(value)
+			So(err, ShouldEqual, ErrInvalidLDAPTeamMapping)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+)
+
+/*
+LoginThrottle
+
+	counts failed logins per username and per client ip in cache. After
+	AUTH_LOGIN_THROTTLE_FREE_ATTEMPTS failures next attempt is delayed, delay
+	is doubled with every failure. After SETTINGS_AUTH_LOCKOUT_THRESHOLD
+	failures of username, account is locked for SETTINGS_AUTH_LOCKOUT_DURATION
+	(regardless of password). Failures are forgotten after
+	AUTH_LOGIN_ATTEMPTS_PERIOD from first failure.
+*/
+type LoginThrottle struct {
+	context *context.Context
+}
+
+func NewLoginThrottle(context *context.Context) *LoginThrottle {
+	return &LoginThrottle{context: context}
+}
+
+/*
+Checks whether login attempt is allowed. Returns ErrAccountLocked when
+username is locked, ErrLoginThrottled with duration to wait when attempt comes
+too early after last failure.
+*/
+func (l *LoginThrottle) Check(username, ip string) (wait time.Duration, err error) {
+	username = normalizeLoginUsername(username)
+
+	if l.IsLocked(username) {
+		return 0, ErrAccountLocked
+	}
+
+	now := time.Now()
+	for _, key := range []string{loginThrottleUserKey(username), loginThrottleIPKey(ip)} {
+		if until, ok := l.getTime(key + ":until"); ok && now.Before(until) {
+			if d := until.Sub(now); d > wait {
+				wait = d
+			}
+		}
+	}
+	if wait > 0 {
+		return wait, ErrLoginThrottled
+	}
+	return
+}
+
+/*
+Records failed login, returns number of failures of username. Account is
+locked when threshold is reached.
+*/
+func (l *LoginThrottle) Failed(username, ip string) (failures int, err error) {
+	username = normalizeLoginUsername(username)
+
+	var ipFailures int
+	if ipFailures, err = l.incr(loginThrottleIPKey(ip)); err != nil {
+		return
+	}
+	if err = l.delay(loginThrottleIPKey(ip), LoginThrottleDelay(ipFailures, settings.AUTH_LOGIN_THROTTLE_FREE_ATTEMPTS_PER_IP)); err != nil {
+		return
+	}
+
+	if failures, err = l.incr(loginThrottleUserKey(username)); err != nil {
+		return
+	}
+	if err = l.delay(loginThrottleUserKey(username), LoginThrottleDelay(failures, settings.AUTH_LOGIN_THROTTLE_FREE_ATTEMPTS)); err != nil {
+		return
+	}
+
+	if settings.SETTINGS_AUTH_LOCKOUT_THRESHOLD > 0 && failures >= settings.SETTINGS_AUTH_LOCKOUT_THRESHOLD {
+		err = l.context.Cache.Set(loginLockKey(username), []byte(strconv.FormatInt(time.Now().Unix(), 10)), settings.SETTINGS_AUTH_LOCKOUT_DURATION)
+	}
+	return
+}
+
+/*
+Records successful login, failures of username are forgotten (failures of ip
+are kept, so attacker cannot reset them with own account)
+*/
+func (l *LoginThrottle) Succeeded(username string) (err error) {
+	username = normalizeLoginUsername(username)
+	if err = l.context.Cache.Delete(loginThrottleUserKey(username)); err != nil {
+		return
+	}
+	return l.context.Cache.Delete(loginThrottleUserKey(username) + ":until")
+}
+
+/*
+Returns number of failed logins of username
+*/
+func (l *LoginThrottle) Failures(username string) int {
+	value, err := l.context.Cache.Get(loginThrottleUserKey(normalizeLoginUsername(username)))
+	if err != nil {
+		return 0
+	}
+	failures, _ := strconv.Atoi(string(value))
+	return failures
+}
+
+/*
+Returns whether username is locked
+*/
+func (l *LoginThrottle) IsLocked(username string) bool {
+	_, err := l.context.Cache.Get(loginLockKey(normalizeLoginUsername(username)))
+	return err == nil
+}
+
+/*
+Unlocks username and forgets its failures
+*/
+func (l *LoginThrottle) Unlock(username string) (err error) {
+	if err = l.context.Cache.Delete(loginLockKey(normalizeLoginUsername(username))); err != nil {
+		return
+	}
+	return l.Succeeded(username)
+}
+
+/*
+Returns delay after given number of failures, first free failures are not
+delayed, then delay is doubled with every failure up to
+AUTH_LOGIN_THROTTLE_MAX_DELAY
+*/
+func LoginThrottleDelay(failures, free int) time.Duration {
+	if failures <= free {
+		return 0
+	}
+
+	delay := settings.AUTH_LOGIN_THROTTLE_DELAY
+	for i := free + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= settings.AUTH_LOGIN_THROTTLE_MAX_DELAY {
+			return settings.AUTH_LOGIN_THROTTLE_MAX_DELAY
+		}
+	}
+	return delay
+}
+
+// increments failure counter, counter expires after AUTH_LOGIN_ATTEMPTS_PERIOD
+func (l *LoginThrottle) incr(key string) (count int, err error) {
+	return IncrTimeout(l.context, key, settings.AUTH_LOGIN_ATTEMPTS_PERIOD)
+}
+
+// stores time until next attempt is not allowed
+func (l *LoginThrottle) delay(key string, delay time.Duration) (err error) {
+	if delay <= 0 {
+		return
+	}
+	until := time.Now().Add(delay)
+	return l.context.Cache.Set(key+":until", []byte(strconv.FormatInt(until.UnixNano(), 10)), delay)
+}
+
+func (l *LoginThrottle) getTime(key string) (result time.Time, ok bool) {
+	value, err := l.context.Cache.Get(key)
+	if err != nil {
+		return
+	}
+	nano, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return
+	}
+	return time.Unix(0, nano), true
+}
+
+func normalizeLoginUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func loginThrottleUserKey(username string) string { return "login:failures:user:" + username }
+func loginThrottleIPKey(ip string) string         { return "login:failures:ip:" + ip }
+func loginLockKey(username string) string         { return "login:locked:" + username }
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLoginThrottleDelay(t *testing.T) {
+	Convey("Test login throttle delay", t, func() {
+		So(LoginThrottleDelay(0, 3), ShouldEqual, 0)
+		So(LoginThrottleDelay(3, 3), ShouldEqual, 0)
+		So(LoginThrottleDelay(4, 3), ShouldEqual, settings.AUTH_LOGIN_THROTTLE_DELAY)
+		So(LoginThrottleDelay(5, 3), ShouldEqual, 2*settings.AUTH_LOGIN_THROTTLE_DELAY)
+		So(LoginThrottleDelay(6, 3), ShouldEqual, 4*settings.AUTH_LOGIN_THROTTLE_DELAY)
+
+		// delay is capped
+		So(LoginThrottleDelay(1000, 3), ShouldEqual, settings.AUTH_LOGIN_THROTTLE_MAX_DELAY)
+		So(LoginThrottleDelay(1000, 3), ShouldBeLessThanOrEqualTo, time.Minute)
+	})
+}
+
+func TestLoginThrottle(t *testing.T) {
+	Convey("Test login throttle lockout", t, func() {
+		cache := &memoryCache{data: map[string][]byte{}}
+		throttle := NewLoginThrottle(&context.Context{Cache: cache})
+
+		threshold := settings.SETTINGS_AUTH_LOCKOUT_THRESHOLD
+		defer func() { settings.SETTINGS_AUTH_LOCKOUT_THRESHOLD = threshold }()
+		settings.SETTINGS_AUTH_LOCKOUT_THRESHOLD = 3
+
+		for i := 1; i < 3; i++ {
+			failures, err := throttle.Failed("John ", "10.0.0.1")
+			So(err, ShouldBeNil)
+			So(failures, ShouldEqual, i)
+			So(throttle.IsLocked("john"), ShouldBeFalse)
+		}
+		So(cache.expirations[loginThrottleUserKey("john")], ShouldEqual, settings.AUTH_LOGIN_ATTEMPTS_PERIOD)
+
+		failures, err := throttle.Failed("john", "10.0.0.2")
+		So(err, ShouldBeNil)
+		So(failures, ShouldEqual, 3)
+		So(throttle.IsLocked("JOHN"), ShouldBeTrue)
+		So(cache.expirations[loginLockKey("john")], ShouldEqual, settings.SETTINGS_AUTH_LOCKOUT_DURATION)
+
+		_, err = throttle.Check("john", "10.0.0.3")
+		So(err, ShouldEqual, ErrAccountLocked)
+
+		// unlock forgets failures
+		So(throttle.Unlock("john"), ShouldBeNil)
+		So(throttle.IsLocked("john"), ShouldBeFalse)
+		So(throttle.Failures("john"), ShouldEqual, 0)
+	})
+}
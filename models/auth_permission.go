@@ -1,6 +1,7 @@
 package models
 
 import (
+	"github.com/lann/squirrel"
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/rest/paginator"
 	"github.com/phonkee/patrol/utils"
@@ -110,3 +111,92 @@ func (p *PermissionManager) Get(target interface{}, qfs ...utils.QueryFunc) (err
 func (p *PermissionManager) GetByCodename(target interface{}, codename string) error {
 	return p.Get(target, p.QueryFilterWhere("codename = ?", codename))
 }
+
+/*
+Registers permissions. Permissions that are not in database are inserted,
+names of existing permissions are updated.
+*/
+func (p *PermissionManager) Register(permissions ...*Permission) (err error) {
+	for _, permission := range permissions {
+		existing := p.NewPermission()
+		if err = p.GetByCodename(existing, permission.Codename); err != nil {
+			if err != ErrObjectDoesNotExists {
+				return
+			}
+			if err = permission.Insert(p.context); err != nil {
+				return
+			}
+			continue
+		}
+
+		permission.ID = existing.ID
+		if existing.Name != permission.Name {
+			if _, err = permission.Update(p.context, "name"); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// orders permissions by codename
+func (p *PermissionManager) QueryFilterOrderCodename() utils.QueryFunc {
+	return func(builder squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return builder.OrderBy(AUTH_PERMISSION_DB_TABLE + ".codename ASC")
+	}
+}
+
+/*
+Returns whether user has permission. Superuser has all permissions, other
+users must have permission granted and when teams are given, user must be
+admin of all of them.
+*/
+func (u *UserManager) HasPermission(user *User, codename string, teams ...*Team) (result bool, err error) {
+	handleNilPointer(user)
+
+	if user.IsSuperuser {
+		return true, nil
+	}
+
+	if !user.Permissions.Has(codename) {
+		return false, nil
+	}
+
+	tmm := NewTeamMemberManager(u.context)
+	for _, team := range teams {
+		var mt MemberType
+		if mt, err = tmm.MemberType(team, user); err != nil {
+			if err == ErrObjectDoesNotExists {
+				return false, nil
+			}
+			return false, err
+		}
+		if mt != MEMBER_TYPE_ADMIN {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// grants permission to user
+func (u *UserManager) GrantPermission(user *User, codename string) (err error) {
+	handleNilPointer(user)
+	if user.Permissions.Has(codename) {
+		return
+	}
+	user.Permissions.Add(codename)
+	_, err = user.Update(u.context, "permissions")
+	return
+}
+
+// revokes permission from user
+func (u *UserManager) RevokePermission(user *User, codename string) (err error) {
+	handleNilPointer(user)
+	if !user.Permissions.Has(codename) {
+		return
+	}
+	user.Permissions.Remove(codename)
+	_, err = user.Update(u.context, "permissions")
+	return
+}
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_AUTH_PERSONALTOKEN_INITIAL_ID = "auth-personaltoken-initial"
+	MIGRATION_AUTH_PERSONALTOKEN_INITIAL    = `CREATE TABLE ` + AUTH_PERSONALTOKEN_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		user_id bigint NOT NULL REFERENCES ` + AUTH_USER_DB_TABLE + ` ON DELETE CASCADE,
+		name character varying (` + strconv.Itoa(settings.AUTH_PERSONAL_TOKEN_MAX_NAME_LENGTH) + `) NOT NULL,
+		token_hash character(64) NOT NULL UNIQUE,
+		scopes character varying(32) array NOT NULL,
+		date_created timestamp with time zone NOT NULL,
+		date_expires timestamp with time zone,
+		last_used timestamp with time zone
+	)`
+)
+
+/*
+Scopes of personal tokens
+
+	every api view requires read scope for safe methods (GET, HEAD, OPTIONS)
+	and write scope for other methods. Views without scopes (e.g. user
+	settings, tokens) cannot be used with personal tokens.
+*/
+const (
+	TOKEN_SCOPE_PROJECT_READ  = "project:read"
+	TOKEN_SCOPE_PROJECT_WRITE = "project:write"
+	TOKEN_SCOPE_EVENT_READ    = "event:read"
+	TOKEN_SCOPE_EVENT_ADMIN   = "event:admin"
+	TOKEN_SCOPE_TEAM_ADMIN    = "team:admin"
+
+	// context key with scope required by actual request
+	AUTH_SCOPE_CONTEXT_KEY = "AUTH:SCOPE"
+)
+
+var (
+	TOKEN_SCOPES = []string{
+		TOKEN_SCOPE_PROJECT_READ,
+		TOKEN_SCOPE_PROJECT_WRITE,
+		TOKEN_SCOPE_EVENT_READ,
+		TOKEN_SCOPE_EVENT_ADMIN,
+		TOKEN_SCOPE_TEAM_ADMIN,
+	}
+)
+
+// returns whether scope is known token scope
+func IsTokenScope(scope string) bool {
+	for _, s := range TOKEN_SCOPES {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// returns whether token string is personal token (not jwt)
+func IsPersonalToken(token string) bool {
+	return strings.HasPrefix(token, settings.AUTH_PERSONAL_TOKEN_PREFIX)
+}
+
+/*
+PersonalToken model
+
+	long lived token of user for scripts and CI. Only hash of token is
+	stored, token itself is returned only when created.
+*/
+type PersonalToken struct {
+	Model
+	UserID      types.ForeignKey  `db:"user_id" json:"user_id"`
+	Name        string            `db:"name" json:"name"`
+	TokenHash   string            `db:"token_hash" json:"-"`
+	Scopes      types.StringSlice `db:"scopes" json:"scopes"`
+	DateCreated time.Time         `db:"date_created" json:"date_created"`
+	DateExpires *time.Time        `db:"date_expires" json:"date_expires"`
+	LastUsed    *time.Time        `db:"last_used" json:"last_used"`
+}
+
+// returns all columns except of primary key
+func (p *PersonalToken) Columns() []string {
+	return []string{"user_id", "name", "token_hash", "scopes", "date_created", "date_expires", "last_used"}
+}
+func (p *PersonalToken) Values() []interface{} {
+	return []interface{}{p.UserID, p.Name, p.TokenHash, p.Scopes, p.DateCreated, p.DateExpires, p.LastUsed}
+}
+func (p *PersonalToken) String() string {
+	return "auth:personaltoken:" + p.PrimaryKey().String()
+}
+func (p *PersonalToken) Table() string { return AUTH_PERSONALTOKEN_DB_TABLE }
+
+// returns whether token is expired (tokens without expiry never expire)
+func (p *PersonalToken) IsExpired(now time.Time) bool {
+	return p.DateExpires != nil && !now.Before(*p.DateExpires)
+}
+
+// returns whether token has scope
+func (p *PersonalToken) HasScope(scope string) bool {
+	return p.Scopes.Has(scope)
+}
+
+/*
+CRUD
+*/
+func (p *PersonalToken) Insert(ctx *context.Context) (err error) {
+	return DBInsert(ctx, p)
+}
+
+func (p *PersonalToken) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return DBUpdate(ctx, p, fields...)
+}
+
+func (p *PersonalToken) Delete(ctx *context.Context) (err error) {
+	return DBDelete(ctx, p)
+}
+
+/*
+PersonalTokenManager
+*/
+type PersonalTokenManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewPersonalTokenManager(context *context.Context) *PersonalTokenManager {
+	return &PersonalTokenManager{context: context}
+}
+
+// returns new model instance with default values
+func NewPersonalToken(funcs ...func(*PersonalToken)) (token *PersonalToken) {
+	token = &PersonalToken{
+		Scopes:      types.StringSlice{},
+		DateCreated: utils.NowTruncated(),
+	}
+	for _, f := range funcs {
+		f(token)
+	}
+	return
+}
+
+func (p *PersonalTokenManager) NewPersonalToken(funcs ...func(*PersonalToken)) *PersonalToken {
+	return NewPersonalToken(funcs...)
+}
+func (p *PersonalTokenManager) NewPersonalTokenList() []*PersonalToken { return []*PersonalToken{} }
+
+// Filter results without paging
+func (p *PersonalTokenManager) Filter(target interface{}, qfs ...utils.QueryFunc) error {
+	_, safe := target.([]*PersonalToken)
+	return DBFilter(p.context, AUTH_PERSONALTOKEN_DB_TABLE+".*", AUTH_PERSONALTOKEN_DB_TABLE, !safe, target, qfs...)
+}
+
+// get from database
+func (p *PersonalTokenManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*PersonalToken)
+	return DBGet(p.context, "*", AUTH_PERSONALTOKEN_DB_TABLE, !safe, target, qfs...)
+}
+
+// get by primary key
+func (p *PersonalTokenManager) GetByID(target interface{}, id types.Keyer, qfs ...utils.QueryFunc) (err error) {
+	qfs = append(qfs, p.QueryFilterWhere("id = ?", id.Int64()))
+	return p.Get(target, qfs...)
+}
+
+/*
+Creates personal token, returns token string (returned only here, database
+stores just hash)
+*/
+func (p *PersonalTokenManager) Create(target *PersonalToken) (token string, err error) {
+	token = settings.AUTH_PERSONAL_TOKEN_PREFIX + utils.NewRandomToken(settings.AUTH_PERSONAL_TOKEN_BYTES)
+
+	target.TokenHash = utils.HashToken(token)
+	target.DateCreated = utils.NowTruncated()
+	target.LastUsed = nil
+
+	if err = target.Insert(p.context); err != nil {
+		return "", err
+	}
+	return
+}
+
+/*
+Returns valid (not expired) personal token by token string and updates its
+last used time
+*/
+func (p *PersonalTokenManager) GetByToken(target *PersonalToken, token string) (err error) {
+	if err = p.Get(target, p.QueryFilterWhere("token_hash = ?", utils.HashToken(token))); err != nil {
+		return
+	}
+
+	now := utils.NowTruncated()
+	if target.IsExpired(now) {
+		return ErrObjectDoesNotExists
+	}
+
+	if target.LastUsed == nil || now.Sub(*target.LastUsed) >= settings.AUTH_PERSONAL_TOKEN_LAST_USED_PRECISION {
+		target.LastUsed = &now
+		_, err = target.Update(p.context, "last_used")
+	}
+	return
+}
+
+// filters tokens by user
+func (p *PersonalTokenManager) QueryFilterUser(user *User) utils.QueryFunc {
+	handleNilPointer(user)
+	return p.QueryFilterWhere(AUTH_PERSONALTOKEN_DB_TABLE+".user_id = ?", user.ID)
+}
+
+// orders tokens by id
+func (p *PersonalTokenManager) QueryFilterOrderID() utils.QueryFunc {
+	return func(builder squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return builder.OrderBy(AUTH_PERSONALTOKEN_DB_TABLE + ".id ASC")
+	}
+}
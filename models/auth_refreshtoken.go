@@ -0,0 +1,219 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_AUTH_REFRESHTOKEN_INITIAL_ID = "auth-refreshtoken-initial"
+	MIGRATION_AUTH_REFRESHTOKEN_INITIAL    = `CREATE TABLE ` + AUTH_REFRESHTOKEN_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		user_id bigint NOT NULL REFERENCES ` + AUTH_USER_DB_TABLE + ` ON DELETE CASCADE,
+		token_hash character(64) NOT NULL UNIQUE,
+		session_id character(32) NOT NULL,
+		is_revoked boolean NOT NULL DEFAULT false,
+		date_created timestamp with time zone NOT NULL,
+		date_expires timestamp with time zone NOT NULL
+	)`
+	MIGRATION_AUTH_REFRESHTOKEN_SESSION_INDEX = `CREATE INDEX ` + AUTH_REFRESHTOKEN_DB_TABLE + `_session_id_idx ON ` + AUTH_REFRESHTOKEN_DB_TABLE + ` (session_id)`
+)
+
+/*
+RefreshToken model
+
+	refresh token is exchanged for new access token and new refresh token
+	(rotation). All refresh tokens issued from single login share session
+	id, so when already used refresh token is presented again (token was
+	probably stolen) whole session is revoked. Only hash of token is stored.
+*/
+type RefreshToken struct {
+	Model
+	UserID      types.ForeignKey `db:"user_id" json:"user_id"`
+	TokenHash   string           `db:"token_hash" json:"-"`
+	SessionID   string           `db:"session_id" json:"session_id"`
+	IsRevoked   bool             `db:"is_revoked" json:"is_revoked"`
+	DateCreated time.Time        `db:"date_created" json:"date_created"`
+	DateExpires time.Time        `db:"date_expires" json:"date_expires"`
+}
+
+// returns all columns except of primary key
+func (r *RefreshToken) Columns() []string {
+	return []string{"user_id", "token_hash", "session_id", "is_revoked", "date_created", "date_expires"}
+}
+func (r *RefreshToken) Values() []interface{} {
+	return []interface{}{r.UserID, r.TokenHash, r.SessionID, r.IsRevoked, r.DateCreated, r.DateExpires}
+}
+func (r *RefreshToken) String() string {
+	return "auth:refreshtoken:" + r.PrimaryKey().String()
+}
+func (r *RefreshToken) Table() string { return AUTH_REFRESHTOKEN_DB_TABLE }
+
+// returns whether token is expired
+func (r *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(r.DateExpires)
+}
+
+/*
+CRUD
+*/
+func (r *RefreshToken) Insert(ctx *context.Context) (err error) {
+	return DBInsert(ctx, r)
+}
+
+func (r *RefreshToken) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return DBUpdate(ctx, r, fields...)
+}
+
+func (r *RefreshToken) Delete(ctx *context.Context) (err error) {
+	return DBDelete(ctx, r)
+}
+
+/*
+RefreshTokenManager
+*/
+type RefreshTokenManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewRefreshTokenManager(context *context.Context) *RefreshTokenManager {
+	return &RefreshTokenManager{context: context}
+}
+
+func (r *RefreshTokenManager) NewRefreshToken(funcs ...func(*RefreshToken)) (token *RefreshToken) {
+	token = &RefreshToken{}
+	for _, f := range funcs {
+		f(token)
+	}
+	return
+}
+
+// get from database
+func (r *RefreshTokenManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*RefreshToken)
+	return DBGet(r.context, "*", AUTH_REFRESHTOKEN_DB_TABLE, !safe, target, qfs...)
+}
+
+/*
+Creates refresh token for user in given session and returns token string
+(returned only here, database stores just hash)
+*/
+func (r *RefreshTokenManager) Create(user *User, sessionID string) (token string, err error) {
+	handleNilPointer(user)
+
+	token = utils.NewRandomToken(settings.AUTH_REFRESH_TOKEN_BYTES)
+	now := utils.NowTruncated()
+
+	rt := r.NewRefreshToken(func(rt *RefreshToken) {
+		rt.UserID = user.ID.ToForeignKey()
+		rt.TokenHash = utils.HashToken(token)
+		rt.SessionID = sessionID
+		rt.DateCreated = now
+		rt.DateExpires = now.Add(settings.SETTINGS_AUTH_REFRESH_TOKEN_TTL)
+	})
+
+	if err = rt.Insert(r.context); err != nil {
+		return "", err
+	}
+	return
+}
+
+/*
+Rotates refresh token: token is revoked and user (filled to target) gets new
+refresh token in the same session. Reused token revokes whole session.
+*/
+func (r *RefreshTokenManager) Rotate(user *User, token string) (rt *RefreshToken, newToken string, err error) {
+	handleNilPointer(user)
+
+	rt = r.NewRefreshToken()
+	if err = r.Get(rt, r.QueryFilterWhere("token_hash = ?", utils.HashToken(token))); err != nil {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	if rt.IsRevoked {
+		if err = r.RevokeSession(rt.SessionID); err != nil {
+			return
+		}
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	if rt.IsExpired(time.Now()) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	if err = NewUserManager(r.context).GetByID(user, rt.UserID); err != nil {
+		return nil, "", ErrInvalidRefreshToken
+	}
+	if !user.IsActive {
+		return nil, "", ErrCannotLoginUser
+	}
+
+	// revoke only when not revoked by concurrent request in meantime
+	var revoked bool
+	if revoked, err = r.revoke(utils.QueryBuilder().Update(AUTH_REFRESHTOKEN_DB_TABLE).
+		Set("is_revoked", true).
+		Where("id = ? AND is_revoked = ?", rt.ID, false)); err != nil {
+		return
+	}
+	if !revoked {
+		if err = r.RevokeSession(rt.SessionID); err != nil {
+			return
+		}
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	newToken, err = r.Create(user, rt.SessionID)
+	return
+}
+
+// revokes all refresh tokens of session
+func (r *RefreshTokenManager) RevokeSession(sessionID string) (err error) {
+	_, err = r.revoke(utils.QueryBuilder().Update(AUTH_REFRESHTOKEN_DB_TABLE).
+		Set("is_revoked", true).
+		Where("session_id = ?", sessionID))
+	return
+}
+
+// revokes all refresh tokens of user
+func (r *RefreshTokenManager) RevokeUser(user *User) (err error) {
+	handleNilPointer(user)
+	_, err = r.revoke(utils.QueryBuilder().Update(AUTH_REFRESHTOKEN_DB_TABLE).
+		Set("is_revoked", true).
+		Where("user_id = ?", user.ID))
+	return
+}
+
+// runs revoke update, returns whether any token was revoked
+func (r *RefreshTokenManager) revoke(builder squirrel.UpdateBuilder) (revoked bool, err error) {
+	var (
+		query  string
+		args   []interface{}
+		result sql.Result
+	)
+	if query, args, err = builder.ToSql(); err != nil {
+		return
+	}
+
+	execfunc := r.context.DB.Exec
+	if r.context.Tx != nil {
+		execfunc = r.context.Tx.Exec
+	}
+
+	if result, err = execfunc(query, args...); err != nil {
+		return
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phonkee/patrol/context"
+)
+
+/*
+Signed tokens
+
+	tokens sent by e-mail (password reset, e-mail verification) and tokens
+	of second step of login (two-factor authentication). Token
+	contains purpose, user id and expiration signed with secret key together
+	with state of user that changes when token is used (password hash, e-mail
+	verification). So token is valid only until used, no storage is needed.
+*/
+const (
+	SIGNED_TOKEN_PASSWORD_RESET = "password-reset"
+	SIGNED_TOKEN_EMAIL_VERIFY   = "email-verify"
+	SIGNED_TOKEN_TWO_FACTOR     = "two-factor"
+)
+
+// returns state of user that invalidates token of given purpose once used
+func signedTokenState(user *User, purpose string) string {
+	switch purpose {
+	case SIGNED_TOKEN_PASSWORD_RESET:
+		return user.Password
+	case SIGNED_TOKEN_EMAIL_VERIFY:
+		return user.Email + ":" + strconv.FormatBool(user.EmailVerified)
+	case SIGNED_TOKEN_TWO_FACTOR:
+		// last login is updated when login is finished
+		return user.Password + ":" + strconv.FormatInt(user.LastLogin.UnixNano(), 10)
+	}
+	return ""
+}
+
+// returns hex encoded signature of payload and user state
+func (u *UserManager) signToken(payload string, user *User, purpose string) string {
+	secretKey := u.context.Get(context.SECRET_KEY).(string)
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(payload + ":" + signedTokenState(user, purpose)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+/*
+Returns new signed token for user valid for ttl
+*/
+func (u *UserManager) NewSignedToken(user *User, purpose string, ttl time.Duration) string {
+	handleNilPointer(user)
+
+	payload := purpose + ":" + user.ID.String() + ":" + strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + u.signToken(payload, user, purpose)
+}
+
+/*
+Returns user by signed token with given purpose, returns ErrInvalidSignedToken
+if token is malformed, expired, already used or signature does not match.
+*/
+func (u *UserManager) GetBySignedToken(user *User, purpose, token string) (err error) {
+	handleNilPointer(user)
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return ErrInvalidSignedToken
+	}
+
+	var decoded []byte
+	if decoded, err = base64.RawURLEncoding.DecodeString(parts[0]); err != nil {
+		return ErrInvalidSignedToken
+	}
+
+	payload := string(decoded)
+	values := strings.Split(payload, ":")
+	if len(values) != 3 || values[0] != purpose {
+		return ErrInvalidSignedToken
+	}
+
+	var id, expires int64
+	if id, err = strconv.ParseInt(values[1], 10, 64); err != nil {
+		return ErrInvalidSignedToken
+	}
+	if expires, err = strconv.ParseInt(values[2], 10, 64); err != nil {
+		return ErrInvalidSignedToken
+	}
+	if time.Now().Unix() > expires {
+		return ErrInvalidSignedToken
+	}
+
+	// cached user does not have password, so always read from database
+	if err = u.Get(user, u.QueryFilterWhere("id = ?", id)); err != nil {
+		if err == ErrObjectDoesNotExists {
+			return ErrInvalidSignedToken
+		}
+		return
+	}
+
+	if !hmac.Equal([]byte(parts[1]), []byte(u.signToken(payload, user, purpose))) {
+		return ErrInvalidSignedToken
+	}
+
+	return
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Access tokens
+
+	access tokens are short lived jwt tokens (SETTINGS_AUTH_ACCESS_TOKEN_TTL)
+	with unique id (jti) and id of session (refresh token family).
+	Logged out token is stored in denylist (cache) until it expires, all
+	tokens of user are revoked by storing revocation time, tokens issued
+	before it are rejected.
+*/
+
+// cache key of denylisted token id
+func tokenDenylistKey(jti string) string {
+	return "auth:token:denylist:" + jti
+}
+
+// cache key with time of revocation of all tokens of user
+func tokenRevokedKey(userID int64) string {
+	return "auth:token:revoked:" + strconv.FormatInt(userID, 10)
+}
+
+// returns time as milliseconds since epoch
+func unixMilli(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+/*
+Returns new signed access token for user in session (session can be empty)
+*/
+func (u *UserManager) NewAccessToken(user *User, sessionID string) (t string, err error) {
+	if user.ID == 0 {
+		err = ErrObjectDoesNotExists
+		return
+	}
+	// Inactive user cannot be logged in
+	if !user.IsActive {
+		err = ErrCannotLoginUser
+		return
+	}
+
+	now := time.Now()
+
+	token := jwt.New(jwt.SigningMethodHS256)
+	token.Claims[USER_ID_TOKEN_KEY] = user.ID
+	token.Claims[EXPIRATION_TOKEN_KEY] = now.Add(settings.SETTINGS_AUTH_ACCESS_TOKEN_TTL).Unix()
+	// milliseconds precision so token issued right after revocation is valid
+	token.Claims[ISSUED_AT_TOKEN_KEY] = float64(unixMilli(now)) / 1000
+	token.Claims[TOKEN_ID_TOKEN_KEY] = utils.NewUUID()
+	if sessionID != "" {
+		token.Claims[SESSION_ID_TOKEN_KEY] = sessionID
+	}
+
+	// get secret key from context
+	secretKey := u.context.Get(context.SECRET_KEY).(string)
+	t, err = token.SignedString([]byte(secretKey))
+	return
+}
+
+/*
+Logs out token: token is denylisted until it expires and refresh tokens of
+its session are revoked
+*/
+func (u *UserManager) Logout(token *jwt.Token) (err error) {
+	if jti, ok := token.Claims[TOKEN_ID_TOKEN_KEY].(string); ok {
+		ttl := settings.SETTINGS_AUTH_ACCESS_TOKEN_TTL
+		if exp, ok := token.Claims[EXPIRATION_TOKEN_KEY].(float64); ok {
+			ttl = time.Unix(int64(exp), 0).Sub(time.Now())
+		}
+		if ttl > 0 {
+			if err = u.context.Cache.Set(tokenDenylistKey(jti), []byte("1"), ttl); err != nil {
+				return
+			}
+		}
+	}
+
+	if sid, ok := token.Claims[SESSION_ID_TOKEN_KEY].(string); ok && sid != "" {
+		return NewRefreshTokenManager(u.context).RevokeSession(sid)
+	}
+	return
+}
+
+/*
+Revokes all access and refresh tokens of user (log out everywhere,
+deactivation of user)
+*/
+func (u *UserManager) RevokeTokens(user *User) (err error) {
+	handleNilPointer(user)
+
+	now := []byte(strconv.FormatInt(unixMilli(time.Now()), 10))
+	if err = u.context.Cache.Set(tokenRevokedKey(user.ID.Int64()), now, settings.SETTINGS_AUTH_ACCESS_TOKEN_TTL); err != nil {
+		return
+	}
+
+	return NewRefreshTokenManager(u.context).RevokeUser(user)
+}
+
+// returns ErrTokenRevoked if token was logged out or revoked
+func (u *UserManager) checkRevoked(token *jwt.Token) (err error) {
+	if jti, ok := token.Claims[TOKEN_ID_TOKEN_KEY].(string); ok {
+		if _, errGet := u.context.Cache.Get(tokenDenylistKey(jti)); errGet == nil {
+			return ErrTokenRevoked
+		}
+	}
+
+	uid, ok := token.Claims[USER_ID_TOKEN_KEY].(float64)
+	if !ok {
+		return
+	}
+
+	var value []byte
+	if value, err = u.context.Cache.Get(tokenRevokedKey(int64(uid))); err != nil {
+		// nothing revoked
+		return nil
+	}
+
+	revoked, errParse := strconv.ParseInt(string(value), 10, 64)
+	if errParse != nil {
+		return nil
+	}
+
+	// tokens without issued at are older than revocation
+	iat, _ := token.Claims[ISSUED_AT_TOKEN_KEY].(float64)
+	if int64(math.Round(iat*1000)) <= revoked {
+		return ErrTokenRevoked
+	}
+	return
+}
@@ -0,0 +1,316 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_AUTH_TWOFACTOR_INITIAL_ID = "auth-twofactor-initial"
+	MIGRATION_AUTH_TWOFACTOR_INITIAL    = `CREATE TABLE ` + AUTH_TWOFACTOR_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		user_id bigint NOT NULL UNIQUE REFERENCES ` + AUTH_USER_DB_TABLE + ` ON DELETE CASCADE,
+		secret character varying(64) NOT NULL,
+		confirmed boolean NOT NULL DEFAULT false,
+		last_counter bigint NOT NULL DEFAULT 0,
+		recovery_codes character varying(64) array NOT NULL,
+		date_added timestamp with time zone NOT NULL,
+		date_confirmed timestamp with time zone
+	)`
+)
+
+/*
+TwoFactor model
+
+	totp (RFC 6238) two-factor authentication of user. Enrollment is not
+	active until confirmed with first code. Counter of last used code is
+	stored, so every code can be used only once. Only hashes of recovery
+	codes are stored, codes are returned only when generated.
+*/
+type TwoFactor struct {
+	Model
+	UserID        types.ForeignKey  `db:"user_id" json:"user_id"`
+	Secret        string            `db:"secret" json:"-"`
+	Confirmed     bool              `db:"confirmed" json:"confirmed"`
+	LastCounter   int64             `db:"last_counter" json:"-"`
+	RecoveryCodes types.StringSlice `db:"recovery_codes" json:"-"`
+	DateAdded     time.Time         `db:"date_added" json:"date_added"`
+	DateConfirmed *time.Time        `db:"date_confirmed" json:"date_confirmed"`
+}
+
+// returns all columns except of primary key
+func (t *TwoFactor) Columns() []string {
+	return []string{"user_id", "secret", "confirmed", "last_counter", "recovery_codes", "date_added", "date_confirmed"}
+}
+func (t *TwoFactor) Values() []interface{} {
+	return []interface{}{t.UserID, t.Secret, t.Confirmed, t.LastCounter, t.RecoveryCodes, t.DateAdded, t.DateConfirmed}
+}
+func (t *TwoFactor) String() string {
+	return "auth:twofactor:" + t.PrimaryKey().String()
+}
+func (t *TwoFactor) Table() string { return AUTH_TWOFACTOR_DB_TABLE }
+
+// returns otpauth:// uri of secret for authenticator apps
+func (t *TwoFactor) ProvisioningURI(account string) string {
+	return utils.TOTPProvisioningURI(t.Secret, settings.AUTH_TOTP_ISSUER, account, settings.AUTH_TOTP_PERIOD, settings.AUTH_TOTP_DIGITS)
+}
+
+/*
+CRUD
+*/
+func (t *TwoFactor) Insert(ctx *context.Context) (err error) {
+	return DBInsert(ctx, t)
+}
+
+func (t *TwoFactor) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return DBUpdate(ctx, t, fields...)
+}
+
+func (t *TwoFactor) Delete(ctx *context.Context) (err error) {
+	return DBDelete(ctx, t)
+}
+
+/*
+TwoFactorManager
+*/
+type TwoFactorManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewTwoFactorManager(context *context.Context) *TwoFactorManager {
+	return &TwoFactorManager{context: context}
+}
+
+// returns new model instance with default values
+func NewTwoFactor(funcs ...func(*TwoFactor)) (twofactor *TwoFactor) {
+	twofactor = &TwoFactor{
+		RecoveryCodes: types.StringSlice{},
+		DateAdded:     utils.NowTruncated(),
+	}
+	for _, f := range funcs {
+		f(twofactor)
+	}
+	return
+}
+
+func (t *TwoFactorManager) NewTwoFactor(funcs ...func(*TwoFactor)) *TwoFactor {
+	return NewTwoFactor(funcs...)
+}
+
+// get from database
+func (t *TwoFactorManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*TwoFactor)
+	return DBGet(t.context, "*", AUTH_TWOFACTOR_DB_TABLE, !safe, target, qfs...)
+}
+
+// get two-factor authentication of user
+func (t *TwoFactorManager) GetByUser(target *TwoFactor, user *User) (err error) {
+	handleNilPointer(user)
+	return t.Get(target, t.QueryFilterWhere("user_id = ?", user.ID))
+}
+
+/*
+Returns whether user has confirmed two-factor authentication
+*/
+func (t *TwoFactorManager) IsEnabled(user *User) (enabled bool, err error) {
+	twofactor := t.NewTwoFactor()
+	if err = t.GetByUser(twofactor, user); err != nil {
+		if err == ErrObjectDoesNotExists {
+			err = nil
+		}
+		return
+	}
+	return twofactor.Confirmed, nil
+}
+
+/*
+Returns whether two-factor authentication is required for user (user is
+member or owner of team that requires it)
+*/
+func (t *TwoFactorManager) IsRequired(user *User) (required bool, err error) {
+	handleNilPointer(user)
+
+	var count int64
+	count, err = DBCount(t.context, "COUNT(*)", TEAMS_TEAM_DB_TABLE, t.QueryFilterWhere(
+		"require_two_factor = ? AND (owner_id = ? OR id IN (SELECT team_id FROM "+TEAMS_TEAMMEMBER_DB_TABLE+" WHERE user_id = ?))",
+		true, user.ID, user.ID,
+	))
+	return count > 0, err
+}
+
+/*
+Starts enrollment of two-factor authentication with new secret. Enrollment
+that is not confirmed yet is restarted, confirmed returns
+ErrTwoFactorAlreadyEnabled.
+*/
+func (t *TwoFactorManager) Enroll(target *TwoFactor, user *User) (err error) {
+	if err = t.GetByUser(target, user); err != nil && err != ErrObjectDoesNotExists {
+		return
+	}
+
+	if err == nil {
+		if target.Confirmed {
+			return ErrTwoFactorAlreadyEnabled
+		}
+		target.Secret = utils.NewTOTPSecret()
+		target.LastCounter = 0
+		target.DateAdded = utils.NowTruncated()
+		_, err = target.Update(t.context, "secret", "last_counter", "date_added")
+		return
+	}
+
+	target.UserID = user.ID.ToForeignKey()
+	target.Secret = utils.NewTOTPSecret()
+	target.Confirmed = false
+	target.LastCounter = 0
+	target.RecoveryCodes = types.StringSlice{}
+	target.DateAdded = utils.NowTruncated()
+	target.DateConfirmed = nil
+	return target.Insert(t.context)
+}
+
+/*
+Confirms enrollment with code from authenticator app and returns new recovery
+codes
+*/
+func (t *TwoFactorManager) Confirm(target *TwoFactor, code string) (codes []string, err error) {
+	if target.Confirmed {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	counter, ok := utils.VerifyTOTP(target.Secret, code, time.Now(), settings.AUTH_TOTP_PERIOD, settings.AUTH_TOTP_DIGITS, settings.AUTH_TOTP_SKEW)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	now := utils.NowTruncated()
+	codes = newRecoveryCodes(target)
+	target.Confirmed = true
+	target.DateConfirmed = &now
+	target.LastCounter = counter
+
+	if _, err = target.Update(t.context, "confirmed", "date_confirmed", "last_counter", "recovery_codes"); err != nil {
+		return nil, err
+	}
+	return
+}
+
+/*
+Verifies code of confirmed two-factor authentication. Code is either totp
+code (every code can be used only once) or recovery code (removed when used).
+*/
+func (t *TwoFactorManager) Verify(target *TwoFactor, code string) (err error) {
+	if !target.Confirmed {
+		return ErrInvalidTwoFactorCode
+	}
+
+	var used bool
+	if counter, ok := utils.VerifyTOTP(target.Secret, code, time.Now(), settings.AUTH_TOTP_PERIOD, settings.AUTH_TOTP_DIGITS, settings.AUTH_TOTP_SKEW); ok {
+		// conditional update, so concurrent requests cannot use same code
+		if used, err = t.exec(utils.QueryBuilder().Update(AUTH_TWOFACTOR_DB_TABLE).
+			Set("last_counter", counter).
+			Where("id = ?", target.ID).
+			Where("last_counter < ?", counter)); err != nil {
+			return
+		}
+		if used {
+			target.LastCounter = counter
+		}
+	} else {
+		hash := hashRecoveryCode(code)
+		if !target.RecoveryCodes.Has(hash) {
+			return ErrInvalidTwoFactorCode
+		}
+
+		remaining := types.StringSlice{}
+		for _, h := range target.RecoveryCodes {
+			if h != hash {
+				remaining = append(remaining, h)
+			}
+		}
+
+		// update only when codes were not changed by concurrent request
+		if used, err = t.exec(utils.QueryBuilder().Update(AUTH_TWOFACTOR_DB_TABLE).
+			Set("recovery_codes", remaining).
+			Where("id = ?", target.ID).
+			Where("recovery_codes = ?", target.RecoveryCodes)); err != nil {
+			return
+		}
+		if used {
+			target.RecoveryCodes = remaining
+		}
+	}
+
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return
+}
+
+/*
+Replaces recovery codes with new ones and returns them
+*/
+func (t *TwoFactorManager) NewRecoveryCodes(target *TwoFactor) (codes []string, err error) {
+	codes = newRecoveryCodes(target)
+	if _, err = target.Update(t.context, "recovery_codes"); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// runs update, returns whether any row was updated
+func (t *TwoFactorManager) exec(builder squirrel.UpdateBuilder) (updated bool, err error) {
+	var (
+		query  string
+		args   []interface{}
+		result sql.Result
+	)
+	if query, args, err = builder.ToSql(); err != nil {
+		return
+	}
+
+	execfunc := t.context.DB.Exec
+	if t.context.Tx != nil {
+		execfunc = t.context.Tx.Exec
+	}
+
+	result, err = execfunc(query, args...)
+	LogSQL(query, args, err, SQL_CALLER_SKIP)
+	if err != nil {
+		return
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// generates recovery codes (xxxxx-xxxxx), hashes are set to two factor
+func newRecoveryCodes(target *TwoFactor) (codes []string) {
+	codes = make([]string, 0, settings.AUTH_RECOVERY_CODES_COUNT)
+	target.RecoveryCodes = types.StringSlice{}
+	for i := 0; i < settings.AUTH_RECOVERY_CODES_COUNT; i++ {
+		token := utils.NewRandomToken(5)
+		code := token[:5] + "-" + token[5:]
+		codes = append(codes, code)
+		target.RecoveryCodes = append(target.RecoveryCodes, hashRecoveryCode(code))
+	}
+	return
+}
+
+// returns hash of normalized recovery code (case and dashes are ignored)
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.Replace(strings.TrimSpace(code), "-", "", -1))
+	return utils.HashToken(code)
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/phonkee/patrol/settings"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecoveryCodes(t *testing.T) {
+	Convey("Test recovery codes", t, func() {
+		twofactor := NewTwoFactor()
+		codes := newRecoveryCodes(twofactor)
+
+		So(len(codes), ShouldEqual, settings.AUTH_RECOVERY_CODES_COUNT)
+		So(len(twofactor.RecoveryCodes), ShouldEqual, len(codes))
+
+		for i, code := range codes {
+			So(code, ShouldNotContainSubstring, " ")
+			So(len(code), ShouldEqual, 11)
+			So(twofactor.RecoveryCodes[i], ShouldNotEqual, code)
+			So(twofactor.RecoveryCodes.Has(hashRecoveryCode(code)), ShouldBeTrue)
+		}
+
+		// dashes, spaces and case are ignored
+		code := " " + strings.ToUpper(strings.Replace(codes[0], "-", "", -1)) + " "
+		So(hashRecoveryCode(code), ShouldEqual, twofactor.RecoveryCodes[0])
+
+		// new codes replace old ones
+		So(newRecoveryCodes(twofactor)[0], ShouldNotEqual, codes[0])
+		So(twofactor.RecoveryCodes.Has(hashRecoveryCode(codes[0])), ShouldBeFalse)
+	})
+}
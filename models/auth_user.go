@@ -30,6 +30,10 @@ type User struct {
 	DateAdded   time.Time         `db:"date_added" json:"date_added"`
 	LastLogin   time.Time         `db:"last_login" json:"last_login"`
 	Permissions types.StringSlice `db:"permissions" json:"permissions"`
+
+	// users created with e-mail verification enabled must verify e-mail
+	// address before first login
+	EmailVerified bool `db:"email_verified" json:"email_verified"`
 }
 
 // returns all columns except of primary key
@@ -37,12 +41,14 @@ func (u *User) Columns() []string {
 	return []string{
 		"username", "email", "password", "name", "is_active",
 		"is_superuser", "date_added", "last_login", "permissions",
+		"email_verified",
 	}
 }
 func (u *User) Values() []interface{} {
 	return []interface{}{
 		u.Username, u.Email, u.Password, u.Name, u.IsActive,
 		u.IsSuperuser, u.DateAdded, u.LastLogin, u.Permissions,
+		u.EmailVerified,
 	}
 }
 func (u *User) String() string { return "auth:user:" + u.ID.String() }
@@ -126,6 +132,11 @@ func (u *User) SetPassword(password string) error {
 	return nil
 }
 
+// sets random password, used for users authenticated externally (ldap, sso)
+func (u *User) SetUnusablePassword() error {
+	return u.SetPassword(utils.NewRandomToken(32))
+}
+
 // Verifies user password
 func (u *User) VerifyPassword(password string) (bool, error) {
 	return utils.VerifyHashedPassword(u.Password, password, settings.SETTINGS_SECRET_KEY)
@@ -154,6 +165,11 @@ const (
         last_login timestamp with time zone,
         permissions character varying(64) array
     )`
+
+	// existing users are treated as verified
+	MIGRATION_AUTH_USER_EMAIL_VERIFIED_ID = "auth-user-email-verified"
+	MIGRATION_AUTH_USER_EMAIL_VERIFIED    = `ALTER TABLE ` + AUTH_USER_DB_TABLE + `
+	ADD COLUMN email_verified boolean NOT NULL DEFAULT true`
 )
 
 /*
@@ -162,6 +178,9 @@ Auth managers
 const (
 	USER_ID_TOKEN_KEY    = "user_id"
 	EXPIRATION_TOKEN_KEY = "exp"
+	ISSUED_AT_TOKEN_KEY  = "iat"
+	TOKEN_ID_TOKEN_KEY   = "jti"
+	SESSION_ID_TOKEN_KEY = "sid"
 )
 
 // Constructor function for new UserManager
@@ -179,7 +198,8 @@ type UserManager struct {
 // Returns new User with default values
 func NewUser(funcs ...func(*User)) (user *User) {
 	user = &User{
-		DateAdded: utils.NowTruncated(),
+		DateAdded:     utils.NowTruncated(),
+		EmailVerified: true,
 	}
 	for _, f := range funcs {
 		f(user)
@@ -259,14 +279,23 @@ const (
 	AUTH_USER_CONTEXT_KEY  = "AUTH:AUTH_USER"
 )
 
-// Returns jwt Token from request
-func (u *UserManager) GetAuthToken(r *http.Request) (token *jwt.Token, err error) {
-	tokenString := r.Header.Get("Authorization")
+// Returns token string from Authorization header
+func authTokenString(r *http.Request) (tokenString string) {
+	tokenString = r.Header.Get("Authorization")
 
 	if len(tokenString) > 7 {
 		tokenString = tokenString[7:]
 	}
+	return
+}
+
+// Returns jwt Token from request
+func (u *UserManager) GetAuthToken(r *http.Request) (token *jwt.Token, err error) {
+	return u.ParseAuthToken(authTokenString(r))
+}
 
+// Parses and verifies jwt token string
+func (u *UserManager) ParseAuthToken(tokenString string) (token *jwt.Token, err error) {
 	secretKey := u.context.Get(context.SECRET_KEY).(string)
 
 	if token, err = jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
@@ -275,6 +304,11 @@ func (u *UserManager) GetAuthToken(r *http.Request) (token *jwt.Token, err error
 		return
 	}
 
+	// logged out or revoked token
+	if err = u.checkRevoked(token); err != nil {
+		return nil, err
+	}
+
 	return
 }
 
@@ -282,6 +316,11 @@ func (u *UserManager) GetAuthToken(r *http.Request) (token *jwt.Token, err error
 func (u *UserManager) GetAuthUser(user *User, r *http.Request) (err error) {
 	handleNilPointer(user)
 
+	// personal token
+	if tokenString := authTokenString(r); IsPersonalToken(tokenString) {
+		return u.getPersonalTokenUser(user, tokenString)
+	}
+
 	var token *jwt.Token
 
 	// get token first
@@ -289,34 +328,83 @@ func (u *UserManager) GetAuthUser(user *User, r *http.Request) (err error) {
 		return
 	}
 
-	uid := token.Claims[USER_ID_TOKEN_KEY]
-	id := types.PrimaryKey(uid.(float64))
+	return u.getTokenUser(user, token)
+}
+
+/*
+Returns user of personal token. Token must have scope required by request
+(set by TokenScopeMiddleware), requests without required scope cannot be
+authenticated by personal token.
+*/
+func (u *UserManager) getPersonalTokenUser(user *User, tokenString string) (err error) {
+	manager := NewPersonalTokenManager(u.context)
+	token := manager.NewPersonalToken()
+	if err = manager.GetByToken(token, tokenString); err != nil {
+		return
+	}
+
+	scope, _ := u.context.Get(AUTH_SCOPE_CONTEXT_KEY).(string)
+	if scope == "" || !token.HasScope(scope) {
+		return ErrTokenScope
+	}
+
+	if err = u.GetByID(user, token.UserID); err != nil {
+		return
+	}
+
+	u.context.Set(AUTH_TOKEN_CONTEXT_KEY, token)
+	return
+}
+
+/*
+Returns User by jwt token string (e.g. token sent by websocket client which
+cannot set Authorization header)
+*/
+func (u *UserManager) GetUserByToken(user *User, tokenString string) (err error) {
+	handleNilPointer(user)
+
+	var token *jwt.Token
+	if token, err = u.ParseAuthToken(tokenString); err != nil {
+		return
+	}
+
+	return u.getTokenUser(user, token)
+}
+
+// returns user identified by token claims
+func (u *UserManager) getTokenUser(user *User, token *jwt.Token) (err error) {
+	uid, ok := token.Claims[USER_ID_TOKEN_KEY].(float64)
+	if !ok {
+		return ErrObjectDoesNotExists
+	}
 
-	if err = u.GetByID(user, id); err != nil {
+	if err = u.GetByID(user, types.PrimaryKey(uid)); err != nil {
 		return err
 	}
 
 	return
 }
 
-// logs in user and returns token
+// logs in user and returns access token (without session)
 func (u *UserManager) Login(user *User) (t string, err error) {
+	return u.NewAccessToken(user, "")
+}
+
+/*
+Logs in user and returns access token and refresh token of new session
+*/
+func (u *UserManager) LoginSession(user *User) (access, refresh string, err error) {
 	if user.ID == 0 {
 		err = ErrObjectDoesNotExists
 		return
 	}
-	// Inactive user cannot be logged in
-	if !user.IsActive {
-		err = ErrCannotLoginUser
+
+	sessionID := utils.NewUUID()
+	if refresh, err = NewRefreshTokenManager(u.context).Create(user, sessionID); err != nil {
 		return
 	}
-	token := jwt.New(jwt.SigningMethodHS256)
-	token.Claims[USER_ID_TOKEN_KEY] = user.ID
-	token.Claims[EXPIRATION_TOKEN_KEY] = time.Now().Add(time.Hour * 24).Unix()
 
-	// get secret key from context
-	secretKey := u.context.Get(context.SECRET_KEY).(string)
-	t, err = token.SignedString([]byte(secretKey))
+	access, err = u.NewAccessToken(user, sessionID)
 	return
 }
 
@@ -328,6 +416,17 @@ func (u *UserManager) QueryFilterUsername(username string) utils.QueryFunc {
 	return utils.QueryFilterWhere("username ILIKE ?", username)
 }
 
+// filters users that are members of project team
+func (u *UserManager) QueryFilterProjectMember(project *Project) utils.QueryFunc {
+	handleNilPointer(project)
+	return utils.QueryFilterWhere(AUTH_USER_DB_TABLE+".id IN (SELECT user_id FROM "+TEAMS_TEAMMEMBER_DB_TABLE+" WHERE team_id = ?)", project.TeamID)
+}
+
+// filters users that are admins of any team
+func (u *UserManager) QueryFilterTeamAdmin() utils.QueryFunc {
+	return utils.QueryFilterWhere(AUTH_USER_DB_TABLE+".id IN (SELECT user_id FROM "+TEAMS_TEAMMEMBER_DB_TABLE+" WHERE type = ?)", MEMBER_TYPE_ADMIN)
+}
+
 func (u *UserManager) QueryFilterIsActive() utils.QueryFunc {
 	return utils.QueryFilterWhere("is_active = ?", false)
 }
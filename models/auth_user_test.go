@@ -9,11 +9,7 @@ import (
 )
 
 func TestUser(t *testing.T) {
-	context, err := utils.NewTestPatrolContext()
-	if err != nil {
-		t.Error(err)
-		t.Fail()
-	}
+	context := newTestContext(t)
 
 	Convey("Test insert/update valid user", t, func() {
 		var err error
@@ -26,23 +22,23 @@ func TestUser(t *testing.T) {
 		})
 		So(err, ShouldBeNil)
 
-		_, err = user.Update(manager)
+		_, err = user.Update(context)
 		So(err, ShouldNotBeNil)
 
-		err = user.Insert(manager)
+		err = user.Insert(context)
 
 		So(err, ShouldBeNil)
 		So(user.ID, ShouldNotEqual, 0)
 
-		err = user.Insert(manager)
+		err = user.Insert(context)
 		So(err, ShouldNotBeNil)
 
 		user.Name = randomdata.FullName(randomdata.Male)
-		changed, err = user.Update(manager, "name")
+		changed, err = user.Update(context, "name")
 		So(err, ShouldBeNil)
 		So(changed, ShouldBeTrue)
 
-		changed, err = user.Update(manager, "name", "last_login")
+		changed, err = user.Update(context, "name", "last_login")
 		So(err, ShouldBeNil)
 		_, err = user.Update(context, "nonexistingfield")
 		So(err, ShouldNotBeNil)
@@ -62,7 +58,7 @@ func TestUser(t *testing.T) {
 			u.SetPassword(password)
 		})
 
-		err = user.Insert(manager)
+		err = user.Insert(context)
 		So(err, ShouldBeNil)
 
 		_, err = manager.Login(user)
@@ -70,7 +66,7 @@ func TestUser(t *testing.T) {
 		So(err, ShouldEqual, ErrCannotLoginUser)
 
 		user.IsActive = true
-		changed, err = user.Update(manager, "is_active")
+		changed, err = user.Update(context, "is_active")
 		So(changed, ShouldNotEqual, 0)
 		So(err, ShouldBeNil)
 
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/rest/paginator"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_CHATOPS_CHATWEBHOOK_INITIAL_ID = "chatops-chatwebhook-initial"
+	MIGRATION_CHATOPS_CHATWEBHOOK_INITIAL    = `CREATE TABLE ` + CHATOPS_CHATWEBHOOK_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		project_id bigint NOT NULL REFERENCES ` + PROJECTS_PROJECT_DB_TABLE + ` ON DELETE CASCADE,
+		url character varying (` + strconv.Itoa(MAX_WEBHOOK_URL_LENGTH) + `) NOT NULL,
+		channel character varying (` + strconv.Itoa(MAX_CHAT_CHANNEL_LENGTH) + `) NOT NULL DEFAULT '',
+		enabled boolean NOT NULL DEFAULT true,
+		date_created timestamp with time zone NOT NULL
+	)`
+	MIGRATION_CHATOPS_CHATWEBHOOK_INITIAL_DEPENDENCIES = []string{
+		settings.PROJECTS_PLUGIN_ID + ":" + MIGRATION_PROJECT_INITIAL_ID,
+	}
+)
+
+/*
+ChatWebhook model
+
+	Slack or Mattermost compatible incoming webhook of project. Channel
+	overrides default channel of incoming webhook when set.
+*/
+type ChatWebhook struct {
+	Model
+	ProjectID   types.ForeignKey `db:"project_id" json:"project_id"`
+	URL         string           `db:"url" json:"url"`
+	Channel     string           `db:"channel" json:"channel"`
+	Enabled     bool             `db:"enabled" json:"enabled"`
+	DateCreated time.Time        `db:"date_created" json:"date_created"`
+}
+
+// returns all columns except of primary key
+func (c *ChatWebhook) Columns() []string {
+	return []string{"project_id", "url", "channel", "enabled", "date_created"}
+}
+func (c *ChatWebhook) Values() []interface{} {
+	return []interface{}{c.ProjectID, c.URL, c.Channel, c.Enabled, c.DateCreated}
+}
+func (c *ChatWebhook) String() string { return "chatops:chatwebhook:" + c.PrimaryKey().String() }
+func (c *ChatWebhook) Table() string  { return CHATOPS_CHATWEBHOOK_DB_TABLE }
+
+// enabled chat webhooks are cached by project
+func chatWebhookProjectCacheKey(projectID types.ForeignKey) string {
+	return "chatops:chatwebhook:project:" + strconv.FormatInt(projectID.Int64(), 10)
+}
+
+/*
+CRUD
+*/
+func (c *ChatWebhook) Insert(ctx *context.Context) (err error) {
+	if err = DBInsert(ctx, c); err != nil {
+		return
+	}
+	return RemoveCached(ctx, chatWebhookProjectCacheKey(c.ProjectID))
+}
+
+func (c *ChatWebhook) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	if changed, err = DBUpdate(ctx, c, fields...); err != nil {
+		return
+	}
+	err = RemoveCached(ctx, chatWebhookProjectCacheKey(c.ProjectID))
+	return
+}
+
+func (c *ChatWebhook) Delete(ctx *context.Context) (err error) {
+	if err = DBDelete(ctx, c); err != nil {
+		return
+	}
+	return RemoveCached(ctx, chatWebhookProjectCacheKey(c.ProjectID))
+}
+
+/*
+ChatWebhookManager
+*/
+type ChatWebhookManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewChatWebhookManager(context *context.Context) *ChatWebhookManager {
+	return &ChatWebhookManager{context: context}
+}
+
+// returns new model instance with default values
+func NewChatWebhook(funcs ...func(*ChatWebhook)) (webhook *ChatWebhook) {
+	webhook = &ChatWebhook{
+		Enabled:     true,
+		DateCreated: utils.NowTruncated(),
+	}
+	for _, f := range funcs {
+		f(webhook)
+	}
+	return
+}
+
+func (c *ChatWebhookManager) NewChatWebhook(funcs ...func(*ChatWebhook)) *ChatWebhook {
+	return NewChatWebhook(funcs...)
+}
+func (c *ChatWebhookManager) NewChatWebhookList() []*ChatWebhook { return []*ChatWebhook{} }
+
+// Filter results without paging
+func (c *ChatWebhookManager) Filter(target interface{}, qfs ...utils.QueryFunc) error {
+	_, safe := target.([]*ChatWebhook)
+	return DBFilter(c.context, CHATOPS_CHATWEBHOOK_DB_TABLE+".*", CHATOPS_CHATWEBHOOK_DB_TABLE, !safe, target, qfs...)
+}
+
+// Filter results with paging
+func (c *ChatWebhookManager) FilterPaged(target interface{}, paging *paginator.Paginator, qfs ...utils.QueryFunc) (err error) {
+	if err = DBFilterCount(c.context, CHATOPS_CHATWEBHOOK_DB_TABLE, paging, qfs...); err != nil {
+		return
+	}
+
+	// add paging query filter
+	qfs = append(qfs, c.QueryFilterPaging(paging))
+
+	_, safe := target.([]*ChatWebhook)
+
+	return DBFilter(c.context, CHATOPS_CHATWEBHOOK_DB_TABLE+".*", CHATOPS_CHATWEBHOOK_DB_TABLE, !safe, target, qfs...)
+}
+
+// get from database
+func (c *ChatWebhookManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*ChatWebhook)
+	return DBGet(c.context, "*", CHATOPS_CHATWEBHOOK_DB_TABLE, !safe, target, qfs...)
+}
+
+// returns by id and possibly other queryFuncs
+func (c *ChatWebhookManager) GetByID(target interface{}, id types.Keyer, qfs ...utils.QueryFunc) (err error) {
+	qfs = append(qfs, c.QueryFilterWhere("id = ?", id.Int64()))
+	return c.Get(target, qfs...)
+}
+
+/*
+Returns enabled chat webhooks of project, webhooks are cached until some chat
+webhook of project is changed.
+*/
+func (c *ChatWebhookManager) FilterEnabled(target *[]*ChatWebhook, projectID types.ForeignKey) (err error) {
+	cacheKey := chatWebhookProjectCacheKey(projectID)
+	if err = GetCached(c.context, cacheKey, target); err == nil {
+		return
+	}
+
+	if err = c.Filter(target,
+		c.QueryFilterWhere(CHATOPS_CHATWEBHOOK_DB_TABLE+".project_id = ? AND "+CHATOPS_CHATWEBHOOK_DB_TABLE+".enabled = ?", projectID, true),
+		c.QueryFilterOrderID(),
+	); err != nil {
+		return
+	}
+
+	return Cache(c.context, cacheKey, target)
+}
+
+// filters chat webhooks by project
+func (c *ChatWebhookManager) QueryFilterProject(project *Project) utils.QueryFunc {
+	handleNilPointer(project)
+	return c.QueryFilterWhere(CHATOPS_CHATWEBHOOK_DB_TABLE+".project_id = ?", project.ID)
+}
+
+// orders chat webhooks by id
+func (c *ChatWebhookManager) QueryFilterOrderID() utils.QueryFunc {
+	return func(builder squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return builder.OrderBy(CHATOPS_CHATWEBHOOK_DB_TABLE + ".id ASC")
+	}
+}
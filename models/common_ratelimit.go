@@ -0,0 +1,51 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/phonkee/patrol/context"
+)
+
+/*
+Returns whether action identified by key was performed more than limit
+times in period. Every call is counted, period starts with first call.
+*/
+func IsRateLimited(context *context.Context, key string, limit int, period time.Duration) (limited bool, err error) {
+	var count int
+	if count, err = IncrTimeout(context, "ratelimit:"+key, period); err != nil {
+		return
+	}
+
+	return count > limit, nil
+}
+
+/*
+Increments counter of key and returns its value, counter expires after
+timeout from first increment. Cache cannot increment and set expiration at
+once, so marker key is stored after expiration of counter is set. Counter
+without marker (process failed between Incr and Set) gets expiration with
+next increment, so it never stays in cache forever.
+*/
+func IncrTimeout(context *context.Context, key string, timeout time.Duration) (count int, err error) {
+	if count, err = context.Cache.Incr(key); err != nil {
+		return
+	}
+
+	if count > 1 {
+		if _, errGet := context.Cache.Get(incrTimeoutKey(key)); errGet == nil {
+			return
+		}
+	}
+
+	if err = context.Cache.Set(key, []byte(strconv.Itoa(count)), timeout); err != nil {
+		return
+	}
+	err = context.Cache.Set(incrTimeoutKey(key), []byte("1"), timeout)
+	return
+}
+
+// cache key of marker that counter has expiration set
+func incrTimeoutKey(key string) string {
+	return key + ":timeout"
+}
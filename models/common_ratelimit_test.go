@@ -0,0 +1,58 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/phonkee/patrol/context"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIsRateLimited(t *testing.T) {
+	Convey("Test rate limit", t, func() {
+		ctx := &context.Context{
+			Cache: &memoryCache{data: map[string][]byte{}},
+		}
+
+		for i := 0; i < 3; i++ {
+			limited, err := IsRateLimited(ctx, "test", 3, time.Minute)
+			So(err, ShouldBeNil)
+			So(limited, ShouldBeFalse)
+		}
+
+		limited, err := IsRateLimited(ctx, "test", 3, time.Minute)
+		So(err, ShouldBeNil)
+		So(limited, ShouldBeTrue)
+
+		// other keys are not affected
+		limited, err = IsRateLimited(ctx, "other", 3, time.Minute)
+		So(err, ShouldBeNil)
+		So(limited, ShouldBeFalse)
+	})
+
+	Convey("Test counter expiration", t, func() {
+		cache := &memoryCache{data: map[string][]byte{}}
+		ctx := &context.Context{Cache: cache}
+
+		count, err := IncrTimeout(ctx, "counter", time.Minute)
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 1)
+		So(cache.expirations["counter"], ShouldEqual, time.Minute)
+
+		count, err = IncrTimeout(ctx, "counter", time.Minute)
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 2)
+		So(cache.expirations["counter"], ShouldEqual, time.Minute)
+
+		// process failed between Incr and Set, next increment sets expiration
+		count, err = cache.Incr("failed")
+		So(err, ShouldBeNil)
+		_, ok := cache.expirations["failed"]
+		So(ok, ShouldBeFalse)
+
+		count, err = IncrTimeout(ctx, "failed", time.Minute)
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 2)
+		So(cache.expirations["failed"], ShouldEqual, time.Minute)
+	})
+}
@@ -9,6 +9,29 @@ const (
 	MAX_TAG_KEY_LENGTH   = 32
 	MAX_TAG_VALUE_LENGTH = 200
 	MAX_CULPRIT_LENGTH   = 200
+
+	MAX_RELEASE_VERSION_LENGTH  = 200
+	MAX_ENVIRONMENT_NAME_LENGTH = 64
+
+	MAX_SCRUB_FIELD_LENGTH    = 100
+	MAX_FILTER_PATTERN_LENGTH = 200
+
+	MAX_ALERT_RULE_NAME_LENGTH  = 200
+	MAX_ALERT_RULE_CONDITIONS   = 20
+	MAX_ALERT_RULE_ACTIONS      = 10
+	MAX_ALERT_CONDITION_MINUTES = 60 * 24
+
+	MAX_WEBHOOK_URL_LENGTH               = 500
+	MAX_WEBHOOK_SECRET_LENGTH            = 200
+	MAX_WEBHOOK_DELIVERY_RESPONSE_LENGTH = 1000
+	MAX_WEBHOOK_DELIVERY_ERROR_LENGTH    = 500
+
+	MAX_CHAT_CHANNEL_LENGTH = 100
+
+	MAX_AUDIT_ACTION_LENGTH      = 64
+	MAX_AUDIT_TARGET_TYPE_LENGTH = 64
+	MAX_AUDIT_IP_LENGTH          = 45
+	MAX_AUDIT_USER_AGENT_LENGTH  = 255
 )
 
 /*
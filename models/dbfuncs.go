@@ -9,6 +9,7 @@ import (
 	"runtime"
 
 	"github.com/golang/glog"
+	"github.com/lib/pq"
 	"github.com/mgutz/ansi"
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/rest/paginator"
@@ -35,7 +36,7 @@ Handles situations if nil pointer is passed
 func handleNilPointer(value interface{}) (err error) {
 	if value == nil {
 		if _, file, line, ok := runtime.Caller(NIL_POINTER_CALLER_SKIP); ok {
-			err = fmt.Errorf("nil pointer passed %s:%s", file, line)
+			err = fmt.Errorf("nil pointer passed %s:%d", file, line)
 		} else {
 			err = fmt.Errorf("nil pointer")
 		}
@@ -128,6 +129,31 @@ func DBFilterCount(ctx *context.Context, dbtable string, paging *paginator.Pagin
 	return
 }
 
+/*
+Returns result of count expression (e.g. COUNT(*), COUNT(DISTINCT value))
+*/
+func DBCount(ctx *context.Context, sel, dbtable string, qfs ...utils.QueryFunc) (count int64, err error) {
+	qb := utils.QueryBuilderTable(dbtable, sel, qfs...)
+	var (
+		query string
+		args  []interface{}
+	)
+
+	qrxfunc := ctx.DB.QueryRowx
+	if ctx.Tx != nil {
+		qrxfunc = ctx.Tx.QueryRowx
+	}
+
+	if query, args, err = qb.ToSql(); err != nil {
+		LogSQL(query, args, err, SQL_CALLER_SKIP)
+		return
+	}
+
+	err = qrxfunc(query, args...).Scan(&count)
+	LogSQL(query, args, err, SQL_CALLER_SKIP)
+	return
+}
+
 /*
 Scans single object into target interface
 This method should not be used directly from views, rather shiould be used
@@ -352,6 +378,16 @@ func ChangedModelFields(model, another Modeler) (result []string, err error) {
 	return
 }
 
+/*
+Returns whether error is postgres unique violation
+*/
+func IsUniqueViolation(err error) bool {
+	if pqerr, ok := err.(*pq.Error); ok {
+		return pqerr.Code.Name() == "unique_violation"
+	}
+	return false
+}
+
 /*
 	Caching
 */
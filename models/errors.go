@@ -10,10 +10,29 @@ var (
 	ErrInvalidEmail        = errors.New("incorrect email address")
 	ErrUpdateNoFieldsGiven = errors.New("no valid fields given to update")
 	ErrCannotLoginUser     = errors.New("cannot login user")
+	ErrTokenRevoked        = errors.New("token_revoked")
+	ErrInvalidRefreshToken = errors.New("invalid_refresh_token")
+	ErrTokenScope          = errors.New("insufficient_scope")
+	ErrInvalidSignedToken  = errors.New("invalid_token")
+	ErrEmailNotVerified    = errors.New("email_not_verified")
+	ErrRateLimited         = errors.New("rate_limited")
+	ErrInvalidCredentials  = errors.New("invalid_credentials")
+	ErrLoginThrottled      = errors.New("login_throttled")
+	ErrAccountLocked       = errors.New("account_locked")
+
+	ErrTwoFactorRequired       = errors.New("two_factor_required")
+	ErrTwoFactorNotEnabled     = errors.New("two_factor_not_enabled")
+	ErrTwoFactorAlreadyEnabled = errors.New("two_factor_already_enabled")
+	ErrInvalidTwoFactorCode    = errors.New("invalid_two_factor_code")
+
+	ErrAuthBackendAlreadyRegistered = errors.New("auth backend already registered")
+	ErrAuthBackendNotFound          = errors.New("auth backend not found")
+	ErrInvalidLDAPTeamMapping       = errors.New("invalid ldap team mapping")
 
 	ErrTeamNameTooLong = errors.New("Team name should not exceed 64 characters.")
 
 	ErrInvalidChoice = errors.New("invalid_choice")
+	ErrInvalidValue  = errors.New("invalid_value")
 
 	ErrCannotParseAuthHeaders = errors.New("cannot parser auth headers.")
 
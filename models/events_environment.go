@@ -0,0 +1,201 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_EVENTS_ENVIRONMENT_INITIAL_ID = "events-environment-initial"
+	MIGRATION_EVENTS_ENVIRONMENT_INITIAL    = `CREATE TABLE ` + EVENTS_ENVIRONMENT_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		project_id bigint NOT NULL REFERENCES ` + PROJECTS_PROJECT_DB_TABLE + ` ON DELETE CASCADE,
+		name character varying (` + strconv.Itoa(MAX_ENVIRONMENT_NAME_LENGTH) + `) NOT NULL,
+		date_created timestamp with time zone NOT NULL,
+		UNIQUE (project_id, name)
+	)`
+	MIGRATION_EVENTS_ENVIRONMENT_EVENT = `ALTER TABLE ` + EVENTS_EVENT_DB_TABLE + `
+		ADD COLUMN environment_id bigint REFERENCES ` + EVENTS_ENVIRONMENT_DB_TABLE + ` ON DELETE SET NULL`
+	MIGRATION_EVENTS_ENVIRONMENT_INITIAL_DEPENDENCIES = []string{
+		settings.EVENTS_PLUGIN_ID + ":" + MIGRATION_EVENTS_EVENT_INITIAL_ID,
+	}
+)
+
+/*
+Environment model
+
+	environment of project (e.g. production, staging), created from first
+	event that reports it.
+*/
+type Environment struct {
+	Model
+	ProjectID   types.ForeignKey `db:"project_id" json:"project_id"`
+	Name        string           `db:"name" json:"name"`
+	DateCreated time.Time        `db:"date_created" json:"date_created"`
+}
+
+// returns all columns except of primary key
+func (e *Environment) Columns() []string {
+	return []string{"project_id", "name", "date_created"}
+}
+func (e *Environment) Values() []interface{} {
+	return []interface{}{e.ProjectID, e.Name, e.DateCreated}
+}
+func (e *Environment) String() string { return "events:environment:" + e.PrimaryKey().String() }
+func (e *Environment) Table() string  { return EVENTS_ENVIRONMENT_DB_TABLE }
+
+// returns nullable foreign key to environment
+func (e *Environment) ToNullForeignKey() (result types.NullForeignKey) {
+	result.Int64 = e.ID.Int64()
+	result.Valid = e.ID != 0
+	return
+}
+
+/*
+CRUD
+*/
+func (e *Environment) Insert(ctx *context.Context) (err error) {
+	return DBInsert(ctx, e)
+}
+
+func (e *Environment) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return DBUpdate(ctx, e, fields...)
+}
+
+func (e *Environment) Delete(ctx *context.Context) (err error) {
+	return DBDelete(ctx, e)
+}
+
+/*
+EnvironmentManager
+*/
+type EnvironmentManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewEnvironmentManager(context *context.Context) *EnvironmentManager {
+	return &EnvironmentManager{context: context}
+}
+
+// returns new model instance
+func NewEnvironment(funcs ...func(*Environment)) (environment *Environment) {
+	environment = &Environment{
+		DateCreated: utils.NowTruncated(),
+	}
+	for _, f := range funcs {
+		f(environment)
+	}
+	return
+}
+
+func (e *EnvironmentManager) NewEnvironment(funcs ...func(*Environment)) *Environment {
+	return NewEnvironment(funcs...)
+}
+func (e *EnvironmentManager) NewEnvironmentList() []*Environment { return []*Environment{} }
+
+// Filter results without paging
+func (e *EnvironmentManager) Filter(target interface{}, qfs ...utils.QueryFunc) error {
+	_, safe := target.([]*Environment)
+	return DBFilter(e.context, EVENTS_ENVIRONMENT_DB_TABLE+".*", EVENTS_ENVIRONMENT_DB_TABLE, !safe, target, qfs...)
+}
+
+// get from database
+func (e *EnvironmentManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*Environment)
+	return DBGet(e.context, "*", EVENTS_ENVIRONMENT_DB_TABLE, !safe, target, qfs...)
+}
+
+/*
+Returns environment for project and name, creates it if it does not exist.
+*/
+func (e *EnvironmentManager) GetOrCreate(target *Environment, projectID types.ForeignKey, name string) (err error) {
+	name = CleanEnvironmentName(name)
+	filter := e.QueryFilterWhere("project_id = ? AND name = ?", projectID, name)
+
+	if err = e.Get(target, filter); err != ErrObjectDoesNotExists {
+		return
+	}
+
+	*target = *e.NewEnvironment(func(environment *Environment) {
+		environment.ProjectID = projectID
+		environment.Name = name
+	})
+
+	// other worker could create same environment in meantime
+	if err = target.Insert(e.context); err != nil && IsUniqueViolation(err) {
+		err = e.Get(target, filter)
+	}
+
+	return
+}
+
+// filters environments by project
+func (e *EnvironmentManager) QueryFilterProject(project *Project) utils.QueryFunc {
+	handleNilPointer(project)
+	return e.QueryFilterWhere(EVENTS_ENVIRONMENT_DB_TABLE+".project_id = ?", project.ID)
+}
+
+// orders environments by name
+func (e *EnvironmentManager) QueryFilterOrderName() utils.QueryFunc {
+	return func(builder squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return builder.OrderBy(EVENTS_ENVIRONMENT_DB_TABLE + ".name ASC")
+	}
+}
+
+/*
+Environment names are case insensitive and trimmed
+*/
+func CleanEnvironmentName(name string) string {
+	return utils.StringTruncate(strings.ToLower(strings.TrimSpace(name)), MAX_ENVIRONMENT_NAME_LENGTH)
+}
+
+/*
+Query filter funcs usable in other managers, names are combined with OR
+*/
+
+// returns cleaned environment names as postgres array
+func environmentNames(names []string) (result types.StringSlice) {
+	result = types.StringSlice{}
+	for _, name := range names {
+		result = append(result, CleanEnvironmentName(name))
+	}
+	return
+}
+
+// filters events by environment names
+func QueryFilterEventEnvironment(names ...string) utils.QueryFunc {
+	return utils.QueryFilterWhere(
+		EVENTS_EVENT_DB_TABLE+".environment_id IN (SELECT id FROM "+EVENTS_ENVIRONMENT_DB_TABLE+" WHERE name = ANY(?))",
+		environmentNames(names),
+	)
+}
+
+// filters eventgroups that have at least one event in one of environments
+func QueryFilterEventGroupEnvironment(names ...string) utils.QueryFunc {
+	return utils.QueryFilterWhere(
+		EVENTS_EVENTGROUP_DB_TABLE+".id IN (SELECT ege.eventgroup_id FROM "+EVENTS_EVENTGROUPENVIRONMENT_DB_TABLE+" ege"+
+			" JOIN "+EVENTS_ENVIRONMENT_DB_TABLE+" env ON env.id = ege.environment_id WHERE env.name = ANY(?))",
+		environmentNames(names),
+	)
+}
+
+// filters tags of events in one of environments
+func QueryFilterEventTagEnvironment(names ...string) utils.QueryFunc {
+	return utils.QueryFilterWhere(
+		EVENTS_EVENTTAG_DB_TABLE+".event_id IN (SELECT ev.id FROM "+EVENTS_EVENT_DB_TABLE+" ev"+
+			" JOIN "+EVENTS_ENVIRONMENT_DB_TABLE+" env ON env.id = ev.environment_id WHERE env.name = ANY(?))",
+		environmentNames(names),
+	)
+}
@@ -1,15 +1,22 @@
 package models
 
 import (
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/parser"
 	"github.com/phonkee/patrol/rest/paginator"
+	"github.com/phonkee/patrol/settings"
 	"github.com/phonkee/patrol/types"
 	"github.com/phonkee/patrol/utils"
 )
 
+var (
+	ErrEventAlreadyExists = errors.New("event_already_exists")
+)
+
 /*
 Migrations
 */
@@ -27,6 +34,18 @@ var (
 		time_spent bigint NOT NULL,
 		data bytea NOT NULL
 	)`
+
+	// event_id must be unique in project (clients retry sending events).
+	// Existing events may already contain duplicates, they are kept, so
+	// unique index covers only events stored after migration.
+	MIGRATION_EVENTS_EVENT_UNIQUE_EVENT_ID_ID = "events-event-unique-event-id"
+	MIGRATION_EVENTS_EVENT_EVENT_ID_INDEX     = `CREATE INDEX ` + EVENTS_EVENT_DB_TABLE + `_project_id_event_id ON ` +
+		EVENTS_EVENT_DB_TABLE + ` (project_id, event_id)`
+	MIGRATION_EVENTS_EVENT_UNIQUE_EVENT_ID = `DO $$ BEGIN EXECUTE 'CREATE UNIQUE INDEX ` + EVENTS_EVENT_DB_TABLE + `_project_id_event_id_unique ON ` +
+		EVENTS_EVENT_DB_TABLE + ` (project_id, event_id) WHERE id > ' || COALESCE((SELECT max(id) FROM ` + EVENTS_EVENT_DB_TABLE + `), 0); END $$`
+	MIGRATION_EVENTS_EVENT_UNIQUE_EVENT_ID_DEPENDENCIES = []string{
+		settings.EVENTS_PLUGIN_ID + ":" + MIGRATION_EVENTS_EVENT_INITIAL_ID,
+	}
 )
 
 /*
@@ -34,27 +53,33 @@ Event model
 */
 type Event struct {
 	Model
-	EventID      string           `db:"event_id" json:"event_id"`
-	EventGroupID types.ForeignKey `db:"eventgroup_id" json:"eventgroup_id"`
-	ProjectID    types.ForeignKey `db:"project_id" json:"project_id"`
-	Message      string           `db:"message" json:"message"`
-	Platform     string           `db:"platform" json:"platform"`
-	Datetime     time.Time        `db:"datetime" json:"datetime"`
-	TimeSpent    int64            `db:"time_spent" json:"time_spent"`
-	Data         types.GzippedMap `db:"data" json:"data"`
+	EventID       string               `db:"event_id" json:"event_id"`
+	EventGroupID  types.ForeignKey     `db:"eventgroup_id" json:"eventgroup_id"`
+	ProjectID     types.ForeignKey     `db:"project_id" json:"project_id"`
+	ReleaseID     types.NullForeignKey `db:"release_id" json:"release_id"`
+	EnvironmentID types.NullForeignKey `db:"environment_id" json:"environment_id"`
+	Message       string               `db:"message" json:"message"`
+	Platform      string               `db:"platform" json:"platform"`
+	Datetime      time.Time            `db:"datetime" json:"datetime"`
+	TimeSpent     int64                `db:"time_spent" json:"time_spent"`
+	Data          types.GzippedMap     `db:"data" json:"data"`
+
+	// level of processed event (eventgroup keeps level of first event), set
+	// by processing of raw event, not stored
+	Level int `db:"-" json:"-"`
 }
 
 // returns all columns except of primary key
 func (e *Event) Columns() []string {
 	return []string{
-		"event_id", "eventgroup_id", "project_id", "message",
-		"platform", "datetime", "time_spent", "data",
+		"event_id", "eventgroup_id", "project_id", "release_id", "environment_id",
+		"message", "platform", "datetime", "time_spent", "data",
 	}
 }
 func (e *Event) Values() []interface{} {
 	return []interface{}{
-		e.EventID, e.EventGroupID, e.ProjectID, e.Message,
-		e.Platform, e.Datetime, e.TimeSpent, e.Data,
+		e.EventID, e.EventGroupID, e.ProjectID, e.ReleaseID, e.EnvironmentID,
+		e.Message, e.Platform, e.Datetime, e.TimeSpent, e.Data,
 	}
 }
 func (e *Event) String() string { return "events:event:" + e.PrimaryKey().String() }
@@ -159,12 +184,74 @@ func (e *EventManager) GetByID(target interface{}, id types.Keyer, qfs ...utils.
 	return e.Get(target, qfs...)
 }
 
+/*
+Returns count of eventgroup events since given time
+*/
+func (e *EventManager) CountSince(eventgroup *EventGroup, since time.Time) (count int64, err error) {
+	handleNilPointer(eventgroup)
+	return DBCount(e.context, "COUNT(*)", EVENTS_EVENT_DB_TABLE,
+		e.QueryFilterWhere(EVENTS_EVENT_DB_TABLE+".eventgroup_id = ? AND "+EVENTS_EVENT_DB_TABLE+".datetime >= ?", eventgroup.ID, since),
+	)
+}
+
+/*
+Returns whether event_id was already seen for project in last
+EVENT_ID_DEDUPLICATION_TIMEOUT. First call for given event_id returns false.
+*/
+func (e *EventManager) IsDuplicateEventID(projectID types.Keyer, eventID string) (duplicate bool, err error) {
+	var count int
+	if count, err = IncrTimeout(e.context, eventIDCacheKey(projectID, eventID), settings.EVENT_ID_DEDUPLICATION_TIMEOUT); err != nil {
+		return
+	}
+
+	return count > 1, nil
+}
+
+/*
+Forgets that event_id was seen (e.g. event could not be queued), so client can
+retry it.
+*/
+func (e *EventManager) ForgetEventID(projectID types.Keyer, eventID string) error {
+	return e.context.Cache.Delete(eventIDCacheKey(projectID, eventID))
+}
+
+func eventIDCacheKey(projectID types.Keyer, eventID string) string {
+	return "events:event:eventid:" + strconv.FormatInt(projectID.Int64(), 10) + ":" + eventID
+}
+
 // NewEventFromRaw creates new event from raw event
+// returns ErrEventAlreadyExists if event with given event_id was already stored
 func (e *EventManager) NewEventFromRaw(raw *parser.RawEvent) (event *Event, eventgroup *EventGroup, err error) {
 	egm := NewEventGroupManager(e.context)
 
+	// check if event is not already stored
+	existing := e.NewEvent()
+	if err = e.Get(existing, e.QueryFilterWhere("project_id = ? AND event_id = ?", raw.ProjectID, raw.EventID)); err == nil {
+		return nil, nil, ErrEventAlreadyExists
+	} else if err != ErrObjectDoesNotExists {
+		return
+	}
+
+	// release reported by client
+	var release *Release
+	if raw.Release != "" {
+		release = NewRelease()
+		if err = NewReleaseManager(e.context).GetOrCreate(release, raw.ProjectID, raw.Release); err != nil {
+			return
+		}
+	}
+
+	// environment reported by client
+	var environment *Environment
+	if CleanEnvironmentName(raw.Environment) != "" {
+		environment = NewEnvironment()
+		if err = NewEnvironmentManager(e.context).GetOrCreate(environment, raw.ProjectID, raw.Environment); err != nil {
+			return
+		}
+	}
+
 	// some serious error occured
-	if eventgroup, err = egm.GetByRaw(raw); err != nil {
+	if eventgroup, err = egm.GetByRaw(raw, release); err != nil {
 		return
 	}
 
@@ -172,14 +259,29 @@ func (e *EventManager) NewEventFromRaw(raw *parser.RawEvent) (event *Event, even
 		ev.EventID = raw.EventID
 		ev.EventGroupID = eventgroup.ID.ToForeignKey()
 		ev.ProjectID = eventgroup.ProjectID
+		if release != nil {
+			ev.ReleaseID = release.ToNullForeignKey()
+		}
+		if environment != nil {
+			ev.EnvironmentID = environment.ToNullForeignKey()
+		}
 		ev.Message = raw.Message
 		ev.Platform = raw.Platform
 		ev.Datetime = utils.NowTruncated()
 		ev.Data = raw.Data
+		ev.Level = parser.LevelValue(raw.Level)
 	})
 
-	// some serious error occured
+	// some serious error occured (unique violation means concurrent insert of same event)
 	if err = event.Insert(e.context); err != nil {
+		if IsUniqueViolation(err) {
+			err = ErrEventAlreadyExists
+		}
+		return
+	}
+
+	// store tags
+	if err = NewEventTagManager(e.context).InsertTags(event, raw.Tags); err != nil {
 		return
 	}
 
@@ -17,6 +17,8 @@ import (
 
 var (
 	ErrEventGroupAlreadyResolved = errors.New("eventgroup_already_resolved")
+	ErrEventGroupAlreadyMuted    = errors.New("eventgroup_already_muted")
+	ErrNoRelease                 = errors.New("no_release")
 )
 
 type EventGroup struct {
@@ -38,6 +40,14 @@ type EventGroup struct {
 	TimeSpentCount int              `db:"time_spent_count" json:"time_spent_count"`
 	Score          int              `db:"score" json:"score"`
 	Data           types.GzippedMap `db:"data" json:"data"`
+
+	FirstReleaseID      types.NullForeignKey `db:"first_release_id" json:"first_release_id"`
+	LastReleaseID       types.NullForeignKey `db:"last_release_id" json:"last_release_id"`
+	ResolvedInReleaseID types.NullForeignKey `db:"resolved_in_release_id" json:"resolved_in_release_id"`
+
+	// set by GetByRaw, not stored
+	IsNew        bool `db:"-" json:"-"`
+	IsRegression bool `db:"-" json:"-"`
 }
 
 // returns all columns except of primary key
@@ -46,7 +56,8 @@ func (e *EventGroup) Columns() []string {
 		"project_id", "logger", "level", "message", "culprit",
 		"checksum", "platform", "status", "times_seen", "first_seen",
 		"last_seen", "resolved_at", "active_at", "time_spent_total",
-		"time_spent_count", "score", "data",
+		"time_spent_count", "score", "data", "first_release_id",
+		"last_release_id", "resolved_in_release_id",
 	}
 }
 func (e *EventGroup) Values() []interface{} {
@@ -54,7 +65,8 @@ func (e *EventGroup) Values() []interface{} {
 		e.ProjectID, e.Logger, e.Level, e.Message, e.Culprit,
 		e.Checksum, e.Platform, e.Status, e.TimesSeen, e.FirstSeen,
 		e.LastSeen, e.ResolvedAt, e.ActiveAt, e.TimeSpentTotal,
-		e.TimeSpentCount, e.Score, e.Data,
+		e.TimeSpentCount, e.Score, e.Data, e.FirstReleaseID,
+		e.LastReleaseID, e.ResolvedInReleaseID,
 	}
 }
 func (e *EventGroup) String() string { return "events:eventgroup:" + e.PrimaryKey().String() }
@@ -218,7 +230,8 @@ func (e *EventGroupManager) GetByID(target interface{}, id types.PrimaryKey, qfs
 }
 
 // returns eventgroup by raw event (returns from db or craetes one)
-func (e *EventGroupManager) GetByRaw(raw *parser.RawEvent) (eventgroup *EventGroup, err error) {
+// release is optional (nil when event does not report release)
+func (e *EventGroupManager) GetByRaw(raw *parser.RawEvent, release *Release) (eventgroup *EventGroup, err error) {
 
 	eventgroup = e.NewEventGroup()
 
@@ -231,8 +244,7 @@ func (e *EventGroupManager) GetByRaw(raw *parser.RawEvent) (eventgroup *EventGro
 		eventgroup = e.NewEventGroup(func(eg *EventGroup) {
 			eg.ProjectID = raw.ProjectID
 			eg.Logger = raw.Logger
-			// @TODO: map from string to int value
-			// eg.Level = raw.Level
+			eg.Level = parser.LevelValue(raw.Level)
 			eg.Message = raw.Message
 			eg.Culprit = raw.Culprit
 			eg.Checksum = raw.Checksum
@@ -242,23 +254,76 @@ func (e *EventGroupManager) GetByRaw(raw *parser.RawEvent) (eventgroup *EventGro
 			eg.FirstSeen = utils.NowTruncated()
 			eg.LastSeen = utils.NowTruncated()
 			eg.Data = raw.Data
+			if release != nil {
+				eg.FirstReleaseID = release.ToNullForeignKey()
+				eg.LastReleaseID = release.ToNullForeignKey()
+			}
 		})
 
 		// something bad happened
 		if err = eventgroup.Insert(e.context); err != nil {
 			return
 		}
+		eventgroup.IsNew = true
+		return
+	}
+
+	err = e.reopen(eventgroup, release)
+	return
+}
+
+/*
+Resolved eventgroup is reopened (regression) when new event occurs. Eventgroup
+resolved in release is reopened only when it occurs in newer release.
+*/
+func (e *EventGroupManager) reopen(eventgroup *EventGroup, release *Release) (err error) {
+	if eventgroup.Status != EVENT_GROUP_STATUS_RESOLVED {
+		return
+	}
+
+	if eventgroup.ResolvedInReleaseID.Valid {
+		if release == nil || eventgroup.ResolvedInReleaseID.Int64 == release.ID.Int64() {
+			return
+		}
+
+		resolvedIn := NewRelease()
+		if err = NewReleaseManager(e.context).GetByID(resolvedIn, types.ForeignKey(eventgroup.ResolvedInReleaseID.Int64)); err != nil {
+			if err == ErrObjectDoesNotExists {
+				err = nil
+			}
+			return
+		}
+
+		if !release.DateCreated.After(resolvedIn.DateCreated) {
+			return
+		}
 	}
 
+	eventgroup.IsRegression = true
+	eventgroup.Status = EVENT_GROUP_STATUS_UNRESOLVED
+	eventgroup.ResolvedInReleaseID = types.NullForeignKey{}
+	eventgroup.ActiveAt = utils.NowTruncated()
+	_, err = eventgroup.Update(e.context, "status", "resolved_in_release_id", "active_at")
 	return
 }
 
 // increments counter safe way
-func (e *EventGroupManager) IncrementCounters(eventgroup *EventGroup) (err error) {
+// last release is set from event, counters of event environment are incremented too
+func (e *EventGroupManager) IncrementCounters(eventgroup *EventGroup, event *Event) (err error) {
+	eventgroup.LastSeen = utils.NowTruncated()
+
 	builder := utils.QueryBuilder().
 		Update(eventgroup.Table()).
 		Set("times_seen", squirrel.Expr("times_seen + 1")).
-		Set("last_seen", eventgroup.LastSeen).
+		Set("last_seen", eventgroup.LastSeen)
+
+	if event != nil && event.ReleaseID.Valid {
+		eventgroup.LastReleaseID = event.ReleaseID
+		builder = builder.Set("last_release_id", eventgroup.LastReleaseID)
+	}
+
+	builder = builder.
+		Where("id = ?", eventgroup.ID).
 		Suffix("RETURNING times_seen")
 
 	query, args, err := builder.ToSql()
@@ -272,6 +337,13 @@ func (e *EventGroupManager) IncrementCounters(eventgroup *EventGroup) (err error
 		return
 	}
 
+	if event != nil && event.EnvironmentID.Valid {
+		environmentID := types.ForeignKey(event.EnvironmentID.Int64)
+		if err = NewEventGroupEnvironmentManager(e.context).Increment(eventgroup, environmentID); err != nil {
+			return
+		}
+	}
+
 	return
 }
 
@@ -285,6 +357,44 @@ func (e *EventGroupManager) Resolve(eventgroup *EventGroup, user *User) (err err
 	}
 	eventgroup.Status = EVENT_GROUP_STATUS_RESOLVED
 	eventgroup.ResolvedAt = utils.NowTruncated()
+	eventgroup.ResolvedInReleaseID = types.NullForeignKey{}
+	_, err = eventgroup.Update(e.context)
+	return
+}
+
+/*
+	Mutes given eventgroup, muted eventgroup does not trigger alerts.
+*/
+func (e *EventGroupManager) Mute(eventgroup *EventGroup, user *User) (err error) {
+	if eventgroup.Status == EVENT_GROUP_STATUS_MUTED {
+		return ErrEventGroupAlreadyMuted
+	}
+	eventgroup.Status = EVENT_GROUP_STATUS_MUTED
+	_, err = eventgroup.Update(e.context, "status")
+	return
+}
+
+/*
+	Resolves given eventgroup in next release. Eventgroup is reopened when
+	it occurs in release newer than latest release of project.
+	Returns ErrNoRelease if project has no release.
+*/
+func (e *EventGroupManager) ResolveInNextRelease(eventgroup *EventGroup, project *Project, user *User) (err error) {
+	if eventgroup.Status == EVENT_GROUP_STATUS_RESOLVED {
+		return ErrEventGroupAlreadyResolved
+	}
+
+	latest := NewRelease()
+	if err = NewReleaseManager(e.context).GetLatest(latest, project); err != nil {
+		if err == ErrObjectDoesNotExists {
+			err = ErrNoRelease
+		}
+		return
+	}
+
+	eventgroup.Status = EVENT_GROUP_STATUS_RESOLVED
+	eventgroup.ResolvedAt = utils.NowTruncated()
+	eventgroup.ResolvedInReleaseID = latest.ToNullForeignKey()
 	_, err = eventgroup.Update(e.context)
 	return
 }
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_EVENTS_EVENTGROUPENVIRONMENT_INITIAL_ID = "events-eventgroupenvironment-initial"
+	MIGRATION_EVENTS_EVENTGROUPENVIRONMENT_INITIAL    = `CREATE TABLE ` + EVENTS_EVENTGROUPENVIRONMENT_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		eventgroup_id bigint NOT NULL REFERENCES ` + EVENTS_EVENTGROUP_DB_TABLE + ` ON DELETE CASCADE,
+		environment_id bigint NOT NULL REFERENCES ` + EVENTS_ENVIRONMENT_DB_TABLE + ` ON DELETE CASCADE,
+		times_seen bigint NOT NULL DEFAULT 0,
+		first_seen timestamp with time zone NOT NULL,
+		last_seen timestamp with time zone NOT NULL,
+		UNIQUE (eventgroup_id, environment_id)
+	)`
+	MIGRATION_EVENTS_EVENTGROUPENVIRONMENT_INITIAL_DEPENDENCIES = []string{
+		settings.EVENTS_PLUGIN_ID + ":" + MIGRATION_EVENTS_EVENTGROUP_INITIAL_ID,
+		settings.EVENTS_PLUGIN_ID + ":" + MIGRATION_EVENTS_ENVIRONMENT_INITIAL_ID,
+	}
+)
+
+/*
+EventGroupEnvironment model
+
+	per environment counters of eventgroup
+*/
+type EventGroupEnvironment struct {
+	Model
+	EventGroupID  types.ForeignKey `db:"eventgroup_id" json:"eventgroup_id"`
+	EnvironmentID types.ForeignKey `db:"environment_id" json:"environment_id"`
+	TimesSeen     int64            `db:"times_seen" json:"times_seen"`
+	FirstSeen     time.Time        `db:"first_seen" json:"first_seen"`
+	LastSeen      time.Time        `db:"last_seen" json:"last_seen"`
+}
+
+// returns all columns except of primary key
+func (e *EventGroupEnvironment) Columns() []string {
+	return []string{"eventgroup_id", "environment_id", "times_seen", "first_seen", "last_seen"}
+}
+func (e *EventGroupEnvironment) Values() []interface{} {
+	return []interface{}{e.EventGroupID, e.EnvironmentID, e.TimesSeen, e.FirstSeen, e.LastSeen}
+}
+func (e *EventGroupEnvironment) String() string {
+	return "events:eventgroupenvironment:" + e.PrimaryKey().String()
+}
+func (e *EventGroupEnvironment) Table() string { return EVENTS_EVENTGROUPENVIRONMENT_DB_TABLE }
+
+/*
+CRUD
+*/
+func (e *EventGroupEnvironment) Insert(ctx *context.Context) (err error) {
+	return DBInsert(ctx, e)
+}
+
+func (e *EventGroupEnvironment) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return DBUpdate(ctx, e, fields...)
+}
+
+func (e *EventGroupEnvironment) Delete(ctx *context.Context) (err error) {
+	return DBDelete(ctx, e)
+}
+
+/*
+Eventgroup counters in environment together with environment name
+*/
+type EventGroupEnvironmentStat struct {
+	EventGroupEnvironment
+	Name string `db:"name" json:"name"`
+}
+
+/*
+EventGroupEnvironmentManager
+*/
+type EventGroupEnvironmentManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewEventGroupEnvironmentManager(context *context.Context) *EventGroupEnvironmentManager {
+	return &EventGroupEnvironmentManager{context: context}
+}
+
+// returns new model instance
+func NewEventGroupEnvironment(funcs ...func(*EventGroupEnvironment)) (ege *EventGroupEnvironment) {
+	now := utils.NowTruncated()
+	ege = &EventGroupEnvironment{
+		FirstSeen: now,
+		LastSeen:  now,
+	}
+	for _, f := range funcs {
+		f(ege)
+	}
+	return
+}
+
+func (e *EventGroupEnvironmentManager) NewEventGroupEnvironment(funcs ...func(*EventGroupEnvironment)) *EventGroupEnvironment {
+	return NewEventGroupEnvironment(funcs...)
+}
+
+/*
+Increments counters of eventgroup in environment. Row is created on first
+event in environment.
+*/
+func (e *EventGroupEnvironmentManager) Increment(eventgroup *EventGroup, environmentID types.ForeignKey) (err error) {
+	handleNilPointer(eventgroup)
+
+	now := utils.NowTruncated()
+
+	builder := utils.QueryBuilder().
+		Update(EVENTS_EVENTGROUPENVIRONMENT_DB_TABLE).
+		Set("times_seen", squirrel.Expr("times_seen + 1")).
+		Set("last_seen", now).
+		Where("eventgroup_id = ? AND environment_id = ?", eventgroup.ID, environmentID)
+
+	var (
+		query  string
+		args   []interface{}
+		result sql.Result
+	)
+	if query, args, err = builder.ToSql(); err != nil {
+		return
+	}
+
+	execfunc := e.context.DB.Exec
+	if e.context.Tx != nil {
+		execfunc = e.context.Tx.Exec
+	}
+
+	if result, err = execfunc(query, args...); err != nil {
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return
+	}
+
+	ege := e.NewEventGroupEnvironment(func(item *EventGroupEnvironment) {
+		item.EventGroupID = eventgroup.ID.ToForeignKey()
+		item.EnvironmentID = environmentID
+		item.TimesSeen = 1
+		item.FirstSeen = now
+		item.LastSeen = now
+	})
+
+	// concurrent worker could insert row in meantime, so try update again
+	if err = ege.Insert(e.context); err != nil {
+		_, err = execfunc(query, args...)
+	}
+
+	return
+}
+
+/*
+Returns counters of eventgroup for all environments it was seen in
+*/
+func (e *EventGroupEnvironmentManager) Stats(target *[]*EventGroupEnvironmentStat, eventgroup *EventGroup) (err error) {
+	handleNilPointer(eventgroup)
+
+	return DBFilter(e.context,
+		EVENTS_EVENTGROUPENVIRONMENT_DB_TABLE+".*, env.name",
+		EVENTS_EVENTGROUPENVIRONMENT_DB_TABLE, false, target,
+		func(builder squirrel.SelectBuilder) squirrel.SelectBuilder {
+			return builder.
+				Join(EVENTS_ENVIRONMENT_DB_TABLE+" env ON env.id = "+EVENTS_EVENTGROUPENVIRONMENT_DB_TABLE+".environment_id").
+				Where(EVENTS_EVENTGROUPENVIRONMENT_DB_TABLE+".eventgroup_id = ?", eventgroup.ID).
+				OrderBy("env.name ASC")
+		},
+	)
+}
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_EVENTS_EVENTTAG_INITIAL_ID = "events-eventtag-initial"
+	MIGRATION_EVENTS_EVENTTAG_INITIAL    = `CREATE TABLE ` + EVENTS_EVENTTAG_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		project_id bigint REFERENCES ` + PROJECTS_PROJECT_DB_TABLE + ` ON DELETE CASCADE,
+		eventgroup_id bigint REFERENCES ` + EVENTS_EVENTGROUP_DB_TABLE + ` ON DELETE CASCADE,
+		event_id bigint REFERENCES ` + EVENTS_EVENT_DB_TABLE + ` ON DELETE CASCADE,
+		key character varying (32) NOT NULL,
+		value character varying (200) NOT NULL,
+		datetime timestamp with time zone NOT NULL
+	)`
+	MIGRATION_EVENTS_EVENTTAG_INDEX = `CREATE INDEX ` + EVENTS_EVENTTAG_DB_TABLE + `_key_value ON ` +
+		EVENTS_EVENTTAG_DB_TABLE + ` (project_id, key, value)`
+	MIGRATION_EVENTS_EVENTTAG_INITIAL_DEPENDENCIES = []string{
+		settings.EVENTS_PLUGIN_ID + ":" + MIGRATION_EVENTS_EVENT_INITIAL_ID,
+	}
+)
+
+/*
+EventTag model
+every tag of event is stored as single row, so we can filter events/eventgroups
+by tags and compute tag value distributions.
+*/
+type EventTag struct {
+	Model
+	ProjectID    types.ForeignKey `db:"project_id" json:"project_id"`
+	EventGroupID types.ForeignKey `db:"eventgroup_id" json:"eventgroup_id"`
+	EventID      types.ForeignKey `db:"event_id" json:"event_id"`
+	Key          string           `db:"key" json:"key"`
+	Value        string           `db:"value" json:"value"`
+	Datetime     time.Time        `db:"datetime" json:"datetime"`
+}
+
+// returns all columns except of primary key
+func (e *EventTag) Columns() []string {
+	return []string{"project_id", "eventgroup_id", "event_id", "key", "value", "datetime"}
+}
+func (e *EventTag) Values() []interface{} {
+	return []interface{}{e.ProjectID, e.EventGroupID, e.EventID, e.Key, e.Value, e.Datetime}
+}
+func (e *EventTag) String() string { return "events:eventtag:" + e.PrimaryKey().String() }
+func (e *EventTag) Table() string  { return EVENTS_EVENTTAG_DB_TABLE }
+
+/*
+CRUD
+*/
+func (e *EventTag) Insert(ctx *context.Context) (err error) {
+	return DBInsert(ctx, e)
+}
+
+func (e *EventTag) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return DBUpdate(ctx, e, fields...)
+}
+
+func (e *EventTag) Delete(ctx *context.Context) (err error) {
+	return DBDelete(ctx, e)
+}
+
+/*
+Tag value with count of events
+*/
+type EventTagValueCount struct {
+	Key   string `db:"key" json:"key"`
+	Value string `db:"value" json:"value"`
+	Count int64  `db:"count" json:"count"`
+}
+
+/*
+EventTagManager
+*/
+type EventTagManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewEventTagManager(context *context.Context) *EventTagManager {
+	return &EventTagManager{context: context}
+}
+
+// returns new model instance
+func NewEventTag(funcs ...func(*EventTag)) (tag *EventTag) {
+	tag = &EventTag{
+		Datetime: utils.NowTruncated(),
+	}
+	for _, f := range funcs {
+		f(tag)
+	}
+	return
+}
+
+func (e *EventTagManager) NewEventTag(funcs ...func(*EventTag)) *EventTag {
+	return NewEventTag(funcs...)
+}
+func (e *EventTagManager) NewEventTagList() []*EventTag { return []*EventTag{} }
+
+// Filter results without paging
+func (e *EventTagManager) Filter(target interface{}, qfs ...utils.QueryFunc) error {
+	_, safe := target.([]*EventTag)
+	return DBFilter(e.context, EVENTS_EVENTTAG_DB_TABLE+".*", EVENTS_EVENTTAG_DB_TABLE, !safe, target, qfs...)
+}
+
+/*
+Stores tags for given event
+keys and values are truncated to fit into database
+*/
+func (e *EventTagManager) InsertTags(event *Event, tags map[string]string) (err error) {
+	handleNilPointer(event)
+
+	for key, value := range tags {
+		tag := e.NewEventTag(func(et *EventTag) {
+			et.ProjectID = event.ProjectID
+			et.EventGroupID = event.EventGroupID
+			et.EventID = event.ID.ToForeignKey()
+			et.Key = utils.StringTruncate(key, MAX_TAG_KEY_LENGTH)
+			et.Value = utils.StringTruncate(value, MAX_TAG_VALUE_LENGTH)
+			et.Datetime = event.Datetime
+		})
+		if err = tag.Insert(e.context); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+/*
+Returns distribution of tag values (count of events per key/value)
+*/
+func (e *EventTagManager) Distribution(target *[]*EventTagValueCount, qfs ...utils.QueryFunc) (err error) {
+	qfs = append(qfs, func(builder squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return builder.GroupBy("key", "value").OrderBy("key ASC", "count DESC")
+	})
+	return DBFilter(e.context, "key, value, COUNT(*) AS count", EVENTS_EVENTTAG_DB_TABLE, false, target, qfs...)
+}
+
+/*
+Returns count of distinct values of tag key in eventgroup events since given time
+(e.g. count of affected users)
+*/
+func (e *EventTagManager) CountValuesSince(eventgroup *EventGroup, key string, since time.Time) (count int64, err error) {
+	handleNilPointer(eventgroup)
+	return DBCount(e.context, "COUNT(DISTINCT value)", EVENTS_EVENTTAG_DB_TABLE,
+		e.QueryFilterEventGroup(eventgroup),
+		e.QueryFilterWhere(EVENTS_EVENTTAG_DB_TABLE+".key = ? AND "+EVENTS_EVENTTAG_DB_TABLE+".datetime >= ?", key, since),
+	)
+}
+
+// filters tags by eventgroup
+func (e *EventTagManager) QueryFilterEventGroup(eventgroup *EventGroup) utils.QueryFunc {
+	handleNilPointer(eventgroup)
+	return utils.QueryFilterWhere(EVENTS_EVENTTAG_DB_TABLE+".eventgroup_id = ?", eventgroup.ID)
+}
+
+/*
+Query filter funcs usable in other managers
+*/
+
+// filters events of project that have given tag
+func QueryFilterEventTag(projectID types.Keyer, key, value string) utils.QueryFunc {
+	return utils.QueryFilterWhere(
+		EVENTS_EVENT_DB_TABLE+".id IN (SELECT event_id FROM "+EVENTS_EVENTTAG_DB_TABLE+" WHERE project_id = ? AND key = ? AND value = ?)",
+		projectID.Int64(), key, value,
+	)
+}
+
+// filters eventgroups of project that have at least one event with given tag
+func QueryFilterEventGroupTag(projectID types.Keyer, key, value string) utils.QueryFunc {
+	return utils.QueryFilterWhere(
+		EVENTS_EVENTGROUP_DB_TABLE+".id IN (SELECT eventgroup_id FROM "+EVENTS_EVENTTAG_DB_TABLE+" WHERE project_id = ? AND key = ? AND value = ?)",
+		projectID.Int64(), key, value,
+	)
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/rest/paginator"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_EVENTS_RELEASE_INITIAL_ID = "events-release-initial"
+	MIGRATION_EVENTS_RELEASE_INITIAL    = `CREATE TABLE ` + EVENTS_RELEASE_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		project_id bigint NOT NULL REFERENCES ` + PROJECTS_PROJECT_DB_TABLE + ` ON DELETE CASCADE,
+		version character varying (` + strconv.Itoa(MAX_RELEASE_VERSION_LENGTH) + `) NOT NULL,
+		date_created timestamp with time zone NOT NULL,
+		first_event timestamp with time zone NOT NULL,
+		last_event timestamp with time zone NOT NULL,
+		UNIQUE (project_id, version)
+	)`
+	MIGRATION_EVENTS_RELEASE_EVENT = `ALTER TABLE ` + EVENTS_EVENT_DB_TABLE + `
+		ADD COLUMN release_id bigint REFERENCES ` + EVENTS_RELEASE_DB_TABLE + ` ON DELETE SET NULL`
+	MIGRATION_EVENTS_RELEASE_EVENTGROUP = `ALTER TABLE ` + EVENTS_EVENTGROUP_DB_TABLE + `
+		ADD COLUMN first_release_id bigint REFERENCES ` + EVENTS_RELEASE_DB_TABLE + ` ON DELETE SET NULL,
+		ADD COLUMN last_release_id bigint REFERENCES ` + EVENTS_RELEASE_DB_TABLE + ` ON DELETE SET NULL,
+		ADD COLUMN resolved_in_release_id bigint REFERENCES ` + EVENTS_RELEASE_DB_TABLE + ` ON DELETE SET NULL`
+	MIGRATION_EVENTS_RELEASE_INITIAL_DEPENDENCIES = []string{
+		settings.EVENTS_PLUGIN_ID + ":" + MIGRATION_EVENTS_EVENT_INITIAL_ID,
+		settings.EVENTS_PLUGIN_ID + ":" + MIGRATION_EVENTS_EVENTGROUP_INITIAL_ID,
+	}
+)
+
+/*
+Release model
+
+	version of project deployed, created from first event that reports it.
+*/
+type Release struct {
+	Model
+	ProjectID   types.ForeignKey `db:"project_id" json:"project_id"`
+	Version     string           `db:"version" json:"version"`
+	DateCreated time.Time        `db:"date_created" json:"date_created"`
+	FirstEvent  time.Time        `db:"first_event" json:"first_event"`
+	LastEvent   time.Time        `db:"last_event" json:"last_event"`
+}
+
+// returns all columns except of primary key
+func (r *Release) Columns() []string {
+	return []string{"project_id", "version", "date_created", "first_event", "last_event"}
+}
+func (r *Release) Values() []interface{} {
+	return []interface{}{r.ProjectID, r.Version, r.DateCreated, r.FirstEvent, r.LastEvent}
+}
+func (r *Release) String() string { return "events:release:" + r.PrimaryKey().String() }
+func (r *Release) Table() string  { return EVENTS_RELEASE_DB_TABLE }
+
+// returns nullable foreign key to release
+func (r *Release) ToNullForeignKey() (result types.NullForeignKey) {
+	result.Int64 = r.ID.Int64()
+	result.Valid = r.ID != 0
+	return
+}
+
+/*
+CRUD
+*/
+func (r *Release) Insert(ctx *context.Context) (err error) {
+	return DBInsert(ctx, r)
+}
+
+func (r *Release) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return DBUpdate(ctx, r, fields...)
+}
+
+func (r *Release) Delete(ctx *context.Context) (err error) {
+	return DBDelete(ctx, r)
+}
+
+/*
+ReleaseManager
+*/
+type ReleaseManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewReleaseManager(context *context.Context) *ReleaseManager {
+	return &ReleaseManager{context: context}
+}
+
+// returns new model instance
+func NewRelease(funcs ...func(*Release)) (release *Release) {
+	now := utils.NowTruncated()
+	release = &Release{
+		DateCreated: now,
+		FirstEvent:  now,
+		LastEvent:   now,
+	}
+	for _, f := range funcs {
+		f(release)
+	}
+	return
+}
+
+func (r *ReleaseManager) NewRelease(funcs ...func(*Release)) *Release { return NewRelease(funcs...) }
+func (r *ReleaseManager) NewReleaseList() []*Release                  { return []*Release{} }
+
+// Filter results without paging
+func (r *ReleaseManager) Filter(target interface{}, qfs ...utils.QueryFunc) error {
+	_, safe := target.([]*Release)
+	return DBFilter(r.context, EVENTS_RELEASE_DB_TABLE+".*", EVENTS_RELEASE_DB_TABLE, !safe, target, qfs...)
+}
+
+// Filter results with paging
+func (r *ReleaseManager) FilterPaged(target interface{}, paging *paginator.Paginator, qfs ...utils.QueryFunc) (err error) {
+	if err = DBFilterCount(r.context, EVENTS_RELEASE_DB_TABLE, paging, qfs...); err != nil {
+		return
+	}
+
+	// add paging query filter
+	qfs = append(qfs, r.QueryFilterPaging(paging))
+
+	_, safe := target.([]*Release)
+
+	return DBFilter(r.context, EVENTS_RELEASE_DB_TABLE+".*", EVENTS_RELEASE_DB_TABLE, !safe, target, qfs...)
+}
+
+// get from database
+func (r *ReleaseManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*Release)
+	return DBGet(r.context, "*", EVENTS_RELEASE_DB_TABLE, !safe, target, qfs...)
+}
+
+// returns by id and possibly other queryFuncs
+func (r *ReleaseManager) GetByID(target interface{}, id types.Keyer, qfs ...utils.QueryFunc) (err error) {
+	qfs = append(qfs, r.QueryFilterWhere("id = ?", id.Int64()))
+	return r.Get(target, qfs...)
+}
+
+/*
+Returns release for project and version, creates it if it does not exist.
+Last event time of existing release is updated.
+*/
+func (r *ReleaseManager) GetOrCreate(target *Release, projectID types.ForeignKey, version string) (err error) {
+	version = utils.StringTruncate(version, MAX_RELEASE_VERSION_LENGTH)
+	filter := r.QueryFilterWhere("project_id = ? AND version = ?", projectID, version)
+
+	if err = r.Get(target, filter); err == nil {
+		target.LastEvent = utils.NowTruncated()
+		_, err = target.Update(r.context, "last_event")
+		return
+	} else if err != ErrObjectDoesNotExists {
+		return
+	}
+
+	*target = *r.NewRelease(func(release *Release) {
+		release.ProjectID = projectID
+		release.Version = version
+	})
+
+	// other worker could create same release in meantime
+	if err = target.Insert(r.context); err != nil && IsUniqueViolation(err) {
+		err = r.Get(target, filter)
+	}
+
+	return
+}
+
+/*
+Returns latest release of project (by date created)
+*/
+func (r *ReleaseManager) GetLatest(target *Release, project *Project) (err error) {
+	handleNilPointer(project)
+	return r.Get(target, r.QueryFilterProject(project), r.QueryFilterOrderLatest(),
+		func(builder squirrel.SelectBuilder) squirrel.SelectBuilder {
+			return builder.Limit(1)
+		},
+	)
+}
+
+// filters releases by project
+func (r *ReleaseManager) QueryFilterProject(project *Project) utils.QueryFunc {
+	handleNilPointer(project)
+	return r.QueryFilterWhere(EVENTS_RELEASE_DB_TABLE+".project_id = ?", project.ID)
+}
+
+// orders releases from latest
+func (r *ReleaseManager) QueryFilterOrderLatest() utils.QueryFunc {
+	return func(builder squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return builder.OrderBy(EVENTS_RELEASE_DB_TABLE+".date_created DESC", EVENTS_RELEASE_DB_TABLE+".id DESC")
+	}
+}
@@ -3,16 +3,21 @@ package models
 import (
 	"testing"
 
-	"github.com/phonkee/patrol/utils"
+	"github.com/phonkee/patrol/context"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
-func TestManager(t *testing.T) {
-	context, err := utils.NewTestPatrolContext()
+// returns test context from environment, test is skipped without database
+func newTestContext(t *testing.T) *context.Context {
+	ctx, err := context.NewTest()
 	if err != nil {
-		t.Error(err)
-		t.Fail()
+		t.Skipf("test context is not available: %s", err)
 	}
+	return ctx
+}
+
+func TestManager(t *testing.T) {
+	context := newTestContext(t)
 	Convey("Test ChangedModelFields", t, func() {
 
 		um := NewUserManager(context)
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_NOTIFICATIONS_PROJECTSETTING_INITIAL_ID = "notifications-projectsetting-initial"
+	MIGRATION_NOTIFICATIONS_PROJECTSETTING_INITIAL    = `CREATE TABLE ` + NOTIFICATIONS_PROJECTSETTING_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		user_id bigint NOT NULL REFERENCES ` + AUTH_USER_DB_TABLE + ` ON DELETE CASCADE,
+		project_id bigint NOT NULL REFERENCES ` + PROJECTS_PROJECT_DB_TABLE + ` ON DELETE CASCADE,
+		mode character varying (20) NOT NULL,
+		UNIQUE (user_id, project_id)
+	)`
+	MIGRATION_NOTIFICATIONS_PROJECTSETTING_INITIAL_DEPENDENCIES = []string{
+		settings.AUTH_PLUGIN_ID + ":" + MIGRATION_AUTH_USER_INITIAL_ID,
+		settings.PROJECTS_PLUGIN_ID + ":" + MIGRATION_PROJECT_INITIAL_ID,
+	}
+)
+
+/*
+Notification modes of project
+
+	all - user is notified about all new issues
+	assigned - user is notified only about subscribed issues
+		(assigned, commented or subscribed manually)
+	none - user is notified only about manually subscribed issues
+*/
+const (
+	NOTIFICATION_MODE_ALL      = "all"
+	NOTIFICATION_MODE_ASSIGNED = "assigned"
+	NOTIFICATION_MODE_NONE     = "none"
+)
+
+var (
+	NOTIFICATION_MODES = []string{
+		NOTIFICATION_MODE_ALL,
+		NOTIFICATION_MODE_ASSIGNED,
+		NOTIFICATION_MODE_NONE,
+	}
+)
+
+// returns whether mode is known notification mode
+func IsNotificationMode(mode string) bool {
+	for _, m := range NOTIFICATION_MODES {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ProjectNotificationSetting model
+
+	default notification mode of user for project. Projects without stored
+	setting use NOTIFICATION_MODE_ALL.
+*/
+type ProjectNotificationSetting struct {
+	Model
+	UserID    types.ForeignKey `db:"user_id" json:"user_id"`
+	ProjectID types.ForeignKey `db:"project_id" json:"project_id"`
+	Mode      string           `db:"mode" json:"mode"`
+}
+
+// returns all columns except of primary key
+func (p *ProjectNotificationSetting) Columns() []string {
+	return []string{"user_id", "project_id", "mode"}
+}
+func (p *ProjectNotificationSetting) Values() []interface{} {
+	return []interface{}{p.UserID, p.ProjectID, p.Mode}
+}
+func (p *ProjectNotificationSetting) String() string {
+	return "notifications:projectsetting:" + p.PrimaryKey().String()
+}
+func (p *ProjectNotificationSetting) Table() string { return NOTIFICATIONS_PROJECTSETTING_DB_TABLE }
+
+/*
+CRUD
+*/
+func (p *ProjectNotificationSetting) Insert(ctx *context.Context) (err error) {
+	return DBInsert(ctx, p)
+}
+
+func (p *ProjectNotificationSetting) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return DBUpdate(ctx, p, fields...)
+}
+
+func (p *ProjectNotificationSetting) Delete(ctx *context.Context) (err error) {
+	return DBDelete(ctx, p)
+}
+
+/*
+ProjectNotificationSettingManager
+*/
+type ProjectNotificationSettingManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewProjectNotificationSettingManager(context *context.Context) *ProjectNotificationSettingManager {
+	return &ProjectNotificationSettingManager{context: context}
+}
+
+// returns new model instance with default values
+func NewProjectNotificationSetting(funcs ...func(*ProjectNotificationSetting)) (setting *ProjectNotificationSetting) {
+	setting = &ProjectNotificationSetting{
+		Mode: NOTIFICATION_MODE_ALL,
+	}
+	for _, f := range funcs {
+		f(setting)
+	}
+	return
+}
+
+func (p *ProjectNotificationSettingManager) NewProjectNotificationSetting(funcs ...func(*ProjectNotificationSetting)) *ProjectNotificationSetting {
+	return NewProjectNotificationSetting(funcs...)
+}
+func (p *ProjectNotificationSettingManager) NewProjectNotificationSettingList() []*ProjectNotificationSetting {
+	return []*ProjectNotificationSetting{}
+}
+
+// Filter results without paging
+func (p *ProjectNotificationSettingManager) Filter(target interface{}, qfs ...utils.QueryFunc) error {
+	_, safe := target.([]*ProjectNotificationSetting)
+	return DBFilter(p.context, NOTIFICATIONS_PROJECTSETTING_DB_TABLE+".*", NOTIFICATIONS_PROJECTSETTING_DB_TABLE, !safe, target, qfs...)
+}
+
+// get from database
+func (p *ProjectNotificationSettingManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*ProjectNotificationSetting)
+	return DBGet(p.context, "*", NOTIFICATIONS_PROJECTSETTING_DB_TABLE, !safe, target, qfs...)
+}
+
+/*
+Returns notification setting of user for project. If user does not have
+stored setting, target is filled with defaults (not stored in database).
+*/
+func (p *ProjectNotificationSettingManager) GetByUserProject(target *ProjectNotificationSetting, user *User, project *Project) (err error) {
+	handleNilPointer(user)
+	handleNilPointer(project)
+
+	if err = p.Get(target, p.QueryFilterWhere("user_id = ? AND project_id = ?", user.ID, project.ID)); err != nil {
+		if err != ErrObjectDoesNotExists {
+			return
+		}
+		*target = *p.NewProjectNotificationSetting(func(pns *ProjectNotificationSetting) {
+			pns.UserID = user.ID.ToForeignKey()
+			pns.ProjectID = project.ID.ToForeignKey()
+		})
+		err = nil
+	}
+	return
+}
+
+/*
+Stores project notification setting (inserts it if it was not stored yet)
+*/
+func (p *ProjectNotificationSettingManager) Save(target *ProjectNotificationSetting) (err error) {
+	if target.ID == 0 {
+		return target.Insert(p.context)
+	}
+	_, err = target.Update(p.context)
+	return
+}
+
+// filters settings by user
+func (p *ProjectNotificationSettingManager) QueryFilterUser(user *User) utils.QueryFunc {
+	handleNilPointer(user)
+	return p.QueryFilterWhere(NOTIFICATIONS_PROJECTSETTING_DB_TABLE+".user_id = ?", user.ID)
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"strconv"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_NOTIFICATIONS_SETTING_INITIAL_ID = "notifications-setting-initial"
+	MIGRATION_NOTIFICATIONS_SETTING_INITIAL    = `CREATE TABLE ` + NOTIFICATIONS_SETTING_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		user_id bigint NOT NULL UNIQUE REFERENCES ` + AUTH_USER_DB_TABLE + ` ON DELETE CASCADE,
+		email boolean NOT NULL DEFAULT true,
+		webhook boolean NOT NULL DEFAULT false,
+		webhook_url character varying (` + strconv.Itoa(MAX_WEBHOOK_URL_LENGTH) + `) NOT NULL DEFAULT '',
+		own_actions boolean NOT NULL DEFAULT false
+	)`
+	MIGRATION_NOTIFICATIONS_SETTING_INITIAL_DEPENDENCIES = []string{
+		settings.AUTH_PLUGIN_ID + ":" + MIGRATION_AUTH_USER_INITIAL_ID,
+	}
+)
+
+/*
+NotificationSetting model
+
+	notification settings of user: enabled channels (e-mail, webhook) and
+	whether user is notified about own actions. User without stored
+	settings uses defaults (e-mail only).
+*/
+type NotificationSetting struct {
+	Model
+	UserID     types.ForeignKey `db:"user_id" json:"user_id"`
+	Email      bool             `db:"email" json:"email"`
+	Webhook    bool             `db:"webhook" json:"webhook"`
+	WebhookURL string           `db:"webhook_url" json:"webhook_url"`
+	OwnActions bool             `db:"own_actions" json:"own_actions"`
+}
+
+// returns all columns except of primary key
+func (n *NotificationSetting) Columns() []string {
+	return []string{"user_id", "email", "webhook", "webhook_url", "own_actions"}
+}
+func (n *NotificationSetting) Values() []interface{} {
+	return []interface{}{n.UserID, n.Email, n.Webhook, n.WebhookURL, n.OwnActions}
+}
+func (n *NotificationSetting) String() string {
+	return "notifications:setting:" + n.PrimaryKey().String()
+}
+func (n *NotificationSetting) Table() string { return NOTIFICATIONS_SETTING_DB_TABLE }
+
+// webhook channel is used only when url is set
+func (n *NotificationSetting) WebhookEnabled() bool {
+	return n.Webhook && n.WebhookURL != ""
+}
+
+/*
+CRUD
+*/
+func (n *NotificationSetting) Insert(ctx *context.Context) (err error) {
+	return DBInsert(ctx, n)
+}
+
+func (n *NotificationSetting) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return DBUpdate(ctx, n, fields...)
+}
+
+func (n *NotificationSetting) Delete(ctx *context.Context) (err error) {
+	return DBDelete(ctx, n)
+}
+
+/*
+NotificationSettingManager
+*/
+type NotificationSettingManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewNotificationSettingManager(context *context.Context) *NotificationSettingManager {
+	return &NotificationSettingManager{context: context}
+}
+
+// returns new model instance with default values
+func NewNotificationSetting(funcs ...func(*NotificationSetting)) (setting *NotificationSetting) {
+	setting = &NotificationSetting{
+		Email: true,
+	}
+	for _, f := range funcs {
+		f(setting)
+	}
+	return
+}
+
+func (n *NotificationSettingManager) NewNotificationSetting(funcs ...func(*NotificationSetting)) *NotificationSetting {
+	return NewNotificationSetting(funcs...)
+}
+
+// get from database
+func (n *NotificationSettingManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*NotificationSetting)
+	return DBGet(n.context, "*", NOTIFICATIONS_SETTING_DB_TABLE, !safe, target, qfs...)
+}
+
+/*
+Returns notification settings of user. If user does not have stored settings,
+target is filled with defaults (not stored in database).
+*/
+func (n *NotificationSettingManager) GetByUser(target *NotificationSetting, user *User) (err error) {
+	handleNilPointer(user)
+
+	if err = n.Get(target, n.QueryFilterWhere("user_id = ?", user.ID)); err != nil {
+		if err != ErrObjectDoesNotExists {
+			return
+		}
+		*target = *n.NewNotificationSetting(func(ns *NotificationSetting) {
+			ns.UserID = user.ID.ToForeignKey()
+		})
+		err = nil
+	}
+	return
+}
+
+/*
+Stores notification settings (inserts them if they were not stored yet)
+*/
+func (n *NotificationSettingManager) Save(target *NotificationSetting) (err error) {
+	if target.ID == 0 {
+		return target.Insert(n.context)
+	}
+	_, err = target.Update(n.context)
+	return
+}
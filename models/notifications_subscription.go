@@ -0,0 +1,177 @@
+package models
+
+import (
+	"time"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_NOTIFICATIONS_SUBSCRIPTION_INITIAL_ID = "notifications-subscription-initial"
+	MIGRATION_NOTIFICATIONS_SUBSCRIPTION_INITIAL    = `CREATE TABLE ` + NOTIFICATIONS_SUBSCRIPTION_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		user_id bigint NOT NULL REFERENCES ` + AUTH_USER_DB_TABLE + ` ON DELETE CASCADE,
+		eventgroup_id bigint NOT NULL REFERENCES ` + EVENTS_EVENTGROUP_DB_TABLE + ` ON DELETE CASCADE,
+		subscribed boolean NOT NULL DEFAULT true,
+		reason character varying (20) NOT NULL,
+		date_created timestamp with time zone NOT NULL,
+		UNIQUE (user_id, eventgroup_id)
+	)`
+	MIGRATION_NOTIFICATIONS_SUBSCRIPTION_INITIAL_DEPENDENCIES = []string{
+		settings.AUTH_PLUGIN_ID + ":" + MIGRATION_AUTH_USER_INITIAL_ID,
+		settings.EVENTS_PLUGIN_ID + ":" + MIGRATION_EVENTS_EVENTGROUP_INITIAL_ID,
+	}
+)
+
+/*
+Reasons of subscription
+*/
+const (
+	SUBSCRIPTION_REASON_MANUAL   = "manual"
+	SUBSCRIPTION_REASON_COMMENT  = "comment"
+	SUBSCRIPTION_REASON_ASSIGNED = "assigned"
+)
+
+/*
+EventGroupSubscription model
+
+	explicit subscription (or unsubscription) of user to eventgroup,
+	overrides notification mode of project.
+*/
+type EventGroupSubscription struct {
+	Model
+	UserID       types.ForeignKey `db:"user_id" json:"user_id"`
+	EventGroupID types.ForeignKey `db:"eventgroup_id" json:"eventgroup_id"`
+	Subscribed   bool             `db:"subscribed" json:"subscribed"`
+	Reason       string           `db:"reason" json:"reason"`
+	DateCreated  time.Time        `db:"date_created" json:"date_created"`
+}
+
+// returns all columns except of primary key
+func (e *EventGroupSubscription) Columns() []string {
+	return []string{"user_id", "eventgroup_id", "subscribed", "reason", "date_created"}
+}
+func (e *EventGroupSubscription) Values() []interface{} {
+	return []interface{}{e.UserID, e.EventGroupID, e.Subscribed, e.Reason, e.DateCreated}
+}
+func (e *EventGroupSubscription) String() string {
+	return "notifications:subscription:" + e.PrimaryKey().String()
+}
+func (e *EventGroupSubscription) Table() string { return NOTIFICATIONS_SUBSCRIPTION_DB_TABLE }
+
+/*
+CRUD
+*/
+func (e *EventGroupSubscription) Insert(ctx *context.Context) (err error) {
+	return DBInsert(ctx, e)
+}
+
+func (e *EventGroupSubscription) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return DBUpdate(ctx, e, fields...)
+}
+
+func (e *EventGroupSubscription) Delete(ctx *context.Context) (err error) {
+	return DBDelete(ctx, e)
+}
+
+/*
+EventGroupSubscriptionManager
+*/
+type EventGroupSubscriptionManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewEventGroupSubscriptionManager(context *context.Context) *EventGroupSubscriptionManager {
+	return &EventGroupSubscriptionManager{context: context}
+}
+
+// returns new model instance with default values
+func NewEventGroupSubscription(funcs ...func(*EventGroupSubscription)) (subscription *EventGroupSubscription) {
+	subscription = &EventGroupSubscription{
+		Subscribed:  true,
+		Reason:      SUBSCRIPTION_REASON_MANUAL,
+		DateCreated: utils.NowTruncated(),
+	}
+	for _, f := range funcs {
+		f(subscription)
+	}
+	return
+}
+
+func (e *EventGroupSubscriptionManager) NewEventGroupSubscription(funcs ...func(*EventGroupSubscription)) *EventGroupSubscription {
+	return NewEventGroupSubscription(funcs...)
+}
+func (e *EventGroupSubscriptionManager) NewEventGroupSubscriptionList() []*EventGroupSubscription {
+	return []*EventGroupSubscription{}
+}
+
+// Filter results without paging
+func (e *EventGroupSubscriptionManager) Filter(target interface{}, qfs ...utils.QueryFunc) error {
+	_, safe := target.([]*EventGroupSubscription)
+	return DBFilter(e.context, NOTIFICATIONS_SUBSCRIPTION_DB_TABLE+".*", NOTIFICATIONS_SUBSCRIPTION_DB_TABLE, !safe, target, qfs...)
+}
+
+// get from database
+func (e *EventGroupSubscriptionManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*EventGroupSubscription)
+	return DBGet(e.context, "*", NOTIFICATIONS_SUBSCRIPTION_DB_TABLE, !safe, target, qfs...)
+}
+
+// returns subscription of user to eventgroup
+func (e *EventGroupSubscriptionManager) GetByUserEventGroup(target *EventGroupSubscription, user *User, eventgroup *EventGroup) (err error) {
+	handleNilPointer(user)
+	handleNilPointer(eventgroup)
+	return e.Get(target, e.QueryFilterWhere("user_id = ? AND eventgroup_id = ?", user.ID, eventgroup.ID))
+}
+
+/*
+Subscribes user to eventgroup. Automatic subscriptions (comment, assigned)
+do not override explicit unsubscription by user.
+*/
+func (e *EventGroupSubscriptionManager) Subscribe(user *User, eventgroup *EventGroup, reason string) (err error) {
+	return e.set(user, eventgroup, true, reason)
+}
+
+// unsubscribes user from eventgroup
+func (e *EventGroupSubscriptionManager) Unsubscribe(user *User, eventgroup *EventGroup) (err error) {
+	return e.set(user, eventgroup, false, SUBSCRIPTION_REASON_MANUAL)
+}
+
+func (e *EventGroupSubscriptionManager) set(user *User, eventgroup *EventGroup, subscribed bool, reason string) (err error) {
+	subscription := e.NewEventGroupSubscription()
+	if err = e.GetByUserEventGroup(subscription, user, eventgroup); err != nil {
+		if err != ErrObjectDoesNotExists {
+			return
+		}
+		subscription = e.NewEventGroupSubscription(func(egs *EventGroupSubscription) {
+			egs.UserID = user.ID.ToForeignKey()
+			egs.EventGroupID = eventgroup.ID.ToForeignKey()
+			egs.Subscribed = subscribed
+			egs.Reason = reason
+		})
+		return subscription.Insert(e.context)
+	}
+
+	if reason != SUBSCRIPTION_REASON_MANUAL && !subscription.Subscribed {
+		return
+	}
+
+	subscription.Subscribed = subscribed
+	subscription.Reason = reason
+	_, err = subscription.Update(e.context, "subscribed", "reason")
+	return
+}
+
+// filters subscriptions by eventgroup
+func (e *EventGroupSubscriptionManager) QueryFilterEventGroup(eventgroup *EventGroup) utils.QueryFunc {
+	handleNilPointer(eventgroup)
+	return e.QueryFilterWhere(NOTIFICATIONS_SUBSCRIPTION_DB_TABLE+".eventgroup_id = ?", eventgroup.ID)
+}
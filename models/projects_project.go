@@ -122,11 +122,26 @@ const (
 	)`
 )
 
+const (
+	// team admins could create projects before project add permission was
+	// required, so existing team admins (type 1) are granted the permission
+	MIGRATION_PROJECT_GRANT_ADD_PERMISSION_ID = "projects-project-grant-add-permission"
+	MIGRATION_PROJECT_GRANT_ADD_PERMISSION    = `UPDATE ` + AUTH_USER_DB_TABLE + `
+	SET permissions = array_append(COALESCE(permissions, '{}'), '` + settings.PERMISSION_PROJECTS_PROJECT_ADD + `')
+	WHERE id IN (SELECT user_id FROM ` + TEAMS_TEAMMEMBER_DB_TABLE + ` WHERE type = 1)
+	AND NOT COALESCE('` + settings.PERMISSION_PROJECTS_PROJECT_ADD + `' = ANY(permissions), false)`
+)
+
 var (
 	MIGRATION_PROJECT_INITIAL_DEPENDENCIES = []string{
 		settings.TEAMS_PLUGIN_ID + ":" + MIGRATION_TEAMS_TEAM_INITIAL_ID,
 		settings.AUTH_PLUGIN_ID + ":" + MIGRATION_AUTH_PERMISSION_INITIAL_ID,
 	}
+	MIGRATION_PROJECT_GRANT_ADD_PERMISSION_DEPENDENCIES = []string{
+		settings.AUTH_PLUGIN_ID + ":" + MIGRATION_AUTH_USER_INITIAL_ID,
+		settings.TEAMS_PLUGIN_ID + ":" + MIGRATION_TEAMS_TEAM_MEMBER_INITIAL_ID,
+		settings.PROJECTS_PLUGIN_ID + ":" + MIGRATION_PROJECT_INITIAL_ID,
+	}
 )
 
 /*
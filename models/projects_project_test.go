@@ -4,16 +4,11 @@ import (
 	"testing"
 
 	"github.com/Pallinder/go-randomdata"
-	"github.com/phonkee/patrol/utils"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestInsertProject(t *testing.T) {
-	context, err := utils.NewTestPatrolContext()
-	if err != nil {
-		t.Error(err)
-		t.Fail()
-	}
+	context := newTestContext(t)
 
 	Convey("Test insert valid project", t, func() {
 		var err error
@@ -29,12 +24,10 @@ func TestInsertProject(t *testing.T) {
 		So(valid, ShouldBeFalse)
 
 		So(project.ID, ShouldEqual, 0)
-		err = project.Insert(manager)
+		err = project.Insert(context)
 		// team id not given
 		So(err, ShouldNotBeNil)
 
-		validator.AddPostgresError(err)
-
 		return
 
 		// So(project.ID, ShouldNotEqual, 0)
@@ -43,7 +36,7 @@ func TestInsertProject(t *testing.T) {
 
 		// var changed bool
 
-		// changed, err = project.Update(manager)
+		// changed, err = project.Update(context)
 		// So(err, ShouldBeNil)
 		// So(changed, ShouldBeTrue)
 
@@ -77,21 +70,21 @@ func TestInsertProject(t *testing.T) {
 
 		team := teammanager.NewTeam(func(team *Team) {
 			team.Name = "some team"
-			team.OwnerID = owner.ID
+			team.OwnerID = owner.ID.ToForeignKey()
 		})
-		errTeamInsert := team.Insert(teammanager)
+		errTeamInsert := team.Insert(context)
 		So(errTeamInsert, ShouldBeNil)
 
-		err = teammembermanager.SetTeamMemberType(team, user, MEMBER_TYPE_MEMBER)
+		_, err = teammembermanager.SetTeamMemberType(team, user, MEMBER_TYPE_MEMBER)
 		So(err, ShouldBeNil)
 
 		project := projectmanager.NewProject(func(p *Project) {
 			p.Platform = "go"
 			p.Name = randomdata.SillyName()
-			p.TeamID = team.ID
+			p.TeamID = team.ID.ToForeignKey()
 		})
 
-		errProjectInsert := project.Insert(teammanager)
+		errProjectInsert := project.Insert(context)
 		So(errProjectInsert, ShouldBeNil)
 
 		projects := projectmanager.NewProjectList()
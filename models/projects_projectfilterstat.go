@@ -0,0 +1,160 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+var (
+	// project filter stat migrations
+	MIGRATION_PROJECT_FILTERSTAT_INITIAL_ID = "initial-migration-project-filterstat"
+	MIGRATION_PROJECT_FILTERSTAT_INITIAL    = `CREATE TABLE ` + PROJECTS_PROJECTFILTERSTAT_DB_TABLE + `(
+		id bigserial NOT NULL PRIMARY KEY,
+		project_id bigint NOT NULL REFERENCES ` + PROJECTS_PROJECT_DB_TABLE + ` ON DELETE CASCADE,
+		reason character varying(32) NOT NULL,
+		count bigint NOT NULL DEFAULT 0,
+		last_dropped timestamp with time zone NOT NULL,
+		UNIQUE (project_id, reason)
+	)`
+	MIGRATION_PROJECT_FILTERSTAT_INITIAL_DEPENDENCIES = []string{
+		settings.PROJECTS_PLUGIN_ID + ":" + MIGRATION_PROJECT_INITIAL_ID,
+	}
+)
+
+/*
+ProjectFilterStat model
+
+	count of events dropped by inbound filters per project and reason
+*/
+type ProjectFilterStat struct {
+	Model
+	ProjectID   types.ForeignKey `db:"project_id" json:"project_id"`
+	Reason      string           `db:"reason" json:"reason"`
+	Count       int64            `db:"count" json:"count"`
+	LastDropped time.Time        `db:"last_dropped" json:"last_dropped"`
+}
+
+// returns all columns except of primary key
+func (p *ProjectFilterStat) Columns() []string {
+	return []string{"project_id", "reason", "count", "last_dropped"}
+}
+func (p *ProjectFilterStat) Values() []interface{} {
+	return []interface{}{p.ProjectID, p.Reason, p.Count, p.LastDropped}
+}
+func (p *ProjectFilterStat) String() string {
+	return "projects:projectfilterstat:" + p.PrimaryKey().String()
+}
+func (p *ProjectFilterStat) Table() string { return PROJECTS_PROJECTFILTERSTAT_DB_TABLE }
+
+/*
+CRUD operations
+*/
+func (p *ProjectFilterStat) Insert(ctx *context.Context) (err error) {
+	return DBInsert(ctx, p)
+}
+
+func (p *ProjectFilterStat) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return DBUpdate(ctx, p, fields...)
+}
+
+func (p *ProjectFilterStat) Delete(ctx *context.Context) (err error) {
+	return DBDelete(ctx, p)
+}
+
+/*
+ProjectFilterStatManager
+*/
+func NewProjectFilterStatManager(context *context.Context) *ProjectFilterStatManager {
+	return &ProjectFilterStatManager{context: context}
+}
+
+type ProjectFilterStatManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewProjectFilterStat(funcs ...func(*ProjectFilterStat)) (pfs *ProjectFilterStat) {
+	pfs = &ProjectFilterStat{}
+	for _, f := range funcs {
+		f(pfs)
+	}
+	return
+}
+
+func (p *ProjectFilterStatManager) NewProjectFilterStat(funcs ...func(*ProjectFilterStat)) *ProjectFilterStat {
+	return NewProjectFilterStat(funcs...)
+}
+
+func (p *ProjectFilterStatManager) NewProjectFilterStatList() []*ProjectFilterStat {
+	return []*ProjectFilterStat{}
+}
+
+// Filter results without paging
+func (p *ProjectFilterStatManager) Filter(target interface{}, qfs ...utils.QueryFunc) error {
+	_, safe := target.([]*ProjectFilterStat)
+	return DBFilter(p.context, PROJECTS_PROJECTFILTERSTAT_DB_TABLE+".*", PROJECTS_PROJECTFILTERSTAT_DB_TABLE, !safe, target, qfs...)
+}
+
+/*
+Increments count of dropped events for project and reason.
+Row is created on first drop.
+*/
+func (p *ProjectFilterStatManager) Increment(project *Project, reason string) (err error) {
+	handleNilPointer(project)
+
+	now := utils.NowTruncated()
+
+	builder := utils.QueryBuilder().
+		Update(PROJECTS_PROJECTFILTERSTAT_DB_TABLE).
+		Set("count", squirrel.Expr("count + 1")).
+		Set("last_dropped", now).
+		Where("project_id = ? AND reason = ?", project.ID, reason)
+
+	var (
+		query  string
+		args   []interface{}
+		result sql.Result
+	)
+	if query, args, err = builder.ToSql(); err != nil {
+		return
+	}
+
+	execfunc := p.context.DB.Exec
+	if p.context.Tx != nil {
+		execfunc = p.context.Tx.Exec
+	}
+
+	if result, err = execfunc(query, args...); err != nil {
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return
+	}
+
+	stat := p.NewProjectFilterStat(func(pfs *ProjectFilterStat) {
+		pfs.ProjectID = project.ID.ToForeignKey()
+		pfs.Reason = reason
+		pfs.Count = 1
+		pfs.LastDropped = now
+	})
+
+	// concurrent request could insert row in meantime, so try update again
+	if err = stat.Insert(p.context); err != nil {
+		_, err = execfunc(query, args...)
+	}
+
+	return
+}
+
+// filters stats by project
+func (p *ProjectFilterStatManager) QueryFilterProject(project *Project) utils.QueryFunc {
+	handleNilPointer(project)
+	return p.QueryFilterWhere(PROJECTS_PROJECTFILTERSTAT_DB_TABLE+".project_id = ?", project.ID)
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"strconv"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/parser"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+var (
+	// project inbound filter migrations
+	MIGRATION_PROJECT_INBOUNDFILTER_INITIAL_ID = "initial-migration-project-inboundfilter"
+	MIGRATION_PROJECT_INBOUNDFILTER_INITIAL    = `CREATE TABLE ` + PROJECTS_PROJECTINBOUNDFILTER_DB_TABLE + `(
+		id bigserial NOT NULL PRIMARY KEY,
+		project_id bigint NOT NULL UNIQUE REFERENCES ` + PROJECTS_PROJECT_DB_TABLE + ` ON DELETE CASCADE,
+		localhost boolean NOT NULL DEFAULT false,
+		web_crawlers boolean NOT NULL DEFAULT false,
+		legacy_browsers boolean NOT NULL DEFAULT false,
+		releases text[] NOT NULL DEFAULT '{}',
+		error_messages text[] NOT NULL DEFAULT '{}'
+	)`
+	MIGRATION_PROJECT_INBOUNDFILTER_INITIAL_DEPENDENCIES = []string{
+		settings.PROJECTS_PLUGIN_ID + ":" + MIGRATION_PROJECT_INITIAL_ID,
+	}
+)
+
+/*
+ProjectInboundFilter model
+
+	inbound filter settings for project. Project without stored settings
+	does not filter anything.
+*/
+type ProjectInboundFilter struct {
+	Model
+	ProjectID      types.ForeignKey  `db:"project_id" json:"project_id"`
+	Localhost      bool              `db:"localhost" json:"localhost"`
+	WebCrawlers    bool              `db:"web_crawlers" json:"web_crawlers"`
+	LegacyBrowsers bool              `db:"legacy_browsers" json:"legacy_browsers"`
+	Releases       types.StringSlice `db:"releases" json:"releases"`
+	ErrorMessages  types.StringSlice `db:"error_messages" json:"error_messages"`
+}
+
+// returns all columns except of primary key
+func (p *ProjectInboundFilter) Columns() []string {
+	return []string{
+		"project_id", "localhost", "web_crawlers", "legacy_browsers",
+		"releases", "error_messages",
+	}
+}
+func (p *ProjectInboundFilter) Values() []interface{} {
+	return []interface{}{
+		p.ProjectID, p.Localhost, p.WebCrawlers, p.LegacyBrowsers,
+		p.Releases, p.ErrorMessages,
+	}
+}
+func (p *ProjectInboundFilter) String() string {
+	return projectInboundFilterCacheKey(p.ProjectID)
+}
+func (p *ProjectInboundFilter) Table() string { return PROJECTS_PROJECTINBOUNDFILTER_DB_TABLE }
+
+// settings are always retrieved by project so we cache them by project id
+func projectInboundFilterCacheKey(projectID types.ForeignKey) string {
+	return "projects:projectinboundfilter:project:" + strconv.FormatInt(projectID.Int64(), 10)
+}
+
+/*
+CRUD operations
+*/
+func (p *ProjectInboundFilter) Insert(ctx *context.Context) (err error) {
+	if err = DBInsert(ctx, p); err != nil {
+		return
+	}
+	return Cache(ctx, p.String(), p)
+}
+
+func (p *ProjectInboundFilter) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	if changed, err = DBUpdate(ctx, p, fields...); err != nil {
+		return
+	}
+	err = Cache(ctx, p.String(), p)
+	return
+}
+
+func (p *ProjectInboundFilter) Delete(ctx *context.Context) (err error) {
+	cacheKey := p.String()
+	if err = DBDelete(ctx, p); err != nil {
+		return
+	}
+	return RemoveCached(ctx, cacheKey)
+}
+
+/*
+Returns inbound filter configured by project settings
+*/
+func (p *ProjectInboundFilter) InboundFilter() *parser.InboundFilter {
+	return parser.NewInboundFilter(func(i *parser.InboundFilter) {
+		i.Localhost = p.Localhost
+		i.WebCrawlers = p.WebCrawlers
+		i.LegacyBrowsers = p.LegacyBrowsers
+		i.Releases = append(i.Releases, p.Releases...)
+		i.ErrorMessages = append(i.ErrorMessages, p.ErrorMessages...)
+	})
+}
+
+/*
+ProjectInboundFilterManager
+*/
+func NewProjectInboundFilterManager(context *context.Context) *ProjectInboundFilterManager {
+	return &ProjectInboundFilterManager{context: context}
+}
+
+type ProjectInboundFilterManager struct {
+	Manager
+	context *context.Context
+}
+
+/*
+Returns new ProjectInboundFilter with default values (nothing filtered)
+*/
+func NewProjectInboundFilter(funcs ...func(*ProjectInboundFilter)) (pif *ProjectInboundFilter) {
+	pif = &ProjectInboundFilter{
+		Releases:      types.StringSlice{},
+		ErrorMessages: types.StringSlice{},
+	}
+	for _, f := range funcs {
+		f(pif)
+	}
+	return
+}
+
+func (p *ProjectInboundFilterManager) NewProjectInboundFilter(funcs ...func(*ProjectInboundFilter)) *ProjectInboundFilter {
+	return NewProjectInboundFilter(funcs...)
+}
+
+/* Returns project inbound filter by Query filter funcs
+ */
+func (p *ProjectInboundFilterManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*ProjectInboundFilter)
+	return DBGet(p.context, "*", PROJECTS_PROJECTINBOUNDFILTER_DB_TABLE, !safe, target, qfs...)
+}
+
+/*
+Returns inbound filter settings for project. If project does not have stored
+settings, target is filled with defaults (not stored in database).
+*/
+func (p *ProjectInboundFilterManager) GetByProject(target *ProjectInboundFilter, project *Project) (err error) {
+	handleNilPointer(project)
+
+	cacheKey := projectInboundFilterCacheKey(project.ID.ToForeignKey())
+	if err = GetCached(p.context, cacheKey, target); err == nil {
+		return
+	}
+
+	if err = p.Get(target, p.QueryFilterWhere("project_id = ?", project.ID)); err != nil {
+		if err != ErrObjectDoesNotExists {
+			return
+		}
+		*target = *p.NewProjectInboundFilter(func(pif *ProjectInboundFilter) {
+			pif.ProjectID = project.ID.ToForeignKey()
+		})
+	}
+
+	return Cache(p.context, cacheKey, target)
+}
+
+/*
+Stores inbound filter settings (inserts them if they were not stored yet)
+*/
+func (p *ProjectInboundFilterManager) Save(target *ProjectInboundFilter) (err error) {
+	if target.ID == 0 {
+		return target.Insert(p.context)
+	}
+	_, err = target.Update(p.context)
+	return
+}
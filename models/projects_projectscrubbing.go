@@ -0,0 +1,189 @@
+package models
+
+import (
+	"strconv"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/parser"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+var (
+	// project scrubbing migrations
+	MIGRATION_PROJECT_SCRUBBING_INITIAL_ID = "initial-migration-project-scrubbing"
+	MIGRATION_PROJECT_SCRUBBING_INITIAL    = `CREATE TABLE ` + PROJECTS_PROJECTSCRUBBING_DB_TABLE + `(
+		id bigserial NOT NULL PRIMARY KEY,
+		project_id bigint NOT NULL UNIQUE REFERENCES ` + PROJECTS_PROJECT_DB_TABLE + ` ON DELETE CASCADE,
+		enabled boolean NOT NULL DEFAULT true,
+		scrub_defaults boolean NOT NULL DEFAULT true,
+		scrub_credit_cards boolean NOT NULL DEFAULT true,
+		scrub_ip_addresses boolean NOT NULL DEFAULT true,
+		extra_fields text[] NOT NULL DEFAULT '{}',
+		safe_fields text[] NOT NULL DEFAULT '{}'
+	)`
+	MIGRATION_PROJECT_SCRUBBING_INITIAL_DEPENDENCIES = []string{
+		settings.PROJECTS_PLUGIN_ID + ":" + MIGRATION_PROJECT_INITIAL_ID,
+	}
+)
+
+/*
+ProjectScrubbing model
+
+	data scrubbing settings for project. Project without stored settings
+	uses default settings (everything enabled).
+*/
+type ProjectScrubbing struct {
+	Model
+	ProjectID        types.ForeignKey  `db:"project_id" json:"project_id"`
+	Enabled          bool              `db:"enabled" json:"enabled"`
+	ScrubDefaults    bool              `db:"scrub_defaults" json:"scrub_defaults"`
+	ScrubCreditCards bool              `db:"scrub_credit_cards" json:"scrub_credit_cards"`
+	ScrubIPAddresses bool              `db:"scrub_ip_addresses" json:"scrub_ip_addresses"`
+	ExtraFields      types.StringSlice `db:"extra_fields" json:"extra_fields"`
+	SafeFields       types.StringSlice `db:"safe_fields" json:"safe_fields"`
+}
+
+// returns all columns except of primary key
+func (p *ProjectScrubbing) Columns() []string {
+	return []string{
+		"project_id", "enabled", "scrub_defaults", "scrub_credit_cards",
+		"scrub_ip_addresses", "extra_fields", "safe_fields",
+	}
+}
+func (p *ProjectScrubbing) Values() []interface{} {
+	return []interface{}{
+		p.ProjectID, p.Enabled, p.ScrubDefaults, p.ScrubCreditCards,
+		p.ScrubIPAddresses, p.ExtraFields, p.SafeFields,
+	}
+}
+func (p *ProjectScrubbing) String() string {
+	return projectScrubbingCacheKey(p.ProjectID)
+}
+func (p *ProjectScrubbing) Table() string { return PROJECTS_PROJECTSCRUBBING_DB_TABLE }
+
+// settings are always retrieved by project so we cache them by project id
+func projectScrubbingCacheKey(projectID types.ForeignKey) string {
+	return "projects:projectscrubbing:project:" + strconv.FormatInt(projectID.Int64(), 10)
+}
+
+/*
+CRUD operations
+*/
+func (p *ProjectScrubbing) Insert(ctx *context.Context) (err error) {
+	if err = DBInsert(ctx, p); err != nil {
+		return
+	}
+	return Cache(ctx, p.String(), p)
+}
+
+func (p *ProjectScrubbing) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	if changed, err = DBUpdate(ctx, p, fields...); err != nil {
+		return
+	}
+	err = Cache(ctx, p.String(), p)
+	return
+}
+
+func (p *ProjectScrubbing) Delete(ctx *context.Context) (err error) {
+	cacheKey := p.String()
+	if err = DBDelete(ctx, p); err != nil {
+		return
+	}
+	return RemoveCached(ctx, cacheKey)
+}
+
+/*
+Returns scrubber configured by project settings, nil if scrubbing is disabled
+*/
+func (p *ProjectScrubbing) Scrubber() *parser.Scrubber {
+	if !p.Enabled {
+		return nil
+	}
+	return parser.NewScrubber(func(s *parser.Scrubber) {
+		if !p.ScrubDefaults {
+			s.Fields = []string{}
+		}
+		s.Fields = append(s.Fields, p.ExtraFields...)
+		s.SafeFields = append(s.SafeFields, p.SafeFields...)
+		s.CreditCards = p.ScrubCreditCards
+		s.IPAddresses = p.ScrubIPAddresses
+	})
+}
+
+/*
+ProjectScrubbingManager
+*/
+func NewProjectScrubbingManager(context *context.Context) *ProjectScrubbingManager {
+	return &ProjectScrubbingManager{context: context}
+}
+
+type ProjectScrubbingManager struct {
+	Manager
+	context *context.Context
+}
+
+/*
+Returns new ProjectScrubbing with default values
+*/
+func NewProjectScrubbing(funcs ...func(*ProjectScrubbing)) (ps *ProjectScrubbing) {
+	ps = &ProjectScrubbing{
+		Enabled:          true,
+		ScrubDefaults:    true,
+		ScrubCreditCards: true,
+		ScrubIPAddresses: true,
+		ExtraFields:      types.StringSlice{},
+		SafeFields:       types.StringSlice{},
+	}
+	for _, f := range funcs {
+		f(ps)
+	}
+	return
+}
+
+func (p *ProjectScrubbingManager) NewProjectScrubbing(funcs ...func(*ProjectScrubbing)) *ProjectScrubbing {
+	return NewProjectScrubbing(funcs...)
+}
+
+/* Returns project scrubbing by Query filter funcs
+ */
+func (p *ProjectScrubbingManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*ProjectScrubbing)
+	return DBGet(p.context, "*", PROJECTS_PROJECTSCRUBBING_DB_TABLE, !safe, target, qfs...)
+}
+
+/*
+Returns scrubbing settings for project. If project does not have stored
+settings, target is filled with defaults (not stored in database).
+*/
+func (p *ProjectScrubbingManager) GetByProject(target *ProjectScrubbing, project *Project) (err error) {
+	handleNilPointer(project)
+
+	cacheKey := projectScrubbingCacheKey(project.ID.ToForeignKey())
+	if err = GetCached(p.context, cacheKey, target); err == nil {
+		return
+	}
+
+	if err = p.Get(target, p.QueryFilterWhere("project_id = ?", project.ID)); err != nil {
+		if err != ErrObjectDoesNotExists {
+			return
+		}
+		*target = *p.NewProjectScrubbing(func(ps *ProjectScrubbing) {
+			ps.ProjectID = project.ID.ToForeignKey()
+		})
+	}
+
+	return Cache(p.context, cacheKey, target)
+}
+
+/*
+Stores scrubbing settings (inserts them if they were not stored yet)
+*/
+func (p *ProjectScrubbingManager) Save(target *ProjectScrubbing) (err error) {
+	if target.ID == 0 {
+		return target.Insert(p.context)
+	}
+	_, err = target.Update(p.context)
+	return
+}
@@ -1,11 +1,52 @@
 package models
 
-import "github.com/phonkee/patrol/context"
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/phonkee/ergoq"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+)
 
 const (
-	MESSAGE_TYPE_REFRESH_SOCKET = "refresh-socket"
+	MESSAGE_TYPE_REFRESH_SOCKET     = "refresh-socket"
+	MESSAGE_TYPE_USER_LOGGED        = "user-logged"
+	MESSAGE_TYPE_EVENT_CREATED      = "event-created"
+	MESSAGE_TYPE_EVENTGROUP_CHANGED = "eventgroup-changed"
+
+	// prefix of all realtime pub/sub channels
+	REALTIME_CHANNEL_PREFIX = "realtime:"
 )
 
+// returns pub/sub channel of project
+func RealtimeProjectChannel(projectID types.Keyer) string {
+	return REALTIME_CHANNEL_PREFIX + "project:" + strconv.FormatInt(projectID.Int64(), 10)
+}
+
+// returns pub/sub channel of eventgroup
+func RealtimeEventGroupChannel(eventgroupID types.Keyer) string {
+	return REALTIME_CHANNEL_PREFIX + "eventgroup:" + strconv.FormatInt(eventgroupID.Int64(), 10)
+}
+
+// returns pub/sub channel of user
+func RealtimeUserChannel(userID types.Keyer) string {
+	return REALTIME_CHANNEL_PREFIX + "user:" + strconv.FormatInt(userID.Int64(), 10)
+}
+
+/*
+RealtimeEnvelope is published to pub/sub channel and sent to websockets as is.
+ID is sequence number of message in channel.
+*/
+type RealtimeEnvelope struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	Identifier string          `json:"identifier"`
+	Channel    string          `json:"channel"`
+	Data       json.RawMessage `json:"data"`
+}
+
 /*
 Realtime manager handles publishing of messages to websocket
 */
@@ -20,7 +61,103 @@ func NewRealtimeManager(c *context.Context) *RealtimeManager {
 	}
 }
 
-//  Publishes message to queue
-func (r *RealtimeManager) Publish(message RealtimeMessage) (err error) {
+/*
+Publishes message to given pub/sub channels, every http instance fans it out
+to websockets subscribed to these channels.
+*/
+func (r *RealtimeManager) Publish(message RealtimeMessage, channels ...string) (err error) {
+	var data []byte
+	if data, err = json.Marshal(message); err != nil {
+		return
+	}
+
+	for _, channel := range channels {
+		var id int
+		if id, err = r.context.Cache.Incr(realtimeSequenceKey(channel)); err != nil {
+			return
+		}
+
+		envelope := &RealtimeEnvelope{
+			ID:         int64(id),
+			Type:       message.Type(),
+			Identifier: message.Identifier(),
+			Channel:    channel,
+			Data:       data,
+		}
+
+		var body []byte
+		if body, err = json.Marshal(envelope); err != nil {
+			return
+		}
+
+		if err = r.remember(envelope); err != nil {
+			return
+		}
+
+		if err = r.context.Queue.Publish(channel, body); err != nil {
+			return
+		}
+	}
+	return
+}
+
+/*
+Subscribes to pub/sub channels until quit is closed
+*/
+func (r *RealtimeManager) Subscribe(quit <-chan struct{}, channels ...string) (chan ergoq.SubscribeMessage, chan error) {
+	return r.context.Queue.Subscribe(quit, channels...)
+}
+
+/*
+Returns buffered messages of channel published after message with lastID.
+Buffer is short (REALTIME_REPLAY_BUFFER_SIZE), so client disconnected for
+long time does not receive all messages.
+*/
+func (r *RealtimeManager) Replay(channel string, lastID int64) (result []*RealtimeEnvelope, err error) {
+	result = []*RealtimeEnvelope{}
+
+	var sequence int64
+	if err = GetCached(r.context, realtimeSequenceKey(channel), &sequence); err != nil {
+		// nothing published yet
+		return result, nil
+	}
+
+	first := lastID + 1
+	if oldest := sequence - settings.REALTIME_REPLAY_BUFFER_SIZE + 1; first < oldest {
+		first = oldest
+	}
+
+	for id := first; id <= sequence; id++ {
+		envelope := &RealtimeEnvelope{}
+		if GetCached(r.context, realtimeReplayKey(channel, id), envelope) != nil {
+			// expired or not stored yet
+			continue
+		}
+		result = append(result, envelope)
+	}
 	return
 }
+
+/*
+Stores envelope for replay. Every envelope has its own key (by sequence id
+from atomic Incr), so concurrent publishers on multiple instances never
+overwrite each other's messages. Envelopes expire after
+REALTIME_REPLAY_TIMEOUT and only last REALTIME_REPLAY_BUFFER_SIZE are read.
+*/
+func (r *RealtimeManager) remember(envelope *RealtimeEnvelope) (err error) {
+	var body []byte
+	if body, err = json.Marshal(envelope); err != nil {
+		return
+	}
+	return r.context.Cache.Set(realtimeReplayKey(envelope.Channel, envelope.ID), body, settings.REALTIME_REPLAY_TIMEOUT)
+}
+
+// cache key with sequence of channel messages
+func realtimeSequenceKey(channel string) string {
+	return channel + ":sequence"
+}
+
+// cache key with replayed message of channel
+func realtimeReplayKey(channel string, id int64) string {
+	return channel + ":replay:" + strconv.FormatInt(id, 10)
+}
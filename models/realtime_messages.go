@@ -1,6 +1,10 @@
 package models
 
-import "github.com/phonkee/patrol/types"
+import (
+	"time"
+
+	"github.com/phonkee/patrol/types"
+)
 
 /*
 RealtimeMessage is interface for sending messages to websockets
@@ -18,3 +22,57 @@ type UserLoggedRealtimeMessage struct {
 func (u *UserLoggedRealtimeMessage) Identifier() string {
 	return "auth:user_logged:" + u.ID.String()
 }
+func (u *UserLoggedRealtimeMessage) Type() string { return MESSAGE_TYPE_USER_LOGGED }
+
+/*
+EventCreatedRealtimeMessage is published when new event is processed
+*/
+type EventCreatedRealtimeMessage struct {
+	ID           types.PrimaryKey `json:"id"`
+	EventID      string           `json:"event_id"`
+	EventGroupID types.ForeignKey `json:"eventgroup_id"`
+	ProjectID    types.ForeignKey `json:"project_id"`
+	Message      string           `json:"message"`
+	Culprit      string           `json:"culprit"`
+	Level        int              `json:"level"`
+	Platform     string           `json:"platform"`
+	Datetime     time.Time        `json:"datetime"`
+}
+
+// returns realtime message about event, culprit is taken from eventgroup and
+// level from event (eventgroup level when event was not processed from raw)
+func NewEventCreatedRealtimeMessage(event *Event, eventgroup *EventGroup) *EventCreatedRealtimeMessage {
+	level := event.Level
+	if level == 0 {
+		level = eventgroup.Level
+	}
+	return &EventCreatedRealtimeMessage{
+		ID:           event.ID,
+		EventID:      event.EventID,
+		EventGroupID: event.EventGroupID,
+		ProjectID:    event.ProjectID,
+		Message:      event.Message,
+		Culprit:      eventgroup.Culprit,
+		Level:        level,
+		Platform:     event.Platform,
+		Datetime:     event.Datetime,
+	}
+}
+
+func (e *EventCreatedRealtimeMessage) Identifier() string {
+	return "events:event:" + e.ID.String()
+}
+func (e *EventCreatedRealtimeMessage) Type() string { return MESSAGE_TYPE_EVENT_CREATED }
+
+/*
+EventGroupChangedRealtimeMessage is published when eventgroup is changed
+(new event, resolved, regressed)
+*/
+type EventGroupChangedRealtimeMessage struct {
+	EventGroup *EventGroup `json:"eventgroup"`
+}
+
+func (e *EventGroupChangedRealtimeMessage) Identifier() string {
+	return "events:eventgroup:" + e.EventGroup.ID.String()
+}
+func (e *EventGroupChangedRealtimeMessage) Type() string { return MESSAGE_TYPE_EVENTGROUP_CHANGED }
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/phonkee/ergoq"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/parser"
+	"github.com/phonkee/patrol/settings"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// message queue recording published messages
+type publishedQueue struct {
+	ergoq.MessageQueuer
+	published map[string][][]byte
+}
+
+func (p *publishedQueue) Publish(topic string, message []byte) error {
+	p.published[topic] = append(p.published[topic], message)
+	return nil
+}
+
+// in memory cache
+type memoryCache struct {
+	data map[string][]byte
+
+	// expirations of keys (not enforced)
+	expirations map[string]time.Duration
+}
+
+func (m *memoryCache) Close() error { return nil }
+func (m *memoryCache) Delete(key string) error {
+	delete(m.data, key)
+	delete(m.expirations, key)
+	return nil
+}
+func (m *memoryCache) Get(key string) ([]byte, error) {
+	value, ok := m.data[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return value, nil
+}
+func (m *memoryCache) Incr(key string) (result int, err error) {
+	if value, ok := m.data[key]; ok {
+		json.Unmarshal(value, &result)
+	} else {
+		delete(m.expirations, key)
+	}
+	result++
+	m.data[key], err = json.Marshal(result)
+	return
+}
+func (m *memoryCache) Set(key string, value []byte, expiration ...time.Duration) error {
+	m.data[key] = value
+	if m.expirations == nil {
+		m.expirations = map[string]time.Duration{}
+	}
+	delete(m.expirations, key)
+	if len(expiration) > 0 {
+		m.expirations[key] = expiration[0]
+	}
+	return nil
+}
+
+func TestRealtimeManager(t *testing.T) {
+	Convey("Test channels", t, func() {
+		So(RealtimeProjectChannel(NewProject(func(p *Project) { p.ID = 12 }).ID), ShouldEqual, "realtime:project:12")
+		So(RealtimeUserChannel(NewUser(func(u *User) { u.ID = 3 }).ID), ShouldEqual, "realtime:user:3")
+	})
+
+	Convey("Test event created message level", t, func() {
+		eventgroup := NewEventGroup(func(eg *EventGroup) {
+			eg.Level = parser.LEVEL_ERROR
+			eg.Culprit = "main.go"
+		})
+
+		// level of processed event, not of eventgroup
+		event := NewEvent(func(e *Event) {
+			e.Level = parser.LEVEL_WARNING
+		})
+		message := NewEventCreatedRealtimeMessage(event, eventgroup)
+		So(message.Level, ShouldEqual, parser.LEVEL_WARNING)
+		So(message.Culprit, ShouldEqual, "main.go")
+
+		// event loaded from database has no level
+		So(NewEventCreatedRealtimeMessage(NewEvent(), eventgroup).Level, ShouldEqual, parser.LEVEL_ERROR)
+	})
+
+	Convey("Test Publish", t, func() {
+		queue := &publishedQueue{published: map[string][][]byte{}}
+		manager := NewRealtimeManager(&context.Context{
+			Queue: queue,
+			Cache: &memoryCache{data: map[string][]byte{}},
+		})
+
+		message := &UserLoggedRealtimeMessage{ID: 3, Username: "user"}
+		So(manager.Publish(message, "realtime:user:3", "realtime:project:1"), ShouldBeNil)
+
+		So(len(queue.published["realtime:user:3"]), ShouldEqual, 1)
+		So(len(queue.published["realtime:project:1"]), ShouldEqual, 1)
+
+		envelope := &RealtimeEnvelope{}
+		So(json.Unmarshal(queue.published["realtime:user:3"][0], envelope), ShouldBeNil)
+		So(envelope.ID, ShouldEqual, 1)
+		So(envelope.Type, ShouldEqual, MESSAGE_TYPE_USER_LOGGED)
+		So(envelope.Identifier, ShouldEqual, "auth:user_logged:3")
+		So(envelope.Channel, ShouldEqual, "realtime:user:3")
+
+		data := &UserLoggedRealtimeMessage{}
+		So(json.Unmarshal(envelope.Data, data), ShouldBeNil)
+		So(data.Username, ShouldEqual, "user")
+
+		Convey("Test Replay", func() {
+			for i := 0; i < 3; i++ {
+				So(manager.Publish(message, "realtime:user:3"), ShouldBeNil)
+			}
+
+			replayed, err := manager.Replay("realtime:user:3", 2)
+			So(err, ShouldBeNil)
+			So(len(replayed), ShouldEqual, 2)
+			So(replayed[0].ID, ShouldEqual, 3)
+			So(replayed[1].ID, ShouldEqual, 4)
+
+			replayed, err = manager.Replay("realtime:project:2", 0)
+			So(err, ShouldBeNil)
+			So(len(replayed), ShouldEqual, 0)
+		})
+
+		Convey("Test Replay of multiple publishers", func() {
+			// second instance shares cache, messages of both are replayed
+			other := NewRealtimeManager(&context.Context{Queue: queue, Cache: manager.context.Cache})
+			for i := 0; i < 3; i++ {
+				So(manager.Publish(message, "realtime:project:1"), ShouldBeNil)
+				So(other.Publish(message, "realtime:project:1"), ShouldBeNil)
+			}
+
+			replayed, err := manager.Replay("realtime:project:1", 0)
+			So(err, ShouldBeNil)
+			So(len(replayed), ShouldEqual, 7)
+			for i, envelope := range replayed {
+				So(envelope.ID, ShouldEqual, i+1)
+			}
+		})
+
+		Convey("Test Replay buffer size", func() {
+			for i := 0; i < settings.REALTIME_REPLAY_BUFFER_SIZE+5; i++ {
+				So(manager.Publish(message, "realtime:user:3"), ShouldBeNil)
+			}
+
+			replayed, err := manager.Replay("realtime:user:3", 0)
+			So(err, ShouldBeNil)
+			So(len(replayed), ShouldEqual, settings.REALTIME_REPLAY_BUFFER_SIZE)
+			So(replayed[len(replayed)-1].ID, ShouldEqual, settings.REALTIME_REPLAY_BUFFER_SIZE+6)
+		})
+	})
+}
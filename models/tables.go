@@ -1,12 +1,30 @@
 package models
 
 const (
-	AUTH_USER_DB_TABLE           = "auth_user"
-	AUTH_PERMISSION_DB_TABLE     = "auth_permission"
-	PROJECTS_PROJECT_DB_TABLE    = "projects_project"
-	PROJECTS_PROJECTKEY_DB_TABLE = "projects_projectkey"
-	TEAMS_TEAM_DB_TABLE          = "teams_team"
-	TEAMS_TEAMMEMBER_DB_TABLE    = "teams_teammember"
-	EVENTS_EVENT_DB_TABLE        = "events_event"
-	EVENTS_EVENTGROUP_DB_TABLE   = "events_eventgroup"
+	AUDIT_AUDITLOG_DB_TABLE                = "audit_auditlog"
+	AUTH_USER_DB_TABLE                     = "auth_user"
+	AUTH_PERMISSION_DB_TABLE               = "auth_permission"
+	AUTH_REFRESHTOKEN_DB_TABLE             = "auth_refreshtoken"
+	AUTH_PERSONALTOKEN_DB_TABLE            = "auth_personaltoken"
+	AUTH_TWOFACTOR_DB_TABLE                = "auth_twofactor"
+	ALERTS_ALERTRULE_DB_TABLE              = "alerts_alertrule"
+	CHATOPS_CHATWEBHOOK_DB_TABLE           = "chatops_chatwebhook"
+	NOTIFICATIONS_SETTING_DB_TABLE         = "notifications_setting"
+	NOTIFICATIONS_PROJECTSETTING_DB_TABLE  = "notifications_projectsetting"
+	NOTIFICATIONS_SUBSCRIPTION_DB_TABLE    = "notifications_subscription"
+	PROJECTS_PROJECT_DB_TABLE              = "projects_project"
+	PROJECTS_PROJECTKEY_DB_TABLE           = "projects_projectkey"
+	PROJECTS_PROJECTSCRUBBING_DB_TABLE     = "projects_projectscrubbing"
+	PROJECTS_PROJECTINBOUNDFILTER_DB_TABLE = "projects_projectinboundfilter"
+	PROJECTS_PROJECTFILTERSTAT_DB_TABLE    = "projects_projectfilterstat"
+	TEAMS_TEAM_DB_TABLE                    = "teams_team"
+	TEAMS_TEAMMEMBER_DB_TABLE              = "teams_teammember"
+	EVENTS_EVENT_DB_TABLE                  = "events_event"
+	EVENTS_EVENTGROUP_DB_TABLE             = "events_eventgroup"
+	EVENTS_EVENTTAG_DB_TABLE               = "events_eventtag"
+	EVENTS_RELEASE_DB_TABLE                = "events_release"
+	EVENTS_ENVIRONMENT_DB_TABLE            = "events_environment"
+	EVENTS_EVENTGROUPENVIRONMENT_DB_TABLE  = "events_eventgroupenvironment"
+	WEBHOOKS_WEBHOOK_DB_TABLE              = "webhooks_webhook"
+	WEBHOOKS_WEBHOOKDELIVERY_DB_TABLE      = "webhooks_webhookdelivery"
 )
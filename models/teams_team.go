@@ -23,14 +23,17 @@ type Team struct {
 	OwnerID   types.ForeignKey `db:"owner_id" json:"owner_id"`
 	DateAdded time.Time        `db:"date_added" json:"date_added"`
 	Status    TeamStatus       `db:"status" json:"status"`
+
+	// all members must use two-factor authentication
+	RequireTwoFactor bool `db:"require_two_factor" json:"require_two_factor"`
 }
 
 // returns all columns except of primary key
 func (t *Team) Columns() []string {
-	return []string{"name", "owner_id", "date_added", "status"}
+	return []string{"name", "owner_id", "date_added", "status", "require_two_factor"}
 }
 func (t *Team) Values() []interface{} {
-	return []interface{}{t.Name, t.OwnerID, t.DateAdded, t.Status}
+	return []interface{}{t.Name, t.OwnerID, t.DateAdded, t.Status, t.RequireTwoFactor}
 }
 func (t *Team) String() string { return "teams:team:" + t.PrimaryKey().String() }
 func (t *Team) Table() string  { return TEAMS_TEAM_DB_TABLE }
@@ -110,6 +113,10 @@ const (
 		date_added timestamp with time zone NOT NULL,
 		status integer CHECK (status > 0)
 	)`
+
+	MIGRATION_TEAMS_TEAM_REQUIRE_TWO_FACTOR_ID = "teams-team-require-two-factor"
+	MIGRATION_TEAMS_TEAM_REQUIRE_TWO_FACTOR    = `ALTER TABLE ` + TEAMS_TEAM_DB_TABLE + `
+	ADD COLUMN require_two_factor boolean NOT NULL DEFAULT false`
 )
 
 /*
@@ -4,16 +4,11 @@ import (
 	"testing"
 
 	"github.com/Pallinder/go-randomdata"
-	"github.com/phonkee/patrol/utils"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestTeam(t *testing.T) {
-	context, err := utils.NewTestPatrolContext()
-	if err != nil {
-		t.Error(err)
-		t.Fail()
-	}
+	context := newTestContext(t)
 
 	Convey("Test insert/update valid team", t, func() {
 
@@ -30,14 +25,14 @@ func TestTeam(t *testing.T) {
 		teamm := NewTeamManager(context)
 		team := teamm.NewTeam(func(te *Team) {
 			te.Name = randomdata.SillyName()
-			te.OwnerID = user.ID
+			te.OwnerID = user.ID.ToForeignKey()
 		})
-		err = team.Insert(teamm)
+		err = team.Insert(context)
 		So(err, ShouldBeNil)
 
 		return
 
-		err = team.Insert(teamm)
+		err = team.Insert(context)
 		So(err, ShouldBeNil)
 		So(team.ID, ShouldNotEqual, 0)
 
@@ -2,16 +2,33 @@ package models
 
 import (
 	"errors"
+	"net/url"
 
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/rest/validator"
+	"github.com/phonkee/patrol/settings"
 	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
 )
 
 var (
 	ErrInvalidTeamID     = errors.New("invalid_team")
 	ErrInvalidUserID     = errors.New("invalid_user")
 	ErrInvalidMemberType = errors.New("invalid_member_type")
+	ErrInvalidScrubField = errors.New("invalid_scrub_field")
+	ErrInvalidPattern    = errors.New("invalid_pattern")
+
+	ErrInvalidAlertCondition = errors.New("invalid_alert_condition")
+	ErrInvalidAlertAction    = errors.New("invalid_alert_action")
+
+	ErrInvalidWebhookURL   = errors.New("invalid_url")
+	ErrInvalidWebhookEvent = errors.New("invalid_webhook_event")
+
+	ErrInvalidNotificationMode = errors.New("invalid_notification_mode")
+
+	ErrInvalidTokenScope = errors.New("invalid_scope")
+
+	ErrInvalidPermission = errors.New("invalid_permission")
 )
 
 /*
@@ -79,3 +96,187 @@ func ValidateMemberType() validator.ValidatorFunc {
 		return
 	}
 }
+
+/*
+Validate list of scrubbing field names
+*/
+func ValidateScrubFields() validator.ValidatorFunc {
+	return func(value interface{}) (err error) {
+		fields, ok := value.(types.StringSlice)
+		if !ok {
+			return ErrInvalidScrubField
+		}
+		for _, field := range fields {
+			if field == "" || len(field) > MAX_SCRUB_FIELD_LENGTH {
+				return ErrInvalidScrubField
+			}
+		}
+		return
+	}
+}
+
+/*
+Validate list of glob patterns for inbound filters
+*/
+func ValidateFilterPatterns() validator.ValidatorFunc {
+	return func(value interface{}) (err error) {
+		patterns, ok := value.(types.StringSlice)
+		if !ok {
+			return ErrInvalidPattern
+		}
+		for _, pattern := range patterns {
+			if pattern == "" || len(pattern) > MAX_FILTER_PATTERN_LENGTH {
+				return ErrInvalidPattern
+			}
+		}
+		return
+	}
+}
+
+/*
+Validate list of alert rule conditions
+*/
+func ValidateAlertConditions() validator.ValidatorFunc {
+	return func(value interface{}) (err error) {
+		conditions, ok := value.(AlertConditionList)
+		if !ok || len(conditions) == 0 || len(conditions) > MAX_ALERT_RULE_CONDITIONS {
+			return ErrInvalidAlertCondition
+		}
+		for _, condition := range conditions {
+			if condition == nil || !condition.IsValid() {
+				return ErrInvalidAlertCondition
+			}
+		}
+		return
+	}
+}
+
+/*
+Validate alert rule name
+*/
+func ValidateAlertRuleName() validator.ValidatorFunc {
+	return validator.Any(
+		validator.ValidateStringMinLength(1),
+		validator.ValidateStringMaxLength(MAX_ALERT_RULE_NAME_LENGTH),
+	)
+}
+
+/*
+Validate minutes between actions of alert rule (0 means no throttling)
+*/
+func ValidateAlertRuleFrequency(max int64) validator.ValidatorFunc {
+	return validator.Any(
+		validator.ValidateInt64Min(0),
+		validator.ValidateInt64Max(max),
+	)
+}
+
+/*
+Validate webhook url, only absolute http(s) urls to public hosts are allowed
+*/
+func ValidateWebhookURL() validator.ValidatorFunc {
+	return func(value interface{}) (err error) {
+		str, ok := value.(string)
+		if !ok || str == "" || len(str) > MAX_WEBHOOK_URL_LENGTH {
+			return ErrInvalidWebhookURL
+		}
+		parsed, errParse := url.Parse(str)
+		if errParse != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return ErrInvalidWebhookURL
+		}
+		if !utils.IsPublicHost(parsed.Hostname()) {
+			return ErrInvalidWebhookURL
+		}
+		return
+	}
+}
+
+/*
+Validate list of event types webhook subscribes to
+*/
+func ValidateWebhookEvents() validator.ValidatorFunc {
+	return func(value interface{}) (err error) {
+		events, ok := value.(types.StringSlice)
+		if !ok || len(events) == 0 {
+			return ErrInvalidWebhookEvent
+		}
+		for _, event := range events {
+			if !IsWebhookEventType(event) {
+				return ErrInvalidWebhookEvent
+			}
+		}
+		return
+	}
+}
+
+/*
+Validate webhook secret, empty secret means generated one is used
+*/
+func ValidateWebhookSecret() validator.ValidatorFunc {
+	return validator.ValidateStringMaxLength(MAX_WEBHOOK_SECRET_LENGTH)
+}
+
+/*
+Validate chat channel, empty channel means default channel of incoming webhook
+*/
+func ValidateChatChannel() validator.ValidatorFunc {
+	return validator.ValidateStringMaxLength(MAX_CHAT_CHANNEL_LENGTH)
+}
+
+/*
+Validate notification mode of project
+*/
+func ValidateNotificationMode() validator.ValidatorFunc {
+	return func(value interface{}) (err error) {
+		mode, ok := value.(string)
+		if !ok || !IsNotificationMode(mode) {
+			return ErrInvalidNotificationMode
+		}
+		return
+	}
+}
+
+/*
+Validate personal token name
+*/
+func ValidatePersonalTokenName() validator.ValidatorFunc {
+	return validator.Any(
+		validator.ValidateStringMinLength(1),
+		validator.ValidateStringMaxLength(settings.AUTH_PERSONAL_TOKEN_MAX_NAME_LENGTH),
+	)
+}
+
+/*
+Validate scopes of personal token, at least one scope is required
+*/
+func ValidateTokenScopes() validator.ValidatorFunc {
+	return func(value interface{}) (err error) {
+		scopes, ok := value.(types.StringSlice)
+		if !ok || len(scopes) == 0 {
+			return ErrInvalidTokenScope
+		}
+		for _, scope := range scopes {
+			if !IsTokenScope(scope) {
+				return ErrInvalidTokenScope
+			}
+		}
+		return
+	}
+}
+
+/*
+Validate permission codename, permission must be registered
+*/
+func ValidatePermissionCodename(context *context.Context) validator.ValidatorFunc {
+	pm := NewPermissionManager(context)
+
+	return func(value interface{}) (err error) {
+		permission := pm.NewPermission()
+		if err = pm.GetByCodename(permission, value.(string)); err != nil {
+			if err == ErrObjectDoesNotExists {
+				return ErrInvalidPermission
+			}
+		}
+		return
+	}
+}
@@ -0,0 +1,231 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/rest/paginator"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_WEBHOOKS_WEBHOOK_INITIAL_ID = "webhooks-webhook-initial"
+	MIGRATION_WEBHOOKS_WEBHOOK_INITIAL    = `CREATE TABLE ` + WEBHOOKS_WEBHOOK_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		project_id bigint NOT NULL REFERENCES ` + PROJECTS_PROJECT_DB_TABLE + ` ON DELETE CASCADE,
+		url character varying (` + strconv.Itoa(MAX_WEBHOOK_URL_LENGTH) + `) NOT NULL,
+		secret character varying (` + strconv.Itoa(MAX_WEBHOOK_SECRET_LENGTH) + `) NOT NULL,
+		events text[] NOT NULL DEFAULT '{}',
+		enabled boolean NOT NULL DEFAULT true,
+		date_created timestamp with time zone NOT NULL
+	)`
+	MIGRATION_WEBHOOKS_WEBHOOK_INITIAL_DEPENDENCIES = []string{
+		settings.PROJECTS_PLUGIN_ID + ":" + MIGRATION_PROJECT_INITIAL_ID,
+	}
+)
+
+/*
+Webhook event types
+*/
+const (
+	WEBHOOK_EVENT_EVENTGROUP_CREATED   = "eventgroup.created"
+	WEBHOOK_EVENT_EVENTGROUP_RESOLVED  = "eventgroup.resolved"
+	WEBHOOK_EVENT_EVENTGROUP_REGRESSED = "eventgroup.regressed"
+	WEBHOOK_EVENT_EVENT_CREATED        = "event.created"
+)
+
+var (
+	WEBHOOK_EVENT_TYPES = []string{
+		WEBHOOK_EVENT_EVENTGROUP_CREATED,
+		WEBHOOK_EVENT_EVENTGROUP_RESOLVED,
+		WEBHOOK_EVENT_EVENTGROUP_REGRESSED,
+		WEBHOOK_EVENT_EVENT_CREATED,
+	}
+)
+
+// returns whether event type is known webhook event type
+func IsWebhookEventType(eventType string) bool {
+	for _, et := range WEBHOOK_EVENT_TYPES {
+		if et == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Webhook model
+
+	subscription of project events, JSON payloads are posted to URL and signed
+	with Secret.
+*/
+type Webhook struct {
+	Model
+	ProjectID   types.ForeignKey  `db:"project_id" json:"project_id"`
+	URL         string            `db:"url" json:"url"`
+	Secret      string            `db:"secret" json:"secret"`
+	Events      types.StringSlice `db:"events" json:"events"`
+	Enabled     bool              `db:"enabled" json:"enabled"`
+	DateCreated time.Time         `db:"date_created" json:"date_created"`
+}
+
+// returns all columns except of primary key
+func (w *Webhook) Columns() []string {
+	return []string{"project_id", "url", "secret", "events", "enabled", "date_created"}
+}
+func (w *Webhook) Values() []interface{} {
+	return []interface{}{w.ProjectID, w.URL, w.Secret, w.Events, w.Enabled, w.DateCreated}
+}
+func (w *Webhook) String() string { return "webhooks:webhook:" + w.PrimaryKey().String() }
+func (w *Webhook) Table() string  { return WEBHOOKS_WEBHOOK_DB_TABLE }
+
+// returns whether webhook is subscribed to event type
+func (w *Webhook) IsSubscribed(eventType string) bool {
+	for _, et := range w.Events {
+		if et == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// enabled webhooks are cached by project
+func webhookProjectCacheKey(projectID types.ForeignKey) string {
+	return "webhooks:webhook:project:" + strconv.FormatInt(projectID.Int64(), 10)
+}
+
+/*
+CRUD
+*/
+func (w *Webhook) Insert(ctx *context.Context) (err error) {
+	if err = DBInsert(ctx, w); err != nil {
+		return
+	}
+	return RemoveCached(ctx, webhookProjectCacheKey(w.ProjectID))
+}
+
+func (w *Webhook) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	if changed, err = DBUpdate(ctx, w, fields...); err != nil {
+		return
+	}
+	err = RemoveCached(ctx, webhookProjectCacheKey(w.ProjectID))
+	return
+}
+
+func (w *Webhook) Delete(ctx *context.Context) (err error) {
+	if err = DBDelete(ctx, w); err != nil {
+		return
+	}
+	return RemoveCached(ctx, webhookProjectCacheKey(w.ProjectID))
+}
+
+/*
+WebhookManager
+*/
+type WebhookManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewWebhookManager(context *context.Context) *WebhookManager {
+	return &WebhookManager{context: context}
+}
+
+// returns new model instance with default values, secret is generated
+func NewWebhook(funcs ...func(*Webhook)) (webhook *Webhook) {
+	webhook = &Webhook{
+		Secret:      utils.NewRandomToken(32),
+		Events:      types.StringSlice{},
+		Enabled:     true,
+		DateCreated: utils.NowTruncated(),
+	}
+	for _, f := range funcs {
+		f(webhook)
+	}
+	return
+}
+
+func (w *WebhookManager) NewWebhook(funcs ...func(*Webhook)) *Webhook { return NewWebhook(funcs...) }
+func (w *WebhookManager) NewWebhookList() []*Webhook                  { return []*Webhook{} }
+
+// Filter results without paging
+func (w *WebhookManager) Filter(target interface{}, qfs ...utils.QueryFunc) error {
+	_, safe := target.([]*Webhook)
+	return DBFilter(w.context, WEBHOOKS_WEBHOOK_DB_TABLE+".*", WEBHOOKS_WEBHOOK_DB_TABLE, !safe, target, qfs...)
+}
+
+// Filter results with paging
+func (w *WebhookManager) FilterPaged(target interface{}, paging *paginator.Paginator, qfs ...utils.QueryFunc) (err error) {
+	if err = DBFilterCount(w.context, WEBHOOKS_WEBHOOK_DB_TABLE, paging, qfs...); err != nil {
+		return
+	}
+
+	// add paging query filter
+	qfs = append(qfs, w.QueryFilterPaging(paging))
+
+	_, safe := target.([]*Webhook)
+
+	return DBFilter(w.context, WEBHOOKS_WEBHOOK_DB_TABLE+".*", WEBHOOKS_WEBHOOK_DB_TABLE, !safe, target, qfs...)
+}
+
+// get from database
+func (w *WebhookManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*Webhook)
+	return DBGet(w.context, "*", WEBHOOKS_WEBHOOK_DB_TABLE, !safe, target, qfs...)
+}
+
+// returns by id and possibly other queryFuncs
+func (w *WebhookManager) GetByID(target interface{}, id types.Keyer, qfs ...utils.QueryFunc) (err error) {
+	qfs = append(qfs, w.QueryFilterWhere("id = ?", id.Int64()))
+	return w.Get(target, qfs...)
+}
+
+/*
+Returns enabled webhooks of project subscribed to event type. Enabled webhooks
+are cached until some webhook of project is changed.
+*/
+func (w *WebhookManager) FilterSubscribed(target *[]*Webhook, projectID types.ForeignKey, eventType string) (err error) {
+	enabled := w.NewWebhookList()
+
+	cacheKey := webhookProjectCacheKey(projectID)
+	if err = GetCached(w.context, cacheKey, &enabled); err != nil {
+		if err = w.Filter(&enabled,
+			w.QueryFilterWhere(WEBHOOKS_WEBHOOK_DB_TABLE+".project_id = ? AND "+WEBHOOKS_WEBHOOK_DB_TABLE+".enabled = ?", projectID, true),
+			w.QueryFilterOrderID(),
+		); err != nil {
+			return
+		}
+		if err = Cache(w.context, cacheKey, enabled); err != nil {
+			return
+		}
+	}
+
+	*target = w.NewWebhookList()
+	for _, webhook := range enabled {
+		if webhook.IsSubscribed(eventType) {
+			*target = append(*target, webhook)
+		}
+	}
+	return
+}
+
+// filters webhooks by project
+func (w *WebhookManager) QueryFilterProject(project *Project) utils.QueryFunc {
+	handleNilPointer(project)
+	return w.QueryFilterWhere(WEBHOOKS_WEBHOOK_DB_TABLE+".project_id = ?", project.ID)
+}
+
+// orders webhooks by id
+func (w *WebhookManager) QueryFilterOrderID() utils.QueryFunc {
+	return func(builder squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return builder.OrderBy(WEBHOOKS_WEBHOOK_DB_TABLE + ".id ASC")
+	}
+}
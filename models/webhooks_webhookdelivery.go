@@ -0,0 +1,168 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/lann/squirrel"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/rest/paginator"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Migrations
+*/
+var (
+	MIGRATION_WEBHOOKS_WEBHOOKDELIVERY_INITIAL_ID = "webhooks-webhookdelivery-initial"
+	MIGRATION_WEBHOOKS_WEBHOOKDELIVERY_INITIAL    = `CREATE TABLE ` + WEBHOOKS_WEBHOOKDELIVERY_DB_TABLE + `
+	(
+		id bigserial NOT NULL PRIMARY KEY,
+		webhook_id bigint NOT NULL REFERENCES ` + WEBHOOKS_WEBHOOK_DB_TABLE + ` ON DELETE CASCADE,
+		guid character varying (32) NOT NULL,
+		event_type character varying (64) NOT NULL,
+		payload text NOT NULL,
+		attempt integer NOT NULL,
+		status_code integer NOT NULL DEFAULT 0,
+		response text NOT NULL DEFAULT '',
+		error character varying (` + strconv.Itoa(MAX_WEBHOOK_DELIVERY_ERROR_LENGTH) + `) NOT NULL DEFAULT '',
+		success boolean NOT NULL DEFAULT false,
+		duration integer NOT NULL DEFAULT 0,
+		date_created timestamp with time zone NOT NULL
+	)`
+	MIGRATION_WEBHOOKS_WEBHOOKDELIVERY_INDEX = `CREATE INDEX ` + WEBHOOKS_WEBHOOKDELIVERY_DB_TABLE + `_webhook_id ON ` +
+		WEBHOOKS_WEBHOOKDELIVERY_DB_TABLE + ` (webhook_id, date_created DESC)`
+	MIGRATION_WEBHOOKS_WEBHOOKDELIVERY_INITIAL_DEPENDENCIES = []string{
+		settings.WEBHOOKS_PLUGIN_ID + ":" + MIGRATION_WEBHOOKS_WEBHOOK_INITIAL_ID,
+	}
+)
+
+/*
+WebhookDelivery model
+
+	single delivery attempt of webhook payload. All attempts (retries) of
+	same delivery share guid.
+*/
+type WebhookDelivery struct {
+	Model
+	WebhookID   types.ForeignKey `db:"webhook_id" json:"webhook_id"`
+	GUID        string           `db:"guid" json:"guid"`
+	EventType   string           `db:"event_type" json:"event_type"`
+	Payload     string           `db:"payload" json:"payload"`
+	Attempt     int              `db:"attempt" json:"attempt"`
+	StatusCode  int              `db:"status_code" json:"status_code"`
+	Response    string           `db:"response" json:"response"`
+	Error       string           `db:"error" json:"error"`
+	Success     bool             `db:"success" json:"success"`
+	Duration    int              `db:"duration" json:"duration"`
+	DateCreated time.Time        `db:"date_created" json:"date_created"`
+}
+
+// returns all columns except of primary key
+func (w *WebhookDelivery) Columns() []string {
+	return []string{
+		"webhook_id", "guid", "event_type", "payload", "attempt",
+		"status_code", "response", "error", "success", "duration",
+		"date_created",
+	}
+}
+func (w *WebhookDelivery) Values() []interface{} {
+	return []interface{}{
+		w.WebhookID, w.GUID, w.EventType, w.Payload, w.Attempt,
+		w.StatusCode, w.Response, w.Error, w.Success, w.Duration,
+		w.DateCreated,
+	}
+}
+func (w *WebhookDelivery) String() string {
+	return "webhooks:webhookdelivery:" + w.PrimaryKey().String()
+}
+func (w *WebhookDelivery) Table() string { return WEBHOOKS_WEBHOOKDELIVERY_DB_TABLE }
+
+/*
+CRUD
+*/
+func (w *WebhookDelivery) Insert(ctx *context.Context) (err error) {
+	w.Response = utils.StringTruncate(w.Response, MAX_WEBHOOK_DELIVERY_RESPONSE_LENGTH)
+	w.Error = utils.StringTruncate(w.Error, MAX_WEBHOOK_DELIVERY_ERROR_LENGTH)
+	return DBInsert(ctx, w)
+}
+
+func (w *WebhookDelivery) Update(ctx *context.Context, fields ...string) (changed bool, err error) {
+	return DBUpdate(ctx, w, fields...)
+}
+
+func (w *WebhookDelivery) Delete(ctx *context.Context) (err error) {
+	return DBDelete(ctx, w)
+}
+
+/*
+WebhookDeliveryManager
+*/
+type WebhookDeliveryManager struct {
+	Manager
+	context *context.Context
+}
+
+func NewWebhookDeliveryManager(context *context.Context) *WebhookDeliveryManager {
+	return &WebhookDeliveryManager{context: context}
+}
+
+// returns new model instance
+func NewWebhookDelivery(funcs ...func(*WebhookDelivery)) (delivery *WebhookDelivery) {
+	delivery = &WebhookDelivery{
+		Attempt:     1,
+		DateCreated: utils.NowTruncated(),
+	}
+	for _, f := range funcs {
+		f(delivery)
+	}
+	return
+}
+
+func (w *WebhookDeliveryManager) NewWebhookDelivery(funcs ...func(*WebhookDelivery)) *WebhookDelivery {
+	return NewWebhookDelivery(funcs...)
+}
+func (w *WebhookDeliveryManager) NewWebhookDeliveryList() []*WebhookDelivery {
+	return []*WebhookDelivery{}
+}
+
+// Filter results with paging
+func (w *WebhookDeliveryManager) FilterPaged(target interface{}, paging *paginator.Paginator, qfs ...utils.QueryFunc) (err error) {
+	if err = DBFilterCount(w.context, WEBHOOKS_WEBHOOKDELIVERY_DB_TABLE, paging, qfs...); err != nil {
+		return
+	}
+
+	// add paging query filter
+	qfs = append(qfs, w.QueryFilterPaging(paging))
+
+	_, safe := target.([]*WebhookDelivery)
+
+	return DBFilter(w.context, WEBHOOKS_WEBHOOKDELIVERY_DB_TABLE+".*", WEBHOOKS_WEBHOOKDELIVERY_DB_TABLE, !safe, target, qfs...)
+}
+
+// get from database
+func (w *WebhookDeliveryManager) Get(target interface{}, qfs ...utils.QueryFunc) (err error) {
+	_, safe := target.(*WebhookDelivery)
+	return DBGet(w.context, "*", WEBHOOKS_WEBHOOKDELIVERY_DB_TABLE, !safe, target, qfs...)
+}
+
+// returns by id and possibly other queryFuncs
+func (w *WebhookDeliveryManager) GetByID(target interface{}, id types.Keyer, qfs ...utils.QueryFunc) (err error) {
+	qfs = append(qfs, w.QueryFilterWhere("id = ?", id.Int64()))
+	return w.Get(target, qfs...)
+}
+
+// filters deliveries by webhook
+func (w *WebhookDeliveryManager) QueryFilterWebhook(webhook *Webhook) utils.QueryFunc {
+	handleNilPointer(webhook)
+	return w.QueryFilterWhere(WEBHOOKS_WEBHOOKDELIVERY_DB_TABLE+".webhook_id = ?", webhook.ID)
+}
+
+// orders deliveries from latest
+func (w *WebhookDeliveryManager) QueryFilterOrderLatest() utils.QueryFunc {
+	return func(builder squirrel.SelectBuilder) squirrel.SelectBuilder {
+		return builder.OrderBy(WEBHOOKS_WEBHOOKDELIVERY_DB_TABLE+".date_created DESC", WEBHOOKS_WEBHOOKDELIVERY_DB_TABLE+".id DESC")
+	}
+}
@@ -0,0 +1,117 @@
+package notifications
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"net/url"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/settings"
+)
+
+var (
+	passwordResetTextTemplate = texttemplate.Must(texttemplate.New("text").Parse(
+		`Hello {{ .User.Name }},
+
+somebody (hopefully you) requested password reset of your patrol account {{ .User.Username }}.
+Password can be changed on following link in next {{ .Valid }}:
+
+{{ .Link }}
+
+If you did not request password reset, you can ignore this e-mail.
+`))
+
+	passwordResetHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(
+		`<html>
+<body>
+<p>Hello {{ .User.Name }},</p>
+<p>somebody (hopefully you) requested password reset of your patrol account <strong>{{ .User.Username }}</strong>.
+Password can be changed on following link in next {{ .Valid }}:</p>
+<p><a href="{{ .Link }}">{{ .Link }}</a></p>
+<p>If you did not request password reset, you can ignore this e-mail.</p>
+</body>
+</html>
+`))
+
+	emailVerifyTextTemplate = texttemplate.Must(texttemplate.New("text").Parse(
+		`Hello {{ .User.Name }},
+
+patrol account {{ .User.Username }} was created for this e-mail address.
+Please verify your e-mail address on following link in next {{ .Valid }}:
+
+{{ .Link }}
+`))
+
+	emailVerifyHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(
+		`<html>
+<body>
+<p>Hello {{ .User.Name }},</p>
+<p>patrol account <strong>{{ .User.Username }}</strong> was created for this e-mail address.
+Please verify your e-mail address on following link in next {{ .Valid }}:</p>
+<p><a href="{{ .Link }}">{{ .Link }}</a></p>
+</body>
+</html>
+`))
+)
+
+/*
+AuthMailData is passed to password reset and e-mail verification templates
+*/
+type AuthMailData struct {
+	User  *models.User
+	Link  string
+	Valid string
+}
+
+/*
+Returns password reset message with link containing token
+*/
+func NewPasswordResetMessage(user *models.User, token string) (*Message, error) {
+	data := &AuthMailData{
+		User:  user,
+		Link:  AuthLink(settings.AUTH_PASSWORD_RESET_LINK, token),
+		Valid: settings.AUTH_PASSWORD_RESET_TOKEN_TTL.String(),
+	}
+	return newAuthMessage(user, "Password reset", data, passwordResetTextTemplate, passwordResetHTMLTemplate)
+}
+
+/*
+Returns e-mail verification message with link containing token
+*/
+func NewEmailVerifyMessage(user *models.User, token string) (*Message, error) {
+	data := &AuthMailData{
+		User:  user,
+		Link:  AuthLink(settings.AUTH_EMAIL_VERIFY_LINK, token),
+		Valid: settings.AUTH_EMAIL_VERIFY_TOKEN_TTL.String(),
+	}
+	return newAuthMessage(user, "Verify your e-mail address", data, emailVerifyTextTemplate, emailVerifyHTMLTemplate)
+}
+
+// returns absolute link with token
+func AuthLink(link, token string) string {
+	return strings.TrimRight(settings.SETTINGS_BASE_URL, "/") + link + url.QueryEscape(token)
+}
+
+// renders message for user from templates
+func newAuthMessage(user *models.User, subject string, data *AuthMailData, text *texttemplate.Template, html *htmltemplate.Template) (message *Message, err error) {
+	message = NewMessage(func(m *Message) {
+		m.To = []string{user.Email}
+		m.Subject = "[patrol] " + subject
+	})
+
+	buffer := &bytes.Buffer{}
+	if err = text.Execute(buffer, data); err != nil {
+		return
+	}
+	message.Text = buffer.String()
+
+	buffer.Reset()
+	if err = html.Execute(buffer, data); err != nil {
+		return
+	}
+	message.HTML = buffer.String()
+
+	return
+}
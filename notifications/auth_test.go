@@ -0,0 +1,36 @@
+package notifications
+
+import (
+	"testing"
+
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/settings"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAuthMessages(t *testing.T) {
+	user := models.NewUser(func(u *models.User) {
+		u.Username = "user"
+		u.Name = "User <Name>"
+		u.Email = "user@example.com"
+	})
+
+	Convey("Test auth link", t, func() {
+		So(AuthLink(settings.AUTH_PASSWORD_RESET_LINK, "a+b.c"), ShouldEndWith, settings.AUTH_PASSWORD_RESET_LINK+"a%2Bb.c")
+	})
+
+	Convey("Test password reset message", t, func() {
+		message, err := NewPasswordResetMessage(user, "token")
+		So(err, ShouldBeNil)
+		So(message.To, ShouldResemble, []string{"user@example.com"})
+		So(message.Text, ShouldContainSubstring, AuthLink(settings.AUTH_PASSWORD_RESET_LINK, "token"))
+		So(message.HTML, ShouldContainSubstring, "User &lt;Name&gt;")
+	})
+
+	Convey("Test e-mail verification message", t, func() {
+		message, err := NewEmailVerifyMessage(user, "token")
+		So(err, ShouldBeNil)
+		So(message.To, ShouldResemble, []string{"user@example.com"})
+		So(message.Text, ShouldContainSubstring, AuthLink(settings.AUTH_EMAIL_VERIFY_LINK, "token"))
+	})
+}
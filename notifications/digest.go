@@ -0,0 +1,383 @@
+package notifications
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+)
+
+const (
+	// cache key prefix of pending index, every added notification has slot
+	// there pointing to its digest
+	DIGEST_PENDING_CACHE_KEY = "notifications:digest:pending"
+)
+
+/*
+DigestItem is eventgroup notified in digest
+*/
+type DigestItem struct {
+	EventGroupID types.PrimaryKey `json:"eventgroup_id"`
+	ProjectID    types.ForeignKey `json:"project_id"`
+	Message      string           `json:"message"`
+	Culprit      string           `json:"culprit"`
+	Level        int              `json:"level"`
+	Regression   bool             `json:"regression"`
+	Resolved     bool             `json:"resolved"`
+	TimesSeen    int64            `json:"times_seen"`
+
+	// number of notifications of eventgroup in digest
+	Count int `json:"count"`
+}
+
+// returns digest item of single notification of eventgroup
+func NewDigestItem(eventgroup *models.EventGroup) *DigestItem {
+	return &DigestItem{
+		EventGroupID: eventgroup.ID,
+		ProjectID:    eventgroup.ProjectID,
+		Message:      eventgroup.Message,
+		Culprit:      eventgroup.Culprit,
+		Level:        eventgroup.Level,
+		Regression:   eventgroup.IsRegression,
+		Resolved:     eventgroup.Status == models.EVENT_GROUP_STATUS_RESOLVED,
+		TimesSeen:    eventgroup.TimesSeen,
+		Count:        1,
+	}
+}
+
+// returns absolute link to eventgroup
+func (d *DigestItem) Link() string {
+	return EventGroupLink(models.NewEventGroup(func(eg *models.EventGroup) {
+		eg.ID = d.EventGroupID
+		eg.ProjectID = d.ProjectID
+	}))
+}
+
+/*
+Digest buffers notifications of project for single recipient
+*/
+type Digest struct {
+	ProjectID   types.ForeignKey `json:"project_id"`
+	ProjectName string           `json:"project_name"`
+	Recipient   string           `json:"recipient"`
+	Items       []*DigestItem    `json:"items"`
+	FirstAt     time.Time        `json:"first_at"`
+	LastAt      time.Time        `json:"last_at"`
+}
+
+// returns cache key of digest
+func (d *Digest) Key() string {
+	return DigestKey(d.ProjectID, d.Recipient)
+}
+
+/*
+Adds eventgroup to digest, eventgroup already present in digest is updated
+*/
+func (d *Digest) Add(eventgroup *models.EventGroup, now time.Time) {
+	d.AddItem(NewDigestItem(eventgroup), now)
+}
+
+// adds digest item, item of eventgroup already present in digest is updated
+func (d *Digest) AddItem(added *DigestItem, now time.Time) {
+	if d.FirstAt.IsZero() || now.Before(d.FirstAt) {
+		d.FirstAt = now
+	}
+	if now.After(d.LastAt) {
+		d.LastAt = now
+	}
+
+	for _, item := range d.Items {
+		if item.EventGroupID == added.EventGroupID {
+			item.Count += added.Count
+			item.TimesSeen = added.TimesSeen
+			item.Regression = item.Regression || added.Regression
+			item.Resolved = added.Resolved
+			return
+		}
+	}
+
+	d.Items = append(d.Items, added)
+}
+
+/*
+Digest is due when no notification came for min delay or when max delay
+passed since first notification.
+*/
+func (d *Digest) IsDue(now time.Time, minDelay, maxDelay time.Duration) bool {
+	return now.Sub(d.LastAt) >= minDelay || now.Sub(d.FirstAt) >= maxDelay
+}
+
+// returns cache key of digest for project and recipient
+func DigestKey(projectID types.ForeignKey, recipient string) string {
+	return "notifications:digest:" + strconv.FormatInt(projectID.Int64(), 10) + ":" + recipient
+}
+
+/*
+digestEntry is single notification of digest stored under its own cache key
+*/
+type digestEntry struct {
+	ProjectID   types.ForeignKey `json:"project_id"`
+	ProjectName string           `json:"project_name"`
+	Recipient   string           `json:"recipient"`
+	Item        *DigestItem      `json:"item"`
+	At          time.Time        `json:"at"`
+}
+
+/*
+digestSlot is slot of pending index, it points to notification of digest
+*/
+type digestSlot struct {
+	Key      string `json:"key"`
+	Sequence int64  `json:"sequence"`
+}
+
+/*
+Digester batches notifications into digests. Digests are stored in cache so
+they survive restarts and are sent by flush running every
+MAIL_DIGEST_FLUSH_INTERVAL.
+
+Every notification is stored under its own cache key by sequence number from
+atomic Incr (same as realtime replay buffer) and so is its slot in pending
+index, so digesters of multiple instances never overwrite each other's
+notifications. Notifications are removed only after digest was delivered to
+smtp server, failed delivery is retried by next flush.
+*/
+type Digester struct {
+	MinDelay time.Duration
+	MaxDelay time.Duration
+
+	context *context.Context
+	mailer  *Mailer
+	mutex   sync.Mutex
+	once    sync.Once
+
+	// keys missing in last flush, key missing in two flushes in a row was
+	// abandoned (instance failed between Incr and Set)
+	missing map[string]bool
+}
+
+// returns digester configured by digest settings
+func NewDigester(context *context.Context, mailer *Mailer, funcs ...func(*Digester)) (digester *Digester) {
+	digester = &Digester{
+		MinDelay: settings.SETTINGS_DIGEST_MIN_DELAY,
+		MaxDelay: settings.SETTINGS_DIGEST_MAX_DELAY,
+		context:  context,
+		mailer:   mailer,
+		missing:  map[string]bool{},
+	}
+	for _, f := range funcs {
+		f(digester)
+	}
+	return
+}
+
+// digests are disabled when min delay is not set
+func (d *Digester) Enabled() bool {
+	return d.MinDelay > 0
+}
+
+/*
+Adds eventgroup to digests of all recipients and starts flushing in
+background
+*/
+func (d *Digester) Add(project *models.Project, eventgroup *models.EventGroup, recipients []string) (err error) {
+	d.once.Do(func() { go d.run() })
+
+	now := time.Now()
+
+	for _, recipient := range recipients {
+		key := DigestKey(project.ID.ToForeignKey(), recipient)
+		entry := &digestEntry{
+			ProjectID:   project.ID.ToForeignKey(),
+			ProjectName: project.Name,
+			Recipient:   recipient,
+			Item:        NewDigestItem(eventgroup),
+			At:          now,
+		}
+
+		var sequence int64
+		if sequence, err = d.store(key, entry); err != nil {
+			return
+		}
+
+		// slot is stored after notification, so flush finds it
+		if _, err = d.store(DIGEST_PENDING_CACHE_KEY, &digestSlot{Key: key, Sequence: sequence}); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+/*
+Sends all due digests, digests not yet due stay in cache. Pending index is
+read from last flushed slot, slots of sent notifications are removed.
+*/
+func (d *Digester) Flush(now time.Time) (err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var sequence, flushed int64
+	if models.GetCached(d.context, digestSequenceKey(DIGEST_PENDING_CACHE_KEY), &sequence) != nil {
+		// nothing added yet
+		return
+	}
+	models.GetCached(d.context, digestFlushedKey(), &flushed)
+
+	missing := map[string]bool{}
+	slots := map[int64]*digestSlot{}
+
+	// last pending notification of every digest
+	last := map[string]int64{}
+
+	for id := flushed + 1; id <= sequence; id++ {
+		key := digestEntryKey(DIGEST_PENDING_CACHE_KEY, id)
+		slot := &digestSlot{}
+		if models.GetCached(d.context, key, slot) != nil {
+			missing[key] = true
+			continue
+		}
+		slots[id] = slot
+		if slot.Sequence > last[slot.Key] {
+			last[slot.Key] = slot.Sequence
+		}
+	}
+
+	sent := map[string]int64{}
+	for key, upto := range last {
+		if sent[key], err = d.flushDigest(key, upto, now, missing); err != nil {
+			return
+		}
+	}
+
+	// remove slots of sent notifications and move behind them
+	moving := true
+	for id := flushed + 1; id <= sequence; id++ {
+		key := digestEntryKey(DIGEST_PENDING_CACHE_KEY, id)
+		slot, ok := slots[id]
+		done := !ok && d.missing[key]
+		if ok && slot.Sequence <= sent[slot.Key] {
+			models.RemoveCached(d.context, key)
+			done = true
+		}
+		if moving = moving && done; moving {
+			flushed = id
+		}
+	}
+
+	d.missing = missing
+	return models.Cache(d.context, digestFlushedKey(), flushed)
+}
+
+/*
+Sends digest of notifications up to sequence when it is due and returns
+sequence of last sent notification. Notification still being added stops
+digest, so it is sent by next flush.
+*/
+func (d *Digester) flushDigest(key string, upto int64, now time.Time, missing map[string]bool) (sent int64, err error) {
+	models.GetCached(d.context, digestSentKey(key), &sent)
+
+	var digest *Digest
+	last := sent
+
+	for sequence := sent + 1; sequence <= upto; sequence++ {
+		entryKey := digestEntryKey(key, sequence)
+		entry := &digestEntry{}
+		if models.GetCached(d.context, entryKey, entry) != nil {
+			missing[entryKey] = true
+			if !d.missing[entryKey] {
+				break
+			}
+			last = sequence
+			continue
+		}
+
+		if digest == nil {
+			digest = &Digest{
+				ProjectID: entry.ProjectID,
+				Recipient: entry.Recipient,
+			}
+		}
+		digest.ProjectName = entry.ProjectName
+		digest.AddItem(entry.Item, entry.At)
+		last = sequence
+	}
+
+	if digest != nil {
+		if !digest.IsDue(now, d.MinDelay, d.MaxDelay) {
+			return
+		}
+
+		var message *Message
+		if message, err = NewDigestMessage(digest); err != nil {
+			glog.Errorf("notifications: cannot render digest %s: %s.", key, err)
+		} else if err = d.mailer.Deliver(message); err != nil {
+			// keep digest for next flush
+			glog.Errorf("notifications: cannot deliver digest %s: %s.", key, err)
+			return sent, nil
+		}
+	}
+
+	if last == sent {
+		return
+	}
+
+	if err = models.Cache(d.context, digestSentKey(key), last); err != nil {
+		return
+	}
+	for sequence := sent + 1; sequence <= last; sequence++ {
+		models.RemoveCached(d.context, digestEntryKey(key, sequence))
+	}
+
+	return last, nil
+}
+
+// stores value under next sequence number of key
+func (d *Digester) store(key string, value interface{}) (sequence int64, err error) {
+	var id int
+	if id, err = d.context.Cache.Incr(digestSequenceKey(key)); err != nil {
+		return
+	}
+	sequence = int64(id)
+	err = models.Cache(d.context, digestEntryKey(key, sequence), value)
+	return
+}
+
+// flushes digests until context quits
+func (d *Digester) run() {
+	for {
+		select {
+		case <-d.context.Quit:
+			return
+		case <-time.After(settings.MAIL_DIGEST_FLUSH_INTERVAL):
+			if err := d.Flush(time.Now()); err != nil {
+				glog.Errorf("notifications: digest flush failed: %s.", err)
+			}
+		}
+	}
+}
+
+// cache key with sequence of notifications of digest (or pending slots)
+func digestSequenceKey(key string) string {
+	return key + ":sequence"
+}
+
+// cache key with notification of digest (or pending slot)
+func digestEntryKey(key string, sequence int64) string {
+	return key + ":" + strconv.FormatInt(sequence, 10)
+}
+
+// cache key with sequence of last sent notification of digest
+func digestSentKey(key string) string {
+	return key + ":sent"
+}
+
+// cache key with last flushed slot of pending index
+func digestFlushedKey() string {
+	return DIGEST_PENDING_CACHE_KEY + ":flushed"
+}
@@ -0,0 +1,210 @@
+package notifications
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryCache is in memory cache used instead of redis in tests
+type memoryCache struct {
+	data  map[string][]byte
+	mutex sync.Mutex
+}
+
+func (m *memoryCache) Close() error { return nil }
+func (m *memoryCache) Delete(key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.data, key)
+	return nil
+}
+func (m *memoryCache) Get(key string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	value, ok := m.data[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return value, nil
+}
+func (m *memoryCache) Incr(key string) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	value, _ := strconv.Atoi(string(m.data[key]))
+	value++
+	m.data[key] = []byte(strconv.Itoa(value))
+	return value, nil
+}
+func (m *memoryCache) Set(key string, value []byte, expiration ...time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.data[key] = value
+	return nil
+}
+
+func TestDigest(t *testing.T) {
+
+	address, received, closer := fakeSMTPServer(t)
+	defer closer()
+
+	ctx := &context.Context{
+		Cache: &memoryCache{data: map[string][]byte{}},
+		Quit:  make(chan struct{}),
+	}
+	defer close(ctx.Quit)
+
+	mailer := NewMailer(ctx, func(m *Mailer) {
+		m.Host = address
+		m.Username = ""
+	})
+
+	project := models.NewProject(func(p *models.Project) {
+		p.ID = 1
+		p.Name = "web"
+	})
+	first := models.NewEventGroup(func(eg *models.EventGroup) {
+		eg.ID = 1
+		eg.ProjectID = 1
+		eg.Message = "first error"
+		eg.TimesSeen = 1
+	})
+	second := models.NewEventGroup(func(eg *models.EventGroup) {
+		eg.ID = 2
+		eg.ProjectID = 1
+		eg.Message = "second error"
+		eg.TimesSeen = 7
+		eg.IsRegression = true
+	})
+
+	Convey("Test digest is due", t, func() {
+		now := time.Now()
+		digest := &Digest{}
+		digest.Add(first, now.Add(-10*time.Minute))
+		digest.Add(first, now.Add(-time.Minute))
+
+		So(len(digest.Items), ShouldEqual, 1)
+		So(digest.Items[0].Count, ShouldEqual, 2)
+		So(digest.IsDue(now, 5*time.Minute, 30*time.Minute), ShouldBeFalse)
+		So(digest.IsDue(now, 30*time.Second, 30*time.Minute), ShouldBeTrue)
+		So(digest.IsDue(now, 5*time.Minute, 10*time.Minute), ShouldBeTrue)
+	})
+
+	Convey("Test digests are buffered and flushed", t, func() {
+		digester := NewDigester(ctx, mailer, func(d *Digester) {
+			d.MinDelay = 5 * time.Minute
+			d.MaxDelay = 30 * time.Minute
+		})
+		So(digester.Enabled(), ShouldBeTrue)
+
+		recipients := []string{"john@example.com", "jane@example.com"}
+		So(digester.Add(project, first, recipients), ShouldBeNil)
+		So(digester.Add(project, second, recipients), ShouldBeNil)
+		So(digester.Add(project, first, recipients[:1]), ShouldBeNil)
+
+		// nothing is due yet
+		So(digester.Flush(time.Now()), ShouldBeNil)
+		var flushed int64
+		So(models.GetCached(ctx, digestFlushedKey(), &flushed), ShouldBeNil)
+		So(flushed, ShouldEqual, 0)
+
+		// digests survive in cache for new digester (e.g. after restart)
+		restarted := NewDigester(ctx, mailer, func(d *Digester) {
+			d.MinDelay = 5 * time.Minute
+			d.MaxDelay = 30 * time.Minute
+		})
+		So(restarted.Flush(time.Now().Add(10*time.Minute)), ShouldBeNil)
+		So(models.GetCached(ctx, digestFlushedKey(), &flushed), ShouldBeNil)
+		So(flushed, ShouldEqual, 5)
+		So(models.GetCached(ctx, digestEntryKey(DIGEST_PENDING_CACHE_KEY, 5), &digestSlot{}), ShouldNotBeNil)
+		So(models.GetCached(ctx, digestEntryKey(DigestKey(1, "john@example.com"), 1), &digestEntry{}), ShouldNotBeNil)
+
+		bodies := []string{}
+		for i := 0; i < 2; i++ {
+			select {
+			case body := <-received:
+				bodies = append(bodies, body)
+			case <-time.After(5 * time.Second):
+				t.Fatal("digest was not delivered")
+			}
+		}
+		for _, body := range bodies {
+			So(body, ShouldContainSubstring, "Subject: [web] 2 issues")
+			So(body, ShouldContainSubstring, "first error")
+			So(body, ShouldContainSubstring, "second error")
+		}
+	})
+
+	Convey("Test digest is kept until delivered", t, func() {
+		failing := NewMailer(ctx, func(m *Mailer) {
+			m.Host = "127.0.0.1:1"
+			m.Username = ""
+		})
+		digester := NewDigester(ctx, failing, func(d *Digester) {
+			d.MinDelay = 5 * time.Minute
+			d.MaxDelay = 30 * time.Minute
+		})
+		So(digester.Add(project, first, []string{"kept@example.com"}), ShouldBeNil)
+
+		// smtp server refuses connection, digest stays in cache
+		So(digester.Flush(time.Now().Add(10*time.Minute)), ShouldBeNil)
+		So(models.GetCached(ctx, digestEntryKey(DigestKey(1, "kept@example.com"), 1), &digestEntry{}), ShouldBeNil)
+
+		digester.mailer = mailer
+		So(digester.Flush(time.Now().Add(10*time.Minute)), ShouldBeNil)
+		select {
+		case body := <-received:
+			So(body, ShouldContainSubstring, "first error")
+		case <-time.After(5 * time.Second):
+			t.Fatal("digest was not delivered")
+		}
+		So(models.GetCached(ctx, digestEntryKey(DigestKey(1, "kept@example.com"), 1), &digestEntry{}), ShouldNotBeNil)
+	})
+
+	Convey("Test concurrent digesters do not lose notifications", t, func() {
+		digesters := []*Digester{}
+		for i := 0; i < 4; i++ {
+			digesters = append(digesters, NewDigester(ctx, mailer, func(d *Digester) {
+				d.MinDelay = 5 * time.Minute
+				d.MaxDelay = 30 * time.Minute
+			}))
+		}
+
+		wg := sync.WaitGroup{}
+		for i, digester := range digesters {
+			wg.Add(1)
+			go func(i int, digester *Digester) {
+				defer wg.Done()
+				eventgroup := models.NewEventGroup(func(eg *models.EventGroup) {
+					eg.ID = types.PrimaryKey(100 + i)
+					eg.ProjectID = 1
+					eg.Message = "concurrent error " + strconv.Itoa(i)
+				})
+				digester.Add(project, eventgroup, []string{"concurrent@example.com"})
+			}(i, digester)
+		}
+		wg.Wait()
+
+		So(digesters[0].Flush(time.Now().Add(10*time.Minute)), ShouldBeNil)
+		select {
+		case body := <-received:
+			So(body, ShouldContainSubstring, "Subject: [web] 4 issues")
+		case <-time.After(5 * time.Second):
+			t.Fatal("digest was not delivered")
+		}
+	})
+
+	Convey("Test digests disabled", t, func() {
+		digester := NewDigester(ctx, mailer, func(d *Digester) {
+			d.MinDelay = 0
+		})
+		So(digester.Enabled(), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,20 @@
+/*
+Package notifications provides e-mail notifications.
+
+Mails are delivered by Mailer in background goroutine, so callers (event
+workers) are never blocked by slow smtp server. Mailer works with any smtp
+server configured by smtp_* flags, e.g.
+
+	mailer := notifications.NewMailer(context)
+	mailer.Send(notifications.NewMessage(func(m *notifications.Message) {
+		m.To = []string{"john@example.com"}
+		m.Subject = "hello"
+		m.Text = "hello john"
+	}))
+
+Digester batches notifications of project for every recipient into single
+digest mail. Digests are kept in cache, so pending digests survive worker
+restart, and are flushed in background when no notification came for
+digest_min_delay or digest_max_delay passed since first notification.
+*/
+package notifications
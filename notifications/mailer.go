@@ -0,0 +1,100 @@
+package notifications
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+)
+
+var (
+	ErrMailQueueFull    = errors.New("mail queue is full")
+	ErrMailNoRecipients = errors.New("mail has no recipients")
+)
+
+/*
+Mailer delivers messages to smtp server
+*/
+type Mailer struct {
+	Host     string
+	Username string
+	Password string
+
+	context *context.Context
+	queue   chan *Message
+	once    sync.Once
+}
+
+// returns mailer configured by smtp settings
+func NewMailer(context *context.Context, funcs ...func(*Mailer)) (mailer *Mailer) {
+	mailer = &Mailer{
+		Host:     settings.SETTINGS_SMTP_HOST,
+		Username: settings.SETTINGS_SMTP_USERNAME,
+		Password: settings.SETTINGS_SMTP_PASSWORD,
+		context:  context,
+		queue:    make(chan *Message, settings.MAIL_QUEUE_SIZE),
+	}
+	for _, f := range funcs {
+		f(mailer)
+	}
+	return
+}
+
+/*
+Queues message for delivery in background, never blocks. When queue is full
+message is dropped and ErrMailQueueFull is returned.
+*/
+func (m *Mailer) Send(message *Message) error {
+	if len(message.To) == 0 {
+		return ErrMailNoRecipients
+	}
+
+	m.once.Do(func() { go m.run() })
+
+	select {
+	case m.queue <- message:
+		return nil
+	default:
+		return ErrMailQueueFull
+	}
+}
+
+/*
+Delivers message to smtp server immediately
+*/
+func (m *Mailer) Deliver(message *Message) error {
+	if len(message.To) == 0 {
+		return ErrMailNoRecipients
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Host)
+		if err != nil {
+			host = m.Host
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	return smtp.SendMail(m.Host, auth, message.From, message.To, message.Bytes())
+}
+
+// delivers queued messages until context quits
+func (m *Mailer) run() {
+	for {
+		select {
+		case <-m.context.Quit:
+			return
+		case message := <-m.queue:
+			if err := m.Deliver(message); err != nil {
+				glog.Errorf("mailer: delivery of \"%s\" to %v failed: %s.", message.Subject, message.To, err)
+				continue
+			}
+			glog.V(2).Infof("mailer: delivered \"%s\" to %v.", message.Subject, message.To)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package notifications
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/parser"
+	"github.com/phonkee/patrol/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+/*
+fakeSMTPServer accepts connections and speaks just enough smtp to receive
+mails, received mail bodies are sent to channel
+*/
+func fakeSMTPServer(t *testing.T) (address string, received chan string, closer func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	received = make(chan string, 10)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeSMTP(conn, received)
+		}
+	}()
+
+	return listener.Addr().String(), received, func() { listener.Close() }
+}
+
+func serveFakeSMTP(conn net.Conn, received chan string) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost fake smtp")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "DATA"):
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			body := []string{}
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				body = append(body, dataLine)
+			}
+			received <- strings.Join(body, "")
+			reply("250 OK")
+		case strings.HasPrefix(command, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestMailer(t *testing.T) {
+
+	address, received, closer := fakeSMTPServer(t)
+	defer closer()
+
+	ctx := &context.Context{Quit: make(chan struct{})}
+	defer close(ctx.Quit)
+
+	mailer := NewMailer(ctx, func(m *Mailer) {
+		m.Host = address
+		m.Username = ""
+	})
+
+	Convey("Test deliver message", t, func() {
+		message := NewMessage(func(m *Message) {
+			m.From = "patrol@example.com"
+			m.To = []string{"john@example.com"}
+			m.Subject = "hello"
+			m.Text = "plain body"
+			m.HTML = "<p>html body</p>"
+		})
+		So(mailer.Deliver(message), ShouldBeNil)
+
+		body := <-received
+		So(body, ShouldContainSubstring, "Subject: hello")
+		So(body, ShouldContainSubstring, "To: john@example.com")
+		So(body, ShouldContainSubstring, "multipart/alternative")
+		So(body, ShouldContainSubstring, "plain body")
+		So(body, ShouldContainSubstring, "<p>html body</p>")
+	})
+
+	Convey("Test send message in background", t, func() {
+		message := NewMessage(func(m *Message) {
+			m.To = []string{"jane@example.com"}
+			m.Subject = "background"
+			m.Text = "queued body"
+		})
+		So(mailer.Send(message), ShouldBeNil)
+
+		select {
+		case body := <-received:
+			So(body, ShouldContainSubstring, "Subject: background")
+			So(body, ShouldContainSubstring, "text/plain")
+			So(body, ShouldContainSubstring, "queued body")
+		case <-time.After(5 * time.Second):
+			t.Fatal("message was not delivered")
+		}
+	})
+
+	Convey("Test message without recipients", t, func() {
+		So(mailer.Send(NewMessage()), ShouldEqual, ErrMailNoRecipients)
+		So(mailer.Deliver(NewMessage()), ShouldEqual, ErrMailNoRecipients)
+	})
+}
+
+func TestEventGroupMessage(t *testing.T) {
+
+	Convey("Test stack summary", t, func() {
+		data := types.GzippedMap{
+			"interfaces": []parser.EventParserInterfacer{
+				&parser.HttpInterfaceV4{
+					PatrolInterface: parser.PatrolInterface{ID: "http"},
+					URL:             "http://example.com",
+				},
+				&parser.ExceptionInterfaceV4{
+					PatrolInterface: parser.PatrolInterface{ID: "exception"},
+					Type:            "ValueError",
+					Value:           "bad value",
+					Stacktrace: &parser.StacktraceInterfaceV4{
+						Frames: []parser.StacktraceFrameV4{
+							{Filename: "a.py", Lineno: 1, Function: "a"},
+							{Filename: "b.py", Lineno: 2, Function: "b"},
+							{Filename: "c.py", Lineno: 3, Function: "c"},
+						},
+					},
+				},
+			},
+		}
+
+		So(StackSummary(data, 2), ShouldResemble, []string{
+			`File "b.py", line 2, in b`,
+			`File "c.py", line 3, in c`,
+			"ValueError: bad value",
+		})
+		So(StackSummary(types.GzippedMap{}, 2), ShouldBeEmpty)
+	})
+
+	Convey("Test render eventgroup message", t, func() {
+		project := models.NewProject(func(p *models.Project) {
+			p.ID = 1
+			p.Name = "web"
+		})
+		eventgroup := models.NewEventGroup(func(eg *models.EventGroup) {
+			eg.ID = 2
+			eg.ProjectID = 1
+			eg.Message = "division by <zero>"
+			eg.Culprit = "views.index"
+			eg.IsRegression = true
+		})
+		event := &models.Event{Data: types.GzippedMap{}}
+
+		message, err := NewEventGroupMessage(project, event, eventgroup, []string{"john@example.com"})
+		So(err, ShouldBeNil)
+		So(message.To, ShouldResemble, []string{"john@example.com"})
+		So(message.Subject, ShouldEqual, "[web] Regression: division by <zero>")
+		So(message.Text, ShouldContainSubstring, "Culprit: views.index")
+		So(message.Text, ShouldContainSubstring, EventGroupLink(eventgroup))
+		So(message.HTML, ShouldContainSubstring, "division by &lt;zero&gt;")
+		So(EventGroupLink(eventgroup), ShouldEndWith, "/api/projects/project/1/eventgroup/2")
+	})
+}
@@ -0,0 +1,91 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/phonkee/patrol/settings"
+)
+
+/*
+Message is single e-mail, when both Text and HTML are set message is sent as
+multipart/alternative.
+*/
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// returns new message with sender from settings
+func NewMessage(funcs ...func(*Message)) (message *Message) {
+	message = &Message{
+		From: settings.SETTINGS_SMTP_FROM,
+		To:   []string{},
+	}
+	for _, f := range funcs {
+		f(message)
+	}
+	return
+}
+
+/*
+Returns message encoded as MIME with headers
+*/
+func (m *Message) Bytes() []byte {
+	buffer := &bytes.Buffer{}
+
+	fmt.Fprintf(buffer, "From: %s\r\n", m.From)
+	fmt.Fprintf(buffer, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(buffer, "MIME-Version: 1.0\r\n")
+
+	// single part message
+	if m.HTML == "" {
+		fmt.Fprintf(buffer, "Content-Type: text/plain; charset=utf-8\r\n")
+		fmt.Fprintf(buffer, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		writeQuotedPrintable(buffer, m.Text)
+		return buffer.Bytes()
+	}
+
+	writer := multipart.NewWriter(buffer)
+	fmt.Fprintf(buffer, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	}
+	for _, part := range parts {
+		pw, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			continue
+		}
+		writeQuotedPrintable(pw, part.body)
+	}
+	writer.Close()
+
+	return buffer.Bytes()
+}
+
+// writes body encoded as quoted printable
+func writeQuotedPrintable(w io.Writer, body string) {
+	qw := quotedprintable.NewWriter(w)
+	qw.Write([]byte(body))
+	qw.Close()
+}
@@ -0,0 +1,92 @@
+package notifications
+
+import (
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+)
+
+/*
+Recipient is project member who wants to be notified, with notification
+settings (enabled channels)
+*/
+type Recipient struct {
+	User    *models.User
+	Setting *models.NotificationSetting
+}
+
+/*
+Returns whether user wants notification about eventgroup.
+
+	explicit subscription (or unsubscription) of eventgroup always wins,
+	otherwise new issues are notified only in NOTIFICATION_MODE_ALL and
+	other notifications (e.g. resolved) only to subscribed users.
+	User is never notified about own actions unless opted in.
+*/
+func Wants(user *models.User, setting *models.NotificationSetting, mode string, subscription *models.EventGroupSubscription, actor *models.User, newIssue bool) bool {
+	if actor != nil && actor.ID == user.ID && !setting.OwnActions {
+		return false
+	}
+	if subscription != nil {
+		return subscription.Subscribed
+	}
+	return newIssue && mode == models.NOTIFICATION_MODE_ALL
+}
+
+/*
+Returns active project members who want notification about eventgroup.
+Actor is user who caused notification (nil for new events).
+*/
+func Recipients(context *context.Context, project *models.Project, eventgroup *models.EventGroup, actor *models.User, newIssue bool) (result []*Recipient, err error) {
+	userManager := models.NewUserManager(context)
+	users := userManager.NewUserList()
+	if err = userManager.Filter(&users,
+		userManager.QueryFilterProjectMember(project),
+		userManager.QueryFilterWhere(models.AUTH_USER_DB_TABLE+".is_active = ?", true),
+	); err != nil {
+		return
+	}
+
+	// explicit subscriptions of eventgroup by user
+	subscriptionManager := models.NewEventGroupSubscriptionManager(context)
+	subscriptions := subscriptionManager.NewEventGroupSubscriptionList()
+	if err = subscriptionManager.Filter(&subscriptions, subscriptionManager.QueryFilterEventGroup(eventgroup)); err != nil {
+		return
+	}
+	byUser := map[int64]*models.EventGroupSubscription{}
+	for _, subscription := range subscriptions {
+		byUser[subscription.UserID.Int64()] = subscription
+	}
+
+	settingManager := models.NewNotificationSettingManager(context)
+	projectSettingManager := models.NewProjectNotificationSettingManager(context)
+
+	result = []*Recipient{}
+	for _, user := range users {
+		setting := settingManager.NewNotificationSetting()
+		if err = settingManager.GetByUser(setting, user); err != nil {
+			return
+		}
+
+		projectSetting := projectSettingManager.NewProjectNotificationSetting()
+		if err = projectSettingManager.GetByUserProject(projectSetting, user, project); err != nil {
+			return
+		}
+
+		if Wants(user, setting, projectSetting.Mode, byUser[user.ID.Int64()], actor, newIssue) {
+			result = append(result, &Recipient{User: user, Setting: setting})
+		}
+	}
+
+	return
+}
+
+// returns e-mail addresses of recipients with e-mail channel enabled
+func EmailAddresses(recipients []*Recipient) (result []string) {
+	result = []string{}
+	for _, recipient := range recipients {
+		if recipient.Setting.Email && recipient.User.Email != "" {
+			result = append(result, recipient.User.Email)
+		}
+	}
+	return
+}
@@ -0,0 +1,61 @@
+package notifications
+
+import (
+	"testing"
+
+	"github.com/phonkee/patrol/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPreferences(t *testing.T) {
+
+	user := models.NewUser(func(u *models.User) {
+		u.ID = 1
+		u.Email = "john@example.com"
+	})
+	other := models.NewUser(func(u *models.User) {
+		u.ID = 2
+	})
+	setting := models.NewNotificationSetting()
+
+	subscribed := models.NewEventGroupSubscription()
+	unsubscribed := models.NewEventGroupSubscription(func(egs *models.EventGroupSubscription) {
+		egs.Subscribed = false
+	})
+
+	Convey("Test notification modes for new issues", t, func() {
+		So(Wants(user, setting, models.NOTIFICATION_MODE_ALL, nil, nil, true), ShouldBeTrue)
+		So(Wants(user, setting, models.NOTIFICATION_MODE_ASSIGNED, nil, nil, true), ShouldBeFalse)
+		So(Wants(user, setting, models.NOTIFICATION_MODE_NONE, nil, nil, true), ShouldBeFalse)
+	})
+
+	Convey("Test subscription overrides notification mode", t, func() {
+		So(Wants(user, setting, models.NOTIFICATION_MODE_NONE, subscribed, nil, true), ShouldBeTrue)
+		So(Wants(user, setting, models.NOTIFICATION_MODE_ALL, unsubscribed, nil, true), ShouldBeFalse)
+		So(Wants(user, setting, models.NOTIFICATION_MODE_ALL, nil, other, false), ShouldBeFalse)
+		So(Wants(user, setting, models.NOTIFICATION_MODE_ALL, subscribed, other, false), ShouldBeTrue)
+	})
+
+	Convey("Test own actions", t, func() {
+		So(Wants(user, setting, models.NOTIFICATION_MODE_ALL, subscribed, user, false), ShouldBeFalse)
+
+		own := models.NewNotificationSetting(func(ns *models.NotificationSetting) {
+			ns.OwnActions = true
+		})
+		So(Wants(user, own, models.NOTIFICATION_MODE_ALL, subscribed, user, false), ShouldBeTrue)
+	})
+
+	Convey("Test channels", t, func() {
+		disabled := models.NewNotificationSetting(func(ns *models.NotificationSetting) {
+			ns.Email = false
+			ns.Webhook = true
+		})
+		So(disabled.WebhookEnabled(), ShouldBeFalse)
+
+		recipients := []*Recipient{
+			{User: user, Setting: setting},
+			{User: user, Setting: disabled},
+		}
+		So(EmailAddresses(recipients), ShouldResemble, []string{"john@example.com"})
+	})
+}
@@ -0,0 +1,223 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/parser"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+)
+
+var (
+	eventGroupSubjectTemplate = texttemplate.Must(texttemplate.New("subject").Parse(
+		`[{{ .Project.Name }}] {{ if .Resolved }}Resolved{{ else if .Regression }}Regression{{ else }}New issue{{ end }}: {{ .EventGroup.Message }}`,
+	))
+
+	eventGroupTextTemplate = texttemplate.Must(texttemplate.New("text").Parse(
+		`{{ if .Resolved }}Issue was resolved{{ else if .Regression }}Resolved issue occured again{{ else }}New issue{{ end }} in project {{ .Project.Name }}.
+
+Message: {{ .EventGroup.Message }}
+{{ if .EventGroup.Culprit }}Culprit: {{ .EventGroup.Culprit }}
+{{ end }}{{ if .Stack }}
+Stacktrace (most recent call last):
+{{ range .Stack }}  {{ . }}
+{{ end }}{{ end }}
+{{ .Link }}
+`))
+
+	eventGroupHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(
+		`<html>
+<body>
+<p>{{ if .Resolved }}Issue was resolved{{ else if .Regression }}Resolved issue occured again{{ else }}New issue{{ end }} in project <strong>{{ .Project.Name }}</strong>.</p>
+<h2>{{ .EventGroup.Message }}</h2>
+{{ if .EventGroup.Culprit }}<p>Culprit: <code>{{ .EventGroup.Culprit }}</code></p>
+{{ end }}{{ if .Stack }}<p>Stacktrace (most recent call last):</p>
+<pre>{{ range .Stack }}{{ . }}
+{{ end }}</pre>
+{{ end }}<p><a href="{{ .Link }}">{{ .Link }}</a></p>
+</body>
+</html>
+`))
+
+	digestSubjectTemplate = texttemplate.Must(texttemplate.New("subject").Parse(
+		`[{{ .ProjectName }}] {{ len .Items }} {{ if eq (len .Items) 1 }}issue{{ else }}issues{{ end }}`,
+	))
+
+	digestTextTemplate = texttemplate.Must(texttemplate.New("text").Parse(
+		`Issues in project {{ .ProjectName }}:
+{{ range .Items }}
+{{ if .Resolved }}[resolved]{{ else if .Regression }}[regression]{{ else }}[new]{{ end }} {{ .Message }}
+{{ if .Culprit }}  Culprit: {{ .Culprit }}
+{{ end }}  Seen {{ .TimesSeen }} times, notified {{ .Count }} times
+  {{ .Link }}
+{{ end }}`))
+
+	digestHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(
+		`<html>
+<body>
+<p>Issues in project <strong>{{ .ProjectName }}</strong>:</p>
+<table>
+<tr><th></th><th>Issue</th><th>Seen</th><th>Notified</th></tr>
+{{ range .Items }}<tr>
+<td>{{ if .Resolved }}resolved{{ else if .Regression }}regression{{ else }}new{{ end }}</td>
+<td><a href="{{ .Link }}">{{ .Message }}</a>{{ if .Culprit }}<br><code>{{ .Culprit }}</code>{{ end }}</td>
+<td>{{ .TimesSeen }}</td>
+<td>{{ .Count }}</td>
+</tr>
+{{ end }}</table>
+</body>
+</html>
+`))
+)
+
+/*
+EventGroupMailData is passed to eventgroup mail templates
+*/
+type EventGroupMailData struct {
+	Project    *models.Project
+	EventGroup *models.EventGroup
+	Regression bool
+	Resolved   bool
+	Stack      []string
+	Link       string
+}
+
+/*
+Returns message about new, regressed or resolved eventgroup rendered from
+templates, event is nil for resolved eventgroup.
+*/
+func NewEventGroupMessage(project *models.Project, event *models.Event, eventgroup *models.EventGroup, to []string) (message *Message, err error) {
+	data := &EventGroupMailData{
+		Project:    project,
+		EventGroup: eventgroup,
+		Regression: eventgroup.IsRegression,
+		Resolved:   eventgroup.Status == models.EVENT_GROUP_STATUS_RESOLVED,
+		Link:       EventGroupLink(eventgroup),
+	}
+	if event != nil {
+		data.Stack = StackSummary(event.Data, settings.MAIL_STACKTRACE_FRAMES)
+	}
+
+	message = NewMessage(func(m *Message) {
+		m.To = to
+	})
+
+	buffer := &bytes.Buffer{}
+	if err = eventGroupSubjectTemplate.Execute(buffer, data); err != nil {
+		return
+	}
+	message.Subject = strings.Join(strings.Fields(buffer.String()), " ")
+
+	buffer.Reset()
+	if err = eventGroupTextTemplate.Execute(buffer, data); err != nil {
+		return
+	}
+	message.Text = buffer.String()
+
+	buffer.Reset()
+	if err = eventGroupHTMLTemplate.Execute(buffer, data); err != nil {
+		return
+	}
+	message.HTML = buffer.String()
+
+	return
+}
+
+/*
+Returns digest message listing all eventgroups of digest
+*/
+func NewDigestMessage(digest *Digest) (message *Message, err error) {
+	message = NewMessage(func(m *Message) {
+		m.To = []string{digest.Recipient}
+	})
+
+	buffer := &bytes.Buffer{}
+	if err = digestSubjectTemplate.Execute(buffer, digest); err != nil {
+		return
+	}
+	message.Subject = strings.Join(strings.Fields(buffer.String()), " ")
+
+	buffer.Reset()
+	if err = digestTextTemplate.Execute(buffer, digest); err != nil {
+		return
+	}
+	message.Text = buffer.String()
+
+	buffer.Reset()
+	if err = digestHTMLTemplate.Execute(buffer, digest); err != nil {
+		return
+	}
+	message.HTML = buffer.String()
+
+	return
+}
+
+// returns absolute link to eventgroup
+func EventGroupLink(eventgroup *models.EventGroup) string {
+	return fmt.Sprintf("%s/api/projects/project/%d/eventgroup/%d",
+		strings.TrimRight(settings.SETTINGS_BASE_URL, "/"),
+		eventgroup.ProjectID.Int64(), eventgroup.ID.Int64(),
+	)
+}
+
+/*
+Returns exception line followed by last frames of stacktrace from event data.
+Interfaces are decoded from json since event data could be read from queue
+or database.
+*/
+func StackSummary(data types.GzippedMap, frames int) (result []string) {
+	result = []string{}
+
+	body, err := json.Marshal(data["interfaces"])
+	if err != nil {
+		return
+	}
+
+	ifs := []json.RawMessage{}
+	if err = json.Unmarshal(body, &ifs); err != nil {
+		return
+	}
+
+	for _, raw := range ifs {
+		iface := &parser.ExceptionInterfaceV4{}
+		if err = json.Unmarshal(raw, iface); err != nil || iface.ID != "exception" {
+			continue
+		}
+
+		if iface.Stacktrace != nil {
+			stack := iface.Stacktrace.Frames
+			if len(stack) > frames {
+				stack = stack[len(stack)-frames:]
+			}
+			for _, frame := range stack {
+				result = append(result, formatFrame(frame))
+			}
+		}
+
+		if iface.Type != "" || iface.Value != "" {
+			result = append(result, strings.TrimPrefix(iface.Type+": "+iface.Value, ": "))
+		}
+		break
+	}
+
+	return
+}
+
+// returns single line representation of frame
+func formatFrame(frame parser.StacktraceFrameV4) string {
+	location := frame.Filename
+	if location == "" {
+		location = frame.Module
+	}
+	line := fmt.Sprintf("File \"%s\", line %d", location, frame.Lineno)
+	if frame.Function != "" {
+		line += ", in " + frame.Function
+	}
+	return line
+}
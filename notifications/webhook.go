@@ -0,0 +1,46 @@
+package notifications
+
+import (
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/webhooks"
+)
+
+/*
+WebhookPayload is posted to personal webhook of user (webhook channel)
+*/
+type WebhookPayload struct {
+	Event      string             `json:"event"`
+	ProjectID  int64              `json:"project_id"`
+	Project    string             `json:"project"`
+	EventGroup *models.EventGroup `json:"eventgroup"`
+	Link       string             `json:"link"`
+}
+
+// returns payload about eventgroup, event is one of models.WEBHOOK_EVENT_*
+func NewWebhookPayload(event string, project *models.Project, eventgroup *models.EventGroup) *WebhookPayload {
+	return &WebhookPayload{
+		Event:      event,
+		ProjectID:  project.ID.Int64(),
+		Project:    project.Name,
+		EventGroup: eventgroup,
+		Link:       EventGroupLink(eventgroup),
+	}
+}
+
+/*
+Queues payload to personal webhooks of recipients with webhook channel
+enabled. Deliveries are made by webhook worker with bounded concurrency.
+*/
+func PostWebhooks(context *context.Context, recipients []*Recipient, payload *WebhookPayload) {
+	dispatcher := webhooks.NewDispatcher(context)
+	for _, recipient := range recipients {
+		if !recipient.Setting.WebhookEnabled() {
+			continue
+		}
+		if err := dispatcher.DispatchURL(recipient.Setting.WebhookURL, payload.Event, payload); err != nil {
+			glog.Errorf("notifications: cannot queue %s to webhook of %s: %s.", payload.Event, recipient.User, err)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+/*
+Package oidc implements OpenID Connect single sign-on (authorization code flow
+with PKCE).
+
+Login view redirects browser to authorization endpoint of identity provider
+with random state, nonce and code challenge. State is stored in cache and
+bound to browser by cookie. Identity provider redirects back to callback view
+which exchanges code for id token, verifies it with keys of provider and
+creates or links user by verified e-mail address.
+
+Provider endpoints are discovered from issuer:
+
+	{issuer}/.well-known/openid-configuration
+*/
+package oidc
@@ -0,0 +1,214 @@
+/*
+Package oidctest provides stub OpenID Connect identity provider for tests.
+
+	server := oidctest.NewServer("client", "secret")
+	defer server.Close()
+
+	// simulate user consent, returns redirect to callback with code and state
+	callback, err := server.Authorize(authURL, map[string]interface{}{
+		"email":          "user@example.com",
+		"email_verified": true,
+	})
+*/
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrInvalidAuthRequest = errors.New("invalid authorization request")
+)
+
+/*
+Server is stub identity provider
+*/
+func NewServer(clientID, clientSecret string) (server *Server) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	server = &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Key:          key,
+		KeyID:        "test-key",
+		codes:        map[string]*authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", server.configuration)
+	mux.HandleFunc("/token", server.token)
+	mux.HandleFunc("/jwks", server.jwks)
+	server.Server = httptest.NewServer(mux)
+	return
+}
+
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+	Key          *rsa.PrivateKey
+	KeyID        string
+
+	codes map[string]*authorization
+	mutex sync.Mutex
+}
+
+type authorization struct {
+	nonce       string
+	challenge   string
+	redirectURI string
+	claims      map[string]interface{}
+}
+
+/*
+Simulates user consent at authorization endpoint. Returns url of callback with
+code and state. Claims are added to id token issued for code.
+*/
+func (s *Server) Authorize(authURL string, claims map[string]interface{}) (callback *url.URL, err error) {
+	var u *url.URL
+	if u, err = url.Parse(authURL); err != nil {
+		return
+	}
+
+	query := u.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != s.ClientID ||
+		query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		return nil, ErrInvalidAuthRequest
+	}
+
+	if callback, err = url.Parse(query.Get("redirect_uri")); err != nil {
+		return
+	}
+
+	code := randomString()
+
+	s.mutex.Lock()
+	s.codes[code] = &authorization{
+		nonce:       query.Get("nonce"),
+		challenge:   query.Get("code_challenge"),
+		redirectURI: query.Get("redirect_uri"),
+		claims:      claims,
+	}
+	s.mutex.Unlock()
+
+	values := callback.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	callback.RawQuery = values.Encode()
+	return
+}
+
+/*
+Returns signed id token with default claims (iss, aud, sub, iat, exp)
+overridden by given claims.
+*/
+func (s *Server) IDToken(claims map[string]interface{}) string {
+	token := jwt.New(jwt.SigningMethodRS256)
+	token.Header["kid"] = s.KeyID
+	token.Claims["iss"] = s.URL
+	token.Claims["aud"] = s.ClientID
+	token.Claims["sub"] = "subject"
+	token.Claims["iat"] = time.Now().Unix()
+	token.Claims["exp"] = time.Now().Add(time.Hour).Unix()
+	for key, value := range claims {
+		token.Claims[key] = value
+	}
+
+	result, err := token.SignedString(s.Key)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func (s *Server) configuration(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 s.URL,
+		"authorization_endpoint": s.URL + "/authorize",
+		"token_endpoint":         s.URL + "/token",
+		"jwks_uri":               s.URL + "/jwks",
+	})
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if id, secret, ok := r.BasicAuth(); !ok || id != s.ClientID || secret != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostFormValue("code")
+
+	s.mutex.Lock()
+	auth, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mutex.Unlock()
+
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("redirect_uri") != auth.redirectURI {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	// verify pkce
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "code verifier"})
+		return
+	}
+
+	claims := map[string]interface{}{"nonce": auth.nonce}
+	for key, value := range auth.claims {
+		claims[key] = value
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     s.IDToken(claims),
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kid": s.KeyID,
+				"kty": "RSA",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(s.Key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.Key.PublicKey.E)).Bytes()),
+			},
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,365 @@
+package oidc
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/phonkee/patrol/settings"
+)
+
+var (
+	ErrNotConfigured  = errors.New("oidc is not configured")
+	ErrInvalidIssuer  = errors.New("invalid issuer")
+	ErrInvalidIDToken = errors.New("invalid id token")
+	ErrUnknownKey     = errors.New("unknown signing key")
+	ErrRequestFailed  = errors.New("oidc request failed")
+)
+
+/*
+Configuration of provider returned by discovery endpoint
+*/
+type Configuration struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+/*
+Claims of verified id token
+*/
+type Claims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+/*
+Provider is OpenID Connect identity provider. Configuration and signing keys
+are fetched lazily and cached for OIDC_CONFIGURATION_TIMEOUT.
+*/
+func NewProvider(funcs ...func(*Provider)) (provider *Provider) {
+	provider = &Provider{
+		Issuer:         settings.SETTINGS_OIDC_ISSUER,
+		ClientID:       settings.SETTINGS_OIDC_CLIENT_ID,
+		ClientSecret:   settings.SETTINGS_OIDC_CLIENT_SECRET,
+		Scopes:         strings.Fields(settings.OIDC_SCOPES),
+		AllowedDomains: splitList(settings.SETTINGS_OIDC_ALLOWED_DOMAINS),
+		client:         &http.Client{Timeout: settings.OIDC_REQUEST_TIMEOUT},
+	}
+	for _, f := range funcs {
+		f(provider)
+	}
+	return
+}
+
+type Provider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// when not empty only users with e-mail in these domains can sign in
+	AllowedDomains []string
+
+	client     *http.Client
+	mutex      sync.Mutex
+	config     *Configuration
+	keys       map[string]*rsa.PublicKey
+	configured time.Time
+}
+
+// returns whether provider is configured
+func (p *Provider) Enabled() bool {
+	return p.Issuer != "" && p.ClientID != ""
+}
+
+/*
+Returns url of authorization endpoint where browser is redirected
+*/
+func (p *Provider) AuthCodeURL(state *State, redirectURL string) (result string, err error) {
+	var config *Configuration
+	if config, err = p.Configuration(); err != nil {
+		return
+	}
+
+	values := url.Values{}
+	values.Set("response_type", "code")
+	values.Set("client_id", p.ClientID)
+	values.Set("redirect_uri", redirectURL)
+	values.Set("scope", strings.Join(p.Scopes, " "))
+	values.Set("state", state.ID)
+	values.Set("nonce", state.Nonce)
+	values.Set("code_challenge", state.CodeChallenge())
+	values.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(config.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return config.AuthorizationEndpoint + separator + values.Encode(), nil
+}
+
+/*
+Exchanges authorization code for id token and verifies it
+*/
+func (p *Provider) Exchange(code string, state *State, redirectURL string) (claims *Claims, err error) {
+	var config *Configuration
+	if config, err = p.Configuration(); err != nil {
+		return
+	}
+
+	values := url.Values{}
+	values.Set("grant_type", "authorization_code")
+	values.Set("code", code)
+	values.Set("redirect_uri", redirectURL)
+	values.Set("client_id", p.ClientID)
+	values.Set("code_verifier", state.Verifier)
+
+	var request *http.Request
+	if request, err = http.NewRequest("POST", config.TokenEndpoint, strings.NewReader(values.Encode())); err != nil {
+		return
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+
+	response := struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{}
+	if err = p.do(request, &response); err != nil {
+		if response.Error != "" {
+			err = fmt.Errorf("%s: %s %s", ErrRequestFailed, response.Error, response.ErrorDescription)
+		}
+		return
+	}
+
+	return p.Verify(response.IDToken, state.Nonce)
+}
+
+/*
+Verifies signature and claims of id token
+*/
+func (p *Provider) Verify(raw, nonce string) (claims *Claims, err error) {
+	var config *Configuration
+	if config, err = p.Configuration(); err != nil {
+		return
+	}
+
+	parser := &jwt.Parser{ValidMethods: []string{"RS256"}}
+
+	var token *jwt.Token
+	if token, err = parser.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(kid)
+	}); err != nil {
+		return
+	}
+
+	// exp is validated by parser only when present
+	if _, ok := token.Claims["exp"]; !ok {
+		return nil, ErrInvalidIDToken
+	}
+	if iss, _ := token.Claims["iss"].(string); iss != config.Issuer {
+		return nil, ErrInvalidIDToken
+	}
+	if !hasAudience(token.Claims["aud"], p.ClientID) {
+		return nil, ErrInvalidIDToken
+	}
+	if azp, ok := token.Claims["azp"].(string); ok && azp != p.ClientID {
+		return nil, ErrInvalidIDToken
+	}
+	if value, _ := token.Claims["nonce"].(string); value == "" || value != nonce {
+		return nil, ErrInvalidIDToken
+	}
+
+	claims = &Claims{}
+	claims.Subject, _ = token.Claims["sub"].(string)
+	claims.Email, _ = token.Claims["email"].(string)
+	claims.Name, _ = token.Claims["name"].(string)
+	claims.PreferredUsername, _ = token.Claims["preferred_username"].(string)
+
+	// some providers send email_verified as string
+	switch verified := token.Claims["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = verified
+	case string:
+		claims.EmailVerified = verified == "true"
+	}
+
+	if claims.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	return
+}
+
+/*
+Returns configuration of provider, configuration is discovered from issuer
+*/
+func (p *Provider) Configuration() (config *Configuration, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.Enabled() {
+		return nil, ErrNotConfigured
+	}
+
+	issuer := strings.TrimSuffix(p.Issuer, "/")
+	if p.config != nil && strings.TrimSuffix(p.config.Issuer, "/") == issuer && time.Since(p.configured) < settings.OIDC_CONFIGURATION_TIMEOUT {
+		return p.config, nil
+	}
+
+	var request *http.Request
+	if request, err = http.NewRequest("GET", issuer+"/.well-known/openid-configuration", nil); err != nil {
+		return
+	}
+
+	config = &Configuration{}
+	if err = p.do(request, config); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(config.Issuer, "/") != issuer {
+		return nil, ErrInvalidIssuer
+	}
+
+	p.config = config
+	p.keys = nil
+	p.configured = time.Now()
+	return
+}
+
+/*
+Returns signing key by key id, keys are refetched when key is not known
+(provider rotated keys)
+*/
+func (p *Provider) key(kid string) (key *rsa.PublicKey, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if key = p.findKey(kid); key != nil {
+		return
+	}
+
+	var request *http.Request
+	if request, err = http.NewRequest("GET", p.config.JWKSURI, nil); err != nil {
+		return
+	}
+
+	jwks := struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}{}
+	if err = p.do(request, &jwks); err != nil {
+		return
+	}
+
+	p.keys = map[string]*rsa.PublicKey{}
+	for _, item := range jwks.Keys {
+		if item.Kty != "RSA" || (item.Use != "" && item.Use != "sig") {
+			continue
+		}
+
+		var n, e []byte
+		if n, err = base64.RawURLEncoding.DecodeString(item.N); err != nil {
+			continue
+		}
+		if e, err = base64.RawURLEncoding.DecodeString(item.E); err != nil {
+			continue
+		}
+
+		p.keys[item.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if key = p.findKey(kid); key == nil {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// returns key by id, when token has no key id and provider has single key it is used
+func (p *Provider) findKey(kid string) *rsa.PublicKey {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[kid]
+}
+
+// performs request and decodes json response to target
+func (p *Provider) do(request *http.Request, target interface{}) (err error) {
+	request.Header.Set("Accept", "application/json")
+
+	var response *http.Response
+	if response, err = p.client.Do(request); err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	var body []byte
+	if body, err = ioutil.ReadAll(io.LimitReader(response.Body, settings.OIDC_MAX_RESPONSE_SIZE)); err != nil {
+		return
+	}
+
+	// error responses of token endpoint are json too
+	json.Unmarshal(body, target)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s status %d", ErrRequestFailed, request.URL.Path, response.StatusCode)
+	}
+
+	return json.Unmarshal(body, target)
+}
+
+// aud claim is either string or list of strings
+func hasAudience(aud interface{}, clientID string) bool {
+	switch value := aud.(type) {
+	case string:
+		return value == clientID
+	case []interface{}:
+		for _, item := range value {
+			if item == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splits comma separated list, blank items are skipped
+func splitList(value string) (result []string) {
+	result = []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return
+}
@@ -0,0 +1,133 @@
+package oidc
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/phonkee/patrol/oidc/oidctest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCodeChallenge(t *testing.T) {
+	Convey("Test code challenge (RFC 7636 appendix B)", t, func() {
+		state := &State{Verifier: "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"}
+		So(state.CodeChallenge(), ShouldEqual, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM")
+	})
+}
+
+func TestProvider(t *testing.T) {
+	server := oidctest.NewServer("client", "secret")
+	defer server.Close()
+
+	redirectURL := "http://patrol.example.com/api/auth/oidc/callback"
+
+	newProvider := func() *Provider {
+		return NewProvider(func(p *Provider) {
+			p.Issuer = server.URL
+			p.ClientID = "client"
+			p.ClientSecret = "secret"
+		})
+	}
+
+	Convey("Test not configured provider", t, func() {
+		provider := NewProvider(func(p *Provider) { p.Issuer = "" })
+		So(provider.Enabled(), ShouldBeFalse)
+
+		_, err := provider.AuthCodeURL(NewState(), redirectURL)
+		So(err, ShouldEqual, ErrNotConfigured)
+	})
+
+	Convey("Test issuer mismatch", t, func() {
+		provider := newProvider()
+		provider.Issuer = server.URL + "/other"
+		_, err := provider.Configuration()
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Test authorization code flow", t, func() {
+		provider := newProvider()
+		state := NewState()
+
+		authURL, err := provider.AuthCodeURL(state, redirectURL)
+		So(err, ShouldBeNil)
+
+		u, err := url.Parse(authURL)
+		So(err, ShouldBeNil)
+		So(u.Query().Get("state"), ShouldEqual, state.ID)
+		So(u.Query().Get("nonce"), ShouldEqual, state.Nonce)
+		So(u.Query().Get("code_challenge"), ShouldEqual, state.CodeChallenge())
+		So(u.Query().Get("scope"), ShouldContainSubstring, "openid")
+
+		callback, err := server.Authorize(authURL, map[string]interface{}{
+			"email":          "user@example.com",
+			"email_verified": true,
+			"name":           "Some User",
+		})
+		So(err, ShouldBeNil)
+		So(callback.Query().Get("state"), ShouldEqual, state.ID)
+
+		code := callback.Query().Get("code")
+
+		// code verifier must match challenge
+		_, err = provider.Exchange(code, &State{Nonce: state.Nonce, Verifier: "invalid"}, redirectURL)
+		So(err, ShouldNotBeNil)
+
+		callback, err = server.Authorize(authURL, map[string]interface{}{
+			"email":          "user@example.com",
+			"email_verified": "true",
+			"name":           "Some User",
+		})
+		So(err, ShouldBeNil)
+
+		claims, err := provider.Exchange(callback.Query().Get("code"), state, redirectURL)
+		So(err, ShouldBeNil)
+		So(claims.Subject, ShouldEqual, "subject")
+		So(claims.Email, ShouldEqual, "user@example.com")
+		So(claims.EmailVerified, ShouldBeTrue)
+		So(claims.Name, ShouldEqual, "Some User")
+	})
+
+	Convey("Test verify id token", t, func() {
+		provider := newProvider()
+
+		claims, err := provider.Verify(server.IDToken(map[string]interface{}{"nonce": "nonce"}), "nonce")
+		So(err, ShouldBeNil)
+		So(claims.Subject, ShouldEqual, "subject")
+		So(claims.EmailVerified, ShouldBeFalse)
+
+		for _, invalid := range []map[string]interface{}{
+			{"nonce": "other"},
+			{"nonce": "nonce", "aud": "other"},
+			{"nonce": "nonce", "aud": []string{"other", "client"}, "azp": "other"},
+			{"nonce": "nonce", "iss": "http://other"},
+			{"nonce": "nonce", "exp": time.Now().Add(-time.Minute).Unix()},
+			{"nonce": "nonce", "sub": ""},
+		} {
+			_, err = provider.Verify(server.IDToken(invalid), "nonce")
+			So(err, ShouldNotBeNil)
+		}
+
+		// audience can be list
+		_, err = provider.Verify(server.IDToken(map[string]interface{}{"nonce": "nonce", "aud": []string{"other", "client"}, "azp": "client"}), "nonce")
+		So(err, ShouldBeNil)
+
+		// token signed by unknown key
+		other := oidctest.NewServer("client", "secret")
+		defer other.Close()
+		other.URL = server.URL
+		_, err = provider.Verify(other.IDToken(map[string]interface{}{"nonce": "nonce"}), "nonce")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestIsAllowedDomain(t *testing.T) {
+	Convey("Test allowed domains", t, func() {
+		So(IsAllowedDomain("user@example.com", []string{}), ShouldBeTrue)
+		So(IsAllowedDomain("user@example.com", []string{"example.com"}), ShouldBeTrue)
+		So(IsAllowedDomain("user@EXAMPLE.com", []string{"other.com", "@example.com"}), ShouldBeTrue)
+		So(IsAllowedDomain("user@example.com.evil.org", []string{"example.com"}), ShouldBeFalse)
+		So(IsAllowedDomain("user@sub.example.com", []string{"example.com"}), ShouldBeFalse)
+		So(IsAllowedDomain("user", []string{"example.com"}), ShouldBeFalse)
+	})
+}
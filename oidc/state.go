@@ -0,0 +1,77 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/utils"
+)
+
+var (
+	ErrInvalidState = errors.New("invalid_state")
+)
+
+/*
+State of single login, stored in cache between redirect to provider and
+callback. Every state can be used only once.
+*/
+type State struct {
+	ID       string `json:"-"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// returns new random state
+func NewState() *State {
+	return &State{
+		ID:       utils.NewRandomToken(16),
+		Nonce:    utils.NewRandomToken(16),
+		Verifier: utils.NewRandomToken(32),
+	}
+}
+
+// returns S256 code challenge of verifier (PKCE)
+func (s *State) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(s.Verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// stores state to cache
+func (s *State) Save(context *context.Context) (err error) {
+	var body []byte
+	if body, err = json.Marshal(s); err != nil {
+		return
+	}
+	return context.Cache.Set(stateCacheKey(s.ID), body, settings.OIDC_STATE_TIMEOUT)
+}
+
+/*
+Returns state stored in cache and removes it
+*/
+func PopState(context *context.Context, id string) (state *State, err error) {
+	if id == "" {
+		return nil, ErrInvalidState
+	}
+
+	var body []byte
+	if body, err = context.Cache.Get(stateCacheKey(id)); err != nil || len(body) == 0 {
+		return nil, ErrInvalidState
+	}
+	if err = context.Cache.Delete(stateCacheKey(id)); err != nil {
+		return
+	}
+
+	state = &State{ID: id}
+	if err = json.Unmarshal(body, state); err != nil {
+		return nil, ErrInvalidState
+	}
+	return
+}
+
+func stateCacheKey(id string) string {
+	return "oidc:state:" + id
+}
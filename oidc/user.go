@@ -0,0 +1,137 @@
+package oidc
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/utils"
+)
+
+var (
+	ErrDomainNotAllowed = errors.New("email_domain_not_allowed")
+	ErrUserInactive     = errors.New("user_inactive")
+)
+
+/*
+Returns user with e-mail address from claims, user is created when it does not
+exist. E-mail address must be verified by identity provider and in allowed
+domains (if any given).
+*/
+func GetOrCreateUser(context *context.Context, claims *Claims, allowedDomains []string) (user *models.User, err error) {
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, models.ErrEmailNotVerified
+	}
+
+	if !strings.Contains(claims.Email, "@") {
+		return nil, models.ErrInvalidEmail
+	}
+
+	if !IsAllowedDomain(claims.Email, allowedDomains) {
+		return nil, ErrDomainNotAllowed
+	}
+
+	manager := models.NewUserManager(context)
+	user = manager.NewUser()
+
+	// link existing user
+	if err = manager.Get(user, manager.QueryFilterWhere("LOWER(email) = LOWER(?)", claims.Email)); err == nil {
+		if !user.IsActive {
+			return nil, ErrUserInactive
+		}
+
+		// identity provider verified ownership of e-mail address
+		if !user.EmailVerified {
+			user.EmailVerified = true
+			if _, err = user.Update(context, "email_verified"); err != nil {
+				return nil, err
+			}
+		}
+		return
+	} else if err != models.ErrObjectDoesNotExists {
+		return nil, err
+	}
+
+	local := claims.Email[:strings.LastIndex(claims.Email, "@")]
+
+	user = manager.NewUser(func(u *models.User) {
+		u.Email = claims.Email
+		u.Name = claims.Name
+		u.IsActive = true
+	})
+	if user.Name == "" {
+		user.Name = local
+	}
+
+	base := claims.PreferredUsername
+	if base == "" || strings.Contains(base, "@") {
+		base = local
+	}
+	if user.Username, err = uniqueUsername(context, base); err != nil {
+		return nil, err
+	}
+
+	// users signed in by identity provider have no local password
+	if err = user.SetUnusablePassword(); err != nil {
+		return nil, err
+	}
+
+	if err = user.Insert(context); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+/*
+Returns whether domain of e-mail address is one of domains, empty domains
+allow all e-mail addresses.
+*/
+func IsAllowedDomain(email string, domains []string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at == -1 {
+		return false
+	}
+
+	domain := email[at+1:]
+	for _, allowed := range domains {
+		if strings.EqualFold(domain, strings.TrimPrefix(allowed, "@")) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Returns username based on base that is not used yet, random suffix is added
+when base is taken or too short.
+*/
+func uniqueUsername(context *context.Context, base string) (username string, err error) {
+	if len(base) > models.UserUsernameMaxLength {
+		base = base[:models.UserUsernameMaxLength]
+	}
+
+	manager := models.NewUserManager(context)
+	username = base
+	for {
+		if len(username) >= models.UserUsernameMinLength {
+			err = manager.Get(manager.NewUser(), manager.QueryFilterWhere("LOWER(username) = LOWER(?)", username))
+			if err == models.ErrObjectDoesNotExists {
+				return username, nil
+			} else if err != nil {
+				return
+			}
+		}
+
+		suffix := "-" + utils.NewRandomToken(3)
+		if len(base)+len(suffix) > models.UserUsernameMaxLength {
+			base = base[:models.UserUsernameMaxLength-len(suffix)]
+		}
+		username = base + suffix
+	}
+}
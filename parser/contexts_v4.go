@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+/*
+Contexts interface
+
+Sentry clients send "contexts" object where every key is context name and value
+is object with "type" attribute (if type is not given, key is used as type).
+Well known types are parsed into typed structs, unknown contexts are kept as they
+are in Other.
+*/
+type ContextsInterfaceV4 struct {
+	PatrolInterface
+	OS      *OSContextV4                      `json:"os,omitempty"`
+	Runtime *RuntimeContextV4                 `json:"runtime,omitempty"`
+	Device  *DeviceContextV4                  `json:"device,omitempty"`
+	Browser *BrowserContextV4                 `json:"browser,omitempty"`
+	App     *AppContextV4                     `json:"app,omitempty"`
+	Other   map[string]map[string]interface{} `json:"other,omitempty"`
+}
+
+/*
+Contexts do not participate in grouping, so hash is blank.
+*/
+func (c *ContextsInterfaceV4) Hash() string     { return "" }
+func (c *ContextsInterfaceV4) String() string   { return "contexts" }
+func (c *ContextsInterfaceV4) Template() string { return "this is template for contexts" }
+
+/*
+Unmarshals contexts by their type
+*/
+func (c *ContextsInterfaceV4) UnmarshalJSON(body []byte) (err error) {
+	values := map[string]json.RawMessage{}
+	if err = json.Unmarshal(body, &values); err != nil {
+		return
+	}
+
+	for key, value := range values {
+		typed := struct {
+			Type string `json:"type"`
+		}{}
+		_ = json.Unmarshal(value, &typed)
+
+		ctype := strings.ToLower(strings.TrimSpace(typed.Type))
+		if ctype == "" {
+			ctype = strings.ToLower(key)
+		}
+
+		var target interface{}
+		switch ctype {
+		case "os":
+			c.OS = &OSContextV4{}
+			target = c.OS
+		case "runtime":
+			c.Runtime = &RuntimeContextV4{}
+			target = c.Runtime
+		case "device":
+			c.Device = &DeviceContextV4{}
+			target = c.Device
+		case "browser":
+			c.Browser = &BrowserContextV4{}
+			target = c.Browser
+		case "app":
+			c.App = &AppContextV4{}
+			target = c.App
+		default:
+			if c.Other == nil {
+				c.Other = map[string]map[string]interface{}{}
+			}
+			other := map[string]interface{}{}
+			c.Other[key] = other
+			target = &other
+		}
+
+		// single broken context should not throw away whole event
+		_ = json.Unmarshal(value, target)
+	}
+
+	return nil
+}
+
+/*
+Returns well known context values that are promoted to event tags
+*/
+func (c *ContextsInterfaceV4) Tags() (tags map[string]string) {
+	tags = map[string]string{}
+	if c.OS != nil && c.OS.Name != "" {
+		tags["os.name"] = c.OS.Name
+		tags["os"] = joinNameVersion(c.OS.Name, c.OS.Version)
+	}
+	if c.Runtime != nil && c.Runtime.Name != "" {
+		tags["runtime.name"] = c.Runtime.Name
+		tags["runtime"] = joinNameVersion(c.Runtime.Name, c.Runtime.Version)
+	}
+	if c.Browser != nil && c.Browser.Name != "" {
+		tags["browser.name"] = c.Browser.Name
+		tags["browser"] = joinNameVersion(c.Browser.Name, c.Browser.Version)
+	}
+	if c.Device != nil {
+		if c.Device.Family != "" {
+			tags["device.family"] = c.Device.Family
+		}
+		if c.Device.Model != "" {
+			tags["device"] = c.Device.Model
+		}
+	}
+	return
+}
+
+// returns "name version" or just name if version is blank
+func joinNameVersion(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + " " + version
+}
+
+/*
+Operating system context
+*/
+type OSContextV4 struct {
+	Name          string `json:"name"`
+	Version       string `json:"version,omitempty"`
+	Build         string `json:"build,omitempty"`
+	KernelVersion string `json:"kernel_version,omitempty"`
+	Rooted        bool   `json:"rooted,omitempty"`
+}
+
+/*
+Runtime context (e.g. go, python, node)
+*/
+type RuntimeContextV4 struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+/*
+Device context (mostly mobile clients)
+*/
+type DeviceContextV4 struct {
+	Name         string  `json:"name,omitempty"`
+	Family       string  `json:"family,omitempty"`
+	Model        string  `json:"model,omitempty"`
+	ModelID      string  `json:"model_id,omitempty"`
+	Arch         string  `json:"arch,omitempty"`
+	BatteryLevel float64 `json:"battery_level,omitempty"`
+	Orientation  string  `json:"orientation,omitempty"`
+	Simulator    bool    `json:"simulator,omitempty"`
+}
+
+/*
+Browser context
+*/
+type BrowserContextV4 struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+/*
+Application context
+*/
+type AppContextV4 struct {
+	AppStartTime  string `json:"app_start_time,omitempty"`
+	DeviceAppHash string `json:"device_app_hash,omitempty"`
+	BuildType     string `json:"build_type,omitempty"`
+	AppIdentifier string `json:"app_identifier,omitempty"`
+	AppName       string `json:"app_name,omitempty"`
+	AppVersion    string `json:"app_version,omitempty"`
+	AppBuild      string `json:"app_build,omitempty"`
+}
@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestContextsInterfaceV4(t *testing.T) {
+
+	Convey("Test unmarshal contexts", t, func() {
+		body := []byte(`{
+			"os": {"name": "Linux", "version": "4.4"},
+			"rt": {"type": "runtime", "name": "go", "version": "1.6"},
+			"device": {"family": "Pixel", "model": "Pixel XL"},
+			"custom": {"foo": "bar"}
+		}`)
+
+		contexts := &ContextsInterfaceV4{}
+		So(json.Unmarshal(body, contexts), ShouldBeNil)
+		So(contexts.OS, ShouldNotBeNil)
+		So(contexts.OS.Name, ShouldEqual, "Linux")
+		So(contexts.Runtime, ShouldNotBeNil)
+		So(contexts.Runtime.Version, ShouldEqual, "1.6")
+		So(contexts.Browser, ShouldBeNil)
+		So(contexts.Other["custom"]["foo"], ShouldEqual, "bar")
+
+		tags := contexts.Tags()
+		So(tags["os.name"], ShouldEqual, "Linux")
+		So(tags["os"], ShouldEqual, "Linux 4.4")
+		So(tags["runtime"], ShouldEqual, "go 1.6")
+		So(tags["device.family"], ShouldEqual, "Pixel")
+		So(tags["device"], ShouldEqual, "Pixel XL")
+		_, ok := tags["browser"]
+		So(ok, ShouldBeFalse)
+	})
+
+}
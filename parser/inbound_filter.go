@@ -0,0 +1,189 @@
+package parser
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+Reasons why event was dropped by inbound filter
+*/
+const (
+	FILTER_REASON_LOCALHOST       = "localhost"
+	FILTER_REASON_WEB_CRAWLERS    = "web-crawlers"
+	FILTER_REASON_LEGACY_BROWSERS = "legacy-browsers"
+	FILTER_REASON_RELEASES        = "releases"
+	FILTER_REASON_ERROR_MESSAGES  = "error-messages"
+)
+
+var (
+	FILTER_REASONS = []string{
+		FILTER_REASON_LOCALHOST, FILTER_REASON_WEB_CRAWLERS,
+		FILTER_REASON_LEGACY_BROWSERS, FILTER_REASON_RELEASES,
+		FILTER_REASON_ERROR_MESSAGES,
+	}
+
+	webCrawlersRegexp = regexp.MustCompile(`(?i)(googlebot|mediapartners-google|adsbot-google|bingbot|` +
+		`slurp|duckduckbot|baiduspider|yandexbot|sogou|exabot|facebot|facebookexternalhit|ia_archiver|` +
+		`ahrefsbot|semrushbot|mj12bot|pingdom|uptimerobot|crawler|spider|\bbot\b)`)
+
+	msieRegexp          = regexp.MustCompile(`MSIE (\d+)`)
+	prestoRegexp        = regexp.MustCompile(`Opera/|Presto/`)
+	safariRegexp        = regexp.MustCompile(`Version/(\d+)[\d.]* (?:Mobile/\S+ )?Safari/`)
+	androidStockRegexp  = regexp.MustCompile(`Android (\d+)`)
+	legacyBrowserChecks = []func(ua string) bool{
+		// Internet Explorer 9 and older
+		func(ua string) bool { return versionBelow(msieRegexp, ua, 10) },
+		// Opera with Presto engine
+		func(ua string) bool { return prestoRegexp.MatchString(ua) },
+		// Safari 5 and older
+		func(ua string) bool {
+			return !strings.Contains(ua, "Chrome/") && versionBelow(safariRegexp, ua, 6)
+		},
+		// Android stock browser 3 and older
+		func(ua string) bool {
+			return !strings.Contains(ua, "Chrome/") && versionBelow(androidStockRegexp, ua, 4)
+		},
+	}
+)
+
+/*
+InboundFilter decides whether event should be dropped before it's queued.
+*/
+type InboundFilter struct {
+	Localhost      bool
+	WebCrawlers    bool
+	LegacyBrowsers bool
+
+	// glob patterns
+	Releases      []string
+	ErrorMessages []string
+}
+
+func NewInboundFilter(funcs ...func(*InboundFilter)) (filter *InboundFilter) {
+	filter = &InboundFilter{
+		Releases:      []string{},
+		ErrorMessages: []string{},
+	}
+	for _, f := range funcs {
+		f(filter)
+	}
+	return
+}
+
+/*
+Check returns reason when event should be dropped, blank string otherwise
+*/
+func (i *InboundFilter) Check(event *RawEvent) (reason string) {
+	http, _ := event.Interface("http").(*HttpInterfaceV4)
+	useragent := ""
+	if http != nil {
+		useragent = headerValue(http.Headers, "User-Agent")
+	}
+
+	if i.Localhost && isLocalhostEvent(event, http) {
+		return FILTER_REASON_LOCALHOST
+	}
+
+	if i.WebCrawlers && useragent != "" && webCrawlersRegexp.MatchString(useragent) {
+		return FILTER_REASON_WEB_CRAWLERS
+	}
+
+	if i.LegacyBrowsers && useragent != "" {
+		for _, check := range legacyBrowserChecks {
+			if check(useragent) {
+				return FILTER_REASON_LEGACY_BROWSERS
+			}
+		}
+	}
+
+	if event.Release != "" {
+		for _, pattern := range i.Releases {
+			if utils.GlobMatch(pattern, event.Release) {
+				return FILTER_REASON_RELEASES
+			}
+		}
+	}
+
+	if len(i.ErrorMessages) > 0 {
+		messages := []string{event.Message}
+		if exception, ok := event.Interface("exception").(*ExceptionInterfaceV4); ok {
+			messages = append(messages, exception.Value, exception.Type+": "+exception.Value)
+		}
+		for _, pattern := range i.ErrorMessages {
+			for _, message := range messages {
+				if message != "" && utils.GlobMatch(pattern, message) {
+					return FILTER_REASON_ERROR_MESSAGES
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// returns whether event was sent from local machine
+func isLocalhostEvent(event *RawEvent, http *HttpInterfaceV4) bool {
+	if http != nil {
+		if u, err := url.Parse(http.URL); err == nil && isLocalhost(u.Host) {
+			return true
+		}
+		if isLocalhost(http.Env["REMOTE_ADDR"]) {
+			return true
+		}
+	}
+
+	// user interface is stored as raw json
+	if raw, ok := event.Data["user"].(string); ok {
+		user := struct {
+			IPAddress string `json:"ip_address"`
+		}{}
+		if json.Unmarshal([]byte(raw), &user) == nil && isLocalhost(user.IPAddress) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// returns whether host (optionally with port) is loopback
+func isLocalhost(host string) bool {
+	if host == "" {
+		return false
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// case insensitive header lookup
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// returns whether major version captured by regexp is below given version
+func versionBelow(re *regexp.Regexp, ua string, version int) bool {
+	match := re.FindStringSubmatch(ua)
+	if match == nil {
+		return false
+	}
+	major, err := strconv.Atoi(match[1])
+	return err == nil && major < version
+}
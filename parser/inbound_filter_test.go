@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newHttpRawEvent(url, useragent string) *RawEvent {
+	event := NewRawEvent()
+	event.Data["interfaces"] = []EventParserInterfacer{
+		&HttpInterfaceV4{
+			PatrolInterface: PatrolInterface{ID: "http"},
+			URL:             url,
+			Headers:         map[string]string{"User-Agent": useragent},
+		},
+	}
+	return event
+}
+
+func TestInboundFilter(t *testing.T) {
+
+	chrome := "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/50.0.2661.102 Safari/537.36"
+
+	Convey("Test empty filter", t, func() {
+		filter := NewInboundFilter()
+		So(filter.Check(newHttpRawEvent("http://localhost:8000/", "Googlebot/2.1")), ShouldEqual, "")
+	})
+
+	Convey("Test localhost", t, func() {
+		filter := NewInboundFilter(func(i *InboundFilter) { i.Localhost = true })
+		So(filter.Check(newHttpRawEvent("http://localhost:8000/", chrome)), ShouldEqual, FILTER_REASON_LOCALHOST)
+		So(filter.Check(newHttpRawEvent("http://127.0.0.1/", chrome)), ShouldEqual, FILTER_REASON_LOCALHOST)
+		So(filter.Check(newHttpRawEvent("http://example.com/", chrome)), ShouldEqual, "")
+
+		event := NewRawEvent()
+		event.Data["user"] = `{"ip_address": "::1"}`
+		So(filter.Check(event), ShouldEqual, FILTER_REASON_LOCALHOST)
+	})
+
+	Convey("Test web crawlers", t, func() {
+		filter := NewInboundFilter(func(i *InboundFilter) { i.WebCrawlers = true })
+		So(filter.Check(newHttpRawEvent("http://example.com/", "Mozilla/5.0 (compatible; Googlebot/2.1)")), ShouldEqual, FILTER_REASON_WEB_CRAWLERS)
+		So(filter.Check(newHttpRawEvent("http://example.com/", chrome)), ShouldEqual, "")
+	})
+
+	Convey("Test legacy browsers", t, func() {
+		filter := NewInboundFilter(func(i *InboundFilter) { i.LegacyBrowsers = true })
+		So(filter.Check(newHttpRawEvent("http://example.com/", "Mozilla/5.0 (compatible; MSIE 9.0; Windows NT 6.1)")), ShouldEqual, FILTER_REASON_LEGACY_BROWSERS)
+		So(filter.Check(newHttpRawEvent("http://example.com/", "Mozilla/5.0 (compatible; MSIE 10.0; Windows NT 6.1)")), ShouldEqual, "")
+		So(filter.Check(newHttpRawEvent("http://example.com/", "Opera/9.80 (Windows NT 6.0) Presto/2.12.388 Version/12.14")), ShouldEqual, FILTER_REASON_LEGACY_BROWSERS)
+		So(filter.Check(newHttpRawEvent("http://example.com/", chrome)), ShouldEqual, "")
+	})
+
+	Convey("Test releases and error messages", t, func() {
+		filter := NewInboundFilter(func(i *InboundFilter) {
+			i.Releases = []string{"1.0.*"}
+			i.ErrorMessages = []string{"Script error.", "*ConnectionReset*"}
+		})
+
+		event := NewRawEvent()
+		event.Release = "1.0.3"
+		So(filter.Check(event), ShouldEqual, FILTER_REASON_RELEASES)
+
+		event = NewRawEvent()
+		event.Message = "script error."
+		So(filter.Check(event), ShouldEqual, FILTER_REASON_ERROR_MESSAGES)
+
+		event = NewRawEvent()
+		event.Message = "something"
+		event.Data["interfaces"] = []EventParserInterfacer{
+			&ExceptionInterfaceV4{PatrolInterface: PatrolInterface{ID: "exception"}, Type: "ConnectionResetError", Value: "peer closed"},
+		}
+		So(filter.Check(event), ShouldEqual, FILTER_REASON_ERROR_MESSAGES)
+
+		event = NewRawEvent()
+		event.Release = "1.1.0"
+		event.Message = "other error"
+		So(filter.Check(event), ShouldEqual, "")
+	})
+}
@@ -0,0 +1,54 @@
+package parser
+
+import "strings"
+
+/*
+Event levels (same values as python logging levels)
+*/
+const (
+	LEVEL_DEBUG   = 10
+	LEVEL_INFO    = 20
+	LEVEL_WARNING = 30
+	LEVEL_ERROR   = 40
+	LEVEL_FATAL   = 50
+)
+
+var (
+	LEVELS = map[string]int{
+		"debug":    LEVEL_DEBUG,
+		"info":     LEVEL_INFO,
+		"warning":  LEVEL_WARNING,
+		"warn":     LEVEL_WARNING,
+		"error":    LEVEL_ERROR,
+		"fatal":    LEVEL_FATAL,
+		"critical": LEVEL_FATAL,
+	}
+)
+
+/*
+Returns numeric value of level, unknown levels are treated as errors
+*/
+func LevelValue(level string) int {
+	if value, ok := LEVELS[strings.ToLower(strings.TrimSpace(level))]; ok {
+		return value
+	}
+	return LEVEL_ERROR
+}
+
+/*
+Returns name of level value, values between levels are named by nearest
+lower level
+*/
+func LevelName(value int) string {
+	switch {
+	case value >= LEVEL_FATAL:
+		return "fatal"
+	case value >= LEVEL_ERROR:
+		return "error"
+	case value >= LEVEL_WARNING:
+		return "warning"
+	case value >= LEVEL_INFO:
+		return "info"
+	}
+	return "debug"
+}
@@ -0,0 +1,27 @@
+package parser
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLevelValue(t *testing.T) {
+	Convey("Test level value", t, func() {
+		So(LevelValue("debug"), ShouldEqual, LEVEL_DEBUG)
+		So(LevelValue(" Warning "), ShouldEqual, LEVEL_WARNING)
+		So(LevelValue("critical"), ShouldEqual, LEVEL_FATAL)
+		So(LevelValue(""), ShouldEqual, LEVEL_ERROR)
+		So(LevelValue("unknown"), ShouldEqual, LEVEL_ERROR)
+	})
+}
+
+func TestLevelName(t *testing.T) {
+	Convey("Test level name", t, func() {
+		So(LevelName(LEVEL_DEBUG), ShouldEqual, "debug")
+		So(LevelName(LEVEL_WARNING), ShouldEqual, "warning")
+		So(LevelName(45), ShouldEqual, "error")
+		So(LevelName(LevelValue("critical")), ShouldEqual, "fatal")
+		So(LevelName(0), ShouldEqual, "debug")
+	})
+}
@@ -35,6 +35,11 @@ func init() {
 		"exception", []string{"sentry.interfaces.Exception"}, // id + aliases
 		900, //score
 	)
+	interfacesV4.Register(
+		func() EventParserInterfacer { return &ContextsInterfaceV4{} },
+		"contexts", []string{"sentry.interfaces.Contexts"}, // id + aliases
+		100, //score
+	)
 }
 
 /*
@@ -94,6 +99,20 @@ func (e *EventParserV4) Parse(body []byte) (events []*RawEvent, err error) {
 			event.Datetime, err = time.Parse(settings.SENTRY_TIMESTAMP_LAYOUT, timestamp)
 			return
 		},
+		"release": func(value json.RawMessage) (err error) {
+			// release is optional
+			if len(value) == 0 {
+				return
+			}
+			return json.Unmarshal(value, &event.Release)
+		},
+		"environment": func(value json.RawMessage) (err error) {
+			// environment is optional
+			if len(value) == 0 {
+				return
+			}
+			return json.Unmarshal(value, &event.Environment)
+		},
 		"extra": func(value json.RawMessage) (err error) {
 			_ = json.Unmarshal(value, &event.Extra)
 			return
@@ -125,10 +144,24 @@ func (e *EventParserV4) Parse(body []byte) (events []*RawEvent, err error) {
 	// add iterfaces to data
 	event.Data["interfaces"] = ifs
 
-	// update checksum
-	if len(ifs) > 0 {
-		event.Checksum = ifs[0].Hash()
-	} else {
+	// promote interface values to tags, tags sent by client have precedence
+	for _, iface := range ifs {
+		if tagger, ok := iface.(EventParserTagger); ok {
+			for key, value := range tagger.Tags() {
+				if _, exists := event.Tags[key]; !exists {
+					event.Tags[key] = value
+				}
+			}
+		}
+	}
+
+	// update checksum from first interface that provides hash
+	for _, iface := range ifs {
+		if event.Checksum = iface.Hash(); event.Checksum != "" {
+			break
+		}
+	}
+	if event.Checksum == "" {
 		h := md5.New()
 		io.WriteString(h, event.Message)
 		event.Checksum = fmt.Sprintf("%x", h.Sum(nil))
@@ -142,6 +175,13 @@ func (e *EventParserV4) Parse(body []byte) (events []*RawEvent, err error) {
 		event.Data[key] = string(val)
 	}
 
+	// user affected by event is stored as tag (used to count affected users)
+	if _, exists := event.Tags[USER_TAG]; !exists {
+		if user := event.UserIdentifier(); user != "" {
+			event.Tags[USER_TAG] = user
+		}
+	}
+
 	events = append(events, event)
 
 	return
@@ -182,8 +222,10 @@ type HttpInterfaceV4 struct {
 	URL         string            `json:"url"`
 	Method      string            `json:"method"`
 	QueryString string            `json:"query_string"`
-	Headers     map[string]string `json:"headers'"`
-	Env         map[string]string `json:"env'"`
+	Data        interface{}       `json:"data,omitempty"`
+	Cookies     interface{}       `json:"cookies,omitempty"`
+	Headers     map[string]string `json:"headers"`
+	Env         map[string]string `json:"env"`
 }
 
 /*
@@ -4,13 +4,24 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
+	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/phonkee/ergoq"
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/settings"
 	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+const (
+	// length of event_id column
+	MAX_EVENT_ID_LENGTH = 32
+
+	// tag with identifier of affected user
+	USER_TAG = "user"
 )
 
 /*
@@ -28,21 +39,83 @@ func NewRawEvent() *RawEvent {
 
 // Parsed event
 type RawEvent struct {
-	Culprit    string                 `json:"culprit"`
-	Checksum   string                 `json:"checksum"`
-	Datetime   time.Time              `json:"date_time"`
-	Extra      map[string]interface{} `json:"extra,omitempty"`
-	EventID    string                 `json:"event_id"`
-	Level      string                 `json:"level"`
-	Logger     string                 `json:"logger"`
-	Message    string                 `json:"message"`
-	ProjectID  types.ForeignKey       `json:"project_id"`
-	Platform   string                 `json:"platform"`
-	Release    string                 `json:"release"`
-	ServerName string                 `json:"server_name"`
-	Version    string                 `json:"version"`
-	Tags       map[string]string      `json:"tags"`
-	Data       types.GzippedMap       `json:"data"`
+	Culprit     string                 `json:"culprit"`
+	Environment string                 `json:"environment"`
+	Checksum    string                 `json:"checksum"`
+	Datetime    time.Time              `json:"date_time"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	EventID     string                 `json:"event_id"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger"`
+	Message     string                 `json:"message"`
+	ProjectID   types.ForeignKey       `json:"project_id"`
+	Platform    string                 `json:"platform"`
+	Release     string                 `json:"release"`
+	ServerName  string                 `json:"server_name"`
+	Version     string                 `json:"version"`
+	Tags        map[string]string      `json:"tags"`
+	Data        types.GzippedMap       `json:"data"`
+}
+
+/*
+Normalizes event id (lowercase hex without dashes), generates new one when
+client did not send it
+*/
+func (r *RawEvent) EnsureEventID() {
+	r.EventID = strings.ToLower(strings.Replace(strings.TrimSpace(r.EventID), "-", "", -1))
+	if r.EventID == "" {
+		r.EventID = utils.NewUUID()
+	}
+	r.EventID = utils.StringTruncate(r.EventID, MAX_EVENT_ID_LENGTH)
+}
+
+/*
+Returns identifier of user affected by event (from user interface), blank
+string if event does not have user.
+*/
+func (r *RawEvent) UserIdentifier() string {
+	for _, key := range []string{"user", "sentry.interfaces.User"} {
+		raw, ok := r.Data[key].(string)
+		if !ok {
+			continue
+		}
+		user := struct {
+			ID        interface{} `json:"id"`
+			Email     string      `json:"email"`
+			Username  string      `json:"username"`
+			IPAddress string      `json:"ip_address"`
+		}{}
+		if json.Unmarshal([]byte(raw), &user) != nil {
+			continue
+		}
+		switch {
+		case user.ID != nil && fmt.Sprint(user.ID) != "":
+			return "id:" + fmt.Sprint(user.ID)
+		case user.Email != "":
+			return "email:" + user.Email
+		case user.Username != "":
+			return "username:" + user.Username
+		case user.IPAddress != "":
+			return "ip:" + user.IPAddress
+		}
+	}
+	return ""
+}
+
+/*
+Returns parsed interface with given id, nil if event does not have it
+*/
+func (r *RawEvent) Interface(id string) EventParserInterfacer {
+	ifs, ok := r.Data["interfaces"].([]EventParserInterfacer)
+	if !ok {
+		return nil
+	}
+	for _, iface := range ifs {
+		if iface.GetID() == id {
+			return iface
+		}
+	}
+	return nil
 }
 
 /*
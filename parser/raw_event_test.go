@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRawEvent(t *testing.T) {
+
+	Convey("Test EnsureEventID", t, func() {
+		event := NewRawEvent()
+		event.EnsureEventID()
+		So(len(event.EventID), ShouldEqual, MAX_EVENT_ID_LENGTH)
+
+		generated := event.EventID
+		event.EnsureEventID()
+		So(event.EventID, ShouldEqual, generated)
+
+		event.EventID = " FC6D8C0C-43FC-4630-9F5A-1C4B36D9F0B4 "
+		event.EnsureEventID()
+		So(event.EventID, ShouldEqual, "fc6d8c0c43fc46309f5a1c4b36d9f0b4")
+	})
+
+	Convey("Test Interface", t, func() {
+		event := NewRawEvent()
+		So(event.Interface("http"), ShouldBeNil)
+
+		http := &HttpInterfaceV4{PatrolInterface: PatrolInterface{ID: "http"}}
+		event.Data["interfaces"] = []EventParserInterfacer{http}
+		So(event.Interface("http"), ShouldEqual, http)
+		So(event.Interface("exception"), ShouldBeNil)
+	})
+
+	Convey("Test UserIdentifier", t, func() {
+		event := NewRawEvent()
+		So(event.UserIdentifier(), ShouldEqual, "")
+
+		event.Data["user"] = `{"id": 42, "email": "john@example.com"}`
+		So(event.UserIdentifier(), ShouldEqual, "id:42")
+
+		event.Data["user"] = `{"email": "john@example.com", "ip_address": "10.0.0.1"}`
+		So(event.UserIdentifier(), ShouldEqual, "email:john@example.com")
+
+		event.Data["user"] = `{"ip_address": "10.0.0.1"}`
+		So(event.UserIdentifier(), ShouldEqual, "ip:10.0.0.1")
+	})
+}
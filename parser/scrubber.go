@@ -0,0 +1,399 @@
+package parser
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/phonkee/patrol/settings"
+)
+
+const (
+	// value that replaces scrubbed data
+	SCRUBBED_VALUE = "[Filtered]"
+
+	// key in event data where list of scrubbed fields is stored
+	SCRUBBED_FIELDS_DATA_KEY = "scrubbed_fields"
+)
+
+var (
+	// default sensitive key patterns (key is scrubbed when it contains pattern)
+	DEFAULT_SCRUB_FIELDS = []string{
+		"password", "passwd", "pwd", "secret", "api_key", "apikey", "access_token",
+		"refresh_token", "auth", "credentials", "cookie", "session", "csrf",
+		"token", "private_key", "card_number", "card[number]", "stripetoken",
+		"mysql_pwd", "ssn",
+	}
+
+	// sequences of 13-19 digits optionally separated by spaces or dashes
+	creditCardRegexp = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+)
+
+/*
+Scrubber removes sensitive data from raw events before they are queued.
+
+Fields are patterns matched (case insensitive) against keys anywhere in event
+data, SafeFields are keys that are never scrubbed. When IPAddresses are
+anonymized, user tag is anonymized too: ip is anonymized, e-mail and username
+are replaced with hash keyed by HashKey (so affected users can be counted).
+*/
+type Scrubber struct {
+	Fields      []string
+	SafeFields  []string
+	CreditCards bool
+	IPAddresses bool
+	HashKey     string
+}
+
+/*
+Returns scrubber with default fields
+*/
+func NewScrubber(funcs ...func(*Scrubber)) (scrubber *Scrubber) {
+	scrubber = &Scrubber{
+		Fields:      append([]string{}, DEFAULT_SCRUB_FIELDS...),
+		SafeFields:  []string{},
+		CreditCards: true,
+		IPAddresses: true,
+		HashKey:     settings.SETTINGS_SECRET_KEY,
+	}
+	for _, f := range funcs {
+		f(scrubber)
+	}
+	return
+}
+
+/*
+Scrubs event in place, returns paths of scrubbed fields that are also stored in
+event data under SCRUBBED_FIELDS_DATA_KEY
+*/
+func (s *Scrubber) Scrub(event *RawEvent) (scrubbed []string) {
+	found := map[string]struct{}{}
+	record := func(path string) { found[path] = struct{}{} }
+
+	// message
+	if s.CreditCards {
+		if value, changed := s.scrubCreditCards(event.Message); changed {
+			event.Message = value
+			record("message")
+		}
+	}
+
+	// tags
+	for key, value := range event.Tags {
+		if key == USER_TAG && s.IPAddresses {
+			if anonymized, changed := s.anonymizeUserTag(value); changed {
+				event.Tags[key] = anonymized
+				record("tags." + key)
+			}
+			continue
+		}
+		if scrubbedValue, ok := s.scrubValue("tags."+key, key, value, record).(string); ok {
+			event.Tags[key] = scrubbedValue
+		}
+	}
+
+	// extra
+	for key, value := range event.Extra {
+		event.Extra[key] = s.scrubValue("extra."+key, key, value, record)
+	}
+
+	// data
+	for key, value := range event.Data {
+		if key == SCRUBBED_FIELDS_DATA_KEY {
+			continue
+		}
+
+		if key == "interfaces" {
+			event.Data[key] = s.scrubInterfaces(value, record)
+			continue
+		}
+
+		// other data are stored as raw json strings
+		raw, ok := value.(string)
+		if !ok {
+			event.Data[key] = s.scrubValue(key, key, value, record)
+			continue
+		}
+
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+			event.Data[key] = s.scrubValue(key, key, raw, record)
+			continue
+		}
+
+		if body, err := json.Marshal(s.scrubValue(key, key, decoded, record)); err == nil {
+			event.Data[key] = string(body)
+		}
+	}
+
+	scrubbed = make([]string, 0, len(found))
+	for path := range found {
+		scrubbed = append(scrubbed, path)
+	}
+	sort.Strings(scrubbed)
+
+	if len(scrubbed) > 0 {
+		event.Data[SCRUBBED_FIELDS_DATA_KEY] = scrubbed
+	}
+
+	return
+}
+
+/*
+Interfaces are typed structs, so we convert them to generic values first.
+*/
+func (s *Scrubber) scrubInterfaces(value interface{}, record func(string)) interface{} {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	ifs := []interface{}{}
+	if err = json.Unmarshal(body, &ifs); err != nil {
+		return value
+	}
+
+	for i, iface := range ifs {
+		path := strconv.Itoa(i)
+		if m, ok := iface.(map[string]interface{}); ok {
+			if id, ok := m["id"].(string); ok && id != "" {
+				path = id
+			}
+		}
+		ifs[i] = s.scrubValue(path, "", iface, record)
+	}
+	return ifs
+}
+
+/*
+Scrubs single value recursively
+*/
+func (s *Scrubber) scrubValue(path, key string, value interface{}, record func(string)) interface{} {
+	if key != "" {
+		if s.isSafe(key) {
+			return value
+		}
+		if s.isSensitive(key) && !isBlank(value) {
+			record(path)
+			return SCRUBBED_VALUE
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = s.scrubValue(path+"."+k, k, item, record)
+		}
+		return v
+	case []interface{}:
+		// list of pairs (e.g. headers sent as [["Authorization", "..."]])
+		for i, item := range v {
+			if pair, ok := item.([]interface{}); ok && len(pair) == 2 {
+				if k, ok := pair[0].(string); ok {
+					pair[1] = s.scrubValue(path+"."+k, k, pair[1], record)
+					continue
+				}
+			}
+			v[i] = s.scrubValue(path+"."+strconv.Itoa(i), "", item, record)
+		}
+		return v
+	case string:
+		return s.scrubString(path, key, v, record)
+	}
+
+	return value
+}
+
+/*
+Scrubs string value
+*/
+func (s *Scrubber) scrubString(path, key, value string, record func(string)) string {
+	changed := false
+
+	// query strings and form bodies
+	if key == "query_string" || key == "data" {
+		if scrubbed, ok := s.scrubQueryString(value); ok {
+			value, changed = scrubbed, true
+		}
+	}
+
+	if s.IPAddresses {
+		if anonymized, ok := anonymizeIPList(value); ok {
+			value, changed = anonymized, true
+		}
+	}
+
+	if s.CreditCards {
+		if scrubbed, ok := s.scrubCreditCards(value); ok {
+			value, changed = scrubbed, true
+		}
+	}
+
+	if changed {
+		record(path)
+	}
+
+	return value
+}
+
+/*
+Scrubs sensitive keys in url encoded string, returns false when nothing was
+scrubbed.
+*/
+func (s *Scrubber) scrubQueryString(value string) (result string, changed bool) {
+	if !strings.Contains(value, "=") {
+		return value, false
+	}
+	values, err := url.ParseQuery(value)
+	if err != nil {
+		return value, false
+	}
+	for key := range values {
+		if !s.isSafe(key) && s.isSensitive(key) {
+			values.Set(key, SCRUBBED_VALUE)
+			changed = true
+		}
+	}
+	if !changed {
+		return value, false
+	}
+	return values.Encode(), true
+}
+
+/*
+Replaces all credit card numbers in string
+*/
+func (s *Scrubber) scrubCreditCards(value string) (result string, changed bool) {
+	result = creditCardRegexp.ReplaceAllStringFunc(value, func(match string) string {
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, match)
+		if len(digits) < 13 || len(digits) > 19 || !Luhn(digits) {
+			return match
+		}
+		changed = true
+		return SCRUBBED_VALUE
+	})
+	return
+}
+
+// returns whether key matches any of sensitive patterns
+func (s *Scrubber) isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range s.Fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" && strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns whether key is marked as safe
+func (s *Scrubber) isSafe(key string) bool {
+	for _, field := range s.SafeFields {
+		if strings.EqualFold(strings.TrimSpace(field), key) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns whether value is blank (nothing to scrub)
+func isBlank(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	}
+	return false
+}
+
+/*
+Luhn checks number with Luhn algorithm (credit card checksum)
+*/
+func Luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+		if double {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return number != "" && sum%10 == 0
+}
+
+/*
+AnonymizeIP zeroes last octet of IPv4 address and last 80 bits of IPv6
+address. Returns false if value is not ip address.
+*/
+func AnonymizeIP(value string) (result string, ok bool) {
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if ip == nil {
+		return value, false
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return ipv4.Mask(net.CIDRMask(24, 32)).String(), true
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String(), true
+}
+
+/*
+Anonymizes value of user tag (see RawEvent.UserIdentifier), ip is anonymized,
+e-mail and username are hashed.
+*/
+func (s *Scrubber) anonymizeUserTag(value string) (result string, changed bool) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return "hash:" + s.hash(value), true
+	}
+
+	switch parts[0] {
+	case "id", "hash":
+		return value, false
+	case "ip":
+		if anonymized, ok := AnonymizeIP(parts[1]); ok {
+			return "ip:" + anonymized, anonymized != parts[1]
+		}
+	}
+	return parts[0] + ":" + s.hash(parts[1]), true
+}
+
+// returns keyed hash of value
+func (s *Scrubber) hash(value string) string {
+	mac := hmac.New(sha256.New, []byte(s.HashKey))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// anonymizes comma separated list of ip addresses (e.g. X-Forwarded-For)
+func anonymizeIPList(value string) (result string, changed bool) {
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		anonymized, ok := AnonymizeIP(part)
+		if !ok {
+			return value, false
+		}
+		parts[i] = anonymized
+	}
+	result = strings.Join(parts, ", ")
+	return result, result != value
+}
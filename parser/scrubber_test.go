@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrubber(t *testing.T) {
+
+	Convey("Test Luhn", t, func() {
+		So(Luhn("4111111111111111"), ShouldBeTrue)
+		So(Luhn("4111111111111112"), ShouldBeFalse)
+		So(Luhn(""), ShouldBeFalse)
+		So(Luhn("41a1"), ShouldBeFalse)
+	})
+
+	Convey("Test anonymize ip", t, func() {
+		ip, ok := AnonymizeIP("192.168.1.42")
+		So(ok, ShouldBeTrue)
+		So(ip, ShouldEqual, "192.168.1.0")
+
+		ip, ok = AnonymizeIP("2001:db8:85a3::8a2e:370:7334")
+		So(ok, ShouldBeTrue)
+		So(ip, ShouldEqual, "2001:db8:85a3::")
+
+		_, ok = AnonymizeIP("not ip")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Test scrub event", t, func() {
+		event := NewRawEvent()
+		event.Message = "payment failed for card 4111 1111 1111 1111"
+		event.Extra = map[string]interface{}{
+			"password": "hunter2",
+			"order_id": "4111111111111112",
+			"db_pwd":   "safe",
+		}
+		event.Tags["api_key"] = "abc"
+		event.Data["interfaces"] = []EventParserInterfacer{
+			&HttpInterfaceV4{
+				PatrolInterface: PatrolInterface{ID: "http"},
+				URL:             "http://example.com",
+				QueryString:     "token=secret&page=1",
+				Headers:         map[string]string{"Authorization": "Bearer xyz", "Accept": "*/*"},
+				Env:             map[string]string{"REMOTE_ADDR": "10.1.2.3"},
+			},
+		}
+		event.Data["user"] = `{"ip_address": "10.1.2.3", "username": "john"}`
+
+		scrubber := NewScrubber(func(s *Scrubber) {
+			s.Fields = append(s.Fields, "username")
+			s.SafeFields = []string{"db_pwd"}
+		})
+		scrubbed := scrubber.Scrub(event)
+
+		So(scrubbed, ShouldContain, "message")
+		So(scrubbed, ShouldContain, "extra.password")
+		So(scrubbed, ShouldContain, "tags.api_key")
+		So(scrubbed, ShouldContain, "http.headers.Authorization")
+		So(scrubbed, ShouldContain, "http.query_string")
+		So(scrubbed, ShouldContain, "http.env.REMOTE_ADDR")
+		So(scrubbed, ShouldContain, "user.ip_address")
+		So(scrubbed, ShouldContain, "user.username")
+		So(scrubbed, ShouldNotContain, "extra.order_id")
+		So(scrubbed, ShouldNotContain, "extra.db_pwd")
+		So(event.Data[SCRUBBED_FIELDS_DATA_KEY], ShouldResemble, scrubbed)
+
+		So(event.Message, ShouldEqual, "payment failed for card "+SCRUBBED_VALUE)
+		So(event.Extra["password"], ShouldEqual, SCRUBBED_VALUE)
+		So(event.Extra["db_pwd"], ShouldEqual, "safe")
+		So(event.Tags["api_key"], ShouldEqual, SCRUBBED_VALUE)
+
+		user := map[string]interface{}{}
+		So(json.Unmarshal([]byte(event.Data["user"].(string)), &user), ShouldBeNil)
+		So(user["ip_address"], ShouldEqual, "10.1.2.0")
+
+		http := event.Data["interfaces"].([]interface{})[0].(map[string]interface{})
+		So(http["headers"].(map[string]interface{})["Authorization"], ShouldEqual, SCRUBBED_VALUE)
+		So(http["headers"].(map[string]interface{})["Accept"], ShouldEqual, "*/*")
+	})
+
+	Convey("Test anonymized user tag", t, func() {
+		scrubber := NewScrubber(func(s *Scrubber) {
+			s.HashKey = "key"
+		})
+
+		// user tag is set by parser from user interface
+		tagged := func(user string) *RawEvent {
+			event := NewRawEvent()
+			event.Data["user"] = user
+			event.Tags[USER_TAG] = event.UserIdentifier()
+			return event
+		}
+
+		event := tagged(`{"ip_address": "10.1.2.3"}`)
+		So(scrubber.Scrub(event), ShouldContain, "tags."+USER_TAG)
+		So(event.Tags[USER_TAG], ShouldEqual, "ip:10.1.2.0")
+
+		event = tagged(`{"email": "john@example.com", "ip_address": "10.1.2.3"}`)
+		So(scrubber.Scrub(event), ShouldContain, "tags."+USER_TAG)
+		So(event.Tags[USER_TAG], ShouldStartWith, "email:")
+		So(event.Tags[USER_TAG], ShouldNotContainSubstring, "john")
+		So(event.Tags[USER_TAG], ShouldNotContainSubstring, "10.1.2.3")
+
+		// same user has same tag, so affected users can be counted
+		other := tagged(`{"email": "john@example.com"}`)
+		scrubber.Scrub(other)
+		So(other.Tags[USER_TAG], ShouldEqual, event.Tags[USER_TAG])
+
+		// hash is keyed
+		other = tagged(`{"email": "john@example.com"}`)
+		NewScrubber(func(s *Scrubber) { s.HashKey = "other" }).Scrub(other)
+		So(other.Tags[USER_TAG], ShouldNotEqual, event.Tags[USER_TAG])
+
+		// tag sent by client is anonymized too
+		event = NewRawEvent()
+		event.Tags[USER_TAG] = "ip:192.168.1.42"
+		scrubber.Scrub(event)
+		So(event.Tags[USER_TAG], ShouldEqual, "ip:192.168.1.0")
+
+		// user ids are kept, without ip anonymization tag is kept
+		event = tagged(`{"id": 42, "email": "john@example.com"}`)
+		scrubber.Scrub(event)
+		So(event.Tags[USER_TAG], ShouldEqual, "id:42")
+
+		event = tagged(`{"email": "john@example.com"}`)
+		NewScrubber(func(s *Scrubber) { s.IPAddresses = false }).Scrub(event)
+		So(event.Tags[USER_TAG], ShouldEqual, "email:john@example.com")
+	})
+
+	Convey("Test nothing to scrub", t, func() {
+		event := NewRawEvent()
+		event.Message = "nothing here"
+		So(NewScrubber().Scrub(event), ShouldBeEmpty)
+		_, ok := event.Data[SCRUBBED_FIELDS_DATA_KEY]
+		So(ok, ShouldBeFalse)
+	})
+}
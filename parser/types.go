@@ -79,6 +79,15 @@ type EventParserInterfacer interface {
 	SetScore(int)
 }
 
+/*
+EventParserTagger
+interfaces that promote some of their values to event tags
+*/
+type EventParserTagger interface {
+	// returns tags
+	Tags() map[string]string
+}
+
 type PatrolInterface struct {
 	ID    string `json:"id,omitempty"`
 	Score int    `json:"score"`
@@ -100,9 +100,15 @@ func bootstrap() error {
 	plugins := []core.Pluginer{
 		plugins.NewCommonPlugin(Context, pluginRegistry),
 		plugins.NewAuthPlugin(Context, pluginRegistry),
+		plugins.NewAuditPlugin(Context),
+		plugins.NewOIDCPlugin(Context, pluginRegistry),
 		plugins.NewEventsPlugin(Context, pluginRegistry),
 		plugins.NewProjectsPlugin(Context),
 		plugins.NewTeamsPlugin(Context),
+		plugins.NewAlertsPlugin(Context),
+		plugins.NewNotificationsPlugin(Context),
+		plugins.NewWebhooksPlugin(Context),
+		plugins.NewChatOpsPlugin(Context, pluginRegistry),
 		plugins.NewStaticPlugin(Context, pluginRegistry),
 		plugins.NewRealtimePlugin(Context, pluginRegistry),
 	}
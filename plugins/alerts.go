@@ -0,0 +1,60 @@
+package plugins
+
+import (
+	"github.com/justinas/alice"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/core"
+	"github.com/phonkee/patrol/middlewares"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/views/alerts"
+)
+
+func NewAlertsPlugin(context *context.Context) core.Pluginer {
+	return &AlertsPlugin{context: context}
+}
+
+/*
+Alerts plugin -
+handles alert rules, rules are evaluated by event worker.
+Other plugins can add alert actions with alerts.RegisterAction.
+*/
+type AlertsPlugin struct {
+	core.Plugin
+	context *context.Context
+}
+
+func (a *AlertsPlugin) ID() string { return settings.ALERTS_PLUGIN_ID }
+func (a *AlertsPlugin) URLs() []*views.URL {
+	mids := []alice.Constructor{
+		middlewares.TokenScopeMiddleware(models.TOKEN_SCOPE_PROJECT_READ, models.TOKEN_SCOPE_PROJECT_WRITE),
+		middlewares.AuthTokenValidMiddleware(),
+	}
+	return []*views.URL{
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/alertrule/",
+			alerts.NewAlertRuleListAPIView,
+		).Name(settings.ROUTE_ALERTS_ALERTRULE_LIST).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/alertrule/{alertrule_id:[0-9]+}",
+			alerts.NewAlertRuleDetailAPIView,
+		).Name(settings.ROUTE_ALERTS_ALERTRULE_DETAIL).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/alerts/action/",
+			func() views.Viewer { return &alerts.ActionListAPIView{} },
+		).Name(settings.ROUTE_ALERTS_ACTION_LIST).Middlewares(mids...),
+	}
+}
+
+func (a *AlertsPlugin) Migrations() []core.Migrationer {
+	return []core.Migrationer{
+		core.NewMigration(
+			models.MIGRATION_ALERTS_ALERTRULE_INITIAL_ID,
+			[]string{models.MIGRATION_ALERTS_ALERTRULE_INITIAL},
+			models.MIGRATION_ALERTS_ALERTRULE_INITIAL_DEPENDENCIES,
+		),
+	}
+}
@@ -0,0 +1,52 @@
+package plugins
+
+import (
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/core"
+	"github.com/phonkee/patrol/middlewares"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/views/audit"
+)
+
+func NewAuditPlugin(context *context.Context) core.Pluginer {
+	return &AuditPlugin{context: context}
+}
+
+/*
+Audit plugin -
+append-only log of administrative actions (changes of users, teams, team
+members, projects and permissions). Entries are recorded by views with
+mixins.AuditMixin.
+*/
+type AuditPlugin struct {
+	core.Plugin
+	context *context.Context
+}
+
+func (a *AuditPlugin) ID() string { return settings.AUDIT_PLUGIN_ID }
+func (a *AuditPlugin) URLs() []*views.URL {
+	return []*views.URL{
+		views.NewURL("/api/audit/", audit.NewAuditLogListAPIView).Name(settings.ROUTE_AUDIT_AUDITLOG_LIST).Middlewares(
+			middlewares.TokenScopeMiddleware(models.TOKEN_SCOPE_TEAM_ADMIN, models.TOKEN_SCOPE_TEAM_ADMIN),
+			middlewares.AuthTokenValidMiddleware(),
+		),
+	}
+}
+
+func (a *AuditPlugin) Migrations() []core.Migrationer {
+	return []core.Migrationer{
+		core.NewMigration(
+			models.MIGRATION_AUDIT_AUDITLOG_INITIAL_ID,
+			[]string{
+				models.MIGRATION_AUDIT_AUDITLOG_INITIAL,
+				models.MIGRATION_AUDIT_AUDITLOG_INDEX,
+				models.MIGRATION_AUDIT_AUDITLOG_TARGET_INDEX,
+				models.MIGRATION_AUDIT_AUDITLOG_NO_UPDATE,
+				models.MIGRATION_AUDIT_AUDITLOG_NO_DELETE,
+			},
+			[]string{},
+		),
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/phonkee/patrol/core"
 	"github.com/phonkee/patrol/middlewares"
 	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/notifications"
 	"github.com/phonkee/patrol/rest/views"
 	"github.com/phonkee/patrol/settings"
 	"github.com/phonkee/patrol/signals"
@@ -14,25 +15,32 @@ import (
 )
 
 func NewAuthPlugin(context *context.Context, pr *core.PluginRegistry) core.Pluginer {
-	return &AuthPlugin{context: context, pr: pr}
+	return &AuthPlugin{context: context, pr: pr, mailer: notifications.NewMailer(context)}
 }
 
 type AuthPlugin struct {
 	core.Plugin
 	context                         *context.Context
 	pr                              *core.PluginRegistry
+	mailer                          *notifications.Mailer
 	OnSuccessfulLoginSignalHandlers []signals.OnSuccessfulLoginSignalHandler
+	OnLoginFailedSignalHandlers     []signals.OnLoginFailedSignalHandler
 }
 
 // Plugin identifier
 func (p *AuthPlugin) ID() string { return settings.AUTH_PLUGIN_ID }
 func (a *AuthPlugin) Init() error {
 	a.OnSuccessfulLoginSignalHandlers = []signals.OnSuccessfulLoginSignalHandler{}
+	a.OnLoginFailedSignalHandlers = []signals.OnLoginFailedSignalHandler{}
 	a.pr.Do(func(plugin core.Pluginer) error {
 		if t, ok := plugin.(signals.OnSuccessfulLoginSignalHandler); ok {
 			glog.V(2).Infof("event signals: adding %T as OnLoginSignalHandler.", plugin)
 			a.OnSuccessfulLoginSignalHandlers = append(a.OnSuccessfulLoginSignalHandlers, t)
 		}
+		if t, ok := plugin.(signals.OnLoginFailedSignalHandler); ok {
+			glog.V(2).Infof("event signals: adding %T as OnLoginFailedSignalHandler.", plugin)
+			a.OnLoginFailedSignalHandlers = append(a.OnLoginFailedSignalHandlers, t)
+		}
 		return nil
 	})
 	return nil
@@ -44,20 +52,105 @@ func (a *AuthPlugin) URLs() []*views.URL {
 		views.NewURL(
 			"/api/auth/login", func() views.Viewer {
 				return &auth.AuthLoginAPIView{
-					LoginSignal: a.SendSuccessfulLoginSignal,
+					LoginSignal:       a.SendSuccessfulLoginSignal,
+					LoginFailedSignal: a.SendLoginFailedSignal,
 				}
 			},
 		).Name(settings.ROUTE_AUTH_LOGIN),
 
+		views.NewURL(
+			"/api/auth/login/twofactor", func() views.Viewer {
+				return &auth.AuthLoginTwoFactorAPIView{
+					LoginSignal: a.SendSuccessfulLoginSignal,
+				}
+			},
+		).Name(settings.ROUTE_AUTH_LOGIN_TWOFACTOR),
+
+		views.NewURL(
+			"/api/auth/login/twofactor/enroll", func() views.Viewer {
+				return &auth.AuthLoginTwoFactorEnrollAPIView{}
+			},
+		).Name(settings.ROUTE_AUTH_LOGIN_TWOFACTOR_ENROLL),
+
+		views.NewURL(
+			"/api/auth/refresh", func() views.Viewer {
+				return &auth.AuthRefreshAPIView{}
+			},
+		).Name(settings.ROUTE_AUTH_REFRESH),
+
+		views.NewURL(
+			"/api/auth/password/reset", func() views.Viewer {
+				return &auth.AuthPasswordResetAPIView{Mailer: a.mailer}
+			},
+		).Name(settings.ROUTE_AUTH_PASSWORD_RESET),
+
+		views.NewURL(
+			"/api/auth/password/reset/confirm", func() views.Viewer {
+				return &auth.AuthPasswordResetConfirmAPIView{}
+			},
+		).Name(settings.ROUTE_AUTH_PASSWORD_RESET_CONFIRM),
+
+		views.NewURL(
+			"/api/auth/email/verify", func() views.Viewer {
+				return &auth.AuthEmailVerifyAPIView{}
+			},
+		).Name(settings.ROUTE_AUTH_EMAIL_VERIFY),
+
+		views.NewURL(
+			"/api/auth/email/verify/resend", func() views.Viewer {
+				return &auth.AuthEmailVerifyResendAPIView{Mailer: a.mailer}
+			},
+		).Name(settings.ROUTE_AUTH_EMAIL_VERIFY_RESEND),
+
+		views.NewURL(
+			"/api/auth/logout", func() views.Viewer {
+				return &auth.AuthLogoutAPIView{}
+			},
+		).Name(settings.ROUTE_AUTH_LOGOUT).Middlewares(middlewares.AuthTokenValidMiddleware()),
+
+		views.NewURL(
+			"/api/auth/logout/all", func() views.Viewer {
+				return &auth.AuthLogoutAllAPIView{}
+			},
+		).Name(settings.ROUTE_AUTH_LOGOUT_ALL).Middlewares(middlewares.AuthTokenValidMiddleware()),
+
 		views.NewURL(
 			"/api/auth/me", func() views.Viewer {
 				return &auth.AuthMeAPIView{}
 			},
 		).Name(settings.ROUTE_AUTH_ME).Middlewares(middlewares.AuthTokenValidMiddleware()),
 
+		views.NewURL(
+			"/api/auth/me/tokens",
+			auth.NewPersonalTokenListAPIView,
+		).Name(settings.ROUTE_AUTH_PERSONALTOKEN_LIST).Middlewares(middlewares.AuthTokenValidMiddleware()),
+
+		views.NewURL(
+			"/api/auth/me/tokens/{personaltoken_id:[0-9]+}",
+			auth.NewPersonalTokenDetailAPIView,
+		).Name(settings.ROUTE_AUTH_PERSONALTOKEN_DETAIL).Middlewares(middlewares.AuthTokenValidMiddleware()),
+
+		views.NewURL(
+			"/api/auth/me/twofactor", func() views.Viewer {
+				return &auth.AuthTwoFactorAPIView{}
+			},
+		).Name(settings.ROUTE_AUTH_TWOFACTOR).Middlewares(middlewares.AuthTokenValidMiddleware()),
+
+		views.NewURL(
+			"/api/auth/me/twofactor/confirm", func() views.Viewer {
+				return &auth.AuthTwoFactorConfirmAPIView{}
+			},
+		).Name(settings.ROUTE_AUTH_TWOFACTOR_CONFIRM).Middlewares(middlewares.AuthTokenValidMiddleware()),
+
+		views.NewURL(
+			"/api/auth/me/twofactor/recovery", func() views.Viewer {
+				return &auth.AuthTwoFactorRecoveryAPIView{}
+			},
+		).Name(settings.ROUTE_AUTH_TWOFACTOR_RECOVERY).Middlewares(middlewares.AuthTokenValidMiddleware()),
+
 		views.NewURL(
 			"/api/auth/user/", func() views.Viewer {
-				return auth.NewUserListAPIView()
+				return auth.NewUserListAPIView(a.mailer)
 			},
 		).Name(settings.ROUTE_AUTH_USER_LIST).Middlewares(middlewares.AuthTokenValidMiddleware()),
 
@@ -72,6 +165,24 @@ func (a *AuthPlugin) URLs() []*views.URL {
 				return &auth.UserChangePasswordAPIView{}
 			},
 		).Name(settings.ROUTE_AUTH_USER_CHANGE_PASSWORD).Middlewares(middlewares.AuthTokenValidMiddleware()),
+
+		views.NewURL(
+			"/api/auth/user/{user_id:[0-9]+}/lockout", func() views.Viewer {
+				return &auth.UserLockoutAPIView{}
+			},
+		).Name(settings.ROUTE_AUTH_USER_LOCKOUT).Middlewares(middlewares.AuthTokenValidMiddleware()),
+
+		views.NewURL(
+			"/api/auth/user/{user_id:[0-9]+}/permission/", func() views.Viewer {
+				return &auth.UserPermissionListAPIView{}
+			},
+		).Name(settings.ROUTE_AUTH_USER_PERMISSION_LIST).Middlewares(middlewares.AuthTokenValidMiddleware()),
+
+		views.NewURL(
+			"/api/auth/user/{user_id:[0-9]+}/permission/{codename:[a-z0-9_:]+}", func() views.Viewer {
+				return &auth.UserPermissionDetailAPIView{}
+			},
+		).Name(settings.ROUTE_AUTH_USER_PERMISSION_DETAIL).Middlewares(middlewares.AuthTokenValidMiddleware()),
 	}
 	return urls
 }
@@ -81,9 +192,44 @@ func (a *AuthPlugin) Migrations() []core.Migrationer {
 	return []core.Migrationer{
 		core.NewMigration(models.MIGRATION_AUTH_USER_INITIAL_ID, []string{models.MIGRATION_AUTH_USER_INITIAL}, []string{}),
 		core.NewMigration(models.MIGRATION_AUTH_PERMISSION_INITIAL_ID, []string{models.MIGRATION_AUTH_PERMISSION_INITIAL}, []string{}),
+		core.NewMigration(
+			models.MIGRATION_AUTH_REFRESHTOKEN_INITIAL_ID,
+			[]string{models.MIGRATION_AUTH_REFRESHTOKEN_INITIAL, models.MIGRATION_AUTH_REFRESHTOKEN_SESSION_INDEX},
+			[]string{settings.AUTH_PLUGIN_ID + ":" + models.MIGRATION_AUTH_USER_INITIAL_ID},
+		),
+		core.NewMigration(
+			models.MIGRATION_AUTH_PERSONALTOKEN_INITIAL_ID,
+			[]string{models.MIGRATION_AUTH_PERSONALTOKEN_INITIAL},
+			[]string{settings.AUTH_PLUGIN_ID + ":" + models.MIGRATION_AUTH_USER_INITIAL_ID},
+		),
+		core.NewMigration(
+			models.MIGRATION_AUTH_USER_EMAIL_VERIFIED_ID,
+			[]string{models.MIGRATION_AUTH_USER_EMAIL_VERIFIED},
+			[]string{settings.AUTH_PLUGIN_ID + ":" + models.MIGRATION_AUTH_USER_INITIAL_ID},
+		),
+		core.NewMigration(
+			models.MIGRATION_AUTH_TWOFACTOR_INITIAL_ID,
+			[]string{models.MIGRATION_AUTH_TWOFACTOR_INITIAL},
+			[]string{settings.AUTH_PLUGIN_ID + ":" + models.MIGRATION_AUTH_USER_INITIAL_ID},
+		),
 	}
 }
 
+/*
+PostMigrate registers permissions of all plugins (that implement
+PermissionsProvider) to database
+*/
+func (a *AuthPlugin) PostMigrate(ctx *context.Context) (err error) {
+	manager := models.NewPermissionManager(ctx)
+	return a.pr.Do(func(plugin core.Pluginer) error {
+		if t, ok := plugin.(signals.PermissionsProvider); ok {
+			glog.V(2).Infof("auth: registering permissions of %s.", plugin.ID())
+			return manager.Register(t.Permissions()...)
+		}
+		return nil
+	})
+}
+
 // send succesfull login signal
 func (a *AuthPlugin) SendSuccessfulLoginSignal(user *models.User) error {
 	glog.V(2).Infof("signal: sending successful login signal")
@@ -100,6 +246,22 @@ func (a *AuthPlugin) SendSuccessfulLoginSignal(user *models.User) error {
 	return nil
 }
 
+// send failed login signal
+func (a *AuthPlugin) SendLoginFailedSignal(username, ip string, failures int) error {
+	glog.V(2).Infof("signal: sending login failed signal")
+	for _, sh := range a.OnLoginFailedSignalHandlers {
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					glog.Errorf("OnLoginFailedSignalHandler %v panicked", sh)
+				}
+			}()
+			sh.OnLoginFailed(username, ip, failures)
+		}()
+	}
+	return nil
+}
+
 func (a *AuthPlugin) Commands() []core.Commander {
 	return []core.Commander{
 		commands.NewAuthCreateSuperuserCommand(a.context),
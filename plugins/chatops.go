@@ -0,0 +1,92 @@
+package plugins
+
+import (
+	"github.com/golang/glog"
+	"github.com/justinas/alice"
+	ops "github.com/phonkee/patrol/chatops"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/core"
+	"github.com/phonkee/patrol/middlewares"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/views/chatops"
+)
+
+func NewChatOpsPlugin(context *context.Context, pr *core.PluginRegistry) core.Pluginer {
+	return &ChatOpsPlugin{context: context, pr: pr}
+}
+
+/*
+ChatOps plugin -
+posts new and regressed eventgroups to Slack/Mattermost compatible incoming
+webhooks of project. Messages have signed links to resolve or mute eventgroup.
+*/
+type ChatOpsPlugin struct {
+	core.Plugin
+	context *context.Context
+	pr      *core.PluginRegistry
+}
+
+func (c *ChatOpsPlugin) ID() string { return settings.CHATOPS_PLUGIN_ID }
+func (c *ChatOpsPlugin) URLs() []*views.URL {
+	mids := []alice.Constructor{
+		middlewares.TokenScopeMiddleware(models.TOKEN_SCOPE_PROJECT_READ, models.TOKEN_SCOPE_PROJECT_WRITE),
+		middlewares.AuthTokenValidMiddleware(),
+	}
+	return []*views.URL{
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/chatwebhook/",
+			chatops.NewChatWebhookListAPIView,
+		).Name(settings.ROUTE_CHATOPS_CHATWEBHOOK_LIST).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/chatwebhook/{chatwebhook_id:[0-9]+}",
+			chatops.NewChatWebhookDetailAPIView,
+		).Name(settings.ROUTE_CHATOPS_CHATWEBHOOK_DETAIL).Middlewares(mids...),
+
+		// action links are signed, no auth token is needed
+		views.NewURL(
+			"/api/chatops/action/{action:resolve|mute}/{eventgroup_id:[0-9]+}",
+			func() views.Viewer {
+				return chatops.NewActionAPIView(c.SendOnEventGroupResolvedSignal)
+			},
+		).Name(settings.ROUTE_CHATOPS_ACTION),
+	}
+}
+
+func (c *ChatOpsPlugin) Migrations() []core.Migrationer {
+	return []core.Migrationer{
+		core.NewMigration(
+			models.MIGRATION_CHATOPS_CHATWEBHOOK_INITIAL_ID,
+			[]string{models.MIGRATION_CHATOPS_CHATWEBHOOK_INITIAL},
+			models.MIGRATION_CHATOPS_CHATWEBHOOK_INITIAL_DEPENDENCIES,
+		),
+	}
+}
+
+// resolved signal is sent by events plugin to all its handlers
+func (c *ChatOpsPlugin) SendOnEventGroupResolvedSignal(eventgroup *models.EventGroup, user *models.User) {
+	plugin, err := c.pr.Plugin(settings.EVENTS_PLUGIN_ID)
+	if err != nil {
+		glog.Errorf("chatops: cannot send resolved signal: %s.", err)
+		return
+	}
+	if events, ok := plugin.(*EventsPlugin); ok {
+		events.SendOnEventGroupResolvedSignal(eventgroup, user)
+	}
+}
+
+// signal handler, posts new and regressed eventgroups to chat
+func (c *ChatOpsPlugin) OnEvent(event *models.Event, eventgroup *models.EventGroup) {
+	if !eventgroup.IsNew && !eventgroup.IsRegression {
+		return
+	}
+	if eventgroup.Status == models.EVENT_GROUP_STATUS_MUTED {
+		return
+	}
+
+	if err := ops.NewNotifier(c.context).Notify(event, eventgroup); err != nil {
+		glog.Errorf("chatops: cannot notify about %s: %s.", eventgroup, err)
+	}
+}
@@ -24,8 +24,9 @@ type EventsPlugin struct {
 	pr      *core.PluginRegistry
 
 	// signal handlers
-	onEventRequestHandlers []signals.OnEventRequestSignalHandler
-	onEventHandlers        []signals.OnEventSignalHandler
+	onEventRequestHandlers       []signals.OnEventRequestSignalHandler
+	onEventHandlers              []signals.OnEventSignalHandler
+	onEventGroupResolvedHandlers []signals.OnEventGroupResolvedSignalHandler
 }
 
 func (e *EventsPlugin) ID() string { return settings.EVENTS_PLUGIN_ID }
@@ -41,6 +42,17 @@ func (e *EventsPlugin) Init() (err error) {
 		return err
 	}
 
+	// add on eventgroup resolved signal handlers
+	if err = e.pr.Do(func(plugin core.Pluginer) error {
+		if t, ok := plugin.(signals.OnEventGroupResolvedSignalHandler); ok {
+			glog.V(2).Infof("event signals: adding %T as OnEventGroupResolvedSignalHandler.", plugin)
+			e.onEventGroupResolvedHandlers = append(e.onEventGroupResolvedHandlers, t)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
 	// add on event request signal handlers
 	if err = e.pr.Do(func(plugin core.Pluginer) error {
 		if t, ok := plugin.(signals.OnEventRequestSignalHandler); ok {
@@ -67,8 +79,23 @@ func (e *EventsPlugin) SendOnEventSignal(event *models.Event, eventgroup *models
 	}
 }
 
+// send OnEventGroupResolved
+func (e *EventsPlugin) SendOnEventGroupResolvedSignal(eventgroup *models.EventGroup, user *models.User) {
+	for _, sh := range e.onEventGroupResolvedHandlers {
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					glog.Errorf("signal handler panicked %+v", err)
+				}
+			}()
+			sh.OnEventGroupResolved(eventgroup, user)
+		}()
+	}
+}
+
 func (e *EventsPlugin) URLs() []*views.URL {
 	mids := []alice.Constructor{
+		middlewares.TokenScopeMiddleware(models.TOKEN_SCOPE_EVENT_READ, models.TOKEN_SCOPE_EVENT_ADMIN),
 		middlewares.AuthTokenValidMiddleware(),
 	}
 	result := []*views.URL{
@@ -91,14 +118,43 @@ func (e *EventsPlugin) URLs() []*views.URL {
 
 		views.NewURL("/api/projects/project/{project_id:[0-9]+}/eventgroup/{eventgroup_id:[0-9]+}/resolve",
 			func() views.Viewer {
-				return &events.EventGroupResolveAPIView{}
+				return events.NewEventGroupResolveAPIView(e.SendOnEventGroupResolvedSignal)
 			},
 		).Name(settings.ROUTE_EVENTS_EVENTGROUP_RESOLVE).Middlewares(mids...),
 
+		views.NewURL("/api/projects/project/{project_id:[0-9]+}/eventgroup/{eventgroup_id:[0-9]+}/mute",
+			events.NewEventGroupMuteAPIView,
+		).Name(settings.ROUTE_EVENTS_EVENTGROUP_MUTE).Middlewares(mids...),
+
 		views.NewURL(
 			"/api/projects/project/{project_id:[0-9]+}/eventgroup/{eventgroup_id:[0-9]+}/event/",
 			events.NewEventListView,
 		).Name(settings.ROUTE_EVENTS_EVENT_LIST).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/eventgroup/{eventgroup_id:[0-9]+}/tags",
+			events.NewEventGroupTagsAPIView,
+		).Name(settings.ROUTE_EVENTS_EVENTGROUP_TAGS).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/eventgroup/{eventgroup_id:[0-9]+}/environments",
+			events.NewEventGroupEnvironmentsAPIView,
+		).Name(settings.ROUTE_EVENTS_EVENTGROUP_ENVIRONMENTS).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/release/",
+			events.NewReleaseListAPIView,
+		).Name(settings.ROUTE_EVENTS_RELEASE_LIST).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/release/{release_id:[0-9]+}",
+			events.NewReleaseDetailAPIView,
+		).Name(settings.ROUTE_EVENTS_RELEASE_DETAIL).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/environment/",
+			events.NewEnvironmentListAPIView,
+		).Name(settings.ROUTE_EVENTS_ENVIRONMENT_LIST).Middlewares(mids...),
 	}
 
 	return result
@@ -107,6 +163,7 @@ func (e *EventsPlugin) URLs() []*views.URL {
 func (e *EventsPlugin) Commands() []core.Commander {
 	return []core.Commander{
 		commands.NewEventWorkerCommand(e.context, e.SendOnEventSignal),
+		commands.NewEventTailCommand(e.context),
 	}
 }
 
@@ -122,6 +179,41 @@ func (e *EventsPlugin) Migrations() []core.Migrationer {
 			[]string{models.MIGRATION_EVENTS_EVENT_INITIAL},
 			[]string{},
 		),
+		core.NewMigration(
+			models.MIGRATION_EVENTS_EVENTTAG_INITIAL_ID,
+			[]string{models.MIGRATION_EVENTS_EVENTTAG_INITIAL, models.MIGRATION_EVENTS_EVENTTAG_INDEX},
+			models.MIGRATION_EVENTS_EVENTTAG_INITIAL_DEPENDENCIES,
+		),
+		core.NewMigration(
+			models.MIGRATION_EVENTS_EVENT_UNIQUE_EVENT_ID_ID,
+			[]string{
+				models.MIGRATION_EVENTS_EVENT_EVENT_ID_INDEX,
+				models.MIGRATION_EVENTS_EVENT_UNIQUE_EVENT_ID,
+			},
+			models.MIGRATION_EVENTS_EVENT_UNIQUE_EVENT_ID_DEPENDENCIES,
+		),
+		core.NewMigration(
+			models.MIGRATION_EVENTS_RELEASE_INITIAL_ID,
+			[]string{
+				models.MIGRATION_EVENTS_RELEASE_INITIAL,
+				models.MIGRATION_EVENTS_RELEASE_EVENT,
+				models.MIGRATION_EVENTS_RELEASE_EVENTGROUP,
+			},
+			models.MIGRATION_EVENTS_RELEASE_INITIAL_DEPENDENCIES,
+		),
+		core.NewMigration(
+			models.MIGRATION_EVENTS_ENVIRONMENT_INITIAL_ID,
+			[]string{
+				models.MIGRATION_EVENTS_ENVIRONMENT_INITIAL,
+				models.MIGRATION_EVENTS_ENVIRONMENT_EVENT,
+			},
+			models.MIGRATION_EVENTS_ENVIRONMENT_INITIAL_DEPENDENCIES,
+		),
+		core.NewMigration(
+			models.MIGRATION_EVENTS_EVENTGROUPENVIRONMENT_INITIAL_ID,
+			[]string{models.MIGRATION_EVENTS_EVENTGROUPENVIRONMENT_INITIAL},
+			models.MIGRATION_EVENTS_EVENTGROUPENVIRONMENT_INITIAL_DEPENDENCIES,
+		),
 	}
 }
 
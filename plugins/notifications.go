@@ -0,0 +1,144 @@
+package plugins
+
+import (
+	"github.com/golang/glog"
+	"github.com/justinas/alice"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/core"
+	"github.com/phonkee/patrol/middlewares"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/notifications"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/settings"
+	notificationviews "github.com/phonkee/patrol/views/notifications"
+)
+
+func NewNotificationsPlugin(context *context.Context) core.Pluginer {
+	return &NotificationsPlugin{context: context}
+}
+
+/*
+Notifications plugin -
+notifies members of project team about new, regressed and resolved
+eventgroups by e-mail and personal webhook, respecting notification settings
+of every user. E-mails are batched into digests per project and recipient
+unless digests are disabled.
+*/
+type NotificationsPlugin struct {
+	core.Plugin
+	context  *context.Context
+	mailer   *notifications.Mailer
+	digester *notifications.Digester
+}
+
+func (n *NotificationsPlugin) ID() string { return settings.NOTIFICATIONS_PLUGIN_ID }
+func (n *NotificationsPlugin) Init() (err error) {
+	n.mailer = notifications.NewMailer(n.context)
+	n.digester = notifications.NewDigester(n.context, n.mailer)
+	return
+}
+
+func (n *NotificationsPlugin) URLs() []*views.URL {
+	mids := []alice.Constructor{
+		middlewares.AuthTokenValidMiddleware(),
+	}
+	return []*views.URL{
+		views.NewURL(
+			"/api/auth/me/notifications",
+			notificationviews.NewSettingAPIView,
+		).Name(settings.ROUTE_NOTIFICATIONS_SETTING).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/auth/me/notifications/project/{project_id:[0-9]+}",
+			notificationviews.NewProjectSettingAPIView,
+		).Name(settings.ROUTE_NOTIFICATIONS_PROJECTSETTING).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/auth/me/notifications/eventgroup/{eventgroup_id:[0-9]+}",
+			notificationviews.NewSubscriptionAPIView,
+		).Name(settings.ROUTE_NOTIFICATIONS_SUBSCRIPTION).Middlewares(mids...),
+	}
+}
+
+func (n *NotificationsPlugin) Migrations() []core.Migrationer {
+	return []core.Migrationer{
+		core.NewMigration(
+			models.MIGRATION_NOTIFICATIONS_SETTING_INITIAL_ID,
+			[]string{models.MIGRATION_NOTIFICATIONS_SETTING_INITIAL},
+			models.MIGRATION_NOTIFICATIONS_SETTING_INITIAL_DEPENDENCIES,
+		),
+		core.NewMigration(
+			models.MIGRATION_NOTIFICATIONS_PROJECTSETTING_INITIAL_ID,
+			[]string{models.MIGRATION_NOTIFICATIONS_PROJECTSETTING_INITIAL},
+			models.MIGRATION_NOTIFICATIONS_PROJECTSETTING_INITIAL_DEPENDENCIES,
+		),
+		core.NewMigration(
+			models.MIGRATION_NOTIFICATIONS_SUBSCRIPTION_INITIAL_ID,
+			[]string{models.MIGRATION_NOTIFICATIONS_SUBSCRIPTION_INITIAL},
+			models.MIGRATION_NOTIFICATIONS_SUBSCRIPTION_INITIAL_DEPENDENCIES,
+		),
+	}
+}
+
+// signal handler, notifies about new and regressed eventgroups
+func (n *NotificationsPlugin) OnEvent(event *models.Event, eventgroup *models.EventGroup) {
+	if !eventgroup.IsNew && !eventgroup.IsRegression {
+		return
+	}
+
+	webhookEvent := models.WEBHOOK_EVENT_EVENTGROUP_CREATED
+	if eventgroup.IsRegression {
+		webhookEvent = models.WEBHOOK_EVENT_EVENTGROUP_REGRESSED
+	}
+	n.notify(event, eventgroup, nil, true, webhookEvent)
+}
+
+// signal handler, notifies subscribed users about resolved eventgroup
+func (n *NotificationsPlugin) OnEventGroupResolved(eventgroup *models.EventGroup, user *models.User) {
+	n.notify(nil, eventgroup, user, false, models.WEBHOOK_EVENT_EVENTGROUP_RESOLVED)
+}
+
+/*
+Notifies project members who want notification about eventgroup by their
+enabled channels. Actor is user who caused notification.
+*/
+func (n *NotificationsPlugin) notify(event *models.Event, eventgroup *models.EventGroup, actor *models.User, newIssue bool, webhookEvent string) {
+	project := models.NewProject()
+	if err := models.NewProjectManager(n.context).GetByID(project, eventgroup.ProjectID); err != nil {
+		glog.Errorf("notifications: cannot get project %d: %s.", eventgroup.ProjectID, err)
+		return
+	}
+
+	recipients, err := notifications.Recipients(n.context, project, eventgroup, actor, newIssue)
+	if err != nil {
+		glog.Errorf("notifications: cannot get recipients of project %d: %s.", project.ID, err)
+		return
+	}
+
+	notifications.PostWebhooks(n.context, recipients, notifications.NewWebhookPayload(webhookEvent, project, eventgroup))
+
+	to := notifications.EmailAddresses(recipients)
+	if len(to) == 0 {
+		return
+	}
+
+	if n.digester.Enabled() {
+		if err = n.digester.Add(project, eventgroup, to); err != nil {
+			glog.Errorf("notifications: cannot add %s to digest: %s.", eventgroup, err)
+		}
+		return
+	}
+
+	// every recipient gets own message, so addresses are not disclosed
+	for _, address := range to {
+		var message *notifications.Message
+		if message, err = notifications.NewEventGroupMessage(project, event, eventgroup, []string{address}); err != nil {
+			glog.Errorf("notifications: cannot render message for %s: %s.", eventgroup, err)
+			return
+		}
+
+		if err = n.mailer.Send(message); err != nil {
+			glog.Errorf("notifications: cannot queue message for %s: %s.", eventgroup, err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package plugins
+
+import (
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/core"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/oidc"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/views/auth"
+)
+
+func NewOIDCPlugin(context *context.Context, pr *core.PluginRegistry) core.Pluginer {
+	return &OIDCPlugin{context: context, pr: pr, Provider: oidc.NewProvider()}
+}
+
+/*
+OIDC plugin -
+single sign-on by OpenID Connect identity provider. Users are created or
+linked by verified e-mail address and get patrol tokens after login.
+*/
+type OIDCPlugin struct {
+	core.Plugin
+	context *context.Context
+	pr      *core.PluginRegistry
+
+	// identity provider, configured by oidc_* settings
+	Provider *oidc.Provider
+}
+
+func (o *OIDCPlugin) ID() string { return settings.OIDC_PLUGIN_ID }
+func (o *OIDCPlugin) URLs() []*views.URL {
+	return []*views.URL{
+		views.NewURL(
+			"/api/auth/oidc/login", func() views.Viewer {
+				return &auth.OIDCLoginAPIView{Provider: o.Provider}
+			},
+		).Name(settings.ROUTE_AUTH_OIDC_LOGIN),
+
+		views.NewURL(
+			"/api/auth/oidc/callback", func() views.Viewer {
+				return &auth.OIDCCallbackAPIView{
+					Provider:    o.Provider,
+					LoginSignal: o.SendSuccessfulLoginSignal,
+				}
+			},
+		).Name(settings.ROUTE_AUTH_OIDC_CALLBACK),
+	}
+}
+
+// successful login signal is sent by auth plugin to all its handlers
+func (o *OIDCPlugin) SendSuccessfulLoginSignal(user *models.User) error {
+	plugin, err := o.pr.Plugin(settings.AUTH_PLUGIN_ID)
+	if err != nil {
+		glog.Errorf("oidc: cannot send login signal: %s.", err)
+		return err
+	}
+	if a, ok := plugin.(*AuthPlugin); ok {
+		return a.SendSuccessfulLoginSignal(user)
+	}
+	return nil
+}
@@ -27,6 +27,7 @@ type ProjectsPlugin struct {
 func (p *ProjectsPlugin) ID() string { return settings.PROJECTS_PLUGIN_ID }
 func (p *ProjectsPlugin) URLs() []*views.URL {
 	mids := []alice.Constructor{
+		middlewares.TokenScopeMiddleware(models.TOKEN_SCOPE_PROJECT_READ, models.TOKEN_SCOPE_PROJECT_WRITE),
 		middlewares.AuthTokenValidMiddleware(),
 	}
 	return []*views.URL{
@@ -52,6 +53,24 @@ func (p *ProjectsPlugin) URLs() []*views.URL {
 			},
 		).Name(settings.ROUTE_PROJECTS_PROJECTKEY_DETAIL).Middlewares(mids...),
 
+		views.NewURL("/api/projects/project/{project_id:[0-9]+}/scrubbing",
+			func() views.Viewer {
+				return &projects.ProjectScrubbingAPIView{}
+			},
+		).Name(settings.ROUTE_PROJECTS_PROJECT_SCRUBBING).Middlewares(mids...),
+
+		views.NewURL("/api/projects/project/{project_id:[0-9]+}/filters",
+			func() views.Viewer {
+				return &projects.ProjectInboundFilterAPIView{}
+			},
+		).Name(settings.ROUTE_PROJECTS_PROJECT_FILTERS).Middlewares(mids...),
+
+		views.NewURL("/api/projects/project/{project_id:[0-9]+}/filters/stats",
+			func() views.Viewer {
+				return &projects.ProjectFilterStatsAPIView{}
+			},
+		).Name(settings.ROUTE_PROJECTS_PROJECT_FILTER_STATS).Middlewares(mids...),
+
 	}
 }
 func (p *ProjectsPlugin) Migrations() []core.Migrationer {
@@ -60,24 +79,60 @@ func (p *ProjectsPlugin) Migrations() []core.Migrationer {
 			models.MIGRATION_PROJECT_INITIAL_ID,           // migration id
 			[]string{models.MIGRATION_PROJECT_INITIAL},    // migration queries
 			models.MIGRATION_PROJECT_INITIAL_DEPENDENCIES, // migration dependencies
-			p.PostInitialMigration,                        // post migration method
 		),
 		core.NewMigration(
 			models.MIGRATION_PROJECT_KEY_INITIAL_ID,
 			[]string{models.MIGRATION_PROJECT_KEY_INITIAL},
 			[]string{},
 		),
+		core.NewMigration(
+			models.MIGRATION_PROJECT_SCRUBBING_INITIAL_ID,
+			[]string{models.MIGRATION_PROJECT_SCRUBBING_INITIAL},
+			models.MIGRATION_PROJECT_SCRUBBING_INITIAL_DEPENDENCIES,
+		),
+		core.NewMigration(
+			models.MIGRATION_PROJECT_INBOUNDFILTER_INITIAL_ID,
+			[]string{models.MIGRATION_PROJECT_INBOUNDFILTER_INITIAL},
+			models.MIGRATION_PROJECT_INBOUNDFILTER_INITIAL_DEPENDENCIES,
+		),
+		core.NewMigration(
+			models.MIGRATION_PROJECT_FILTERSTAT_INITIAL_ID,
+			[]string{models.MIGRATION_PROJECT_FILTERSTAT_INITIAL},
+			models.MIGRATION_PROJECT_FILTERSTAT_INITIAL_DEPENDENCIES,
+		),
+		core.NewMigration(
+			models.MIGRATION_PROJECT_GRANT_ADD_PERMISSION_ID,
+			[]string{models.MIGRATION_PROJECT_GRANT_ADD_PERMISSION},
+			models.MIGRATION_PROJECT_GRANT_ADD_PERMISSION_DEPENDENCIES,
+			p.PostGrantAddPermissionMigration,
+		),
 	}
 }
 
-func (p *ProjectsPlugin) PostInitialMigration(ctx *context.Context) (err error) {
-	manager := models.NewPermissionManager(ctx)
-	permission := manager.NewPermission(func(perm *models.Permission) {
-		perm.Codename = settings.PERMISSION_TEAMS_TEAM_ADD
-		perm.Name = "Can add new project"
-	})
-	if err = permission.Insert(ctx); err != nil {
+/*
+Permission was granted to team admins directly in database, so cached team
+admins are removed from cache
+*/
+func (p *ProjectsPlugin) PostGrantAddPermissionMigration(ctx *context.Context) (err error) {
+	manager := models.NewUserManager(ctx)
+	users := manager.NewUserList()
+	if err = manager.Filter(&users, manager.QueryFilterTeamAdmin()); err != nil {
 		return
 	}
+	for _, user := range users {
+		if err = models.RemoveCached(ctx, user.String()); err != nil {
+			return
+		}
+	}
 	return
 }
+
+// Returns permissions of projects plugin
+func (p *ProjectsPlugin) Permissions() []*models.Permission {
+	return []*models.Permission{
+		models.NewPermission(func(perm *models.Permission) {
+			perm.Codename = settings.PERMISSION_PROJECTS_PROJECT_ADD
+			perm.Name = "Can add new project"
+		}),
+	}
+}
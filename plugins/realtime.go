@@ -6,8 +6,10 @@ import (
 	"github.com/golang/glog"
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/core"
+	"github.com/phonkee/patrol/middlewares"
 	"github.com/phonkee/patrol/models"
 	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/settings"
 	"github.com/phonkee/patrol/signals"
 	"github.com/phonkee/patrol/views/realtime"
 )
@@ -59,6 +61,16 @@ func (r *RealtimePlugin) URLs() []*views.URL {
 				return realtime.NewWebsocketAPIView(r.getSubscribeQueues)
 			},
 		).Name(ROUTE_REALTIME),
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/eventgroup/stream",
+			func() views.Viewer {
+				return &realtime.EventGroupStreamAPIView{}
+			},
+		).Name(settings.ROUTE_REALTIME_EVENTGROUP_STREAM).Middlewares(
+			middlewares.QueryTokenMiddleware(settings.REALTIME_TOKEN_PARAM_NAME),
+			middlewares.TokenScopeMiddleware(models.TOKEN_SCOPE_EVENT_READ, models.TOKEN_SCOPE_EVENT_READ),
+			middlewares.AuthTokenValidMiddleware(),
+		),
 	}
 }
 
@@ -72,12 +84,65 @@ func (r *RealtimePlugin) getSubscribeQueues(user *models.User, request *http.Req
 	return
 }
 
+/*
+Subscribes websocket to channel of user and channels of all projects visible
+to user
+*/
 func (r *RealtimePlugin) OnRealtimeWebsocketSubscribeTo(u *models.User, req *http.Request) (result []string) {
 	result = []string{}
 
-	if u != nil {
-		result = append(result, "reload-websocket"+u.ID.String())
+	if u == nil {
+		return
+	}
+
+	result = append(result, models.RealtimeUserChannel(u.ID))
+
+	manager := models.NewProjectManager(r.context)
+	projects := manager.NewProjectList()
+	if err := manager.Filter(&projects, manager.QueryFilterUser(u)); err != nil {
+		glog.Errorf("realtime: cannot list projects of %s: %s.", u, err)
+		return
+	}
+	for _, project := range projects {
+		result = append(result, models.RealtimeProjectChannel(project.ID))
 	}
 
 	return
 }
+
+// signal handler, publishes new event and changed eventgroup to project and
+// eventgroup channels
+func (r *RealtimePlugin) OnEvent(event *models.Event, eventgroup *models.EventGroup) {
+	channels := []string{
+		models.RealtimeProjectChannel(eventgroup.ProjectID),
+		models.RealtimeEventGroupChannel(eventgroup.ID),
+	}
+	manager := models.NewRealtimeManager(r.context)
+
+	if err := manager.Publish(models.NewEventCreatedRealtimeMessage(event, eventgroup), channels...); err != nil {
+		glog.Errorf("realtime: cannot publish %s: %s.", event, err)
+	}
+	if err := manager.Publish(&models.EventGroupChangedRealtimeMessage{EventGroup: eventgroup}, channels...); err != nil {
+		glog.Errorf("realtime: cannot publish %s: %s.", eventgroup, err)
+	}
+}
+
+// signal handler, publishes resolved eventgroup to project and eventgroup
+// channels
+func (r *RealtimePlugin) OnEventGroupResolved(eventgroup *models.EventGroup, user *models.User) {
+	message := &models.EventGroupChangedRealtimeMessage{EventGroup: eventgroup}
+	if err := models.NewRealtimeManager(r.context).Publish(message,
+		models.RealtimeProjectChannel(eventgroup.ProjectID),
+		models.RealtimeEventGroupChannel(eventgroup.ID),
+	); err != nil {
+		glog.Errorf("realtime: cannot publish %s: %s.", eventgroup, err)
+	}
+}
+
+// signal handler, publishes login to channel of user
+func (r *RealtimePlugin) OnSuccessfulLogin(user *models.User) {
+	message := &models.UserLoggedRealtimeMessage{ID: user.ID, Username: user.Username}
+	if err := models.NewRealtimeManager(r.context).Publish(message, models.RealtimeUserChannel(user.ID)); err != nil {
+		glog.Errorf("realtime: cannot publish %s: %s.", user, err)
+	}
+}
@@ -24,6 +24,7 @@ func (t *TeamsPlugin) ID() string { return settings.TEAMS_PLUGIN_ID }
 func (t *TeamsPlugin) URLs() []*views.URL {
 
 	mids := []alice.Constructor{
+		middlewares.TokenScopeMiddleware(models.TOKEN_SCOPE_TEAM_ADMIN, models.TOKEN_SCOPE_TEAM_ADMIN),
 		middlewares.AuthTokenValidMiddleware(),
 	}
 	return []*views.URL{
@@ -32,7 +33,11 @@ func (t *TeamsPlugin) URLs() []*views.URL {
 			func() views.Viewer {
 				return &teams.TeamListAPIView{}
 			},
-		).Name(settings.ROUTE_TEAMS_TEAM_LIST).Middlewares(mids...),
+		).Name(settings.ROUTE_TEAMS_TEAM_LIST).Middlewares(
+			middlewares.TokenScopeMiddleware(models.TOKEN_SCOPE_TEAM_ADMIN, models.TOKEN_SCOPE_TEAM_ADMIN),
+			middlewares.AuthTokenValidMiddleware(),
+			middlewares.RequirePermission(settings.PERMISSION_TEAMS_TEAM_ADD, "POST"),
+		),
 
 		views.NewURL("/api/teams/team/{team_id:[0-9]+}",
 			func() views.Viewer {
@@ -40,6 +45,11 @@ func (t *TeamsPlugin) URLs() []*views.URL {
 			},
 		).Name(settings.ROUTE_TEAMS_TEAM_DETAIL).Middlewares(mids...),
 
+		views.NewURL(
+			"/api/teams/team/{team_id:[0-9]+}/twofactor",
+			teams.NewTeamTwoFactorAPIView,
+		).Name(settings.ROUTE_TEAMS_TEAM_TWOFACTOR).Middlewares(mids...),
+
 		views.NewURL(
 			"/api/teams/team/{team_id:[0-9]+}/member/",
 			teams.NewTeamMemberListAPIView,
@@ -52,9 +62,24 @@ func (t *TeamsPlugin) URLs() []*views.URL {
 	}
 }
 
+// Returns permissions of teams plugin
+func (t *TeamsPlugin) Permissions() []*models.Permission {
+	return []*models.Permission{
+		models.NewPermission(func(perm *models.Permission) {
+			perm.Codename = settings.PERMISSION_TEAMS_TEAM_ADD
+			perm.Name = "Can add new team"
+		}),
+	}
+}
+
 func (t *TeamsPlugin) Migrations() []core.Migrationer {
 	return []core.Migrationer{
 		core.NewMigration(models.MIGRATION_TEAMS_TEAM_INITIAL_ID, []string{models.MIGRATION_TEAMS_TEAM_INITIAL}, []string{}),
 		core.NewMigration(models.MIGRATION_TEAMS_TEAM_MEMBER_INITIAL_ID, []string{models.MIGRATION_TEAMS_TEAM_MEMBER_INITIAL}, []string{}),
+		core.NewMigration(
+			models.MIGRATION_TEAMS_TEAM_REQUIRE_TWO_FACTOR_ID,
+			[]string{models.MIGRATION_TEAMS_TEAM_REQUIRE_TWO_FACTOR},
+			[]string{settings.TEAMS_PLUGIN_ID + ":" + models.MIGRATION_TEAMS_TEAM_INITIAL_ID},
+		),
 	}
 }
@@ -0,0 +1,106 @@
+package plugins
+
+import (
+	"github.com/golang/glog"
+	"github.com/justinas/alice"
+	"github.com/phonkee/patrol/commands"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/core"
+	"github.com/phonkee/patrol/middlewares"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/views/webhooks"
+	wh "github.com/phonkee/patrol/webhooks"
+)
+
+func NewWebhooksPlugin(context *context.Context) core.Pluginer {
+	return &WebhooksPlugin{context: context}
+}
+
+/*
+Webhooks plugin -
+posts project events to subscribed webhooks, deliveries are queued in signal
+handlers and delivered by webhook worker.
+*/
+type WebhooksPlugin struct {
+	core.Plugin
+	context *context.Context
+}
+
+func (p *WebhooksPlugin) ID() string { return settings.WEBHOOKS_PLUGIN_ID }
+func (p *WebhooksPlugin) URLs() []*views.URL {
+	mids := []alice.Constructor{
+		middlewares.TokenScopeMiddleware(models.TOKEN_SCOPE_PROJECT_READ, models.TOKEN_SCOPE_PROJECT_WRITE),
+		middlewares.AuthTokenValidMiddleware(),
+	}
+	return []*views.URL{
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/webhook/",
+			webhooks.NewWebhookListAPIView,
+		).Name(settings.ROUTE_WEBHOOKS_WEBHOOK_LIST).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/webhook/{webhook_id:[0-9]+}",
+			webhooks.NewWebhookDetailAPIView,
+		).Name(settings.ROUTE_WEBHOOKS_WEBHOOK_DETAIL).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/webhook/{webhook_id:[0-9]+}/delivery/",
+			webhooks.NewDeliveryListAPIView,
+		).Name(settings.ROUTE_WEBHOOKS_DELIVERY_LIST).Middlewares(mids...),
+
+		views.NewURL(
+			"/api/projects/project/{project_id:[0-9]+}/webhook/{webhook_id:[0-9]+}/delivery/{delivery_id:[0-9]+}/redeliver",
+			webhooks.NewDeliveryRedeliverAPIView,
+		).Name(settings.ROUTE_WEBHOOKS_DELIVERY_REDELIVER).Middlewares(mids...),
+	}
+}
+
+func (p *WebhooksPlugin) Commands() []core.Commander {
+	return []core.Commander{
+		commands.NewWebhookWorkerCommand(p.context),
+	}
+}
+
+func (p *WebhooksPlugin) Migrations() []core.Migrationer {
+	return []core.Migrationer{
+		core.NewMigration(
+			models.MIGRATION_WEBHOOKS_WEBHOOK_INITIAL_ID,
+			[]string{models.MIGRATION_WEBHOOKS_WEBHOOK_INITIAL},
+			models.MIGRATION_WEBHOOKS_WEBHOOK_INITIAL_DEPENDENCIES,
+		),
+		core.NewMigration(
+			models.MIGRATION_WEBHOOKS_WEBHOOKDELIVERY_INITIAL_ID,
+			[]string{
+				models.MIGRATION_WEBHOOKS_WEBHOOKDELIVERY_INITIAL,
+				models.MIGRATION_WEBHOOKS_WEBHOOKDELIVERY_INDEX,
+			},
+			models.MIGRATION_WEBHOOKS_WEBHOOKDELIVERY_INITIAL_DEPENDENCIES,
+		),
+	}
+}
+
+// signal handler, queues deliveries for processed event
+func (p *WebhooksPlugin) OnEvent(event *models.Event, eventgroup *models.EventGroup) {
+	eventTypes := []string{models.WEBHOOK_EVENT_EVENT_CREATED}
+	if eventgroup.IsNew {
+		eventTypes = append(eventTypes, models.WEBHOOK_EVENT_EVENTGROUP_CREATED)
+	} else if eventgroup.IsRegression {
+		eventTypes = append(eventTypes, models.WEBHOOK_EVENT_EVENTGROUP_REGRESSED)
+	}
+
+	dispatcher := wh.NewDispatcher(p.context)
+	for _, eventType := range eventTypes {
+		if err := dispatcher.Dispatch(eventgroup.ProjectID, eventType, eventgroup, event); err != nil {
+			glog.Errorf("webhooks: cannot dispatch %s for %s: %s.", eventType, eventgroup, err)
+		}
+	}
+}
+
+// signal handler, queues deliveries for resolved eventgroup
+func (p *WebhooksPlugin) OnEventGroupResolved(eventgroup *models.EventGroup, user *models.User) {
+	if err := wh.NewDispatcher(p.context).Dispatch(eventgroup.ProjectID, models.WEBHOOK_EVENT_EVENTGROUP_RESOLVED, eventgroup, nil); err != nil {
+		glog.Errorf("webhooks: cannot dispatch %s for %s: %s.", models.WEBHOOK_EVENT_EVENTGROUP_RESOLVED, eventgroup, err)
+	}
+}
@@ -7,6 +7,7 @@ package rest
 
 import (
 	"errors"
+	"net"
 	"net/http"
 	"strconv"
 
@@ -57,3 +58,14 @@ func GetMuxVarPrimaryKey(r *http.Request, name string) (value types.PrimaryKey,
 	}
 	return types.PrimaryKey(intvar), nil
 }
+
+/*
+	Returns ip address of client (without port)
+*/
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,101 @@
+package serializers
+
+import (
+	"strings"
+
+	"github.com/phonkee/patrol/alerts"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/validator"
+)
+
+const (
+	// maximum minutes between actions of alert rule (one week)
+	MAX_ALERT_RULE_FREQUENCY = 60 * 24 * 7
+)
+
+/*
+AlertsAlertRuleSerializer
+
+	serializer for creating and updating alert rules
+*/
+type AlertsAlertRuleSerializer struct {
+	Name       string                    `json:"name"       validator:"name"`
+	Enabled    bool                      `json:"enabled"`
+	MatchAll   bool                      `json:"match_all"`
+	Conditions models.AlertConditionList `json:"conditions" validator:"conditions"`
+	Actions    models.AlertActionList    `json:"actions"    validator:"actions"`
+	Frequency  int64                     `json:"frequency"  validator:"frequency"`
+}
+
+/*
+Cleans data in serializer
+*/
+func (a *AlertsAlertRuleSerializer) Clean() {
+	a.Name = strings.TrimSpace(a.Name)
+	for _, condition := range a.Conditions {
+		if condition != nil {
+			condition.Type = strings.TrimSpace(condition.Type)
+			condition.Key = strings.TrimSpace(condition.Key)
+		}
+	}
+	for _, action := range a.Actions {
+		if action != nil {
+			action.Type = strings.TrimSpace(action.Type)
+		}
+	}
+}
+
+/*
+Validate
+
+	validates alert rule, actions must be registered in alerts registry
+*/
+func (a *AlertsAlertRuleSerializer) Validate(context *context.Context) *validator.Result {
+	a.Clean()
+	validator := validator.New()
+	validator["name"] = models.ValidateAlertRuleName()
+	validator["conditions"] = models.ValidateAlertConditions()
+	validator["actions"] = ValidateAlertActions()
+	validator["frequency"] = models.ValidateAlertRuleFrequency(MAX_ALERT_RULE_FREQUENCY)
+	return validator.Validate(a)
+}
+
+/*
+Saves alert rule to database (inserts new rule)
+*/
+func (a *AlertsAlertRuleSerializer) Save(context *context.Context, rule *models.AlertRule) (err error) {
+	rule.Name = a.Name
+	rule.Enabled = a.Enabled
+	rule.MatchAll = a.MatchAll
+	rule.Conditions = a.Conditions
+	rule.Actions = a.Actions
+	rule.Frequency = int(a.Frequency)
+
+	if rule.ID == 0 {
+		return rule.Insert(context)
+	}
+	_, err = rule.Update(context)
+	return
+}
+
+/*
+Validates list of alert actions against registered actions
+*/
+func ValidateAlertActions() validator.ValidatorFunc {
+	return func(value interface{}) (err error) {
+		actions, ok := value.(models.AlertActionList)
+		if !ok || len(actions) == 0 || len(actions) > models.MAX_ALERT_RULE_ACTIONS {
+			return models.ErrInvalidAlertAction
+		}
+		for _, action := range actions {
+			if action == nil {
+				return models.ErrInvalidAlertAction
+			}
+			if err = alerts.Actions.Validate(action); err != nil {
+				return
+			}
+		}
+		return
+	}
+}
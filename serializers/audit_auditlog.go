@@ -0,0 +1,98 @@
+package serializers
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/validator"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+/*
+AuditAuditLogFilterSerializer
+
+	filter of audit log read from query params, all of them are optional:
+
+	actor_id, action, target_type, target_id, team_id, since, until (RFC 3339)
+*/
+type AuditAuditLogFilterSerializer struct {
+	ActorID    types.PrimaryKey
+	Action     string
+	TargetType string
+	TargetID   types.PrimaryKey
+	TeamID     types.PrimaryKey
+	Since      time.Time
+	Until      time.Time
+}
+
+/*
+Parses and validates query params
+*/
+func (a *AuditAuditLogFilterSerializer) Parse(query url.Values) (result *validator.Result) {
+	result = validator.NewResult()
+
+	for field, target := range map[string]*types.PrimaryKey{
+		"actor_id":  &a.ActorID,
+		"target_id": &a.TargetID,
+		"team_id":   &a.TeamID,
+	} {
+		if value := strings.TrimSpace(query.Get(field)); value != "" {
+			if err := target.Parse(value); err != nil {
+				result.AddFieldError(field, models.ErrInvalidValue)
+			}
+		}
+	}
+
+	for field, target := range map[string]*time.Time{
+		"since": &a.Since,
+		"until": &a.Until,
+	} {
+		if value := strings.TrimSpace(query.Get(field)); value != "" {
+			var err error
+			if *target, err = time.Parse(time.RFC3339, value); err != nil {
+				result.AddFieldError(field, models.ErrInvalidValue)
+			}
+		}
+	}
+
+	a.Action = strings.TrimSpace(query.Get("action"))
+	if a.Action != "" && !models.AUDIT_ACTION_LIST.Has(a.Action) {
+		result.AddFieldError("action", models.ErrInvalidChoice)
+	}
+
+	a.TargetType = strings.TrimSpace(query.Get("target_type"))
+
+	return
+}
+
+/*
+Returns query filters for audit log manager
+*/
+func (a *AuditAuditLogFilterSerializer) QueryFilters(manager *models.AuditLogManager) (result []utils.QueryFunc) {
+	result = []utils.QueryFunc{}
+	if a.ActorID != 0 {
+		result = append(result, manager.QueryFilterActor(a.ActorID))
+	}
+	if a.Action != "" {
+		result = append(result, manager.QueryFilterAction(a.Action))
+	}
+	if a.TargetType != "" {
+		result = append(result, manager.QueryFilterTargetType(a.TargetType))
+	}
+	if a.TargetID != 0 {
+		result = append(result, manager.QueryFilterTargetID(a.TargetID))
+	}
+	if a.TeamID != 0 {
+		result = append(result, manager.QueryFilterTeam(a.TeamID))
+	}
+	if !a.Since.IsZero() {
+		result = append(result, manager.QueryFilterSince(a.Since))
+	}
+	if !a.Until.IsZero() {
+		result = append(result, manager.QueryFilterUntil(a.Until))
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package serializers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/validator"
+	"github.com/phonkee/patrol/types"
+)
+
+/*
+AuthPersonalTokenSerializer
+
+	serializer for creating and updating personal tokens, token without
+	date_expires never expires
+*/
+type AuthPersonalTokenSerializer struct {
+	Name        string            `json:"name"         validator:"name"`
+	Scopes      types.StringSlice `json:"scopes"       validator:"scopes"`
+	DateExpires *time.Time        `json:"date_expires"`
+}
+
+/*
+Cleans data in serializer
+*/
+func (a *AuthPersonalTokenSerializer) Clean() {
+	a.Name = strings.TrimSpace(a.Name)
+	scopes := types.StringSlice{}
+	for _, scope := range a.Scopes {
+		scopes.AddUnique(strings.TrimSpace(scope))
+	}
+	a.Scopes = scopes
+}
+
+/*
+Validate
+
+	validates name, scopes and expiry (must be in future)
+*/
+func (a *AuthPersonalTokenSerializer) Validate(context *context.Context) (result *validator.Result) {
+	a.Clean()
+	validator := validator.New()
+	validator["name"] = models.ValidatePersonalTokenName()
+	validator["scopes"] = models.ValidateTokenScopes()
+	result = validator.Validate(a)
+
+	if a.DateExpires != nil && !a.DateExpires.After(time.Now()) {
+		result.AddFieldError("date_expires", ErrDateInPast)
+	}
+	return
+}
+
+/*
+Saves personal token, new token is created for user and token string is
+returned (only on create)
+*/
+func (a *AuthPersonalTokenSerializer) Save(context *context.Context, token *models.PersonalToken, user *models.User) (result string, err error) {
+	token.Name = a.Name
+	token.Scopes = a.Scopes
+	token.DateExpires = a.DateExpires
+
+	manager := models.NewPersonalTokenManager(context)
+	if token.ID == 0 {
+		token.UserID = user.ID.ToForeignKey()
+		return manager.Create(token)
+	}
+
+	_, err = token.Update(context, "name", "scopes", "date_expires")
+	return
+}
@@ -0,0 +1,287 @@
+package serializers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/validator"
+	"github.com/phonkee/patrol/settings"
+)
+
+/*
+Returns whether login of user needs second step (two-factor authentication is
+enabled or required)
+*/
+func IsTwoFactorLogin(context *context.Context, user *models.User) (result bool, err error) {
+	manager := models.NewTwoFactorManager(context)
+	if result, err = manager.IsEnabled(user); err != nil || result {
+		return
+	}
+	return manager.IsRequired(user)
+}
+
+/*
+AuthTwoFactorChallengeSerializer
+	returned by first step of login when two-factor authentication is needed.
+	Token identifies user in second step, when enroll is true user must first
+	enroll two-factor authentication (it is required by team).
+*/
+type AuthTwoFactorChallengeSerializer struct {
+	TwoFactorToken string `json:"two_factor_token"`
+	Enroll         bool   `json:"enroll"`
+	ExpiresIn      int64  `json:"expires_in"`
+}
+
+/*
+Returns two-factor challenge for authenticated user
+*/
+func NewTwoFactorChallenge(context *context.Context, user *models.User) (result *AuthTwoFactorChallengeSerializer, err error) {
+	var enabled bool
+	if enabled, err = models.NewTwoFactorManager(context).IsEnabled(user); err != nil {
+		return
+	}
+
+	result = &AuthTwoFactorChallengeSerializer{
+		TwoFactorToken: models.NewUserManager(context).NewSignedToken(user, models.SIGNED_TOKEN_TWO_FACTOR, settings.AUTH_TWO_FACTOR_TOKEN_TTL),
+		Enroll:         !enabled,
+		ExpiresIn:      int64(settings.AUTH_TWO_FACTOR_TOKEN_TTL / time.Second),
+	}
+	return
+}
+
+/*
+AuthTwoFactorSerializer
+	status of two-factor authentication of user
+*/
+type AuthTwoFactorSerializer struct {
+	Enabled           bool       `json:"enabled"`
+	Required          bool       `json:"required"`
+	DateConfirmed     *time.Time `json:"date_confirmed"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+/*
+	Loads status of two-factor authentication of user
+*/
+func (a *AuthTwoFactorSerializer) From(context *context.Context, user *models.User) (err error) {
+	manager := models.NewTwoFactorManager(context)
+	if a.Required, err = manager.IsRequired(user); err != nil {
+		return
+	}
+
+	twofactor := manager.NewTwoFactor()
+	if err = manager.GetByUser(twofactor, user); err != nil {
+		if err == models.ErrObjectDoesNotExists {
+			err = nil
+		}
+		return
+	}
+
+	if twofactor.Confirmed {
+		a.Enabled = true
+		a.DateConfirmed = twofactor.DateConfirmed
+		a.RecoveryCodesLeft = len(twofactor.RecoveryCodes)
+	}
+	return
+}
+
+/*
+AuthTwoFactorEnrollSerializer
+	secret of new enrollment, provisioning uri is usually displayed as qr
+	code for authenticator app
+*/
+type AuthTwoFactorEnrollSerializer struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+/*
+Starts enrollment of two-factor authentication of user
+*/
+func NewTwoFactorEnrollment(context *context.Context, user *models.User) (result *AuthTwoFactorEnrollSerializer, err error) {
+	twofactor := models.NewTwoFactor()
+	if err = models.NewTwoFactorManager(context).Enroll(twofactor, user); err != nil {
+		return
+	}
+
+	result = &AuthTwoFactorEnrollSerializer{
+		Secret:          twofactor.Secret,
+		ProvisioningURI: twofactor.ProvisioningURI(user.Email),
+	}
+	return
+}
+
+/*
+AuthTwoFactorRecoveryCodesSerializer
+	new recovery codes, they are returned only once
+*/
+type AuthTwoFactorRecoveryCodesSerializer struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+/*
+AuthTwoFactorCodeSerializer
+	code from authenticator app (or recovery code) of authenticated user
+*/
+type AuthTwoFactorCodeSerializer struct {
+	Code string `json:"code"`
+
+	twofactor *models.TwoFactor
+}
+
+/*
+	Trim spaces
+*/
+func (a *AuthTwoFactorCodeSerializer) Clean() {
+	a.Code = strings.TrimSpace(a.Code)
+}
+
+/*
+	Validates code is given and loads two-factor authentication of user
+*/
+func (a *AuthTwoFactorCodeSerializer) Validate(context *context.Context, user *models.User) (result *validator.Result) {
+	a.Clean()
+	result = validator.NewResult()
+
+	if a.Code == "" {
+		result.AddFieldError("code", models.ErrInvalidTwoFactorCode)
+		return
+	}
+
+	a.twofactor = models.NewTwoFactor()
+	if err := models.NewTwoFactorManager(context).GetByUser(a.twofactor, user); err != nil {
+		result.AddUnboundError(models.ErrTwoFactorNotEnabled)
+	}
+	return
+}
+
+/*
+	Confirms enrollment and returns recovery codes
+*/
+func (a *AuthTwoFactorCodeSerializer) Confirm(context *context.Context) (result *AuthTwoFactorRecoveryCodesSerializer, err error) {
+	result = &AuthTwoFactorRecoveryCodesSerializer{}
+	result.RecoveryCodes, err = models.NewTwoFactorManager(context).Confirm(a.twofactor, a.Code)
+	return
+}
+
+/*
+	Verifies code and returns new recovery codes
+*/
+func (a *AuthTwoFactorCodeSerializer) RecoveryCodes(context *context.Context) (result *AuthTwoFactorRecoveryCodesSerializer, err error) {
+	manager := models.NewTwoFactorManager(context)
+	if err = manager.Verify(a.twofactor, a.Code); err != nil {
+		return
+	}
+
+	result = &AuthTwoFactorRecoveryCodesSerializer{}
+	result.RecoveryCodes, err = manager.NewRecoveryCodes(a.twofactor)
+	return
+}
+
+/*
+	Returns two-factor authentication of user, available after Validate
+*/
+func (a *AuthTwoFactorCodeSerializer) TwoFactor() *models.TwoFactor {
+	return a.twofactor
+}
+
+/*
+	Verifies code and disables two-factor authentication
+*/
+func (a *AuthTwoFactorCodeSerializer) Disable(context *context.Context) (err error) {
+	if err = models.NewTwoFactorManager(context).Verify(a.twofactor, a.Code); err != nil {
+		return
+	}
+	return a.twofactor.Delete(context)
+}
+
+/*
+AuthLoginTwoFactorSerializer
+	second step of login, token is returned by first step
+*/
+type AuthLoginTwoFactorSerializer struct {
+	Token string `json:"token"`
+	Code  string `json:"code"`
+
+	user *models.User
+}
+
+/*
+	Trim spaces
+*/
+func (a *AuthLoginTwoFactorSerializer) Clean() {
+	a.Token = strings.TrimSpace(a.Token)
+	a.Code = strings.TrimSpace(a.Code)
+}
+
+/*
+	Validates token (user of token is loaded)
+*/
+func (a *AuthLoginTwoFactorSerializer) Validate(context *context.Context) (result *validator.Result) {
+	a.Clean()
+	result = validator.NewResult()
+
+	a.user = models.NewUser()
+	if err := models.NewUserManager(context).GetBySignedToken(a.user, models.SIGNED_TOKEN_TWO_FACTOR, a.Token); err != nil {
+		result.AddFieldError("token", models.ErrInvalidSignedToken)
+	}
+	return
+}
+
+// returns user of token (available after validation)
+func (a *AuthLoginTwoFactorSerializer) User() *models.User {
+	return a.user
+}
+
+/*
+	Starts enrollment of two-factor authentication (for users that are
+	required to use it)
+*/
+func (a *AuthLoginTwoFactorSerializer) Enroll(context *context.Context) (result *AuthTwoFactorEnrollSerializer, err error) {
+	return NewTwoFactorEnrollment(context, a.user)
+}
+
+/*
+	Verifies code and finishes login. When code confirms enrollment, recovery
+	codes are returned together with tokens.
+*/
+func (a *AuthLoginTwoFactorSerializer) Login(context *context.Context) (result *AuthLoginTwoFactorResultSerializer, err error) {
+	manager := models.NewTwoFactorManager(context)
+
+	twofactor := manager.NewTwoFactor()
+	if err = manager.GetByUser(twofactor, a.user); err != nil {
+		if err == models.ErrObjectDoesNotExists {
+			err = models.ErrTwoFactorNotEnabled
+		}
+		return
+	}
+
+	result = &AuthLoginTwoFactorResultSerializer{}
+	if twofactor.Confirmed {
+		err = manager.Verify(twofactor, a.Code)
+	} else {
+		result.RecoveryCodes, err = manager.Confirm(twofactor, a.Code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens *AuthTokenSerializer
+	if tokens, err = NewAuthTokens(context, a.user); err != nil {
+		return nil, err
+	}
+	result.AuthTokenSerializer = *tokens
+	return
+}
+
+/*
+AuthLoginTwoFactorResultSerializer
+	tokens returned by second step of login, recovery codes are returned only
+	when login confirmed enrollment
+*/
+type AuthLoginTwoFactorResultSerializer struct {
+	AuthTokenSerializer
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
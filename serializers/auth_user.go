@@ -14,6 +14,7 @@ import (
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/models"
 	"github.com/phonkee/patrol/rest/validator"
+	"github.com/phonkee/patrol/settings"
 	"github.com/phonkee/patrol/types"
 	"github.com/phonkee/patrol/utils"
 )
@@ -24,14 +25,15 @@ AuthUserDetailSerializer
 	password
 */
 type AuthUserDetailSerializer struct {
-	ID          types.PrimaryKey `db:"id"           json:"id"`
-	Username    string           `db:"username"     json:"username"`
-	Email       string           `db:"email"        json:"email" validator:"email"`
-	Name        string           `db:"name"         json:"name"`
-	IsActive    bool             `db:"is_active"    json:"is_active"`
-	IsSuperuser bool             `db:"is_superuser" json:"is_superuser"`
-	DateAdded   time.Time        `db:"date_added"   json:"date_added"`
-	LastLogin   time.Time        `db:"last_login"   json:"last_login"`
+	ID            types.PrimaryKey `db:"id"             json:"id"`
+	Username      string           `db:"username"       json:"username"`
+	Email         string           `db:"email"          json:"email" validator:"email"`
+	Name          string           `db:"name"           json:"name"`
+	IsActive      bool             `db:"is_active"      json:"is_active"`
+	IsSuperuser   bool             `db:"is_superuser"   json:"is_superuser"`
+	DateAdded     time.Time        `db:"date_added"     json:"date_added"`
+	LastLogin     time.Time        `db:"last_login"     json:"last_login"`
+	EmailVerified bool             `db:"email_verified" json:"email_verified"`
 }
 
 /*
@@ -46,6 +48,7 @@ func (u *AuthUserDetailSerializer) From(user *models.User) {
 	u.IsSuperuser = user.IsSuperuser
 	u.DateAdded = user.DateAdded
 	u.LastLogin = user.LastLogin
+	u.EmailVerified = user.EmailVerified
 }
 
 /*
@@ -91,7 +94,9 @@ func (a *AuthUserUpdateSerializer) Save(context *context.Context, user *models.U
 	// fields to update
 	fields := []string{"email", "name"}
 
+	deactivated := false
 	if updater.IsSuperuser {
+		deactivated = user.IsActive && !a.IsActive
 		user.IsActive = a.IsActive
 		user.IsSuperuser = a.IsSuperuser
 		fields = append(fields, "is_active")
@@ -103,6 +108,13 @@ func (a *AuthUserUpdateSerializer) Save(context *context.Context, user *models.U
 		return
 	}
 
+	// deactivated user is logged out everywhere immediately
+	if deactivated {
+		if err = models.NewUserManager(context).RevokeTokens(user); err != nil {
+			return
+		}
+	}
+
 	result = &AuthUserDetailSerializer{}
 	result.From(user)
 	return
@@ -175,6 +187,7 @@ func (a *AuthUserCreateSerializer) Save(context *context.Context) (result *AuthU
 		us.Name = a.Name
 		us.IsActive = a.IsActive
 		us.IsSuperuser = a.IsSuperuser
+		us.EmailVerified = !settings.SETTINGS_AUTH_VERIFY_EMAIL
 		us.SetPassword(a.Password)
 	})
 
@@ -236,6 +249,35 @@ func (a *AuthUserChangePasswordSerializer) Save(context *context.Context, user *
 	return
 }
 
+/*
+AuthUserPermissionSerializer
+	Grant permission serializer
+*/
+type AuthUserPermissionSerializer struct {
+	Codename string `json:"codename" validator:"codename"`
+}
+
+/*
+	Trim spaces
+*/
+func (a *AuthUserPermissionSerializer) Clean() {
+	a.Codename = strings.TrimSpace(a.Codename)
+}
+
+func (a *AuthUserPermissionSerializer) Validate(context *context.Context) *validator.Result {
+	a.Clean()
+	val := validator.New()
+	val["codename"] = models.ValidatePermissionCodename(context)
+	return val.Validate(a)
+}
+
+/*
+	Save grants permission to given user
+*/
+func (a *AuthUserPermissionSerializer) Save(context *context.Context, user *models.User) (err error) {
+	return models.NewUserManager(context).GrantPermission(user, a.Codename)
+}
+
 /*
 AuthLoginSerializer
 */
@@ -263,34 +305,54 @@ func (a *AuthLoginSerializer) Validate(context *context.Context) *validator.Resu
 }
 
 /*
-	Performs login and returns user and token
+	Performs login and returns user and tokens
 */
-func (a *AuthLoginSerializer) Login(context *context.Context) (user *models.User, token string, err error) {
-	usermanager := models.NewUserManager(context)
+func (a *AuthLoginSerializer) Login(context *context.Context) (user *models.User, tokens *AuthTokenSerializer, err error) {
+	// authenticate user by configured backends
+	backends := strings.Split(settings.SETTINGS_AUTH_BACKENDS, ",")
+	if user, err = models.Authenticate(context, backends, a.Username, a.Password); err != nil {
+		if err == models.ErrInvalidCredentials {
+			err = ErrUsernamePassword
+		} else {
+			err = ErrInternalServerError
+		}
+		return
+	}
 
-	// find user in db by username
-	user = usermanager.NewUser()
-	if err = usermanager.Get(user, usermanager.QueryFilterUsername(a.Username)); err != nil {
-		err = ErrUsernamePassword
+	// users must verify e-mail address before first login
+	if !user.EmailVerified {
+		err = models.ErrEmailNotVerified
 		return
 	}
 
-	// check password
-	var ok bool
-	if ok, err = user.VerifyPassword(a.Password); err != nil {
+	// second step of login is needed when user has two-factor authentication
+	// enabled or it is required by some team of user
+	var twofactor bool
+	if twofactor, err = IsTwoFactorLogin(context, user); err != nil {
 		err = ErrInternalServerError
 		return
-	} else {
-		if !ok {
-			err = ErrUsernamePassword
-			return
-		}
+	}
+	if twofactor {
+		err = models.ErrTwoFactorRequired
+		return
 	}
 
-	// create token
-	if token, err = usermanager.Login(user); err != nil {
+	tokens, err = NewAuthTokens(context, user)
+	return
+}
+
+/*
+Creates login session of authenticated user and returns its tokens
+*/
+func NewAuthTokens(context *context.Context, user *models.User) (tokens *AuthTokenSerializer, err error) {
+	usermanager := models.NewUserManager(context)
+
+	// create tokens
+	tokens = &AuthTokenSerializer{}
+	if tokens.Token, tokens.RefreshToken, err = usermanager.LoginSession(user); err != nil {
 		return
 	}
+	tokens.ExpiresIn = int64(settings.SETTINGS_AUTH_ACCESS_TOKEN_TTL / time.Second)
 
 	// update last login
 	user.LastLogin = utils.NowTruncated()
@@ -300,3 +362,210 @@ func (a *AuthLoginSerializer) Login(context *context.Context) (user *models.User
 
 	return
 }
+
+/*
+AuthTokenSerializer
+	access token and refresh token returned by login and refresh,
+	expires_in is lifetime of access token in seconds
+*/
+type AuthTokenSerializer struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+/*
+AuthRefreshSerializer
+	exchanges refresh token for new access token and refresh token
+*/
+type AuthRefreshSerializer struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+/*
+Cleans data
+*/
+func (a *AuthRefreshSerializer) Clean() {
+	a.RefreshToken = strings.TrimSpace(a.RefreshToken)
+}
+
+/*
+Validates input data
+*/
+func (a *AuthRefreshSerializer) Validate(context *context.Context) (result *validator.Result) {
+	result = validator.NewResult()
+	if a.RefreshToken == "" {
+		result.AddFieldError("refresh_token", models.ErrInvalidRefreshToken)
+	}
+	return
+}
+
+/*
+	Rotates refresh token and returns user and new tokens
+*/
+func (a *AuthRefreshSerializer) Refresh(context *context.Context) (user *models.User, tokens *AuthTokenSerializer, err error) {
+	usermanager := models.NewUserManager(context)
+	user = usermanager.NewUser()
+
+	var rt *models.RefreshToken
+	tokens = &AuthTokenSerializer{}
+	if rt, tokens.RefreshToken, err = models.NewRefreshTokenManager(context).Rotate(user, a.RefreshToken); err != nil {
+		return
+	}
+
+	if tokens.Token, err = usermanager.NewAccessToken(user, rt.SessionID); err != nil {
+		return
+	}
+	tokens.ExpiresIn = int64(settings.SETTINGS_AUTH_ACCESS_TOKEN_TTL / time.Second)
+	return
+}
+
+/*
+AuthUserEmailSerializer
+	serializer for requesting password reset and e-mail verification
+*/
+type AuthUserEmailSerializer struct {
+	Email string `json:"email" validator:"email"`
+}
+
+/*
+	Trim spaces
+*/
+func (a *AuthUserEmailSerializer) Clean() {
+	a.Email = strings.TrimSpace(a.Email)
+}
+
+func (a *AuthUserEmailSerializer) Validate(context *context.Context) *validator.Result {
+	a.Clean()
+	val := validator.New()
+	val["email"] = validator.ValidateEmail()
+	return val.Validate(a)
+}
+
+/*
+	Returns active user with e-mail address
+*/
+func (a *AuthUserEmailSerializer) GetUser(context *context.Context, user *models.User) (err error) {
+	um := models.NewUserManager(context)
+	return um.Get(user,
+		um.QueryFilterWhere("LOWER(email) = LOWER(?)", a.Email),
+		um.QueryFilterWhere("is_active = ?", true),
+	)
+}
+
+/*
+AuthPasswordResetConfirmSerializer
+	sets new password of user identified by password reset token
+*/
+type AuthPasswordResetConfirmSerializer struct {
+	Token    string `json:"token"`
+	Password string `json:"password" validator:"password"`
+	Retype   string `json:"retype"`
+
+	user *models.User
+}
+
+/*
+	Trim spaces
+*/
+func (a *AuthPasswordResetConfirmSerializer) Clean() {
+	a.Token = strings.TrimSpace(a.Token)
+	a.Password = strings.TrimSpace(a.Password)
+	a.Retype = strings.TrimSpace(a.Retype)
+}
+
+/*
+	Validates password and token (user of token is loaded)
+*/
+func (a *AuthPasswordResetConfirmSerializer) Validate(context *context.Context) (result *validator.Result) {
+	a.Clean()
+	val := validator.New()
+	val["password"] = models.ValidatePassword()
+	result = val.Validate(a)
+
+	if a.Password != a.Retype {
+		result.AddFieldError("retype", errors.New("passwords_dont_match"))
+	}
+
+	a.user = models.NewUser()
+	if err := models.NewUserManager(context).GetBySignedToken(a.user, models.SIGNED_TOKEN_PASSWORD_RESET, a.Token); err != nil {
+		result.AddFieldError("token", models.ErrInvalidSignedToken)
+	}
+
+	return
+}
+
+/*
+	Saves new password and revokes all tokens of user
+*/
+func (a *AuthPasswordResetConfirmSerializer) Save(context *context.Context) (user *models.User, err error) {
+	user = a.user
+	if err = user.SetPassword(a.Password); err != nil {
+		return
+	}
+	if _, err = user.Update(context, "password"); err != nil {
+		return
+	}
+
+	err = models.NewUserManager(context).RevokeTokens(user)
+	return
+}
+
+/*
+AuthEmailVerifySerializer
+	verifies e-mail address of user identified by verification token
+*/
+type AuthEmailVerifySerializer struct {
+	Token string `json:"token"`
+
+	user *models.User
+}
+
+/*
+	Trim spaces
+*/
+func (a *AuthEmailVerifySerializer) Clean() {
+	a.Token = strings.TrimSpace(a.Token)
+}
+
+/*
+	Validates token (user of token is loaded)
+*/
+func (a *AuthEmailVerifySerializer) Validate(context *context.Context) (result *validator.Result) {
+	a.Clean()
+	result = validator.NewResult()
+
+	a.user = models.NewUser()
+	if err := models.NewUserManager(context).GetBySignedToken(a.user, models.SIGNED_TOKEN_EMAIL_VERIFY, a.Token); err != nil {
+		result.AddFieldError("token", models.ErrInvalidSignedToken)
+	}
+	return
+}
+
+/*
+	Marks e-mail address of user as verified
+*/
+func (a *AuthEmailVerifySerializer) Save(context *context.Context) (user *models.User, err error) {
+	user = a.user
+	user.EmailVerified = true
+	_, err = user.Update(context, "email_verified")
+	return
+}
+
+/*
+AuthUserLockoutSerializer
+	lockout status of user, failures is number of failed logins
+*/
+type AuthUserLockoutSerializer struct {
+	Locked   bool `json:"locked"`
+	Failures int  `json:"failures"`
+}
+
+/*
+	Loads lockout status of user
+*/
+func (a *AuthUserLockoutSerializer) From(context *context.Context, user *models.User) {
+	throttle := models.NewLoginThrottle(context)
+	a.Locked = throttle.IsLocked(user.Username)
+	a.Failures = throttle.Failures(user.Username)
+}
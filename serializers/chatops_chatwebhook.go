@@ -0,0 +1,56 @@
+package serializers
+
+import (
+	"strings"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/validator"
+)
+
+/*
+ChatOpsChatWebhookSerializer
+
+	serializer for creating and updating chat webhooks
+*/
+type ChatOpsChatWebhookSerializer struct {
+	URL     string `json:"url"     validator:"url"`
+	Channel string `json:"channel" validator:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+/*
+Cleans data in serializer
+*/
+func (c *ChatOpsChatWebhookSerializer) Clean() {
+	c.URL = strings.TrimSpace(c.URL)
+	c.Channel = strings.TrimSpace(c.Channel)
+}
+
+/*
+Validate
+
+	validates incoming webhook url and channel
+*/
+func (c *ChatOpsChatWebhookSerializer) Validate(context *context.Context) *validator.Result {
+	c.Clean()
+	validator := validator.New()
+	validator["url"] = models.ValidateWebhookURL()
+	validator["channel"] = models.ValidateChatChannel()
+	return validator.Validate(c)
+}
+
+/*
+Saves chat webhook to database (inserts new chat webhook)
+*/
+func (c *ChatOpsChatWebhookSerializer) Save(context *context.Context, webhook *models.ChatWebhook) (err error) {
+	webhook.URL = c.URL
+	webhook.Channel = c.Channel
+	webhook.Enabled = c.Enabled
+
+	if webhook.ID == 0 {
+		return webhook.Insert(context)
+	}
+	_, err = webhook.Update(context)
+	return
+}
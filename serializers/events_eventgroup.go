@@ -0,0 +1,10 @@
+package serializers
+
+/*
+EventsEventGroupResolveSerializer
+
+	options for resolving eventgroup, body of request is optional
+*/
+type EventsEventGroupResolveSerializer struct {
+	InNextRelease bool `json:"in_next_release"`
+}
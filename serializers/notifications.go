@@ -0,0 +1,99 @@
+package serializers
+
+import (
+	"strings"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/validator"
+)
+
+/*
+NotificationsSettingSerializer
+
+	serializer for updating notification settings of user, webhook url is
+	required only when webhook channel is enabled
+*/
+type NotificationsSettingSerializer struct {
+	Email      bool   `json:"email"`
+	Webhook    bool   `json:"webhook"`
+	WebhookURL string `json:"webhook_url" validator:"webhook_url"`
+	OwnActions bool   `json:"own_actions"`
+}
+
+/*
+Cleans data in serializer
+*/
+func (n *NotificationsSettingSerializer) Clean() {
+	n.WebhookURL = strings.TrimSpace(n.WebhookURL)
+}
+
+/*
+Validate
+
+	validates webhook url
+*/
+func (n *NotificationsSettingSerializer) Validate(context *context.Context) *validator.Result {
+	n.Clean()
+	validator := validator.New()
+	if n.Webhook || n.WebhookURL != "" {
+		validator["webhook_url"] = models.ValidateWebhookURL()
+	}
+	return validator.Validate(n)
+}
+
+/*
+Saves notification settings of user
+*/
+func (n *NotificationsSettingSerializer) Save(context *context.Context, setting *models.NotificationSetting) (err error) {
+	setting.Email = n.Email
+	setting.Webhook = n.Webhook
+	setting.WebhookURL = n.WebhookURL
+	setting.OwnActions = n.OwnActions
+	return models.NewNotificationSettingManager(context).Save(setting)
+}
+
+/*
+NotificationsProjectSettingSerializer
+
+	serializer for updating notification mode of user for project
+*/
+type NotificationsProjectSettingSerializer struct {
+	Mode string `json:"mode" validator:"mode"`
+}
+
+/*
+Cleans data in serializer
+*/
+func (n *NotificationsProjectSettingSerializer) Clean() {
+	n.Mode = strings.TrimSpace(n.Mode)
+}
+
+/*
+Validate
+
+	validates notification mode
+*/
+func (n *NotificationsProjectSettingSerializer) Validate(context *context.Context) *validator.Result {
+	n.Clean()
+	validator := validator.New()
+	validator["mode"] = models.ValidateNotificationMode()
+	return validator.Validate(n)
+}
+
+/*
+Saves notification mode of user for project
+*/
+func (n *NotificationsProjectSettingSerializer) Save(context *context.Context, setting *models.ProjectNotificationSetting) (err error) {
+	setting.Mode = n.Mode
+	return models.NewProjectNotificationSettingManager(context).Save(setting)
+}
+
+/*
+NotificationsSubscriptionSerializer
+
+	serializer for subscribing and unsubscribing eventgroup
+*/
+type NotificationsSubscriptionSerializer struct {
+	Subscribed bool `json:"subscribed"`
+}
@@ -40,9 +40,9 @@ func (p *ProjectsProjectCreateSerializer) Validate(context *context.Context) *va
 }
 
 /*
-	Saves new project to database
+	Saves new project and its project key to database
 */
-func (p *ProjectsProjectCreateSerializer) Save(context *context.Context, team *models.Team, author *models.User) (project *models.Project, err error) {
+func (p *ProjectsProjectCreateSerializer) Save(context *context.Context, team *models.Team, author *models.User) (project *models.Project, projectkey *models.ProjectKey, err error) {
 	project = models.NewProject(func(proj *models.Project) {
 		proj.Name = p.Name
 		proj.Platform = p.Platform
@@ -54,7 +54,7 @@ func (p *ProjectsProjectCreateSerializer) Save(context *context.Context, team *m
 	}
 
 	// create new project key
-	projectkey := models.NewProjectKey(func(projectKey *models.ProjectKey) {
+	projectkey = models.NewProjectKey(func(projectKey *models.ProjectKey) {
 		projectKey.UserID = author.ID.ToForeignKey()
 		projectKey.UserAddedID = author.ID.ToForeignKey()
 		projectKey.ProjectID = project.ID.ToForeignKey()
@@ -0,0 +1,55 @@
+package serializers
+
+import (
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/validator"
+	"github.com/phonkee/patrol/types"
+)
+
+/*
+ProjectsProjectInboundFilterUpdateSerializer
+
+	serializer for updating project inbound filter settings
+*/
+type ProjectsProjectInboundFilterUpdateSerializer struct {
+	Localhost      bool              `json:"localhost"`
+	WebCrawlers    bool              `json:"web_crawlers"`
+	LegacyBrowsers bool              `json:"legacy_browsers"`
+	Releases       types.StringSlice `json:"releases"       validator:"releases"`
+	ErrorMessages  types.StringSlice `json:"error_messages" validator:"error_messages"`
+}
+
+/*
+Cleans data in serializer
+*/
+func (p *ProjectsProjectInboundFilterUpdateSerializer) Clean() {
+	p.Releases = cleanFieldList(p.Releases)
+	p.ErrorMessages = cleanFieldList(p.ErrorMessages)
+}
+
+/*
+Validate
+
+	validates inbound filter settings
+*/
+func (p *ProjectsProjectInboundFilterUpdateSerializer) Validate(context *context.Context) *validator.Result {
+	p.Clean()
+	validator := validator.New()
+	validator["releases"] = models.ValidateFilterPatterns()
+	validator["error_messages"] = models.ValidateFilterPatterns()
+	return validator.Validate(p)
+}
+
+/*
+Saves inbound filter settings to database
+*/
+func (p *ProjectsProjectInboundFilterUpdateSerializer) Save(context *context.Context, filter *models.ProjectInboundFilter) (err error) {
+	filter.Localhost = p.Localhost
+	filter.WebCrawlers = p.WebCrawlers
+	filter.LegacyBrowsers = p.LegacyBrowsers
+	filter.Releases = p.Releases
+	filter.ErrorMessages = p.ErrorMessages
+
+	return models.NewProjectInboundFilterManager(context).Save(filter)
+}
@@ -0,0 +1,70 @@
+package serializers
+
+import (
+	"strings"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/validator"
+	"github.com/phonkee/patrol/types"
+)
+
+/*
+ProjectsProjectScrubbingUpdateSerializer
+
+	serializer for updating project data scrubbing settings
+*/
+type ProjectsProjectScrubbingUpdateSerializer struct {
+	Enabled          bool              `json:"enabled"`
+	ScrubDefaults    bool              `json:"scrub_defaults"`
+	ScrubCreditCards bool              `json:"scrub_credit_cards"`
+	ScrubIPAddresses bool              `json:"scrub_ip_addresses"`
+	ExtraFields      types.StringSlice `json:"extra_fields" validator:"extra_fields"`
+	SafeFields       types.StringSlice `json:"safe_fields"  validator:"safe_fields"`
+}
+
+/*
+Cleans data in serializer
+*/
+func (p *ProjectsProjectScrubbingUpdateSerializer) Clean() {
+	p.ExtraFields = cleanFieldList(p.ExtraFields)
+	p.SafeFields = cleanFieldList(p.SafeFields)
+}
+
+/*
+Validate
+
+	validates scrubbing settings
+*/
+func (p *ProjectsProjectScrubbingUpdateSerializer) Validate(context *context.Context) *validator.Result {
+	p.Clean()
+	validator := validator.New()
+	validator["extra_fields"] = models.ValidateScrubFields()
+	validator["safe_fields"] = models.ValidateScrubFields()
+	return validator.Validate(p)
+}
+
+/*
+Saves scrubbing settings to database
+*/
+func (p *ProjectsProjectScrubbingUpdateSerializer) Save(context *context.Context, scrubbing *models.ProjectScrubbing) (err error) {
+	scrubbing.Enabled = p.Enabled
+	scrubbing.ScrubDefaults = p.ScrubDefaults
+	scrubbing.ScrubCreditCards = p.ScrubCreditCards
+	scrubbing.ScrubIPAddresses = p.ScrubIPAddresses
+	scrubbing.ExtraFields = p.ExtraFields
+	scrubbing.SafeFields = p.SafeFields
+
+	return models.NewProjectScrubbingManager(context).Save(scrubbing)
+}
+
+// trims field names and removes blank and duplicate ones
+func cleanFieldList(fields types.StringSlice) (result types.StringSlice) {
+	result = types.StringSlice{}
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			result.AddUnique(field)
+		}
+	}
+	return
+}
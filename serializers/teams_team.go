@@ -47,3 +47,20 @@ func (s *TeamsTeamCreateSerializer) Save(ctx *context.Context, owner *models.Use
 	err = team.Insert(ctx)
 	return
 }
+
+/*
+TeamsTeamTwoFactorSerializer
+	requires two-factor authentication for all members of team
+*/
+type TeamsTeamTwoFactorSerializer struct {
+	RequireTwoFactor bool `json:"require_two_factor"`
+}
+
+/*
+Updates requirement of team
+*/
+func (t *TeamsTeamTwoFactorSerializer) Save(ctx *context.Context, team *models.Team) (err error) {
+	team.RequireTwoFactor = t.RequireTwoFactor
+	_, err = team.Update(ctx, "require_two_factor")
+	return
+}
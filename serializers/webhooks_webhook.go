@@ -0,0 +1,68 @@
+package serializers
+
+import (
+	"strings"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/validator"
+	"github.com/phonkee/patrol/types"
+)
+
+/*
+WebhooksWebhookSerializer
+
+	serializer for creating and updating webhooks, when secret is empty
+	existing (or generated) secret is kept
+*/
+type WebhooksWebhookSerializer struct {
+	URL     string            `json:"url"     validator:"url"`
+	Secret  string            `json:"secret"  validator:"secret"`
+	Events  types.StringSlice `json:"events"  validator:"events"`
+	Enabled bool              `json:"enabled"`
+}
+
+/*
+Cleans data in serializer
+*/
+func (w *WebhooksWebhookSerializer) Clean() {
+	w.URL = strings.TrimSpace(w.URL)
+	w.Secret = strings.TrimSpace(w.Secret)
+	events := types.StringSlice{}
+	for _, event := range w.Events {
+		events = append(events, strings.TrimSpace(event))
+	}
+	w.Events = events
+}
+
+/*
+Validate
+
+	validates webhook url, secret and subscribed event types
+*/
+func (w *WebhooksWebhookSerializer) Validate(context *context.Context) *validator.Result {
+	w.Clean()
+	validator := validator.New()
+	validator["url"] = models.ValidateWebhookURL()
+	validator["secret"] = models.ValidateWebhookSecret()
+	validator["events"] = models.ValidateWebhookEvents()
+	return validator.Validate(w)
+}
+
+/*
+Saves webhook to database (inserts new webhook)
+*/
+func (w *WebhooksWebhookSerializer) Save(context *context.Context, webhook *models.Webhook) (err error) {
+	webhook.URL = w.URL
+	webhook.Events = w.Events
+	webhook.Enabled = w.Enabled
+	if w.Secret != "" {
+		webhook.Secret = w.Secret
+	}
+
+	if webhook.ID == 0 {
+		return webhook.Insert(context)
+	}
+	_, err = webhook.Update(context)
+	return
+}
@@ -2,7 +2,10 @@
 // all enums for system will be find here
 package settings
 
-import "compress/gzip"
+import (
+	"compress/gzip"
+	"time"
+)
 
 const (
 	// version
@@ -15,12 +18,18 @@ const (
 	EVENT_QUEUE_ID = "post-messages"
 
 	// builtin plugin ids
-	AUTH_PLUGIN_ID     = "auth"
-	COMMON_PLUGIN_ID   = "common"
-	EVENTS_PLUGIN_ID   = "event"
-	PROJECTS_PLUGIN_ID = "project"
-	STATIC_PLUGIN_ID   = "static"
-	TEAMS_PLUGIN_ID    = "teams"
+	ALERTS_PLUGIN_ID        = "alerts"
+	AUDIT_PLUGIN_ID         = "audit"
+	AUTH_PLUGIN_ID          = "auth"
+	CHATOPS_PLUGIN_ID       = "chatops"
+	COMMON_PLUGIN_ID        = "common"
+	EVENTS_PLUGIN_ID        = "event"
+	NOTIFICATIONS_PLUGIN_ID = "notifications"
+	OIDC_PLUGIN_ID          = "oidc"
+	PROJECTS_PLUGIN_ID      = "project"
+	STATIC_PLUGIN_ID        = "static"
+	TEAMS_PLUGIN_ID         = "teams"
+	WEBHOOKS_PLUGIN_ID      = "webhooks"
 
 	// padding of command in list
 	LIST_COMMANDS_COMMAND_PADDING = 30
@@ -29,9 +38,133 @@ const (
 	// event plugin constants
 	EVENT_WORKER_DEFAULT_GOROUTINES_COUNT = 2
 
+	// how long is event_id remembered at store endpoint to drop retries
+	EVENT_ID_DEDUPLICATION_TIMEOUT = 5 * time.Minute
+
+	// default minutes between actions of alert rule for single eventgroup
+	ALERT_RULE_DEFAULT_FREQUENCY = 30
+
+	// size of queue of e-mails waiting for delivery
+	MAIL_QUEUE_SIZE = 100
+
+	// number of stacktrace frames shown in e-mail notifications
+	MAIL_STACKTRACE_FRAMES = 5
+
+	// how often are pending e-mail digests checked and sent
+	MAIL_DIGEST_FLUSH_INTERVAL = 30 * time.Second
+
+	// realtime websocket settings, client not answering ping within pong
+	// wait is disconnected
+	REALTIME_WRITE_WAIT       = 10 * time.Second
+	REALTIME_PONG_WAIT        = 60 * time.Second
+	REALTIME_PING_PERIOD      = (REALTIME_PONG_WAIT * 9) / 10
+	REALTIME_MAX_MESSAGE_SIZE = 4096
+
+	// maximum number of channels subscribed by single websocket, every
+	// channel holds its own pub/sub subscription
+	REALTIME_MAX_SUBSCRIPTIONS = 100
+
+	// how long websocket waits for auth message when token was not sent in
+	// header or query parameter
+	REALTIME_AUTH_WAIT = 10 * time.Second
+
+	// query parameter with auth token for websockets
+	REALTIME_TOKEN_PARAM_NAME = "token"
+
+	// number of last messages of channel kept for resumption of event
+	// streams (Last-Event-ID) and how long they are kept
+	REALTIME_REPLAY_BUFFER_SIZE = 100
+	REALTIME_REPLAY_TIMEOUT     = 10 * time.Minute
+
+	// how long event stream client waits before reconnect
+	REALTIME_STREAM_RETRY = 3 * time.Second
+
+	// queue with webhook deliveries
+	WEBHOOK_QUEUE_ID = "webhook-deliveries"
+
+	// webhook delivery settings, failed delivery is retried with exponential
+	// backoff (base delay doubled by every attempt)
+	WEBHOOK_WORKER_DEFAULT_GOROUTINES_COUNT = 2
+	WEBHOOK_MAX_ATTEMPTS                    = 6
+	WEBHOOK_RETRY_BASE_DELAY                = 30 * time.Second
+	WEBHOOK_REQUEST_TIMEOUT                 = 10 * time.Second
+
+	WEBHOOK_EVENT_HEADER_NAME     = "X-Patrol-Event"
+	WEBHOOK_DELIVERY_HEADER_NAME  = "X-Patrol-Delivery"
+	WEBHOOK_SIGNATURE_HEADER_NAME = "X-Patrol-Signature"
+
+	// how long are action links in chat messages valid
+	CHATOPS_ACTION_LINK_TIMEOUT = 24 * time.Hour
+	CHATOPS_REQUEST_TIMEOUT     = 10 * time.Second
+
 	HTTP_SERVER_DEFAULT_HOST = "127.0.0.1:4434"
 
-	AUTH_TOKEN_HEADER_NAME = "X-Patrol-Token"
+	AUTH_TOKEN_HEADER_NAME         = "X-Patrol-Token"
+	AUTH_REFRESH_TOKEN_HEADER_NAME = "X-Patrol-Refresh-Token"
+
+	// number of random bytes of refresh token
+	AUTH_REFRESH_TOKEN_BYTES = 32
+
+	// personal tokens are recognized by prefix, last used time is stored
+	// at most once per AUTH_PERSONAL_TOKEN_LAST_USED_PRECISION
+	AUTH_PERSONAL_TOKEN_PREFIX              = "patrol_"
+	AUTH_PERSONAL_TOKEN_BYTES               = 20
+	AUTH_PERSONAL_TOKEN_LAST_USED_PRECISION = time.Minute
+	AUTH_PERSONAL_TOKEN_MAX_NAME_LENGTH     = 100
+
+	// lifetime of signed tokens sent by e-mail
+	AUTH_PASSWORD_RESET_TOKEN_TTL = time.Hour
+	AUTH_EMAIL_VERIFY_TOKEN_TTL   = 72 * time.Hour
+
+	// links (relative to SETTINGS_BASE_URL) sent by e-mail, token is appended
+	AUTH_PASSWORD_RESET_LINK = "/#/password/reset/"
+	AUTH_EMAIL_VERIFY_LINK   = "/#/email/verify/"
+
+	// rate limits of password reset and e-mail verification, e-mails are
+	// limited per address, all requests per client ip
+	AUTH_MAIL_RATE_LIMIT_PER_EMAIL = 3
+	AUTH_MAIL_RATE_LIMIT_PER_IP    = 20
+	AUTH_MAIL_RATE_LIMIT_PERIOD    = time.Hour
+
+	// totp two-factor authentication, codes of AUTH_TOTP_SKEW periods around
+	// current time are accepted (clock drift). Second step of login must be
+	// finished within AUTH_TWO_FACTOR_TOKEN_TTL, codes are limited per user
+	// and requests of login step (and enrollment) per client ip.
+	AUTH_TOTP_ISSUER                  = "Patrol"
+	AUTH_TOTP_DIGITS                  = 6
+	AUTH_TOTP_PERIOD                  = 30 * time.Second
+	AUTH_TOTP_SKEW                    = 1
+	AUTH_RECOVERY_CODES_COUNT         = 10
+	AUTH_TWO_FACTOR_TOKEN_TTL         = 5 * time.Minute
+	AUTH_TWO_FACTOR_RATE_LIMIT        = 10
+	AUTH_TWO_FACTOR_RATE_LIMIT_TIME   = 5 * time.Minute
+	AUTH_TWO_FACTOR_RATE_LIMIT_PER_IP = 30
+
+	// login throttling, after free failed attempts (per username, per ip)
+	// next attempt is delayed, delay is doubled with every failure. Failures
+	// are counted for AUTH_LOGIN_ATTEMPTS_PERIOD from first failure.
+	AUTH_LOGIN_THROTTLE_FREE_ATTEMPTS        = 3
+	AUTH_LOGIN_THROTTLE_FREE_ATTEMPTS_PER_IP = 20
+	AUTH_LOGIN_THROTTLE_DELAY                = time.Second
+	AUTH_LOGIN_THROTTLE_MAX_DELAY            = time.Minute
+	AUTH_LOGIN_ATTEMPTS_PERIOD               = time.Hour
+
+	// timeout of ldap requests
+	AUTH_LDAP_TIMEOUT = 10 * time.Second
+
+	// openid connect settings, discovered configuration and keys of provider
+	// are cached for OIDC_CONFIGURATION_TIMEOUT, login must be finished
+	// within OIDC_STATE_TIMEOUT
+	OIDC_SCOPES                = "openid email profile"
+	OIDC_REQUEST_TIMEOUT       = 10 * time.Second
+	OIDC_CONFIGURATION_TIMEOUT = time.Hour
+	OIDC_STATE_TIMEOUT         = 10 * time.Minute
+	OIDC_STATE_COOKIE_NAME     = "patrol_oidc_state"
+	OIDC_MAX_RESPONSE_SIZE     = 1 << 20
+
+	// link (relative to SETTINGS_BASE_URL) where browser is redirected after
+	// single sign-on, tokens or error are appended as query of fragment
+	AUTH_OIDC_LOGIN_LINK = "/#/login/oidc"
 
 	PAGING_DEFAULT_LIMIT_PARAM_NAME = "limit"
 	PAGING_DEFAULT_PAGE_PARAM_NAME  = "page"
@@ -1,11 +1,36 @@
 package settings
 
 const (
-	ROUTE_AUTH_LOGIN                = "api-auth-login"
-	ROUTE_AUTH_ME                   = "api-auth-me"
-	ROUTE_AUTH_USER_LIST            = "api-auth-user-list"
-	ROUTE_AUTH_USER_DETAIL          = "api-auth-user-detail"
-	ROUTE_AUTH_USER_CHANGE_PASSWORD = "api-auth-user-changepassword"
+	ROUTE_ALERTS_ALERTRULE_LIST   = "api-alerts-alertrule-list"
+	ROUTE_ALERTS_ALERTRULE_DETAIL = "api-alerts-alertrule-detail"
+	ROUTE_ALERTS_ACTION_LIST      = "api-alerts-action-list"
+
+	ROUTE_AUDIT_AUDITLOG_LIST = "api-audit-auditlog-list"
+
+	ROUTE_AUTH_LOGIN                  = "api-auth-login"
+	ROUTE_AUTH_REFRESH                = "api-auth-refresh"
+	ROUTE_AUTH_LOGOUT                 = "api-auth-logout"
+	ROUTE_AUTH_LOGOUT_ALL             = "api-auth-logout-all"
+	ROUTE_AUTH_ME                     = "api-auth-me"
+	ROUTE_AUTH_PASSWORD_RESET         = "api-auth-password-reset"
+	ROUTE_AUTH_PASSWORD_RESET_CONFIRM = "api-auth-password-reset-confirm"
+	ROUTE_AUTH_EMAIL_VERIFY           = "api-auth-email-verify"
+	ROUTE_AUTH_EMAIL_VERIFY_RESEND    = "api-auth-email-verify-resend"
+	ROUTE_AUTH_LOGIN_TWOFACTOR        = "api-auth-login-twofactor"
+	ROUTE_AUTH_LOGIN_TWOFACTOR_ENROLL = "api-auth-login-twofactor-enroll"
+	ROUTE_AUTH_TWOFACTOR              = "api-auth-twofactor"
+	ROUTE_AUTH_TWOFACTOR_CONFIRM      = "api-auth-twofactor-confirm"
+	ROUTE_AUTH_TWOFACTOR_RECOVERY     = "api-auth-twofactor-recovery"
+	ROUTE_AUTH_OIDC_LOGIN             = "api-auth-oidc-login"
+	ROUTE_AUTH_OIDC_CALLBACK          = "api-auth-oidc-callback"
+	ROUTE_AUTH_PERSONALTOKEN_LIST     = "api-auth-personaltoken-list"
+	ROUTE_AUTH_PERSONALTOKEN_DETAIL   = "api-auth-personaltoken-detail"
+	ROUTE_AUTH_USER_LIST              = "api-auth-user-list"
+	ROUTE_AUTH_USER_DETAIL            = "api-auth-user-detail"
+	ROUTE_AUTH_USER_CHANGE_PASSWORD   = "api-auth-user-changepassword"
+	ROUTE_AUTH_USER_LOCKOUT           = "api-auth-user-lockout"
+	ROUTE_AUTH_USER_PERMISSION_LIST   = "api-auth-user-permission-list"
+	ROUTE_AUTH_USER_PERMISSION_DETAIL = "api-auth-user-permission-detail"
 
 	ROUTE_COMMON_VERSION = "api-common-version"
 	ROUTE_COMMON_MONITOR = "api-common-monitor"
@@ -14,17 +39,42 @@ const (
 	ROUTE_PROJECTS_PROJECT_DETAIL       = "api-projects-project-detail"
 	ROUTE_PROJECTS_PROJECTKEY_LIST      = "api-projects-projectkey-list"
 	ROUTE_PROJECTS_PROJECTKEY_DETAIL    = "api-projects-projectkey-detail"
+	ROUTE_PROJECTS_PROJECT_SCRUBBING    = "api-projects-project-scrubbing"
+	ROUTE_PROJECTS_PROJECT_FILTERS      = "api-projects-project-filters"
+	ROUTE_PROJECTS_PROJECT_FILTER_STATS = "api-projects-project-filter-stats"
 	ROUTE_PROJECTS_PROJECTMEMBER_LIST   = "api-projects-project-member-list"
 	ROUTE_PROJECTS_PROJECTMEMBER_DETAIL = "api-projects-project-member-detail"
 
-	ROUTE_EVENTS_EVENTGROUP_LIST    = "api-events-eventgroup-list"
-	ROUTE_EVENTS_EVENTGROUP_DETAIL  = "api-events-eventgroup-detail"
-	ROUTE_EVENTS_EVENTGROUP_RESOLVE = "api-events-eventgroup-resolve"
-	ROUTE_EVENTS_EVENT_LIST         = "api-events-event-list"
-	ROUTE_EVENTS_EVENT_STORE        = "api-events-event-store"
+	ROUTE_EVENTS_EVENTGROUP_LIST         = "api-events-eventgroup-list"
+	ROUTE_EVENTS_EVENTGROUP_DETAIL       = "api-events-eventgroup-detail"
+	ROUTE_EVENTS_EVENTGROUP_RESOLVE      = "api-events-eventgroup-resolve"
+	ROUTE_EVENTS_EVENTGROUP_MUTE         = "api-events-eventgroup-mute"
+	ROUTE_EVENTS_EVENTGROUP_TAGS         = "api-events-eventgroup-tags"
+	ROUTE_EVENTS_EVENTGROUP_ENVIRONMENTS = "api-events-eventgroup-environments"
+	ROUTE_EVENTS_EVENT_LIST              = "api-events-event-list"
+	ROUTE_EVENTS_EVENT_STORE             = "api-events-event-store"
+	ROUTE_EVENTS_RELEASE_LIST            = "api-events-release-list"
+	ROUTE_EVENTS_RELEASE_DETAIL          = "api-events-release-detail"
+	ROUTE_EVENTS_ENVIRONMENT_LIST        = "api-events-environment-list"
 
 	ROUTE_TEAMS_TEAM_DETAIL       = "api-teams-team-detail"
 	ROUTE_TEAMS_TEAM_LIST         = "api-teams-team-list"
+	ROUTE_TEAMS_TEAM_TWOFACTOR    = "api-teams-team-twofactor"
 	ROUTE_TEAMS_TEAMMEMBER_LIST   = "api-teams-teammember-list"
 	ROUTE_TEAMS_TEAMMEMBER_DETAIL = "api-teams-teammember-detail"
+
+	ROUTE_WEBHOOKS_WEBHOOK_LIST       = "api-webhooks-webhook-list"
+	ROUTE_WEBHOOKS_WEBHOOK_DETAIL     = "api-webhooks-webhook-detail"
+	ROUTE_WEBHOOKS_DELIVERY_LIST      = "api-webhooks-delivery-list"
+	ROUTE_WEBHOOKS_DELIVERY_REDELIVER = "api-webhooks-delivery-redeliver"
+
+	ROUTE_CHATOPS_CHATWEBHOOK_LIST   = "api-chatops-chatwebhook-list"
+	ROUTE_CHATOPS_CHATWEBHOOK_DETAIL = "api-chatops-chatwebhook-detail"
+	ROUTE_CHATOPS_ACTION             = "api-chatops-action"
+
+	ROUTE_NOTIFICATIONS_SETTING        = "api-notifications-setting"
+	ROUTE_NOTIFICATIONS_PROJECTSETTING = "api-notifications-projectsetting"
+	ROUTE_NOTIFICATIONS_SUBSCRIPTION   = "api-notifications-subscription"
+
+	ROUTE_REALTIME_EVENTGROUP_STREAM = "api-realtime-eventgroup-stream"
 )
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"time"
 
 	"code.google.com/p/go.crypto/bcrypt"
 	_ "github.com/golang/glog"
@@ -18,6 +19,52 @@ var (
 	SETTINGS_SECRET_KEY        string
 	SETTINGS_GOMAXPROCS        int
 	SETTINGS_BCRYPT_COST       int
+	SETTINGS_BASE_URL          string
+
+	// smtp settings for e-mail notifications
+	SETTINGS_SMTP_HOST     string
+	SETTINGS_SMTP_USERNAME string
+	SETTINGS_SMTP_PASSWORD string
+	SETTINGS_SMTP_FROM     string
+
+	// e-mail digest settings, digest is sent when no notification came for
+	// min delay or when max delay passed since first notification
+	SETTINGS_DIGEST_MIN_DELAY time.Duration
+	SETTINGS_DIGEST_MAX_DELAY time.Duration
+
+	// lifetime of access tokens (jwt) and refresh tokens
+	SETTINGS_AUTH_ACCESS_TOKEN_TTL  time.Duration
+	SETTINGS_AUTH_REFRESH_TOKEN_TTL time.Duration
+
+	// users created by api must verify e-mail address before login
+	SETTINGS_AUTH_VERIFY_EMAIL bool
+
+	// comma separated list of authentication backends tried in order
+	SETTINGS_AUTH_BACKENDS string
+
+	// account is locked for lockout duration after threshold of failed
+	// logins, 0 disables lockout
+	SETTINGS_AUTH_LOCKOUT_THRESHOLD int
+	SETTINGS_AUTH_LOCKOUT_DURATION  time.Duration
+
+	// ldap authentication backend, user filter gets escaped username as %s,
+	// team mapping is semicolon separated list of team_id:member_type:group_dn
+	SETTINGS_LDAP_URL             string
+	SETTINGS_LDAP_START_TLS       bool
+	SETTINGS_LDAP_BIND_DN         string
+	SETTINGS_LDAP_BIND_PASSWORD   string
+	SETTINGS_LDAP_BASE_DN         string
+	SETTINGS_LDAP_USER_FILTER     string
+	SETTINGS_LDAP_NAME_ATTRIBUTE  string
+	SETTINGS_LDAP_EMAIL_ATTRIBUTE string
+	SETTINGS_LDAP_GROUP_ATTRIBUTE string
+	SETTINGS_LDAP_TEAM_MAPPING    string
+
+	// openid connect single sign-on, disabled when issuer is empty
+	SETTINGS_OIDC_ISSUER          string
+	SETTINGS_OIDC_CLIENT_ID       string
+	SETTINGS_OIDC_CLIENT_SECRET   string
+	SETTINGS_OIDC_ALLOWED_DOMAINS string
 
 	// restricted plugin ids - no other plugin in the future can have one of these ids
 	RESTRICTED_PLUGIN_IDS []string
@@ -57,6 +104,33 @@ func init() {
 	flag.StringVar(&SETTINGS_CACHE_DSN, "cache_dsn", "redis://localhost:6379/1?prefix=patrol", "gocacher cache dsn")
 	flag.StringVar(&SETTINGS_SECRET_KEY, "secret_key", "", "secret key for various hashing")
 	flag.IntVar(&SETTINGS_GOMAXPROCS, "gomaxprocs", 0, "gomaxprocs, if set to 0 runtime.NumCPU will be used.")
+	flag.StringVar(&SETTINGS_BASE_URL, "base_url", "http://"+HTTP_SERVER_DEFAULT_HOST, "absolute url of patrol used in links (e.g. in e-mails)")
+	flag.StringVar(&SETTINGS_SMTP_HOST, "smtp_host", "localhost:25", "smtp server address host:port")
+	flag.StringVar(&SETTINGS_SMTP_USERNAME, "smtp_username", "", "smtp username, if empty no authentication is used")
+	flag.StringVar(&SETTINGS_SMTP_PASSWORD, "smtp_password", "", "smtp password")
+	flag.StringVar(&SETTINGS_SMTP_FROM, "smtp_from", "patrol@localhost", "sender address of e-mails")
+	flag.DurationVar(&SETTINGS_DIGEST_MIN_DELAY, "digest_min_delay", 5*time.Minute, "e-mail digest is sent after this delay without notifications, 0 disables digests")
+	flag.DurationVar(&SETTINGS_DIGEST_MAX_DELAY, "digest_max_delay", 30*time.Minute, "e-mail digest is sent at latest after this delay from first notification")
+	flag.DurationVar(&SETTINGS_AUTH_ACCESS_TOKEN_TTL, "access_token_ttl", 15*time.Minute, "lifetime of access tokens")
+	flag.DurationVar(&SETTINGS_AUTH_REFRESH_TOKEN_TTL, "refresh_token_ttl", 30*24*time.Hour, "lifetime of refresh tokens")
+	flag.BoolVar(&SETTINGS_AUTH_VERIFY_EMAIL, "verify_email", false, "users created by api must verify e-mail address before login")
+	flag.StringVar(&SETTINGS_AUTH_BACKENDS, "auth_backends", "password", "comma separated list of authentication backends tried in order (password, ldap)")
+	flag.IntVar(&SETTINGS_AUTH_LOCKOUT_THRESHOLD, "auth_lockout_threshold", 10, "number of failed logins after which account is locked, 0 disables lockout")
+	flag.DurationVar(&SETTINGS_AUTH_LOCKOUT_DURATION, "auth_lockout_duration", 15*time.Minute, "how long account stays locked after too many failed logins")
+	flag.StringVar(&SETTINGS_LDAP_URL, "ldap_url", "ldap://localhost:389", "ldap server url")
+	flag.BoolVar(&SETTINGS_LDAP_START_TLS, "ldap_start_tls", false, "use StartTLS for ldap connection")
+	flag.StringVar(&SETTINGS_LDAP_BIND_DN, "ldap_bind_dn", "", "dn of ldap service account used to search users, if empty search is anonymous")
+	flag.StringVar(&SETTINGS_LDAP_BIND_PASSWORD, "ldap_bind_password", "", "password of ldap service account")
+	flag.StringVar(&SETTINGS_LDAP_BASE_DN, "ldap_base_dn", "", "base dn where ldap users are searched")
+	flag.StringVar(&SETTINGS_LDAP_USER_FILTER, "ldap_user_filter", "(uid=%s)", "ldap filter of users, %s is replaced by username")
+	flag.StringVar(&SETTINGS_LDAP_NAME_ATTRIBUTE, "ldap_name_attribute", "cn", "ldap attribute with full name of user")
+	flag.StringVar(&SETTINGS_LDAP_EMAIL_ATTRIBUTE, "ldap_email_attribute", "mail", "ldap attribute with e-mail address of user")
+	flag.StringVar(&SETTINGS_LDAP_GROUP_ATTRIBUTE, "ldap_group_attribute", "memberOf", "ldap attribute with group dns of user")
+	flag.StringVar(&SETTINGS_LDAP_TEAM_MAPPING, "ldap_team_mapping", "", "mapping of ldap groups to teams, e.g. \"1:admin:cn=admins,dc=example,dc=org;1:member:cn=devs,dc=example,dc=org\"")
+	flag.StringVar(&SETTINGS_OIDC_ISSUER, "oidc_issuer", "", "openid connect issuer url, if empty single sign-on is disabled")
+	flag.StringVar(&SETTINGS_OIDC_CLIENT_ID, "oidc_client_id", "", "openid connect client id")
+	flag.StringVar(&SETTINGS_OIDC_CLIENT_SECRET, "oidc_client_secret", "", "openid connect client secret")
+	flag.StringVar(&SETTINGS_OIDC_ALLOWED_DOMAINS, "oidc_allowed_domains", "", "comma separated list of e-mail domains allowed to sign in, if empty all domains are allowed")
 	flag.IntVar(&SETTINGS_BCRYPT_COST, "bcrypt_cost", bcrypt.DefaultCost, fmt.Sprintf("bcrypt hash cost, valid values are %d <= value <= %d.", bcrypt.MinCost, bcrypt.MaxCost))
 
 	if os.Getenv("TESTING") != "TRUE" {
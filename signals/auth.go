@@ -9,3 +9,21 @@ type OnSuccessfulLoginSignalHandler interface {
 	// when event request is received
 	OnSuccessfulLogin(user *models.User)
 }
+
+/* OnLoginFailedSignalHandler
+This signal is called on failed login (invalid username or password), failures
+is number of failed logins of username (account is locked when it reaches
+lockout threshold)
+*/
+type OnLoginFailedSignalHandler interface {
+	OnLoginFailed(username, ip string, failures int)
+}
+
+/* PermissionsProvider
+Plugins that implement this interface provide permissions, auth plugin
+registers them to database after migrations.
+*/
+type PermissionsProvider interface {
+	// returns permissions of plugin
+	Permissions() []*models.Permission
+}
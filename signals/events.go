@@ -25,3 +25,10 @@ This signal is called when event is processed by event worker
 type OnEventSignalHandler interface {
 	OnEvent(event *models.Event, eventgroup *models.EventGroup)
 }
+
+/* OnEventGroupResolvedSignalHandler
+This signal is called when eventgroup is resolved by user
+*/
+type OnEventGroupResolvedSignalHandler interface {
+	OnEventGroupResolved(eventgroup *models.EventGroup, user *models.User)
+}
@@ -1,8 +1,10 @@
 package types
 
 import (
+	"bytes"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"strconv"
 )
 
@@ -50,3 +52,23 @@ type NullForeignKey struct {
 func (n NullForeignKey) String() string {
 	return strconv.FormatInt(n.Int64, 10)
 }
+
+func (n *NullForeignKey) UnmarshalJSON(b []byte) (err error) {
+	if bytes.Equal(b, []byte("null")) {
+		n.Int64 = 0
+		n.Valid = false
+		return
+	}
+	if err = json.Unmarshal(b, &n.Int64); err != nil {
+		return
+	}
+	n.Valid = true
+	return
+}
+
+func (n NullForeignKey) MarshalJSON() (b []byte, err error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Int64)
+}
@@ -0,0 +1,34 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNullForeignKey(t *testing.T) {
+	Convey("Test NullForeignKey json", t, func() {
+		var (
+			body []byte
+			err  error
+		)
+
+		nfk := NullForeignKey{}
+		body, err = json.Marshal(nfk)
+		So(err, ShouldBeNil)
+		So(string(body), ShouldEqual, "null")
+
+		nfk.Int64, nfk.Valid = 42, true
+		body, err = json.Marshal(nfk)
+		So(err, ShouldBeNil)
+		So(string(body), ShouldEqual, "42")
+
+		another := NullForeignKey{}
+		So(json.Unmarshal(body, &another), ShouldBeNil)
+		So(another, ShouldResemble, nfk)
+
+		So(json.Unmarshal([]byte("null"), &another), ShouldBeNil)
+		So(another.Valid, ShouldBeFalse)
+	})
+}
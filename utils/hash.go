@@ -1,6 +1,12 @@
 package utils
 
-import "code.google.com/p/go.crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"code.google.com/p/go.crypto/bcrypt"
+)
 
 func HashPassword(password, secret string, cost int) (string, error) {
 	sum := []byte(secret + password)
@@ -27,3 +33,23 @@ func VerifyHashedPassword(hash, password, secret string) (result bool, err error
 	}
 	return
 }
+
+/*
+Returns cryptographically random token (hex encoded n random bytes)
+*/
+func NewRandomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+/*
+Returns sha256 hash of token, tokens are stored only hashed so leaked
+database does not leak valid tokens
+*/
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
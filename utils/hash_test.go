@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTokens(t *testing.T) {
+	Convey("test NewRandomToken", t, func() {
+		first := NewRandomToken(32)
+		So(len(first), ShouldEqual, 64)
+		So(NewRandomToken(32), ShouldNotEqual, first)
+	})
+
+	Convey("test HashToken", t, func() {
+		So(len(HashToken("token")), ShouldEqual, 64)
+		So(HashToken("token"), ShouldEqual, HashToken("token"))
+		So(HashToken("token"), ShouldNotEqual, HashToken("other"))
+	})
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrAddressNotPublic = errors.New("address is not public")
+	ErrTooManyRedirects = errors.New("too many redirects")
+
+	// ranges that must not be reachable from outgoing requests
+	privateNetworks = mustParseCIDRs(
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.0.0.0/24",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"224.0.0.0/3",
+		"::/128",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+		"ff00::/8",
+	)
+)
+
+func mustParseCIDRs(cidrs ...string) (result []*net.IPNet) {
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		result = append(result, network)
+	}
+	return
+}
+
+/*
+Returns whether ip is publicly routable address (not loopback, link-local,
+private or multicast)
+*/
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Returns whether host (name or ip address) may be target of outgoing request.
+Names are resolved, name that cannot be resolved is allowed since dialer
+checks address again when connecting.
+*/
+func IsPublicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return IsPublicIP(ip)
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return true
+	}
+	for _, ip := range ips {
+		if !IsPublicIP(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Dialer control function that refuses connections to addresses that are not
+public. Used by http clients posting to user supplied urls, so resolved
+address is checked at the moment of connection.
+*/
+func PublicAddressControl(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublicIP(net.ParseIP(host)) {
+		return ErrAddressNotPublic
+	}
+	return nil
+}
+
+/*
+Returns http client for requests to user supplied urls. Client refuses to
+connect to addresses that are not public (checked after name resolution)
+and to follow redirects to hosts that are not public.
+*/
+func NewPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: PublicAddressControl,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return ErrTooManyRedirects
+			}
+			if !IsPublicHost(request.URL.Hostname()) {
+				return ErrAddressNotPublic
+			}
+			return nil
+		},
+	}
+}
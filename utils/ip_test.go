@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	Convey("test IsPublicIP", t, func() {
+		for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1"} {
+			So(IsPublicIP(net.ParseIP(ip)), ShouldBeFalse)
+		}
+		for _, ip := range []string{"8.8.8.8", "93.184.216.34", "2606:4700::1111"} {
+			So(IsPublicIP(net.ParseIP(ip)), ShouldBeTrue)
+		}
+		So(IsPublicIP(nil), ShouldBeFalse)
+	})
+
+	Convey("test IsPublicHost", t, func() {
+		So(IsPublicHost("localhost"), ShouldBeFalse)
+		So(IsPublicHost("api.localhost"), ShouldBeFalse)
+		So(IsPublicHost("127.0.0.1"), ShouldBeFalse)
+		So(IsPublicHost("::1"), ShouldBeFalse)
+		So(IsPublicHost("8.8.8.8"), ShouldBeTrue)
+	})
+
+	Convey("test PublicAddressControl", t, func() {
+		So(PublicAddressControl("tcp", "127.0.0.1:80", nil), ShouldEqual, ErrAddressNotPublic)
+		So(PublicAddressControl("tcp", "[::1]:80", nil), ShouldEqual, ErrAddressNotPublic)
+		So(PublicAddressControl("tcp", "8.8.8.8:443", nil), ShouldBeNil)
+	})
+
+	Convey("test NewPublicHTTPClient redirects", t, func() {
+		client := NewPublicHTTPClient(time.Second)
+
+		internal, _ := http.NewRequest("GET", "http://169.254.169.254/latest", nil)
+		So(client.CheckRedirect(internal, nil), ShouldEqual, ErrAddressNotPublic)
+
+		public, _ := http.NewRequest("GET", "http://8.8.8.8/", nil)
+		So(client.CheckRedirect(public, nil), ShouldBeNil)
+		So(client.CheckRedirect(public, make([]*http.Request, 10)), ShouldEqual, ErrTooManyRedirects)
+	})
+}
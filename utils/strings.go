@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"bytes"
 	"errors"
 	"math/rand"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -67,6 +69,40 @@ func StringIndex(list []string, s string, start ...int) (result int) {
 	return
 }
 
+/*
+	Truncates string to given length (in runes)
+*/
+func StringTruncate(s string, l int) string {
+	runes := []rune(s)
+	if len(runes) <= l {
+		return s
+	}
+	return string(runes[:l])
+}
+
+/*
+	Matches string against glob pattern (case insensitive)
+	supported wildcards are "*" (any sequence) and "?" (single character)
+*/
+func GlobMatch(pattern, s string) bool {
+	var expr bytes.Buffer
+	expr.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+
+	matched, err := regexp.MatchString(expr.String(), s)
+	return err == nil && matched
+}
+
 /*
 	splits migration identifier into id and pluginId
 	so e.g.
@@ -18,6 +18,26 @@ func TestDBArrayPlaceholder(t *testing.T) {
 	})
 }
 
+func TestStringTruncate(t *testing.T) {
+	Convey("test StringTruncate", t, func() {
+		So(StringTruncate("hello", 10), ShouldEqual, "hello")
+		So(StringTruncate("hello", 5), ShouldEqual, "hello")
+		So(StringTruncate("hello", 2), ShouldEqual, "he")
+		So(StringTruncate("čšžýá", 3), ShouldEqual, "čšž")
+	})
+}
+
+func TestGlobMatch(t *testing.T) {
+	Convey("Test GlobMatch", t, func() {
+		So(GlobMatch("Script error.", "script error."), ShouldBeTrue)
+		So(GlobMatch("Script error?", "Script error!"), ShouldBeTrue)
+		So(GlobMatch("*timeout*", "read: connection timeout (5s)"), ShouldBeTrue)
+		So(GlobMatch("1.0.*", "1.0.12"), ShouldBeTrue)
+		So(GlobMatch("1.0.*", "1.1.0"), ShouldBeFalse)
+		So(GlobMatch("a.c", "abc"), ShouldBeFalse)
+	})
+}
+
 func TestStringIndex(t *testing.T) {
 
 	words := "zero one two three four five six seven eight nine ten"
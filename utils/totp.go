@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+/*
+Returns new random base32 encoded secret for TOTP (RFC 6238)
+*/
+func NewTOTPSecret() string {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return totpEncoding.EncodeToString(b)
+}
+
+/*
+Returns HOTP code (RFC 4226) of base32 encoded secret for counter
+*/
+func HOTP(secret string, counter int64, digits int) (code string, err error) {
+	var key []byte
+	if key, err = totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "="))); err != nil {
+		return
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod), nil
+}
+
+/*
+Verifies TOTP code for time now, codes of skew periods around now are
+accepted too (clock drift). Returns counter of matching code, so caller can
+refuse reused codes.
+*/
+func VerifyTOTP(secret, code string, now time.Time, period time.Duration, digits, skew int) (counter int64, ok bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != digits {
+		return 0, false
+	}
+
+	current := now.Unix() / int64(period/time.Second)
+	for i := -skew; i <= skew; i++ {
+		expected, err := HOTP(secret, current+int64(i), digits)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+/*
+Returns otpauth:// provisioning uri for authenticator apps (usually shown as
+qr code)
+*/
+func TOTPProvisioningURI(secret, issuer, account string, period time.Duration, digits int) string {
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprintf("%d", digits))
+	values.Set("period", fmt.Sprintf("%d", int64(period/time.Second)))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + values.Encode()
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTOTP(t *testing.T) {
+	// secret of RFC 6238 test vectors (sha1)
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	Convey("test HOTP with RFC 6238 vectors", t, func() {
+		for unix, expected := range map[int64]string{
+			59:          "94287082",
+			1111111109:  "07081804",
+			1111111111:  "14050471",
+			1234567890:  "89005924",
+			2000000000:  "69279037",
+			20000000000: "65353130",
+		} {
+			code, err := HOTP(secret, unix/30, 8)
+			So(err, ShouldBeNil)
+			So(code, ShouldEqual, expected)
+		}
+
+		_, err := HOTP("not base32!", 1, 6)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("test VerifyTOTP", t, func() {
+		now := time.Unix(1111111111, 0)
+
+		counter, ok := VerifyTOTP(secret, "050471", now, 30*time.Second, 6, 1)
+		So(ok, ShouldBeTrue)
+		So(counter, ShouldEqual, 1111111111/30)
+
+		// previous period is accepted with skew
+		_, ok = VerifyTOTP(secret, "050471", now.Add(30*time.Second), 30*time.Second, 6, 1)
+		So(ok, ShouldBeTrue)
+
+		_, ok = VerifyTOTP(secret, "050471", now.Add(90*time.Second), 30*time.Second, 6, 1)
+		So(ok, ShouldBeFalse)
+
+		_, ok = VerifyTOTP(secret, "123", now, 30*time.Second, 6, 1)
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("test TOTP secret and provisioning uri", t, func() {
+		secret := NewTOTPSecret()
+		So(len(secret), ShouldEqual, 32)
+		So(NewTOTPSecret(), ShouldNotEqual, secret)
+
+		uri := TOTPProvisioningURI(secret, "Patrol", "user name", 30*time.Second, 6)
+		So(uri, ShouldStartWith, "otpauth://totp/Patrol:user%20name?")
+		So(uri, ShouldContainSubstring, "secret="+secret)
+		So(strings.Contains(uri, "issuer=Patrol"), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+/*
+Returns random (version 4) UUID as 32 hex characters without dashes
+(format used by sentry clients for event_id)
+*/
+func NewUUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return hex.EncodeToString(b)
+}
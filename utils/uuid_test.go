@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewUUID(t *testing.T) {
+	Convey("test NewUUID", t, func() {
+		first := NewUUID()
+		So(len(first), ShouldEqual, 32)
+		So(first[12], ShouldEqual, '4')
+		So(NewUUID(), ShouldNotEqual, first)
+	})
+}
@@ -0,0 +1,30 @@
+package alerts
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/alerts"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+/*
+List of actions available for alert rules
+
+	/api/alerts/action/
+*/
+type ActionListAPIView struct {
+	views.APIView
+	mixins.AuthUserMixin
+}
+
+func (a *ActionListAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	user := models.NewUser()
+	if err := a.GetAuthUser(user, w, r); err != nil {
+		return
+	}
+
+	response.New(http.StatusOK).Result(alerts.Actions.Items()).Write(w, r)
+}
@@ -0,0 +1,134 @@
+package alerts
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewAlertRuleDetailAPIView() views.Viewer {
+	return &AlertRuleDetailAPIView{
+		project: models.NewProject(),
+		rule:    models.NewAlertRule(),
+		user:    models.NewUser(),
+	}
+}
+
+/*
+Alert rule detail
+
+	/api/projects/project/{project_id:[0-9]+}/alertrule/{alertrule_id:[0-9]+}
+*/
+type AlertRuleDetailAPIView struct {
+	views.APIView
+
+	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+
+	context *context.Context
+
+	membertype models.MemberType
+	project    *models.Project
+	rule       *models.AlertRule
+	user       *models.User
+}
+
+/*
+Before loads project, checks membership and loads alert rule of project
+*/
+func (a *AlertRuleDetailAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+
+	if err = a.GetAuthUser(a.user, w, r); err != nil {
+		return
+	}
+
+	if err = a.GetProject(a.project, w, r); err != nil {
+		return
+	}
+
+	if a.membertype, err = a.GetMemberType(a.project, a.user, w, r); err != nil {
+		return
+	}
+
+	var pk types.PrimaryKey
+	if pk, err = rest.GetMuxVarPrimaryKey(r, "alertrule_id"); err != nil {
+		err = views.ErrInvalidParam
+		response.New(http.StatusBadRequest).Error(err).Write(w, r)
+		return
+	}
+
+	manager := models.NewAlertRuleManager(a.context)
+	if err = manager.GetByID(a.rule, pk, manager.QueryFilterProject(a.project)); err != nil {
+		if err == models.ErrObjectDoesNotExists {
+			response.New(http.StatusNotFound).Write(w, r)
+		} else {
+			response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		}
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve alert rule
+*/
+func (a *AlertRuleDetailAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	response.New(http.StatusOK).Result(a.rule).Write(w, r)
+}
+
+/*
+Update alert rule, only project admins can update rules
+*/
+func (a *AlertRuleDetailAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	if a.membertype != models.MEMBER_TYPE_ADMIN {
+		response.New(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	serializer := &serializers.AlertsAlertRuleSerializer{}
+	if err = a.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(a.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	if err = serializer.Save(a.context, a.rule); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Result(a.rule).Write(w, r)
+}
+
+/*
+Delete alert rule, only project admins can delete rules
+*/
+func (a *AlertRuleDetailAPIView) DELETE(w http.ResponseWriter, r *http.Request) {
+	if a.membertype != models.MEMBER_TYPE_ADMIN {
+		response.New(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	if err := a.rule.Delete(a.context); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Write(w, r)
+}
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewAuditLogListAPIView() views.Viewer {
+	return &AuditLogListAPIView{
+		user: models.NewUser(),
+	}
+}
+
+/*
+Audit log of administrative actions (latest first)
+
+	/api/audit/
+
+	superuser can read whole audit log, team admin must filter by team_id of
+	team where user is admin.
+*/
+type AuditLogListAPIView struct {
+	views.APIView
+
+	mixins.AuthUserMixin
+
+	context *context.Context
+
+	filter *serializers.AuditAuditLogFilterSerializer
+	user   *models.User
+}
+
+/*
+Before parses filter and checks permissions
+*/
+func (a *AuditLogListAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+
+	if err = a.GetAuthUser(a.user, w, r); err != nil {
+		return
+	}
+
+	a.filter = &serializers.AuditAuditLogFilterSerializer{}
+	if vr := a.filter.Parse(r.URL.Query()); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return views.ErrInvalidParam
+	}
+
+	if a.user.IsSuperuser {
+		return
+	}
+
+	// team admin can read audit log of team
+	if a.filter.TeamID == 0 {
+		response.New(http.StatusForbidden).Write(w, r)
+		return views.ErrForbidden
+	}
+
+	teammanager := models.NewTeamManager(a.context)
+	team := teammanager.NewTeam()
+	if err = teammanager.GetByID(team, a.filter.TeamID); err != nil {
+		response.New(http.StatusForbidden).Write(w, r)
+		return views.ErrForbidden
+	}
+
+	var mt models.MemberType
+	if mt, err = models.NewTeamMemberManager(a.context).MemberType(team, a.user); err != nil || mt != models.MEMBER_TYPE_ADMIN {
+		response.New(http.StatusForbidden).Write(w, r)
+		return views.ErrForbidden
+	}
+
+	return
+}
+
+/*
+Retrieve list of audit log entries
+*/
+func (a *AuditLogListAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	manager := models.NewAuditLogManager(a.context)
+	paginator := manager.NewPaginatorFromRequest(r)
+	result := manager.NewAuditLogList()
+
+	qfs := append(a.filter.QueryFilters(manager), manager.QueryFilterOrderLatest())
+	if err := manager.FilterPaged(&result, paginator, qfs...); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Paginator(paginator).Result(result).Write(w, r)
+}
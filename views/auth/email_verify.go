@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/notifications"
+	"github.com/phonkee/patrol/rest"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+/*
+Sends e-mail verification link to user
+*/
+func SendEmailVerification(ctx *context.Context, mailer *notifications.Mailer, user *models.User) (err error) {
+	token := models.NewUserManager(ctx).NewSignedToken(user, models.SIGNED_TOKEN_EMAIL_VERIFY, settings.AUTH_EMAIL_VERIFY_TOKEN_TTL)
+
+	var message *notifications.Message
+	if message, err = notifications.NewEmailVerifyMessage(user, token); err != nil {
+		return
+	}
+	return mailer.Send(message)
+}
+
+/*
+E-mail verification
+
+	verifies e-mail address of user identified by token from e-mail
+*/
+type AuthEmailVerifyAPIView struct {
+	views.APIView
+	mixins.RateLimitMixin
+
+	// context
+	context *context.Context
+}
+
+func (a *AuthEmailVerifyAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+	return a.CheckRateLimit("auth:email:verify:ip:"+rest.ClientIP(r), settings.AUTH_MAIL_RATE_LIMIT_PER_IP, settings.AUTH_MAIL_RATE_LIMIT_PERIOD, w, r)
+}
+
+func (a *AuthEmailVerifyAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.AuthEmailVerifySerializer{}
+	if err = a.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(a.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	if _, err = serializer.Save(a.context); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Write(w, r)
+}
+
+/*
+E-mail verification resend
+
+	sends verification e-mail again to unverified user. Response is always
+	same, so it cannot be used to find out whether account exists.
+*/
+type AuthEmailVerifyResendAPIView struct {
+	views.APIView
+	mixins.RateLimitMixin
+
+	// mailer that sends verification e-mails
+	Mailer *notifications.Mailer
+
+	// context
+	context *context.Context
+}
+
+func (a *AuthEmailVerifyResendAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+	return a.CheckRateLimit("auth:email:resend:ip:"+rest.ClientIP(r), settings.AUTH_MAIL_RATE_LIMIT_PER_IP, settings.AUTH_MAIL_RATE_LIMIT_PERIOD, w, r)
+}
+
+func (a *AuthEmailVerifyResendAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.AuthUserEmailSerializer{}
+	if err = a.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(a.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	if err = a.CheckRateLimit("auth:email:resend:email:"+strings.ToLower(serializer.Email), settings.AUTH_MAIL_RATE_LIMIT_PER_EMAIL, settings.AUTH_MAIL_RATE_LIMIT_PERIOD, w, r); err != nil {
+		return
+	}
+
+	user := models.NewUser()
+	if err = serializer.GetUser(a.context, user); err == nil {
+		if !user.EmailVerified {
+			if err = SendEmailVerification(a.context, a.Mailer, user); err != nil {
+				glog.Errorf("auth: cannot send verification e-mail to %s: %s", user.Email, err)
+			}
+		}
+	} else if err != models.ErrObjectDoesNotExists {
+		glog.Errorf("auth: e-mail verification error: %s", err)
+	}
+
+	response.New(http.StatusAccepted).Write(w, r)
+}
@@ -2,9 +2,13 @@ package auth
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/golang/glog"
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest"
 	"github.com/phonkee/patrol/rest/metadata"
 	"github.com/phonkee/patrol/rest/response"
 	"github.com/phonkee/patrol/rest/views"
@@ -15,8 +19,9 @@ import (
 type AuthLoginAPIView struct {
 	views.APIView
 
-	// store callback for signal
-	LoginSignal func(user *models.User) error
+	// store callbacks for signals
+	LoginSignal       func(user *models.User) error
+	LoginFailedSignal func(username, ip string, failures int) error
 
 	// context
 	context *context.Context
@@ -54,13 +59,56 @@ func (l *AuthLoginAPIView) POST(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ip := rest.ClientIP(r)
+	throttle := models.NewLoginThrottle(l.context)
+
+	// locked accounts and attempts too early after failure are refused
+	// before password is checked
+	var wait time.Duration
+	if wait, err = throttle.Check(serializer.Username, ip); err != nil {
+		vr.AddUnboundError(err)
+		if err == models.ErrAccountLocked {
+			response.New(http.StatusForbidden).Error(vr).Write(w, r)
+		} else {
+			retry := int64((wait + time.Second - 1) / time.Second)
+			response.New(http.StatusTooManyRequests).Header("Retry-After", strconv.FormatInt(retry, 10)).Error(vr).Write(w, r)
+		}
+		return
+	}
+
 	user := models.NewUser()
-	token := ""
-	if user, token, err = serializer.Login(l.context); err != nil {
+	var tokens *serializers.AuthTokenSerializer
+	user, tokens, err = serializer.Login(l.context)
+
+	// password was valid
+	if err == nil || err == models.ErrEmailNotVerified || err == models.ErrTwoFactorRequired {
+		if errThrottle := throttle.Succeeded(serializer.Username); errThrottle != nil {
+			glog.Errorf("auth: cannot reset failed logins of %s: %s", serializer.Username, errThrottle)
+		}
+	}
+
+	if err != nil {
 		switch err {
 		case serializers.ErrUsernamePassword:
+			failures, errThrottle := throttle.Failed(serializer.Username, ip)
+			if errThrottle != nil {
+				glog.Errorf("auth: cannot record failed login of %s: %s", serializer.Username, errThrottle)
+			}
+			l.LoginFailedSignal(serializer.Username, ip, failures)
+
 			vr.AddUnboundError(err)
 			response.New(http.StatusUnauthorized).Error(vr).Write(w, r)
+		case models.ErrEmailNotVerified:
+			vr.AddUnboundError(err)
+			response.New(http.StatusForbidden).Error(vr).Write(w, r)
+		case models.ErrTwoFactorRequired:
+			// password is valid, code is verified by AuthLoginTwoFactorAPIView
+			var challenge *serializers.AuthTwoFactorChallengeSerializer
+			if challenge, err = serializers.NewTwoFactorChallenge(l.context, user); err != nil {
+				response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+				return
+			}
+			response.New(http.StatusAccepted).Result(challenge).Write(w, r)
 		case serializers.ErrInternalServerError:
 			fallthrough
 		default:
@@ -72,5 +120,9 @@ func (l *AuthLoginAPIView) POST(w http.ResponseWriter, r *http.Request) {
 	// send signal
 	l.LoginSignal(user)
 
-	response.New(http.StatusOK).Header(settings.AUTH_TOKEN_HEADER_NAME, token).Write(w, r)
+	response.New(http.StatusOK).
+		Header(settings.AUTH_TOKEN_HEADER_NAME, tokens.Token).
+		Header(settings.AUTH_REFRESH_TOKEN_HEADER_NAME, tokens.RefreshToken).
+		Result(tokens).
+		Write(w, r)
 }
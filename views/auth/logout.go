@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+/*
+Logout view
+
+	revokes access token of request and refresh tokens of its session
+*/
+type AuthLogoutAPIView struct {
+	views.APIView
+
+	// context
+	context *context.Context
+}
+
+func (a *AuthLogoutAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+	return
+}
+
+func (a *AuthLogoutAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var (
+		err   error
+		token *jwt.Token
+	)
+
+	manager := models.NewUserManager(a.context)
+	if token, err = manager.GetAuthToken(r); err != nil {
+		response.New(http.StatusUnauthorized).Write(w, r)
+		return
+	}
+
+	if err = manager.Logout(token); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Write(w, r)
+}
+
+/*
+Logout everywhere view
+
+	revokes all access and refresh tokens of user
+*/
+type AuthLogoutAllAPIView struct {
+	views.APIView
+	mixins.AuthUserMixin
+
+	// context
+	context *context.Context
+}
+
+func (a *AuthLogoutAllAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+	return
+}
+
+func (a *AuthLogoutAllAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	user := models.NewUser()
+	if err = a.GetAuthUser(user, w, r); err != nil {
+		return
+	}
+
+	if err = models.NewUserManager(a.context).RevokeTokens(user); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Write(w, r)
+}
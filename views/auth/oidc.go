@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/oidc"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+)
+
+/*
+Redirects browser to authorization endpoint of identity provider
+
+	/api/auth/oidc/login
+*/
+type OIDCLoginAPIView struct {
+	views.APIView
+	Provider *oidc.Provider
+
+	context *context.Context
+}
+
+func (o *OIDCLoginAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	o.context = o.GetContext(r)
+
+	if !o.Provider.Enabled() {
+		response.New(http.StatusNotFound).Write(w, r)
+		return oidc.ErrNotConfigured
+	}
+	return
+}
+
+func (o *OIDCLoginAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	state := oidc.NewState()
+	if err = state.Save(o.context); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	var redirectURL, authURL string
+	if redirectURL, err = oidcCallbackURL(o.context); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	if authURL, err = o.Provider.AuthCodeURL(state, redirectURL); err != nil {
+		glog.Errorf("oidc: cannot discover provider: %v", err)
+		response.New(http.StatusBadGateway).Error(err).Write(w, r)
+		return
+	}
+
+	// bind state to browser, so login link cannot be used by someone else
+	http.SetCookie(w, oidcStateCookie(state.ID, int(settings.OIDC_STATE_TIMEOUT.Seconds())))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+/*
+Callback where identity provider redirects browser back. Code is exchanged for
+id token, user is created or linked by verified e-mail and browser is
+redirected to AUTH_OIDC_LOGIN_LINK with tokens (or error). Users with
+two-factor authentication enabled or required by team get two-factor
+challenge instead of tokens and finish login in AuthLoginTwoFactorAPIView.
+
+	/api/auth/oidc/callback?code=..&state=..
+*/
+type OIDCCallbackAPIView struct {
+	views.APIView
+	Provider *oidc.Provider
+
+	// store callback for signal
+	LoginSignal func(user *models.User) error
+
+	context *context.Context
+}
+
+func (o *OIDCCallbackAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	o.context = o.GetContext(r)
+
+	if !o.Provider.Enabled() {
+		response.New(http.StatusNotFound).Write(w, r)
+		return oidc.ErrNotConfigured
+	}
+	return
+}
+
+func (o *OIDCCallbackAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	query := r.URL.Query()
+
+	// state cookie is not needed anymore
+	cookie, _ := r.Cookie(settings.OIDC_STATE_COOKIE_NAME)
+	http.SetCookie(w, oidcStateCookie("", -1))
+
+	if value := query.Get("error"); value != "" {
+		o.redirect(w, r, url.Values{"error": {value}})
+		return
+	}
+
+	if cookie == nil || cookie.Value != query.Get("state") {
+		o.redirect(w, r, url.Values{"error": {oidc.ErrInvalidState.Error()}})
+		return
+	}
+
+	var state *oidc.State
+	if state, err = oidc.PopState(o.context, query.Get("state")); err != nil {
+		o.redirect(w, r, url.Values{"error": {oidc.ErrInvalidState.Error()}})
+		return
+	}
+
+	var redirectURL string
+	if redirectURL, err = oidcCallbackURL(o.context); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	var claims *oidc.Claims
+	if claims, err = o.Provider.Exchange(query.Get("code"), state, redirectURL); err != nil {
+		glog.Errorf("oidc: code exchange failed: %v", err)
+		o.redirect(w, r, url.Values{"error": {"login_failed"}})
+		return
+	}
+
+	var user *models.User
+	if user, err = oidc.GetOrCreateUser(o.context, claims, o.Provider.AllowedDomains); err != nil {
+		switch err {
+		case models.ErrEmailNotVerified, oidc.ErrDomainNotAllowed, oidc.ErrUserInactive:
+			o.redirect(w, r, url.Values{"error": {err.Error()}})
+		default:
+			glog.Errorf("oidc: cannot get user %s: %v", claims.Email, err)
+			o.redirect(w, r, url.Values{"error": {"login_failed"}})
+		}
+		return
+	}
+
+	// second factor is verified by AuthLoginTwoFactorAPIView as after
+	// password login, frontend gets challenge instead of tokens
+	var twoFactor bool
+	if twoFactor, err = serializers.IsTwoFactorLogin(o.context, user); err != nil {
+		glog.Errorf("oidc: cannot check two-factor authentication of %s: %v", user, err)
+		o.redirect(w, r, url.Values{"error": {"login_failed"}})
+		return
+	}
+	if twoFactor {
+		var challenge *serializers.AuthTwoFactorChallengeSerializer
+		if challenge, err = serializers.NewTwoFactorChallenge(o.context, user); err != nil {
+			glog.Errorf("oidc: cannot create two-factor challenge for %s: %v", user, err)
+			o.redirect(w, r, url.Values{"error": {"login_failed"}})
+			return
+		}
+		o.redirect(w, r, url.Values{
+			"two_factor_token": {challenge.TwoFactorToken},
+			"enroll":           {strconv.FormatBool(challenge.Enroll)},
+			"expires_in":       {strconv.FormatInt(challenge.ExpiresIn, 10)},
+		})
+		return
+	}
+
+	var tokens *serializers.AuthTokenSerializer
+	if tokens, err = serializers.NewAuthTokens(o.context, user); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	// send signal
+	o.LoginSignal(user)
+
+	o.redirect(w, r, url.Values{
+		"token":         {tokens.Token},
+		"refresh_token": {tokens.RefreshToken},
+		"expires_in":    {strconv.FormatInt(tokens.ExpiresIn, 10)},
+	})
+}
+
+// redirects browser to frontend, values are in fragment so they are not sent to servers
+func (o *OIDCCallbackAPIView) redirect(w http.ResponseWriter, r *http.Request, values url.Values) {
+	http.Redirect(w, r, strings.TrimRight(settings.SETTINGS_BASE_URL, "/")+settings.AUTH_OIDC_LOGIN_LINK+"?"+values.Encode(), http.StatusFound)
+}
+
+// returns absolute url of callback view
+func oidcCallbackURL(context *context.Context) (result string, err error) {
+	var u *url.URL
+	if u, err = context.Router.Get(settings.ROUTE_AUTH_OIDC_CALLBACK).URL(); err != nil {
+		return
+	}
+	return strings.TrimRight(settings.SETTINGS_BASE_URL, "/") + u.Path, nil
+}
+
+// returns cookie with state, negative maxAge removes cookie
+func oidcStateCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     settings.OIDC_STATE_COOKIE_NAME,
+		Value:    value,
+		Path:     "/api/auth/oidc/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(settings.SETTINGS_BASE_URL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
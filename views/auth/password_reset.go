@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/notifications"
+	"github.com/phonkee/patrol/rest"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+/*
+Password reset request
+
+	sends e-mail with password reset link. Response is always same, so it
+	cannot be used to find out whether account exists.
+*/
+type AuthPasswordResetAPIView struct {
+	views.APIView
+	mixins.RateLimitMixin
+
+	// mailer that sends password reset e-mails
+	Mailer *notifications.Mailer
+
+	// context
+	context *context.Context
+}
+
+func (a *AuthPasswordResetAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+	return a.CheckRateLimit("auth:password:reset:ip:"+rest.ClientIP(r), settings.AUTH_MAIL_RATE_LIMIT_PER_IP, settings.AUTH_MAIL_RATE_LIMIT_PERIOD, w, r)
+}
+
+func (a *AuthPasswordResetAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.AuthUserEmailSerializer{}
+	if err = a.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(a.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	if err = a.CheckRateLimit("auth:password:reset:email:"+strings.ToLower(serializer.Email), settings.AUTH_MAIL_RATE_LIMIT_PER_EMAIL, settings.AUTH_MAIL_RATE_LIMIT_PERIOD, w, r); err != nil {
+		return
+	}
+
+	user := models.NewUser()
+	if err = serializer.GetUser(a.context, user); err == nil {
+		token := models.NewUserManager(a.context).NewSignedToken(user, models.SIGNED_TOKEN_PASSWORD_RESET, settings.AUTH_PASSWORD_RESET_TOKEN_TTL)
+
+		var message *notifications.Message
+		if message, err = notifications.NewPasswordResetMessage(user, token); err == nil {
+			err = a.Mailer.Send(message)
+		}
+		if err != nil {
+			glog.Errorf("auth: cannot send password reset e-mail to %s: %s", user.Email, err)
+		}
+	} else if err != models.ErrObjectDoesNotExists {
+		glog.Errorf("auth: password reset error: %s", err)
+	}
+
+	response.New(http.StatusAccepted).Write(w, r)
+}
+
+/*
+Password reset confirm
+
+	sets new password of user identified by token from e-mail, all tokens of
+	user are revoked
+*/
+type AuthPasswordResetConfirmAPIView struct {
+	views.APIView
+	mixins.RateLimitMixin
+
+	// context
+	context *context.Context
+}
+
+func (a *AuthPasswordResetConfirmAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+	return a.CheckRateLimit("auth:password:confirm:ip:"+rest.ClientIP(r), settings.AUTH_MAIL_RATE_LIMIT_PER_IP, settings.AUTH_MAIL_RATE_LIMIT_PERIOD, w, r)
+}
+
+func (a *AuthPasswordResetConfirmAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.AuthPasswordResetConfirmSerializer{}
+	if err = a.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(a.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	if _, err = serializer.Save(a.context); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Write(w, r)
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewPersonalTokenDetailAPIView() views.Viewer {
+	return &PersonalTokenDetailAPIView{
+		token: models.NewPersonalToken(),
+		user:  models.NewUser(),
+	}
+}
+
+/*
+Personal token detail
+
+	/api/auth/me/tokens/{personaltoken_id:[0-9]+}
+*/
+type PersonalTokenDetailAPIView struct {
+	views.APIView
+
+	mixins.AuditMixin
+	mixins.AuthUserMixin
+	mixins.PersonalTokenMixin
+
+	context *context.Context
+
+	token *models.PersonalToken
+	user  *models.User
+}
+
+/*
+Before loads authenticated user and personal token of user
+*/
+func (p *PersonalTokenDetailAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	p.context = p.GetContext(r)
+
+	if err = p.GetAuthUser(p.user, w, r); err != nil {
+		return
+	}
+
+	if err = p.GetPersonalToken(p.token, p.user, w, r); err != nil {
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve personal token
+*/
+func (p *PersonalTokenDetailAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	response.New(http.StatusOK).Result(p.token).Write(w, r)
+}
+
+/*
+Update name, scopes and expiry of personal token
+*/
+func (p *PersonalTokenDetailAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.AuthPersonalTokenSerializer{}
+	if err = p.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(p.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	before := *p.token
+	if _, err = serializer.Save(p.context, p.token, p.user); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+	p.Audit(r, p.user, models.AUDIT_ACTION_UPDATE, &before, p.token)
+
+	response.New(http.StatusOK).Result(p.token).Write(w, r)
+}
+
+/*
+Revoke (delete) personal token
+*/
+func (p *PersonalTokenDetailAPIView) DELETE(w http.ResponseWriter, r *http.Request) {
+	if err := p.token.Delete(p.context); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+	p.Audit(r, p.user, models.AUDIT_ACTION_DELETE, p.token, nil)
+
+	response.New(http.StatusOK).Write(w, r)
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewPersonalTokenListAPIView() views.Viewer {
+	return &PersonalTokenListAPIView{
+		user: models.NewUser(),
+	}
+}
+
+/*
+Personal tokens of authenticated user
+
+	/api/auth/me/tokens
+*/
+type PersonalTokenListAPIView struct {
+	views.APIView
+
+	mixins.AuditMixin
+	mixins.AuthUserMixin
+
+	context *context.Context
+
+	user *models.User
+}
+
+/*
+Before loads authenticated user
+*/
+func (p *PersonalTokenListAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	p.context = p.GetContext(r)
+
+	if err = p.GetAuthUser(p.user, w, r); err != nil {
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve list of personal tokens
+*/
+func (p *PersonalTokenListAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	manager := models.NewPersonalTokenManager(p.context)
+	result := manager.NewPersonalTokenList()
+
+	if err := manager.Filter(&result, manager.QueryFilterUser(p.user), manager.QueryFilterOrderID()); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Result(result).Write(w, r)
+}
+
+/*
+Create new personal token, token string is returned only in this response
+*/
+func (p *PersonalTokenListAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.AuthPersonalTokenSerializer{}
+	if err = p.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(p.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	token := models.NewPersonalToken()
+
+	var tokenString string
+	if tokenString, err = serializer.Save(p.context, token, p.user); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+	p.Audit(r, p.user, models.AUDIT_ACTION_CREATE, nil, token)
+
+	result := struct {
+		*models.PersonalToken
+		Token string `json:"token"`
+	}{token, tokenString}
+
+	response.New(http.StatusCreated).Result(result).Write(w, r)
+}
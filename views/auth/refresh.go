@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/metadata"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+)
+
+/*
+Refresh view
+
+	exchanges refresh token for new access token and refresh token, used
+	refresh token is revoked
+*/
+type AuthRefreshAPIView struct {
+	views.APIView
+
+	// context
+	context *context.Context
+}
+
+func (a *AuthRefreshAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+	return
+}
+
+// Options request
+func (a *AuthRefreshAPIView) OPTIONS(w http.ResponseWriter, r *http.Request) {
+	md := metadata.New("Refresh access token")
+	md.Action("POST").From(serializers.AuthRefreshSerializer{})
+	response.New().Raw(md).Write(w, r)
+}
+
+/* POST method for refresh
+ */
+func (a *AuthRefreshAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := serializers.AuthRefreshSerializer{}
+	if err = a.context.Bind(&serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	vr := serializer.Validate(a.context)
+	if !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	var tokens *serializers.AuthTokenSerializer
+	if _, tokens, err = serializer.Refresh(a.context); err != nil {
+		switch err {
+		case models.ErrInvalidRefreshToken, models.ErrCannotLoginUser:
+			vr.AddUnboundError(err)
+			response.New(http.StatusUnauthorized).Error(vr).Write(w, r)
+		default:
+			response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		}
+		return
+	}
+
+	response.New(http.StatusOK).
+		Header(settings.AUTH_TOKEN_HEADER_NAME, tokens.Token).
+		Header(settings.AUTH_REFRESH_TOKEN_HEADER_NAME, tokens.RefreshToken).
+		Result(tokens).
+		Write(w, r)
+}
@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/validator"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+// writes response for error of two-factor authentication
+func writeTwoFactorError(err error, w http.ResponseWriter, r *http.Request) {
+	vr := validator.NewResult()
+	switch err {
+	case models.ErrInvalidTwoFactorCode:
+		vr.AddFieldError("code", err)
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+	case models.ErrTwoFactorNotEnabled, models.ErrTwoFactorAlreadyEnabled:
+		vr.AddUnboundError(err)
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+	default:
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+	}
+}
+
+// checks rate limit of codes verified for user
+func checkTwoFactorRateLimit(m *mixins.RateLimitMixin, user *models.User, w http.ResponseWriter, r *http.Request) error {
+	return m.CheckRateLimit("auth:twofactor:user:"+user.ID.String(), settings.AUTH_TWO_FACTOR_RATE_LIMIT, settings.AUTH_TWO_FACTOR_RATE_LIMIT_TIME, w, r)
+}
+
+/*
+Second step of login
+
+	/api/auth/login/twofactor
+
+	verifies code for token returned by AuthLoginAPIView and returns tokens.
+	First code after enrollment confirms it and recovery codes are returned.
+*/
+type AuthLoginTwoFactorAPIView struct {
+	views.APIView
+	mixins.RateLimitMixin
+
+	// store callback for signal
+	LoginSignal func(user *models.User) error
+
+	// context
+	context *context.Context
+}
+
+func (a *AuthLoginTwoFactorAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+	return a.CheckRateLimit("auth:login:twofactor:ip:"+rest.ClientIP(r), settings.AUTH_TWO_FACTOR_RATE_LIMIT_PER_IP, settings.AUTH_TWO_FACTOR_RATE_LIMIT_TIME, w, r)
+}
+
+func (a *AuthLoginTwoFactorAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.AuthLoginTwoFactorSerializer{}
+	if err = a.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(a.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	if err = checkTwoFactorRateLimit(&a.RateLimitMixin, serializer.User(), w, r); err != nil {
+		return
+	}
+
+	var result *serializers.AuthLoginTwoFactorResultSerializer
+	if result, err = serializer.Login(a.context); err != nil {
+		writeTwoFactorError(err, w, r)
+		return
+	}
+
+	// send signal
+	a.LoginSignal(serializer.User())
+
+	response.New(http.StatusOK).
+		Header(settings.AUTH_TOKEN_HEADER_NAME, result.Token).
+		Header(settings.AUTH_REFRESH_TOKEN_HEADER_NAME, result.RefreshToken).
+		Result(result).
+		Write(w, r)
+}
+
+/*
+Enrollment during login
+
+	/api/auth/login/twofactor/enroll
+
+	users that are required to use two-factor authentication (by team) and
+	did not enroll yet, get secret here. Enrollment is confirmed with first
+	code in AuthLoginTwoFactorAPIView.
+*/
+type AuthLoginTwoFactorEnrollAPIView struct {
+	views.APIView
+	mixins.RateLimitMixin
+
+	// context
+	context *context.Context
+}
+
+func (a *AuthLoginTwoFactorEnrollAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+	return a.CheckRateLimit("auth:login:twofactor:enroll:ip:"+rest.ClientIP(r), settings.AUTH_TWO_FACTOR_RATE_LIMIT_PER_IP, settings.AUTH_TWO_FACTOR_RATE_LIMIT_TIME, w, r)
+}
+
+func (a *AuthLoginTwoFactorEnrollAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.AuthLoginTwoFactorSerializer{}
+	if err = a.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(a.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	var result *serializers.AuthTwoFactorEnrollSerializer
+	if result, err = serializer.Enroll(a.context); err != nil {
+		writeTwoFactorError(err, w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Result(result).Write(w, r)
+}
+
+/*
+authTwoFactorAPIView loads authenticated user
+*/
+type authTwoFactorAPIView struct {
+	views.APIView
+	mixins.AuditMixin
+	mixins.AuthUserMixin
+	mixins.RateLimitMixin
+
+	context *context.Context
+	user    *models.User
+}
+
+func (a *authTwoFactorAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+
+	a.user = models.NewUser()
+	return a.GetAuthUser(a.user, w, r)
+}
+
+// binds and validates code serializer, writes response on failure
+func (a *authTwoFactorAPIView) bindCode(serializer *serializers.AuthTwoFactorCodeSerializer, w http.ResponseWriter, r *http.Request) (err error) {
+	if err = a.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(a.context, a.user); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return views.ErrInvalidParam
+	}
+
+	return checkTwoFactorRateLimit(&a.RateLimitMixin, a.user, w, r)
+}
+
+/*
+Two-factor authentication of authenticated user
+
+	/api/auth/me/twofactor
+*/
+type AuthTwoFactorAPIView struct {
+	authTwoFactorAPIView
+}
+
+/*
+Retrieve status of two-factor authentication
+*/
+func (a *AuthTwoFactorAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	result := &serializers.AuthTwoFactorSerializer{}
+	if err := result.From(a.context, a.user); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+	response.New(http.StatusOK).Result(result).Write(w, r)
+}
+
+/*
+Start enrollment, returns secret that must be confirmed with code
+*/
+func (a *AuthTwoFactorAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	result, err := serializers.NewTwoFactorEnrollment(a.context, a.user)
+	if err != nil {
+		writeTwoFactorError(err, w, r)
+		return
+	}
+	response.New(http.StatusOK).Result(result).Write(w, r)
+}
+
+/*
+Disable two-factor authentication (valid code is needed), cannot be disabled
+when it is required by team
+*/
+func (a *AuthTwoFactorAPIView) DELETE(w http.ResponseWriter, r *http.Request) {
+	var (
+		required bool
+		err      error
+	)
+
+	if required, err = models.NewTwoFactorManager(a.context).IsRequired(a.user); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+	if required {
+		response.New(http.StatusForbidden).Error(models.ErrTwoFactorRequired).Write(w, r)
+		return
+	}
+
+	serializer := &serializers.AuthTwoFactorCodeSerializer{}
+	if err = a.bindCode(serializer, w, r); err != nil {
+		return
+	}
+
+	if err = serializer.Disable(a.context); err != nil {
+		writeTwoFactorError(err, w, r)
+		return
+	}
+	a.Audit(r, a.user, models.AUDIT_ACTION_DELETE, serializer.TwoFactor(), nil)
+	response.New(http.StatusOK).Write(w, r)
+}
+
+/*
+Confirm enrollment
+
+	/api/auth/me/twofactor/confirm
+*/
+type AuthTwoFactorConfirmAPIView struct {
+	authTwoFactorAPIView
+}
+
+/*
+Confirms enrollment with code from authenticator app, returns recovery codes
+*/
+func (a *AuthTwoFactorConfirmAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.AuthTwoFactorCodeSerializer{}
+	if err = a.bindCode(serializer, w, r); err != nil {
+		return
+	}
+
+	var result *serializers.AuthTwoFactorRecoveryCodesSerializer
+	if result, err = serializer.Confirm(a.context); err != nil {
+		writeTwoFactorError(err, w, r)
+		return
+	}
+	response.New(http.StatusOK).Result(result).Write(w, r)
+}
+
+/*
+Regenerate recovery codes
+
+	/api/auth/me/twofactor/recovery
+*/
+type AuthTwoFactorRecoveryAPIView struct {
+	authTwoFactorAPIView
+}
+
+/*
+Replaces recovery codes with new ones (valid code is needed)
+*/
+func (a *AuthTwoFactorRecoveryAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.AuthTwoFactorCodeSerializer{}
+	if err = a.bindCode(serializer, w, r); err != nil {
+		return
+	}
+
+	var result *serializers.AuthTwoFactorRecoveryCodesSerializer
+	if result, err = serializer.RecoveryCodes(a.context); err != nil {
+		writeTwoFactorError(err, w, r)
+		return
+	}
+	response.New(http.StatusOK).Result(result).Write(w, r)
+}
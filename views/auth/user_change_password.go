@@ -16,6 +16,7 @@ import (
 
 type UserChangePasswordAPIView struct {
 	views.APIView
+	mixins.AuditMixin
 	mixins.AuthUserMixin
 
 	context  *context.Context
@@ -75,10 +76,12 @@ func (u *UserChangePasswordAPIView) POST(w http.ResponseWriter, r *http.Request)
 
 	var result *serializers.AuthUserDetailSerializer
 
+	before := *u.user
 	if result, err = serializer.Save(u.context, u.user); err != nil {
 		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
 		return
 	}
+	u.Audit(r, u.authuser, models.AUDIT_ACTION_UPDATE, &before, u.user)
 
 	response.New(http.StatusOK).Result(result).Write(w, r)
 	return
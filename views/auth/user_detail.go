@@ -20,6 +20,7 @@ import (
 type UserDetailAPIView struct {
 	views.APIView
 
+	mixins.AuditMixin
 	mixins.AuthUserMixin
 
 	// store context
@@ -101,10 +102,12 @@ func (u *UserDetailAPIView) POST(w http.ResponseWriter, r *http.Request) {
 
 	var result *serializers.AuthUserDetailSerializer
 
+	before := *u.user
 	if result, err = serializer.Save(u.context, u.user, u.authuser); err != nil {
 		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
 		return
 	}
+	u.Audit(r, u.authuser, models.AUDIT_ACTION_UPDATE, &before, u.user)
 
 	response.New(http.StatusOK).Result(result).Write(w, r)
 }
@@ -3,20 +3,23 @@ package auth
 import (
 	"net/http"
 
+	"github.com/golang/glog"
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/notifications"
 	"github.com/phonkee/patrol/rest/metadata"
 	"github.com/phonkee/patrol/rest/response"
 	"github.com/phonkee/patrol/rest/views"
 	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
 	"github.com/phonkee/patrol/views/mixins"
 )
 
 /*
 Constructor that returns New UserListAPIView
 */
-func NewUserListAPIView() *UserListAPIView {
-	return &UserListAPIView{}
+func NewUserListAPIView(mailer *notifications.Mailer) *UserListAPIView {
+	return &UserListAPIView{Mailer: mailer}
 }
 
 /*
@@ -27,8 +30,12 @@ User list view
 type UserListAPIView struct {
 	views.APIView
 
+	mixins.AuditMixin
 	mixins.AuthUserMixin
 
+	// mailer that sends verification e-mails
+	Mailer *notifications.Mailer
+
 	// store context
 	context *context.Context
 
@@ -108,6 +115,22 @@ func (u *UserListAPIView) POST(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// user is created, failures below are only logged
+	user := models.NewUser()
+	if err = models.NewUserManager(u.context).GetByID(user, result.ID); err != nil {
+		glog.Errorf("auth: cannot load created user %s: %s", result.Username, err)
+		response.New(http.StatusCreated).Result(result).Write(w, r)
+		return
+	}
+	u.Audit(r, u.user, models.AUDIT_ACTION_CREATE, nil, user)
+
+	// send verification e-mail to new user
+	if settings.SETTINGS_AUTH_VERIFY_EMAIL {
+		if err = SendEmailVerification(u.context, u.Mailer, user); err != nil {
+			glog.Errorf("auth: cannot send verification e-mail to %s: %s", result.Email, err)
+		}
+	}
+
 	// clear password - no need to send it through wire
 	response.New(http.StatusCreated).Result(result).Write(w, r)
 }
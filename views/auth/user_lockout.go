@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/serializers"
+)
+
+/*
+Lockout of user after too many failed logins
+
+	/api/auth/user/{user_id:[0-9]+}/lockout
+*/
+type UserLockoutAPIView struct {
+	superuserUserAPIView
+}
+
+/*
+Retrieve whether user is locked and number of failed logins
+*/
+func (u *UserLockoutAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	result := &serializers.AuthUserLockoutSerializer{}
+	result.From(u.context, u.user)
+	response.New(http.StatusOK).Result(result).Write(w, r)
+}
+
+/*
+Unlock user, failed logins are forgotten
+*/
+func (u *UserLockoutAPIView) DELETE(w http.ResponseWriter, r *http.Request) {
+	if err := models.NewLoginThrottle(u.context).Unlock(u.user.Username); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+	u.Audit(r, u.authuser, models.AUDIT_ACTION_UNLOCK, u.user, u.user)
+	response.New(http.StatusOK).Write(w, r)
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+/*
+superuserUserAPIView loads user from user_id, only superuser can manage
+permissions and lockout of users
+*/
+type superuserUserAPIView struct {
+	views.APIView
+	mixins.AuditMixin
+	mixins.AuthUserMixin
+
+	context  *context.Context
+	authuser *models.User
+	user     *models.User
+}
+
+func (u *superuserUserAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	u.context = u.GetContext(r)
+
+	u.authuser = models.NewUser()
+	if err = u.GetAuthUser(u.authuser, w, r); err != nil {
+		return
+	}
+
+	if !u.authuser.IsSuperuser {
+		response.New(http.StatusForbidden).Write(w, r)
+		return views.ErrForbidden
+	}
+
+	var id types.PrimaryKey
+	if id, err = rest.GetMuxVarPrimaryKey(r, "user_id"); err != nil {
+		response.New(http.StatusBadRequest).Error(views.ErrInvalidParam).Write(w, r)
+		return
+	}
+
+	u.user = models.NewUser()
+	if err = models.NewUserManager(u.context).GetByID(u.user, id); err != nil {
+		if err == models.ErrObjectDoesNotExists {
+			response.New(http.StatusNotFound).Write(w, r)
+		} else {
+			response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		}
+		return
+	}
+
+	return
+}
+
+/*
+Permissions of user
+
+	/api/auth/user/{user_id:[0-9]+}/permission/
+*/
+type UserPermissionListAPIView struct {
+	superuserUserAPIView
+}
+
+/*
+Retrieve list of permission codenames of user
+*/
+func (u *UserPermissionListAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	response.New(http.StatusOK).Result(u.user.Permissions).Write(w, r)
+}
+
+/*
+Grant permission to user
+*/
+func (u *UserPermissionListAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.AuthUserPermissionSerializer{}
+	if err = u.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(u.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	before := *u.user
+	if err = serializer.Save(u.context, u.user); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+	u.Audit(r, u.authuser, models.AUDIT_ACTION_GRANT, &before, u.user)
+
+	response.New(http.StatusOK).Result(u.user.Permissions).Write(w, r)
+}
+
+/*
+Permission of user
+
+	/api/auth/user/{user_id:[0-9]+}/permission/{codename}
+*/
+type UserPermissionDetailAPIView struct {
+	superuserUserAPIView
+}
+
+/*
+Revoke permission of user
+*/
+func (u *UserPermissionDetailAPIView) DELETE(w http.ResponseWriter, r *http.Request) {
+	var (
+		codename string
+		err      error
+	)
+
+	if codename, err = rest.GetMuxVarString(r, "codename"); err != nil {
+		response.New(http.StatusBadRequest).Error(views.ErrInvalidParam).Write(w, r)
+		return
+	}
+
+	if !u.user.Permissions.Has(codename) {
+		response.New(http.StatusNotFound).Write(w, r)
+		return
+	}
+
+	before := *u.user
+	if err = models.NewUserManager(u.context).RevokePermission(u.user, codename); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+	u.Audit(r, u.authuser, models.AUDIT_ACTION_REVOKE, &before, u.user)
+
+	response.New(http.StatusOK).Result(u.user.Permissions).Write(w, r)
+}
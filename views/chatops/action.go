@@ -0,0 +1,128 @@
+package chatops
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/phonkee/patrol/chatops"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/types"
+)
+
+func NewActionAPIView(resolvedSignal func(*models.EventGroup, *models.User)) views.Viewer {
+	return &ActionAPIView{
+		ResolvedSignal: resolvedSignal,
+		eventgroup:     models.NewEventGroup(),
+	}
+}
+
+/*
+Performs action from signed link posted to chat. Links are not authenticated
+by auth token, signature and expiration of link is verified instead.
+
+GET shows confirmation page, POST performs the action.
+
+	/api/chatops/action/{action:resolve|mute}/{eventgroup_id:[0-9]+}?expires=..&signature=..
+*/
+type ActionAPIView struct {
+	views.APIView
+	context *context.Context
+
+	// store callback for signal
+	ResolvedSignal func(eventgroup *models.EventGroup, user *models.User)
+
+	action     string
+	eventgroup *models.EventGroup
+}
+
+/*
+Before verifies signature of link and retrieves eventgroup
+*/
+func (a *ActionAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+
+	if a.action, err = rest.GetMuxVarString(r, "action"); err != nil {
+		response.New(http.StatusBadRequest).Error(views.ErrInvalidParam).Write(w, r)
+		return views.ErrInvalidParam
+	}
+
+	var pk types.PrimaryKey
+	if pk, err = rest.GetMuxVarPrimaryKey(r, "eventgroup_id"); err != nil {
+		response.New(http.StatusBadRequest).Error(views.ErrInvalidParam).Write(w, r)
+		return views.ErrInvalidParam
+	}
+
+	query := r.URL.Query()
+	var expires int64
+	if expires, err = strconv.ParseInt(query.Get("expires"), 10, 64); err != nil {
+		response.New(http.StatusForbidden).Error(chatops.ErrActionLinkInvalid).Write(w, r)
+		return chatops.ErrActionLinkInvalid
+	}
+
+	secret := a.context.Get(context.SECRET_KEY).(string)
+	if err = chatops.VerifyAction(secret, a.action, pk.Int64(), expires, query.Get("signature"), time.Now()); err != nil {
+		response.New(http.StatusForbidden).Error(err).Write(w, r)
+		return
+	}
+
+	if err = models.NewEventGroupManager(a.context).GetByID(a.eventgroup, pk); err != nil {
+		if err == models.ErrObjectDoesNotExists {
+			response.New(http.StatusNotFound).Write(w, r)
+		} else {
+			response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		}
+		return
+	}
+
+	return
+}
+
+/*
+Shows confirmation page, links are prefetched by chat clients so GET must not
+change anything.
+*/
+func (a *ActionAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	body, err := chatops.RenderConfirmation(a.action, a.eventgroup, r.URL.RequestURI())
+	if err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+/*
+Resolves or mutes eventgroup. Action is idempotent, so repeated submission
+of the same link returns eventgroup unchanged.
+*/
+func (a *ActionAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	manager := models.NewEventGroupManager(a.context)
+	switch a.action {
+	case chatops.ACTION_RESOLVE:
+		if err = manager.Resolve(a.eventgroup, nil); err == nil && a.ResolvedSignal != nil {
+			a.ResolvedSignal(a.eventgroup, nil)
+		} else if err == models.ErrEventGroupAlreadyResolved {
+			err = nil
+		}
+	case chatops.ACTION_MUTE:
+		if err = manager.Mute(a.eventgroup, nil); err == models.ErrEventGroupAlreadyMuted {
+			err = nil
+		}
+	}
+
+	if err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Result(a.eventgroup).Write(w, r)
+}
@@ -0,0 +1,120 @@
+package chatops
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewChatWebhookDetailAPIView() views.Viewer {
+	return &ChatWebhookDetailAPIView{
+		project: models.NewProject(),
+		user:    models.NewUser(),
+		webhook: models.NewChatWebhook(),
+	}
+}
+
+/*
+Chat webhook detail
+
+	/api/projects/project/{project_id:[0-9]+}/chatwebhook/{chatwebhook_id:[0-9]+}
+*/
+type ChatWebhookDetailAPIView struct {
+	views.APIView
+
+	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+	mixins.ChatWebhookMixin
+
+	context *context.Context
+
+	membertype models.MemberType
+	project    *models.Project
+	user       *models.User
+	webhook    *models.ChatWebhook
+}
+
+/*
+Before loads project, checks membership and loads chat webhook of project
+*/
+func (a *ChatWebhookDetailAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+
+	if err = a.GetAuthUser(a.user, w, r); err != nil {
+		return
+	}
+
+	if err = a.GetProject(a.project, w, r); err != nil {
+		return
+	}
+
+	if a.membertype, err = a.GetMemberType(a.project, a.user, w, r); err != nil {
+		return
+	}
+
+	if err = a.GetChatWebhook(a.webhook, a.project, w, r); err != nil {
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve chat webhook
+*/
+func (a *ChatWebhookDetailAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	response.New(http.StatusOK).Result(a.webhook).Write(w, r)
+}
+
+/*
+Update chat webhook, only project admins can update chat webhooks
+*/
+func (a *ChatWebhookDetailAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	if a.membertype != models.MEMBER_TYPE_ADMIN {
+		response.New(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	serializer := &serializers.ChatOpsChatWebhookSerializer{}
+	if err = a.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(a.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	if err = serializer.Save(a.context, a.webhook); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Result(a.webhook).Write(w, r)
+}
+
+/*
+Delete chat webhook, only project admins can delete chat webhooks
+*/
+func (a *ChatWebhookDetailAPIView) DELETE(w http.ResponseWriter, r *http.Request) {
+	if a.membertype != models.MEMBER_TYPE_ADMIN {
+		response.New(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	if err := a.webhook.Delete(a.context); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Write(w, r)
+}
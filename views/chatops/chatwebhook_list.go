@@ -0,0 +1,108 @@
+package chatops
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewChatWebhookListAPIView() views.Viewer {
+	return &ChatWebhookListAPIView{
+		project: models.NewProject(),
+		user:    models.NewUser(),
+	}
+}
+
+/*
+Chat webhooks of project
+
+	/api/projects/project/{project_id:[0-9]+}/chatwebhook/
+*/
+type ChatWebhookListAPIView struct {
+	views.APIView
+
+	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+
+	context *context.Context
+
+	membertype models.MemberType
+	project    *models.Project
+	user       *models.User
+}
+
+/*
+Before loads project and checks membership
+*/
+func (a *ChatWebhookListAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+
+	if err = a.GetAuthUser(a.user, w, r); err != nil {
+		return
+	}
+
+	if err = a.GetProject(a.project, w, r); err != nil {
+		return
+	}
+
+	if a.membertype, err = a.GetMemberType(a.project, a.user, w, r); err != nil {
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve list of chat webhooks
+*/
+func (a *ChatWebhookListAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	manager := models.NewChatWebhookManager(a.context)
+	paginator := manager.NewPaginatorFromRequest(r)
+	result := manager.NewChatWebhookList()
+
+	if err := manager.FilterPaged(&result, paginator, manager.QueryFilterProject(a.project), manager.QueryFilterOrderID()); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Paginator(paginator).Result(result).Write(w, r)
+}
+
+/*
+Create new chat webhook, only project admins can create chat webhooks
+*/
+func (a *ChatWebhookListAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	if a.membertype != models.MEMBER_TYPE_ADMIN {
+		response.New(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	serializer := &serializers.ChatOpsChatWebhookSerializer{}
+	if err = a.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(a.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	webhook := models.NewChatWebhook(func(wh *models.ChatWebhook) {
+		wh.ProjectID = a.project.ID.ToForeignKey()
+	})
+	if err = serializer.Save(a.context, webhook); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusCreated).Result(webhook).Write(w, r)
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewEnvironmentListAPIView() views.Viewer {
+	return &EnvironmentListAPIView{
+		project: models.NewProject(),
+	}
+}
+
+/*
+EnvironmentListAPIView
+
+	list of project environments
+*/
+type EnvironmentListAPIView struct {
+	views.APIView
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+
+	context *context.Context
+
+	project *models.Project
+}
+
+func (p *EnvironmentListAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	p.context = p.GetContext(r)
+
+	if err = p.GetProject(p.project, w, r); err != nil {
+		return
+	}
+
+	// check membership in project
+	if _, err = p.MemberType(p.context, r); err != nil {
+		response.New().Status(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve list of environments
+*/
+func (p *EnvironmentListAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	manager := models.NewEnvironmentManager(p.context)
+	result := manager.NewEnvironmentList()
+
+	if err := manager.Filter(&result, manager.QueryFilterProject(p.project), manager.QueryFilterOrderName()); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Result(result).Write(w, r)
+}
@@ -25,6 +25,8 @@ EventListView
 type EventListView struct {
 	views.APIView
 	mixins.EventGroupMixin
+	mixins.EventTagFilterMixin
+	mixins.EnvironmentFilterMixin
 	mixins.ProjectMemberTypeMixin
 	mixins.ProjectsProjectMixin
 
@@ -69,7 +71,11 @@ func (p *EventListView) GET(w http.ResponseWriter, r *http.Request) {
 	paginator := manager.NewPaginatorFromRequest(r)
 	result := models.NewEventList()
 
-	if err := manager.FilterPaged(&result, paginator, utils.QueryFilterWhere("eventgroup_id = ?", p.eventgroup.ID)); err != nil {
+	qfs := []utils.QueryFunc{utils.QueryFilterWhere("eventgroup_id = ?", p.eventgroup.ID)}
+	qfs = append(qfs, p.GetTagQueryFilters(r, p.eventgroup.ProjectID, models.QueryFilterEventTag)...)
+	qfs = append(qfs, p.GetEnvironmentQueryFilters(r, models.QueryFilterEventEnvironment)...)
+
+	if err := manager.FilterPaged(&result, paginator, qfs...); err != nil {
 		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
 		return
 	}
@@ -95,21 +95,72 @@ func (s *EventStoreAPIView) POST(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// add project id to events
+	// add project id to events and make sure every event has event_id
 	for _, event := range events {
 		event.ProjectID = types.ForeignKey(s.project.ID)
+		event.EnsureEventID()
 	}
 
-	// Here we should send all events to queue
+	// result is returned even if all events are dropped by filters
 	result := map[string]string{
 		"event_id": events[0].EventID,
 	}
 
+	// drop events matching project inbound filters
+	pif := models.NewProjectInboundFilter()
+	if err = models.NewProjectInboundFilterManager(s.context).GetByProject(pif, s.project); err != nil {
+		glog.Errorf("cannot load inbound filters for project %v: %v", s.project.ID, err)
+	} else {
+		inboundfilter := pif.InboundFilter()
+		filterstatmanager := models.NewProjectFilterStatManager(s.context)
+		accepted := make([]*parser.RawEvent, 0, len(events))
+		for _, event := range events {
+			reason := inboundfilter.Check(event)
+			if reason == "" {
+				accepted = append(accepted, event)
+				continue
+			}
+			if err = filterstatmanager.Increment(s.project, reason); err != nil {
+				glog.Errorf("cannot increment filter stat %s: %v", reason, err)
+			}
+		}
+		events = accepted
+	}
+
+	// scrub sensitive data before events leave request, if project settings
+	// cannot be loaded we fallback to defaults rather than store data unscrubbed
+	scrubbing := models.NewProjectScrubbing()
+	if err = models.NewProjectScrubbingManager(s.context).GetByProject(scrubbing, s.project); err != nil {
+		glog.Errorf("cannot load scrubbing settings for project %v: %v", s.project.ID, err)
+		scrubbing = models.NewProjectScrubbing()
+	}
+	if scrubber := scrubbing.Scrubber(); scrubber != nil {
+		for _, event := range events {
+			scrubber.Scrub(event)
+		}
+	}
+
 	raweventmanager := parser.NewRawEventManager(s.context)
+	eventmanager := models.NewEventManager(s.context)
 
-	// push message to queue
+	// push message to queue, repeated submissions (client retries) are dropped
+	// here, event worker guarantees uniqueness if cache fails us.
 	for _, event := range events {
-		raweventmanager.PushRawEvent(event)
+		duplicate, errdup := eventmanager.IsDuplicateEventID(s.project.ID, event.EventID)
+		if errdup != nil {
+			glog.Errorf("cannot check duplicate event_id %s: %v", event.EventID, errdup)
+		} else if duplicate {
+			continue
+		}
+		if err = raweventmanager.PushRawEvent(event); err != nil {
+			// client retries event, so it must not be seen as duplicate
+			if errforget := eventmanager.ForgetEventID(s.project.ID, event.EventID); errforget != nil {
+				glog.Errorf("cannot forget event_id %s: %v", event.EventID, errforget)
+			}
+			glog.Errorf("cannot push event %s to queue: %v", event.EventID, err)
+			response.Status(http.StatusServiceUnavailable).Write(w, r)
+			return
+		}
 	}
 
 	response.Status(http.StatusOK).Raw(result).Write(w, r)
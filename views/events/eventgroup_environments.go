@@ -0,0 +1,75 @@
+package events
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewEventGroupEnvironmentsAPIView() views.Viewer {
+	return &EventGroupEnvironmentsAPIView{
+		eventgroup: models.NewEventGroup(),
+		project:    models.NewProject(),
+	}
+}
+
+/*
+EventGroupEnvironmentsAPIView
+
+	first seen, last seen and times seen of eventgroup per environment
+*/
+type EventGroupEnvironmentsAPIView struct {
+	views.APIView
+	mixins.EventGroupMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+
+	context *context.Context
+
+	eventgroup *models.EventGroup
+	project    *models.Project
+}
+
+func (p *EventGroupEnvironmentsAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	p.context = p.GetContext(r)
+
+	if err = p.GetProject(p.project, w, r); err != nil {
+		return
+	}
+
+	if err = p.GetEventGroup(p.eventgroup, w, r); err != nil {
+		return
+	}
+
+	// check
+	if p.eventgroup.ProjectID.ToPrimaryKey() != p.project.ID {
+		response.New(http.StatusNotFound).Write(w, r)
+		return views.ErrNotFound
+	}
+
+	// check membership in project
+	if _, err = p.MemberType(p.context, r); err != nil {
+		response.New().Status(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve eventgroup counters per environment
+*/
+func (p *EventGroupEnvironmentsAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	result := []*models.EventGroupEnvironmentStat{}
+
+	if err := models.NewEventGroupEnvironmentManager(p.context).Stats(&result, p.eventgroup); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Result(result).Write(w, r)
+}
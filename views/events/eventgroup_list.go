@@ -9,6 +9,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/utils"
 	"github.com/phonkee/patrol/views/mixins"
 )
 
@@ -18,6 +19,12 @@ type EventGroupListAPIView struct {
 	// returns member type
 	mixins.ProjectMemberTypeMixin
 
+	// filtering by tags
+	mixins.EventTagFilterMixin
+
+	// filtering by environments
+	mixins.EnvironmentFilterMixin
+
 	// context
 	context *context.Context
 }
@@ -56,7 +63,11 @@ func (p *EventGroupListAPIView) GET(w http.ResponseWriter, r *http.Request) {
 
 	// filter event groups for given project
 	// @TODO: add query param filtering
-	if err = egm.Filter(&egl, egm.QueryFilterWhere("project_id = ?", vars["project_id"])); err != nil {
+	qfs := []utils.QueryFunc{egm.QueryFilterWhere("project_id = ?", vars["project_id"])}
+	qfs = append(qfs, p.GetTagQueryFilters(r, project.ID, models.QueryFilterEventGroupTag)...)
+	qfs = append(qfs, p.GetEnvironmentQueryFilters(r, models.QueryFilterEventGroupEnvironment)...)
+
+	if err = egm.Filter(&egl, qfs...); err != nil {
 		response.Status(http.StatusInternalServerError).Write(w, r)
 		return
 	}
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewEventGroupMuteAPIView() views.Viewer {
+	return &EventGroupMuteAPIView{
+		eventgroup: models.NewEventGroup(),
+		project:    models.NewProject(),
+	}
+}
+
+/*
+Mark eventgroup as muted, muted eventgroup does not trigger alerts
+
+	/api/projects/project/{project_id:[0-9]+}/eventgroup/{eventgroup_id:[0-9]+}/mute
+*/
+type EventGroupMuteAPIView struct {
+	views.APIView
+	context *context.Context
+	user    *models.User
+
+	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+	mixins.EventGroupMixin
+
+	eventgroup *models.EventGroup
+	project    *models.Project
+}
+
+/*
+Before method retrieves eventgroup, project from datastore.
+*/
+func (p *EventGroupMuteAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	p.context = p.GetContext(r)
+
+	p.user = models.NewUser()
+	if err = p.GetAuthUser(p.user, w, r); err != nil {
+		return
+	}
+
+	if err = p.GetProject(p.project, w, r); err != nil {
+		return
+	}
+
+	if err = p.GetEventGroup(p.eventgroup, w, r); err != nil {
+		return
+	}
+
+	if p.eventgroup.ProjectID.ToPrimaryKey() != p.project.ID {
+		response.New(http.StatusNotFound).Write(w, r)
+		return views.ErrNotFound
+	}
+
+	// check membership in project
+	if _, err = p.MemberType(p.context, r); err != nil {
+		response.New().Status(http.StatusUnauthorized).Write(w, r)
+		return
+	}
+
+	return
+}
+
+/*
+Marks eventgroup as muted
+*/
+func (p *EventGroupMuteAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	if err := models.NewEventGroupManager(p.context).Mute(p.eventgroup, p.user); err != nil {
+		response.New(http.StatusNotAcceptable).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Write(w, r)
+}
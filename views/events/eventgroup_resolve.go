@@ -1,15 +1,25 @@
 package events
 
 import (
+	"io"
 	"net/http"
 
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/models"
 	"github.com/phonkee/patrol/rest/response"
 	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
 	"github.com/phonkee/patrol/views/mixins"
 )
 
+func NewEventGroupResolveAPIView(resolvedSignal func(*models.EventGroup, *models.User)) views.Viewer {
+	return &EventGroupResolveAPIView{
+		ResolvedSignal: resolvedSignal,
+		eventgroup:     models.NewEventGroup(),
+		project:        models.NewProject(),
+	}
+}
+
 /*
 Mark eventgroup as resolved
 send notification for frontend
@@ -19,6 +29,9 @@ type EventGroupResolveAPIView struct {
 	context *context.Context
 	user    *models.User
 
+	// store callback for signal
+	ResolvedSignal func(eventgroup *models.EventGroup, user *models.User)
+
 	// returns member type
 	mixins.AuthUserMixin
 	mixins.ProjectMemberTypeMixin
@@ -65,14 +78,37 @@ func (p *EventGroupResolveAPIView) Before(w http.ResponseWriter, r *http.Request
 
 /*
 	Marks eventgroup as resolved
+	with "in_next_release" eventgroup is resolved in latest release and
+	reopened when it occurs in newer release.
 */
 func (p *EventGroupResolveAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	serializer := serializers.EventsEventGroupResolveSerializer{}
+	if err := p.context.Bind(&serializer); err != nil && err != io.EOF && err != context.ErrNilBody {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
 	egm := models.NewEventGroupManager(p.context)
-	if err := egm.Resolve(p.eventgroup, p.user); err != nil {
+
+	var err error
+	if serializer.InNextRelease {
+		err = egm.ResolveInNextRelease(p.eventgroup, p.project, p.user)
+	} else {
+		err = egm.Resolve(p.eventgroup, p.user)
+	}
+
+	if err == models.ErrNoRelease {
+		response.New(http.StatusBadRequest).Error(err).Write(w, r)
+		return
+	} else if err != nil {
 		response.New(http.StatusNotAcceptable).Write(w, r)
 		return
 	}
 
+	if p.ResolvedSignal != nil {
+		p.ResolvedSignal(p.eventgroup, p.user)
+	}
+
 	response.New(http.StatusOK).Write(w, r)
 	return
 }
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/utils"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewEventGroupTagsAPIView() views.Viewer {
+	return &EventGroupTagsAPIView{
+		eventgroup: models.NewEventGroup(),
+		project:    models.NewProject(),
+	}
+}
+
+/*
+EventGroupTagsAPIView
+
+	distribution of tag values for eventgroup
+*/
+type EventGroupTagsAPIView struct {
+	views.APIView
+	mixins.EnvironmentFilterMixin
+	mixins.EventGroupMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+
+	context *context.Context
+
+	eventgroup *models.EventGroup
+	project    *models.Project
+}
+
+func (p *EventGroupTagsAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	p.context = p.GetContext(r)
+
+	if err = p.GetProject(p.project, w, r); err != nil {
+		return
+	}
+
+	if err = p.GetEventGroup(p.eventgroup, w, r); err != nil {
+		return
+	}
+
+	// check
+	if p.eventgroup.ProjectID.ToPrimaryKey() != p.project.ID {
+		response.New(http.StatusNotFound).Write(w, r)
+		return views.ErrNotFound
+	}
+
+	// check membership in project
+	if _, err = p.MemberType(p.context, r); err != nil {
+		response.New().Status(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve tag values with counts of events (optionally filtered by environment)
+*/
+func (p *EventGroupTagsAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	manager := models.NewEventTagManager(p.context)
+	result := []*models.EventTagValueCount{}
+
+	qfs := []utils.QueryFunc{manager.QueryFilterEventGroup(p.eventgroup)}
+	qfs = append(qfs, p.GetEnvironmentQueryFilters(r, models.QueryFilterEventTagEnvironment)...)
+
+	if err := manager.Distribution(&result, qfs...); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Result(result).Write(w, r)
+	return
+}
@@ -0,0 +1,72 @@
+package events
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewReleaseDetailAPIView() views.Viewer {
+	return &ReleaseDetailAPIView{
+		project: models.NewProject(),
+		release: models.NewRelease(),
+	}
+}
+
+/*
+ReleaseDetailAPIView
+
+	detail of single release
+*/
+type ReleaseDetailAPIView struct {
+	views.APIView
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+	mixins.ReleaseMixin
+
+	context *context.Context
+
+	project *models.Project
+	release *models.Release
+}
+
+/*
+Before method fetches project and release, checks that release belongs to
+project and user is member of project.
+*/
+func (p *ReleaseDetailAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	p.context = p.GetContext(r)
+
+	if err = p.GetProject(p.project, w, r); err != nil {
+		return
+	}
+
+	if err = p.GetRelease(p.release, w, r); err != nil {
+		return
+	}
+
+	// check
+	if p.release.ProjectID.ToPrimaryKey() != p.project.ID {
+		response.New(http.StatusNotFound).Write(w, r)
+		return views.ErrNotFound
+	}
+
+	// check membership in project
+	if _, err = p.MemberType(p.context, r); err != nil {
+		response.New().Status(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve release
+*/
+func (p *ReleaseDetailAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	response.New(http.StatusOK).Result(p.release).Write(w, r)
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewReleaseListAPIView() views.Viewer {
+	return &ReleaseListAPIView{
+		project: models.NewProject(),
+	}
+}
+
+/*
+ReleaseListAPIView
+
+	list of project releases, latest first
+*/
+type ReleaseListAPIView struct {
+	views.APIView
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+
+	context *context.Context
+
+	project *models.Project
+}
+
+func (p *ReleaseListAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	p.context = p.GetContext(r)
+
+	if err = p.GetProject(p.project, w, r); err != nil {
+		return
+	}
+
+	// check membership in project
+	if _, err = p.MemberType(p.context, r); err != nil {
+		response.New().Status(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve list of releases
+*/
+func (p *ReleaseListAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	manager := models.NewReleaseManager(p.context)
+	paginator := manager.NewPaginatorFromRequest(r)
+	result := manager.NewReleaseList()
+
+	if err := manager.FilterPaged(&result, paginator, manager.QueryFilterProject(p.project), manager.QueryFilterOrderLatest()); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Paginator(paginator).Result(result).Write(w, r)
+}
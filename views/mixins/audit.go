@@ -0,0 +1,59 @@
+package mixins
+
+import (
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest"
+)
+
+/*
+AuditMixin records administrative actions to audit log
+*/
+type AuditMixin struct{}
+
+/*
+Records action performed by actor. Before and after are states of target
+before and after action (before is nil for created, after is nil for deleted
+target). Client ip and user agent are read from request.
+
+	Action is already performed, so failure is only logged
+*/
+func (a *AuditMixin) Audit(r *http.Request, actor *models.User, action string, before, after models.Modeler, funcs ...func(*models.AuditLog)) {
+	ctx, err := context.Get(r)
+	if err != nil {
+		glog.Errorf("audit: cannot get context: %s.", err)
+		return
+	}
+
+	auditlog := models.NewAuditLog(func(auditlog *models.AuditLog) {
+		auditlog.ActorID = actor.ID.ToForeignKey()
+		auditlog.Action = action
+		auditlog.IP = rest.ClientIP(r)
+		auditlog.UserAgent = r.UserAgent()
+	})
+
+	if err = auditlog.SetTarget(before, after); err != nil {
+		glog.Errorf("audit: cannot record %s by %s: %s.", action, actor, err)
+		return
+	}
+
+	for _, f := range funcs {
+		f(auditlog)
+	}
+
+	if err = auditlog.Insert(ctx); err != nil {
+		glog.Errorf("audit: cannot record %s by %s: %s.", action, actor, err)
+	}
+}
+
+/*
+Returns function that sets team of audit log
+*/
+func AuditTeam(team *models.Team) func(*models.AuditLog) {
+	return func(auditlog *models.AuditLog) {
+		auditlog.SetTeam(team.ID.ToForeignKey())
+	}
+}
@@ -5,7 +5,9 @@ import (
 
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest"
 	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/types"
 	"github.com/phonkee/patrol/views"
 )
 
@@ -34,3 +36,79 @@ func (a *AuthUserMixin) GetAuthUser(user *models.User, w http.ResponseWriter, r
 
 	return
 }
+
+/*
+PersonalTokenMixin loads personal token of user from storage
+*/
+type PersonalTokenMixin struct{}
+
+/*
+Loads personal token of given user from storage
+*/
+func (p *PersonalTokenMixin) GetPersonalToken(target *models.PersonalToken, user *models.User, w http.ResponseWriter, r *http.Request, muxvar ...string) (err error) {
+	var ctx *context.Context
+
+	if ctx, err = context.Get(r); err != nil {
+		response.New(http.StatusInternalServerError).Write(w, r)
+		return views.ErrInternalServerError
+	}
+
+	varname := "personaltoken_id"
+	if len(muxvar) > 0 {
+		varname = muxvar[0]
+	}
+
+	var pk types.PrimaryKey
+	if pk, err = rest.GetMuxVarPrimaryKey(r, varname); err != nil {
+		err = views.ErrInvalidParam
+		response.New(http.StatusBadRequest).Error(err).Write(w, r)
+		return
+	}
+
+	manager := models.NewPersonalTokenManager(ctx)
+	if err = manager.GetByID(target, pk, manager.QueryFilterUser(user)); err != nil {
+		switch err {
+		case models.ErrObjectDoesNotExists:
+			response.New(http.StatusNotFound).Write(w, r)
+		default:
+			response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		}
+		return
+	}
+
+	return
+}
+
+/*
+PermissionMixin checks permissions of user
+*/
+type PermissionMixin struct{}
+
+/*
+Checks whether user has permission (and is admin of given teams)
+
+	If user does not have permission, writes response and returns error
+*/
+func (p *PermissionMixin) CheckPermission(user *models.User, codename string, w http.ResponseWriter, r *http.Request, teams ...*models.Team) (err error) {
+	var (
+		ctx    *context.Context
+		result bool
+	)
+
+	if ctx, err = context.Get(r); err != nil {
+		response.New(http.StatusInternalServerError).Write(w, r)
+		return views.ErrInternalServerError
+	}
+
+	if result, err = models.NewUserManager(ctx).HasPermission(user, codename, teams...); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return views.ErrInternalServerError
+	}
+
+	if !result {
+		response.New(http.StatusForbidden).Write(w, r)
+		return views.ErrForbidden
+	}
+
+	return
+}
@@ -3,6 +3,7 @@ package mixins
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/models"
@@ -10,6 +11,7 @@ import (
 	"github.com/phonkee/patrol/rest/response"
 	"github.com/phonkee/patrol/rest/views"
 	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
 )
 
 var (
@@ -62,3 +64,95 @@ func (e *EventGroupMixin) GetEventGroup(target interface{}, w http.ResponseWrite
 
 	return
 }
+
+/*
+EventTagFilterMixin reads "tag" query params in form key:value and returns
+query filters. Multiple tag params are combined with AND.
+*/
+type EventTagFilterMixin struct{}
+
+/*
+Returns query filters for all tag query params of project, filterfunc is one
+of models.QueryFilterEventTag, models.QueryFilterEventGroupTag
+*/
+func (e *EventTagFilterMixin) GetTagQueryFilters(r *http.Request, projectID types.Keyer, filterfunc func(projectID types.Keyer, key, value string) utils.QueryFunc) (result []utils.QueryFunc) {
+	result = []utils.QueryFunc{}
+	for _, param := range r.URL.Query()["tag"] {
+		parts := strings.SplitN(param, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			continue
+		}
+		result = append(result, filterfunc(projectID, strings.TrimSpace(parts[0]), parts[1]))
+	}
+	return
+}
+
+/*
+EnvironmentFilterMixin reads "environment" query params and returns query
+filters. Multiple environment params are combined with OR.
+*/
+type EnvironmentFilterMixin struct{}
+
+/*
+Returns query filters for environment query params, filterfunc is one of
+models.QueryFilterEventEnvironment, models.QueryFilterEventGroupEnvironment,
+models.QueryFilterEventTagEnvironment
+*/
+func (e *EnvironmentFilterMixin) GetEnvironmentQueryFilters(r *http.Request, filterfunc func(names ...string) utils.QueryFunc) (result []utils.QueryFunc) {
+	result = []utils.QueryFunc{}
+
+	names := []string{}
+	for _, param := range r.URL.Query()["environment"] {
+		if models.CleanEnvironmentName(param) != "" {
+			names = append(names, param)
+		}
+	}
+
+	if len(names) > 0 {
+		result = append(result, filterfunc(names...))
+	}
+	return
+}
+
+/*
+ReleaseMixin loads release from storage
+*/
+type ReleaseMixin struct{}
+
+/*
+Loads release from storage
+*/
+func (e *ReleaseMixin) GetRelease(target interface{}, w http.ResponseWriter, r *http.Request, muxvar ...string) (err error) {
+	var ctx *context.Context
+	// get context
+	if ctx, err = context.Get(r); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return views.ErrInternalServerError
+	}
+
+	varname := "release_id"
+	if len(muxvar) > 0 {
+		varname = muxvar[0]
+	}
+
+	// read release id from mux vars
+	var pk types.PrimaryKey
+
+	if pk, err = rest.GetMuxVarPrimaryKey(r, varname); err != nil {
+		err = views.ErrInvalidParam
+		response.New(http.StatusBadRequest).Error(err).Write(w, r)
+		return
+	}
+
+	if err = models.NewReleaseManager(ctx).GetByID(target, pk); err != nil {
+		switch err {
+		case models.ErrObjectDoesNotExists:
+			response.New(http.StatusNotFound).Write(w, r)
+		default:
+			response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		}
+		return
+	}
+
+	return
+}
@@ -49,11 +49,18 @@ func (p *ProjectMemberTypeMixin) GetMemberType(project *models.Project, user *mo
 		response.New(http.StatusInternalServerError).Write(w, r)
 		return mt, views.ErrInternalServerError
 	}
-	tmm := models.NewTeamMemberManager(ctx)
-	if mt, err = tmm.MemberTypeByProject(project, user); err != nil {
+	if mt, err = p.ProjectMemberType(ctx, project, user); err != nil {
 		response.New(http.StatusForbidden).Write(w, r)
 		return mt, views.ErrForbidden
 	}
 
 	return
 }
+
+/*
+	Returns member type without writing response (e.g. for websockets),
+	error means that user is not member of project
+*/
+func (p *ProjectMemberTypeMixin) ProjectMemberType(context *context.Context, project *models.Project, user *models.User) (mt models.MemberType, err error) {
+	return models.NewTeamMemberManager(context).MemberTypeByProject(project, user)
+}
@@ -0,0 +1,46 @@
+package mixins
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/views"
+)
+
+/*
+RateLimitMixin limits number of requests
+*/
+type RateLimitMixin struct{}
+
+/*
+Checks whether action identified by key was not performed more than limit
+times in period.
+
+	If rate limit is exceeded, writes response and returns error
+*/
+func (m *RateLimitMixin) CheckRateLimit(key string, limit int, period time.Duration, w http.ResponseWriter, r *http.Request) (err error) {
+	var (
+		ctx     *context.Context
+		limited bool
+	)
+
+	if ctx, err = context.Get(r); err != nil {
+		response.New(http.StatusInternalServerError).Write(w, r)
+		return views.ErrInternalServerError
+	}
+
+	if limited, err = models.IsRateLimited(ctx, key, limit, period); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return views.ErrInternalServerError
+	}
+
+	if limited {
+		response.New(http.StatusTooManyRequests).Error(models.ErrRateLimited).Write(w, r)
+		return models.ErrRateLimited
+	}
+
+	return
+}
@@ -0,0 +1,56 @@
+package mixins
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/types"
+)
+
+/*
+WebhookMixin loads webhook of project from storage
+*/
+type WebhookMixin struct{}
+
+/*
+Loads webhook of given project from storage
+*/
+func (m *WebhookMixin) GetWebhook(target *models.Webhook, project *models.Project, w http.ResponseWriter, r *http.Request, muxvar ...string) (err error) {
+	var ctx *context.Context
+	// get context
+	if ctx, err = context.Get(r); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return views.ErrInternalServerError
+	}
+
+	varname := "webhook_id"
+	if len(muxvar) > 0 {
+		varname = muxvar[0]
+	}
+
+	// read webhook id from mux vars
+	var pk types.PrimaryKey
+
+	if pk, err = rest.GetMuxVarPrimaryKey(r, varname); err != nil {
+		err = views.ErrInvalidParam
+		response.New(http.StatusBadRequest).Error(err).Write(w, r)
+		return
+	}
+
+	manager := models.NewWebhookManager(ctx)
+	if err = manager.GetByID(target, pk, manager.QueryFilterProject(project)); err != nil {
+		switch err {
+		case models.ErrObjectDoesNotExists:
+			response.New(http.StatusNotFound).Write(w, r)
+		default:
+			response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		}
+		return
+	}
+
+	return
+}
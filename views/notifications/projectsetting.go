@@ -0,0 +1,99 @@
+package notifications
+
+import (
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewProjectSettingAPIView() views.Viewer {
+	return &ProjectSettingAPIView{
+		project: models.NewProject(),
+		user:    models.NewUser(),
+		setting: models.NewProjectNotificationSetting(),
+	}
+}
+
+/*
+Notification mode of authenticated user for project
+
+	/api/auth/me/notifications/project/{project_id:[0-9]+}
+*/
+type ProjectSettingAPIView struct {
+	views.APIView
+
+	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+
+	context *context.Context
+
+	project *models.Project
+	user    *models.User
+	setting *models.ProjectNotificationSetting
+}
+
+/*
+Before loads project, checks membership and loads notification mode
+*/
+func (p *ProjectSettingAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	p.context = p.GetContext(r)
+
+	if err = p.GetAuthUser(p.user, w, r); err != nil {
+		return
+	}
+
+	if err = p.GetProject(p.project, w, r); err != nil {
+		return
+	}
+
+	if _, err = p.GetMemberType(p.project, p.user, w, r); err != nil {
+		return
+	}
+
+	if err = models.NewProjectNotificationSettingManager(p.context).GetByUserProject(p.setting, p.user, p.project); err != nil {
+		glog.Error(err)
+		response.New(http.StatusInternalServerError).Write(w, r)
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve notification mode
+*/
+func (p *ProjectSettingAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	response.New(http.StatusOK).Result(p.setting).Write(w, r)
+}
+
+/*
+Update notification mode
+*/
+func (p *ProjectSettingAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.NotificationsProjectSettingSerializer{}
+	if err = p.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(p.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	if err = serializer.Save(p.context, p.setting); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Result(p.setting).Write(w, r)
+}
@@ -0,0 +1,99 @@
+package notifications
+
+import (
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewSettingAPIView() views.Viewer {
+	return &SettingAPIView{
+		user:    models.NewUser(),
+		setting: models.NewNotificationSetting(),
+	}
+}
+
+/*
+Notification settings of authenticated user
+
+	/api/auth/me/notifications
+*/
+type SettingAPIView struct {
+	views.APIView
+
+	mixins.AuthUserMixin
+
+	context *context.Context
+
+	user    *models.User
+	setting *models.NotificationSetting
+}
+
+/*
+Before loads authenticated user and notification settings of user
+*/
+func (s *SettingAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	s.context = s.GetContext(r)
+
+	if err = s.GetAuthUser(s.user, w, r); err != nil {
+		return
+	}
+
+	if err = models.NewNotificationSettingManager(s.context).GetByUser(s.setting, s.user); err != nil {
+		glog.Error(err)
+		response.New(http.StatusInternalServerError).Write(w, r)
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve notification settings together with notification modes of projects
+*/
+func (s *SettingAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	manager := models.NewProjectNotificationSettingManager(s.context)
+	projects := manager.NewProjectNotificationSettingList()
+	if err := manager.Filter(&projects, manager.QueryFilterUser(s.user)); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	result := struct {
+		*models.NotificationSetting
+		Projects []*models.ProjectNotificationSetting `json:"projects"`
+	}{s.setting, projects}
+
+	response.New(http.StatusOK).Result(result).Write(w, r)
+}
+
+/*
+Update notification settings
+*/
+func (s *SettingAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.NotificationsSettingSerializer{}
+	if err = s.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(s.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	if err = serializer.Save(s.context, s.setting); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Result(s.setting).Write(w, r)
+}
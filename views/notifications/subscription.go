@@ -0,0 +1,132 @@
+package notifications
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewSubscriptionAPIView() views.Viewer {
+	return &SubscriptionAPIView{
+		eventgroup: models.NewEventGroup(),
+		project:    models.NewProject(),
+		user:       models.NewUser(),
+	}
+}
+
+/*
+Subscription of authenticated user to eventgroup
+
+	/api/auth/me/notifications/eventgroup/{eventgroup_id:[0-9]+}
+*/
+type SubscriptionAPIView struct {
+	views.APIView
+
+	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.EventGroupMixin
+
+	context *context.Context
+
+	eventgroup *models.EventGroup
+	project    *models.Project
+	user       *models.User
+}
+
+/*
+Before loads eventgroup and checks membership in its project
+*/
+func (s *SubscriptionAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	s.context = s.GetContext(r)
+
+	if err = s.GetAuthUser(s.user, w, r); err != nil {
+		return
+	}
+
+	if err = s.GetEventGroup(s.eventgroup, w, r); err != nil {
+		return
+	}
+
+	if err = models.NewProjectManager(s.context).GetByID(s.project, s.eventgroup.ProjectID); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	if _, err = s.GetMemberType(s.project, s.user, w, r); err != nil {
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve subscription, returns 404 when user has no explicit subscription
+(notification mode of project is used)
+*/
+func (s *SubscriptionAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	subscription := models.NewEventGroupSubscription()
+	if err := models.NewEventGroupSubscriptionManager(s.context).GetByUserEventGroup(subscription, s.user, s.eventgroup); err != nil {
+		if err == models.ErrObjectDoesNotExists {
+			response.New(http.StatusNotFound).Write(w, r)
+		} else {
+			response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		}
+		return
+	}
+
+	response.New(http.StatusOK).Result(subscription).Write(w, r)
+}
+
+/*
+Subscribe or unsubscribe eventgroup
+*/
+func (s *SubscriptionAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.NotificationsSubscriptionSerializer{}
+	if err = s.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	manager := models.NewEventGroupSubscriptionManager(s.context)
+	if serializer.Subscribed {
+		err = manager.Subscribe(s.user, s.eventgroup, models.SUBSCRIPTION_REASON_MANUAL)
+	} else {
+		err = manager.Unsubscribe(s.user, s.eventgroup)
+	}
+	if err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	s.GET(w, r)
+}
+
+/*
+Remove explicit subscription, notification mode of project is used again
+*/
+func (s *SubscriptionAPIView) DELETE(w http.ResponseWriter, r *http.Request) {
+	manager := models.NewEventGroupSubscriptionManager(s.context)
+	subscription := manager.NewEventGroupSubscription()
+	if err := manager.GetByUserEventGroup(subscription, s.user, s.eventgroup); err != nil {
+		if err == models.ErrObjectDoesNotExists {
+			response.New(http.StatusNotFound).Write(w, r)
+		} else {
+			response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		}
+		return
+	}
+
+	if err := subscription.Delete(s.context); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Write(w, r)
+}
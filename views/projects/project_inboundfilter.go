@@ -0,0 +1,176 @@
+package projects
+
+import (
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/parser"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+/*
+Project inbound filter settings
+
+	/api/projects/project/{project_id:[0-9]+}/filters
+*/
+type ProjectInboundFilterAPIView struct {
+	views.APIView
+
+	mixins.AuditMixin
+	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+
+	context *context.Context
+
+	membertype models.MemberType
+	project    *models.Project
+	user       *models.User
+	filter     *models.ProjectInboundFilter
+}
+
+/*
+Before loads project, checks membership and loads filter settings
+*/
+func (p *ProjectInboundFilterAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	p.context = p.GetContext(r)
+
+	p.user = models.NewUser()
+	if err = p.GetAuthUser(p.user, w, r); err != nil {
+		return
+	}
+
+	p.project = models.NewProject()
+	if err = p.GetProject(p.project, w, r); err != nil {
+		return
+	}
+
+	if p.membertype, err = p.GetMemberType(p.project, p.user, w, r); err != nil {
+		return
+	}
+
+	p.filter = models.NewProjectInboundFilter()
+	if err = models.NewProjectInboundFilterManager(p.context).GetByProject(p.filter, p.project); err != nil {
+		glog.Error(err)
+		response.New(http.StatusInternalServerError).Write(w, r)
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve inbound filter settings
+*/
+func (p *ProjectInboundFilterAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	response.New(http.StatusOK).Result(p.filter).Write(w, r)
+}
+
+/*
+Update inbound filter settings, only project admins can change them
+*/
+func (p *ProjectInboundFilterAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	if p.membertype != models.MEMBER_TYPE_ADMIN {
+		response.New(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	serializer := &serializers.ProjectsProjectInboundFilterUpdateSerializer{}
+	if err = p.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(p.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	team := models.NewTeam()
+	if err = p.project.Team(team, p.context); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	before := *p.filter
+	if err = serializer.Save(p.context, p.filter); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+	p.Audit(r, p.user, models.AUDIT_ACTION_UPDATE, &before, p.filter, mixins.AuditTeam(team))
+
+	response.New(http.StatusOK).Result(p.filter).Write(w, r)
+}
+
+/*
+Counts of events dropped by inbound filters
+
+	/api/projects/project/{project_id:[0-9]+}/filters/stats
+*/
+type ProjectFilterStatsAPIView struct {
+	views.APIView
+
+	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+
+	context *context.Context
+
+	project *models.Project
+}
+
+/*
+Before loads project and checks membership
+*/
+func (p *ProjectFilterStatsAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	p.context = p.GetContext(r)
+
+	user := models.NewUser()
+	if err = p.GetAuthUser(user, w, r); err != nil {
+		return
+	}
+
+	p.project = models.NewProject()
+	if err = p.GetProject(p.project, w, r); err != nil {
+		return
+	}
+
+	if _, err = p.GetMemberType(p.project, user, w, r); err != nil {
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve dropped counts for all filter reasons (reasons without drops have zero count)
+*/
+func (p *ProjectFilterStatsAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	manager := models.NewProjectFilterStatManager(p.context)
+	stats := manager.NewProjectFilterStatList()
+
+	if err := manager.Filter(&stats, manager.QueryFilterProject(p.project)); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	result := map[string]*models.ProjectFilterStat{}
+	for _, reason := range parser.FILTER_REASONS {
+		result[reason] = manager.NewProjectFilterStat(func(pfs *models.ProjectFilterStat) {
+			pfs.ProjectID = p.project.ID.ToForeignKey()
+			pfs.Reason = reason
+		})
+	}
+	for _, stat := range stats {
+		result[stat.Reason] = stat
+	}
+
+	response.New(http.StatusOK).Result(result).Write(w, r)
+}
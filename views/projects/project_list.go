@@ -22,7 +22,9 @@ type ProjectListAPIView struct {
 	views.APIView
 
 	// mixins used
+	mixins.AuditMixin
 	mixins.AuthUserMixin
+	mixins.PermissionMixin
 
 	context *context.Context
 
@@ -91,23 +93,22 @@ func (p *ProjectListAPIView) POST(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// check permissions (IsSuperuser, member type)
-	tmm := models.NewTeamMemberManager(p.context)
-	var mt models.MemberType
-	// not superuser so we have to check member type for permissions
-	if !p.user.IsSuperuser {
-		if mt, err = tmm.MemberType(team, p.user); err != nil || mt != models.MEMBER_TYPE_ADMIN {
-			response.New(http.StatusForbidden).Write(w, r)
-			return
-		}
+	// check permissions (superuser, permission and admin of team)
+	if err = p.CheckPermission(p.user, settings.PERMISSION_PROJECTS_PROJECT_ADD, w, r, team); err != nil {
+		return
 	}
 
-	var project *models.Project
+	var (
+		project    *models.Project
+		projectkey *models.ProjectKey
+	)
 
-	if project, err = serializer.Save(p.context, team, p.user); err != nil {
+	if project, projectkey, err = serializer.Save(p.context, team, p.user); err != nil {
 		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
 		return
 	}
+	p.Audit(r, p.user, models.AUDIT_ACTION_CREATE, nil, project, mixins.AuditTeam(team))
+	p.Audit(r, p.user, models.AUDIT_ACTION_CREATE, nil, projectkey, mixins.AuditTeam(team))
 
 	// everything went ok
 	response.New(http.StatusCreated).Result(project).Write(w, r)
@@ -0,0 +1,109 @@
+package projects
+
+import (
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+/*
+Project data scrubbing settings
+
+	/api/projects/project/{project_id:[0-9]+}/scrubbing
+*/
+type ProjectScrubbingAPIView struct {
+	views.APIView
+
+	mixins.AuditMixin
+	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+
+	context *context.Context
+
+	membertype models.MemberType
+	project    *models.Project
+	user       *models.User
+	scrubbing  *models.ProjectScrubbing
+}
+
+/*
+Before loads project, checks membership and loads scrubbing settings
+*/
+func (p *ProjectScrubbingAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	p.context = p.GetContext(r)
+
+	p.user = models.NewUser()
+	if err = p.GetAuthUser(p.user, w, r); err != nil {
+		return
+	}
+
+	p.project = models.NewProject()
+	if err = p.GetProject(p.project, w, r); err != nil {
+		return
+	}
+
+	if p.membertype, err = p.GetMemberType(p.project, p.user, w, r); err != nil {
+		return
+	}
+
+	p.scrubbing = models.NewProjectScrubbing()
+	if err = models.NewProjectScrubbingManager(p.context).GetByProject(p.scrubbing, p.project); err != nil {
+		glog.Error(err)
+		response.New(http.StatusInternalServerError).Write(w, r)
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve scrubbing settings
+*/
+func (p *ProjectScrubbingAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	response.New(http.StatusOK).Result(p.scrubbing).Write(w, r)
+}
+
+/*
+Update scrubbing settings, only project admins can change them
+*/
+func (p *ProjectScrubbingAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	if p.membertype != models.MEMBER_TYPE_ADMIN {
+		response.New(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	serializer := &serializers.ProjectsProjectScrubbingUpdateSerializer{}
+	if err = p.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(p.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	team := models.NewTeam()
+	if err = p.project.Team(team, p.context); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	before := *p.scrubbing
+	if err = serializer.Save(p.context, p.scrubbing); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+	p.Audit(r, p.user, models.AUDIT_ACTION_UPDATE, &before, p.scrubbing, mixins.AuditTeam(team))
+
+	response.New(http.StatusOK).Result(p.scrubbing).Write(w, r)
+}
@@ -0,0 +1,184 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+/*
+EventGroupStreamAPIView
+
+	streams new and changed eventgroups of project as server-sent events
+	(text/event-stream) for clients which cannot use websockets. Every
+	event has id, so client can resume stream with Last-Event-ID header
+	from short replay buffer. EventSource cannot set Authorization header,
+	so token can be sent also in token query parameter. Token, user and
+	membership are checked again on every ping.
+
+		id: 12
+		event: eventgroup-changed
+		data: {"id": 1, ...}
+*/
+type EventGroupStreamAPIView struct {
+	views.APIView
+
+	// returns member type
+	mixins.ProjectMemberTypeMixin
+
+	// context
+	context *context.Context
+}
+
+// check if user is member of project (same as EventGroupListAPIView)
+func (e *EventGroupStreamAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	e.context = e.GetContext(r)
+	if _, err = e.MemberType(e.context, r); err != nil {
+		response.New().Status(http.StatusUnauthorized).Write(w, r)
+		return
+	}
+	return
+}
+
+/*
+Streams eventgroups of project until client disconnects or patrol quits
+*/
+func (e *EventGroupStreamAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		response.New(http.StatusInternalServerError).Write(w, r)
+		return
+	}
+
+	pm := models.NewProjectManager(e.context)
+	project := pm.NewProject()
+	if err = pm.GetFromRequest(project, r); err != nil {
+		response.New(http.StatusNotFound).Write(w, r)
+		return
+	}
+
+	channel := models.RealtimeProjectChannel(project.ID)
+	manager := models.NewRealtimeManager(e.context)
+
+	// subscribe before replay so no message is lost in between
+	quit := make(chan struct{})
+	defer close(quit)
+	messages, errs := manager.Subscribe(quit, channel)
+
+	var lastID int64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		if lastID, err = strconv.ParseInt(header, 10, 64); err != nil {
+			response.New(http.StatusBadRequest).Write(w, r)
+			return
+		}
+	}
+
+	var replay []*models.RealtimeEnvelope
+	if replay, err = manager.Replay(channel, lastID); err != nil {
+		response.New(http.StatusInternalServerError).Write(w, r)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", settings.REALTIME_STREAM_RETRY/time.Millisecond)
+
+	// writes envelope, already sent envelopes are skipped
+	write := func(envelope *models.RealtimeEnvelope) error {
+		if envelope.ID <= lastID || envelope.Type != models.MESSAGE_TYPE_EVENTGROUP_CHANGED {
+			return nil
+		}
+		lastID = envelope.ID
+		_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", envelope.ID, envelope.Type, eventGroupData(envelope))
+		return err
+	}
+
+	for _, envelope := range replay {
+		if err = write(envelope); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(settings.REALTIME_PING_PERIOD)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-e.context.Quit:
+			return
+		case <-ticker.C:
+			// revoked token, deactivated user or removed member loses stream
+			if err = e.revalidate(project, r); err != nil {
+				glog.V(2).Infof("realtime: closing event stream of %s: %s.", project, err)
+				return
+			}
+			// comment keeps connection open through proxies
+			if _, err = fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case err, ok := <-errs:
+			if ok {
+				glog.Errorf("realtime: event stream of %s failed: %s.", project, err)
+			}
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			envelope := &models.RealtimeEnvelope{}
+			if err = json.Unmarshal(message.Message, envelope); err != nil {
+				continue
+			}
+			if err = write(envelope); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+/*
+Re-validates stream: token must not be logged out or revoked, user must be
+active and still member of project
+*/
+func (e *EventGroupStreamAPIView) revalidate(project *models.Project, r *http.Request) (err error) {
+	um := models.NewUserManager(e.context)
+	user := um.NewUser()
+	if err = um.GetAuthUser(user, r); err != nil || !user.IsActive {
+		return ErrProtocolUnauthorized
+	}
+	if _, err = e.ProjectMemberType(e.context, project, user); err != nil {
+		return ErrProtocolForbidden
+	}
+	return
+}
+
+// returns eventgroup from envelope data as single line json
+func eventGroupData(envelope *models.RealtimeEnvelope) []byte {
+	message := &models.EventGroupChangedRealtimeMessage{}
+	if err := json.Unmarshal(envelope.Data, message); err != nil || message.EventGroup == nil {
+		return []byte("{}")
+	}
+	data, _ := json.Marshal(message.EventGroup)
+	return data
+}
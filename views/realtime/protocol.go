@@ -0,0 +1,77 @@
+package realtime
+
+import (
+	"errors"
+
+	"github.com/phonkee/patrol/types"
+)
+
+/*
+Websocket protocol
+
+	Client sends json requests, every request except of auth is answered
+	with ack (with error if request failed):
+
+		{"type": "auth", "token": "<jwt>"}
+		{"type": "subscribe", "id": "1", "project_id": 1}
+		{"type": "subscribe", "id": "2", "eventgroup_id": 10}
+		{"type": "unsubscribe", "id": "3", "project_id": 1}
+		{"type": "ping", "id": "4"}
+
+		{"type": "ack", "id": "2", "request": "subscribe", "channel": "realtime:eventgroup:10"}
+
+	Auth request must be first message when token was not sent in
+	Authorization header or token query parameter. Realtime messages are
+	sent as models.RealtimeEnvelope.
+*/
+const (
+	PROTOCOL_AUTH        = "auth"
+	PROTOCOL_SUBSCRIBE   = "subscribe"
+	PROTOCOL_UNSUBSCRIBE = "unsubscribe"
+	PROTOCOL_PING        = "ping"
+	PROTOCOL_ACK         = "ack"
+)
+
+var (
+	ErrProtocolInvalidRequest = errors.New("invalid request")
+	ErrProtocolUnauthorized   = errors.New("unauthorized")
+	ErrProtocolForbidden      = errors.New("forbidden")
+	ErrProtocolNotSubscribed  = errors.New("not subscribed")
+	ErrProtocolTooManySubs    = errors.New("too many subscriptions")
+)
+
+/*
+Request sent by client
+*/
+type Request struct {
+	Type         string           `json:"type"`
+	ID           string           `json:"id,omitempty"`
+	Token        string           `json:"token,omitempty"`
+	ProjectID    types.PrimaryKey `json:"project_id,omitempty"`
+	EventGroupID types.PrimaryKey `json:"eventgroup_id,omitempty"`
+}
+
+/*
+Ack is answer to client request
+*/
+type Ack struct {
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+	Request string `json:"request"`
+	Channel string `json:"channel,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// returns ack of request, ack with error when err is not nil
+func NewAck(request *Request, channel string, err error) (ack *Ack) {
+	ack = &Ack{
+		Type:    PROTOCOL_ACK,
+		ID:      request.ID,
+		Request: request.Type,
+		Channel: channel,
+	}
+	if err != nil {
+		ack.Error = err.Error()
+	}
+	return
+}
@@ -0,0 +1,93 @@
+package realtime
+
+import (
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/settings"
+)
+
+/*
+subscriptions of single websocket
+
+	every channel has its own pub/sub subscription so client can subscribe
+	and unsubscribe channels at any time. Messages of all channels are
+	sent to out, failed subscriptions to errs.
+*/
+type subscriptions struct {
+	manager  *models.RealtimeManager
+	channels map[string]chan struct{}
+	out      chan []byte
+	errs     chan error
+}
+
+func newSubscriptions(manager *models.RealtimeManager) *subscriptions {
+	return &subscriptions{
+		manager:  manager,
+		channels: map[string]chan struct{}{},
+		out:      make(chan []byte),
+		errs:     make(chan error, 1),
+	}
+}
+
+/*
+subscribes channel, already subscribed channel is ignored. Returns
+ErrProtocolTooManySubs when REALTIME_MAX_SUBSCRIPTIONS is reached.
+*/
+func (s *subscriptions) Add(channel string) (err error) {
+	if _, ok := s.channels[channel]; ok {
+		return
+	}
+	if len(s.channels) >= settings.REALTIME_MAX_SUBSCRIPTIONS {
+		return ErrProtocolTooManySubs
+	}
+
+	quit := make(chan struct{})
+	s.channels[channel] = quit
+
+	messages, errs := s.manager.Subscribe(quit, channel)
+	go func() {
+		for {
+			select {
+			case <-quit:
+				return
+			case err, ok := <-errs:
+				if !ok {
+					return
+				}
+				select {
+				case s.errs <- err:
+				default:
+				}
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case s.out <- message.Message:
+				case <-quit:
+					return
+				}
+			}
+		}
+	}()
+	return
+}
+
+// unsubscribes channel
+func (s *subscriptions) Remove(channel string) (err error) {
+	quit, ok := s.channels[channel]
+	if !ok {
+		return ErrProtocolNotSubscribed
+	}
+	close(quit)
+	delete(s.channels, channel)
+	return
+}
+
+// unsubscribes all channels
+func (s *subscriptions) Close() {
+	for channel, quit := range s.channels {
+		close(quit)
+		delete(s.channels, channel)
+	}
+}
@@ -0,0 +1,30 @@
+package realtime
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/phonkee/patrol/settings"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSubscriptions(t *testing.T) {
+
+	Convey("Test subscriptions limit", t, func() {
+		subs := newSubscriptions(nil)
+		for i := 0; i < settings.REALTIME_MAX_SUBSCRIPTIONS; i++ {
+			subs.channels[strconv.Itoa(i)] = make(chan struct{})
+		}
+
+		// already subscribed channel is ignored
+		So(subs.Add("0"), ShouldBeNil)
+		So(subs.Add("new"), ShouldEqual, ErrProtocolTooManySubs)
+		So(len(subs.channels), ShouldEqual, settings.REALTIME_MAX_SUBSCRIPTIONS)
+
+		So(subs.Remove("0"), ShouldBeNil)
+		So(subs.Remove("0"), ShouldEqual, ErrProtocolNotSubscribed)
+
+		subs.Close()
+		So(len(subs.channels), ShouldEqual, 0)
+	})
+}
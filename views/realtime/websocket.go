@@ -1,6 +1,7 @@
 package realtime
 
 import (
+	"encoding/json"
 	"net/http"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/phonkee/patrol/context"
 	"github.com/phonkee/patrol/models"
 	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/settings"
 	"github.com/phonkee/patrol/views/mixins"
 )
 
@@ -25,9 +27,24 @@ func NewWebsocketAPIView(g func(*models.User, *http.Request) []string) *Websocke
 	}
 }
 
+/*
+WebsocketAPIView
+
+	sends realtime messages published to subscribed channels to websocket.
+	Websocket is subscribed to channels returned by
+	OnRealtimeWebsocketSubscribeTo signal handlers and client can
+	subscribe/unsubscribe projects and eventgroups (see protocol).
+	Messages are distributed by pub/sub backend, so websocket receives
+	messages published by any patrol instance.
+
+	Browsers cannot set Authorization header on websocket, so token can be
+	sent also in query parameter or in auth request. Unauthenticated
+	websockets are closed, so are websockets whose token was revoked or
+	whose user lost access to subscribed channel (checked on every ping).
+*/
 type WebsocketAPIView struct {
 	views.APIView
-	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
 
 	context *context.Context
 
@@ -35,23 +52,22 @@ type WebsocketAPIView struct {
 	getsubs func(*models.User, *http.Request) []string
 
 	user *models.User
+
+	// authenticates user again by token websocket was authenticated with
+	reauth func(*models.User) error
+
+	// projects of channels subscribed by client requests
+	projects map[string]*models.Project
 }
 
 func (v *WebsocketAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
 	v.context = v.GetContext(r)
-
 	v.user = models.NewUser()
-	if e := v.GetAuthUser(v.user, w, r); e == nil {
-		return
-	}
-
+	v.projects = map[string]*models.Project{}
 	return
 }
 
 func (v *WebsocketAPIView) GET(w http.ResponseWriter, r *http.Request) {
-	subs := v.getsubs(v.user, r)
-	glog.V(2).Infof("this is what we will listen to %+v\n", subs)
-
 	var (
 		ws  *websocket.Conn
 		err error
@@ -59,20 +75,251 @@ func (v *WebsocketAPIView) GET(w http.ResponseWriter, r *http.Request) {
 	if ws, err = upgrader.Upgrade(w, r, nil); err != nil {
 		return
 	}
+	defer ws.Close()
+
+	ws.SetReadLimit(settings.REALTIME_MAX_MESSAGE_SIZE)
+
+	if err = v.authenticate(ws, r); err != nil {
+		closePolicyViolation(ws, ErrProtocolUnauthorized)
+		return
+	}
+
+	v.handleWebsocket(ws, r)
+}
+
+/*
+Authenticates user by token from Authorization header, token query parameter
+or auth request (first message)
+*/
+func (v *WebsocketAPIView) authenticate(ws *websocket.Conn, r *http.Request) (err error) {
+	manager := models.NewUserManager(v.context)
+
+	v.reauth = func(user *models.User) error {
+		return manager.GetAuthUser(user, r)
+	}
+	if err = v.reauth(v.user); err != nil {
+		token := r.URL.Query().Get(settings.REALTIME_TOKEN_PARAM_NAME)
+		if token == "" {
+			request := &Request{}
+			ws.SetReadDeadline(time.Now().Add(settings.REALTIME_AUTH_WAIT))
+			if err = ws.ReadJSON(request); err != nil {
+				return
+			}
+			if request.Type != PROTOCOL_AUTH {
+				return ErrProtocolUnauthorized
+			}
+			token = request.Token
+		}
+		v.reauth = func(user *models.User) error {
+			return manager.GetUserByToken(user, token)
+		}
+		err = v.reauth(v.user)
+	}
+
+	if err != nil {
+		return
+	}
+	if !v.user.IsActive {
+		return ErrProtocolUnauthorized
+	}
+
+	return
+}
+
+/*
+Re-validates websocket: token must not be logged out or revoked and user must
+be active. Every subscribed channel must be still returned by subscribe
+signal handlers or user must be still member of project of channel
+subscribed by client request.
+*/
+func (v *WebsocketAPIView) revalidate(subs *subscriptions, r *http.Request) (err error) {
+	user := models.NewUser()
+	if err = v.reauth(user); err != nil || !user.IsActive || user.ID != v.user.ID {
+		return ErrProtocolUnauthorized
+	}
+	v.user = user
+
+	allowed := map[string]bool{}
+	for _, channel := range v.getsubs(user, r) {
+		allowed[channel] = true
+	}
+
+	for channel := range subs.channels {
+		if allowed[channel] {
+			continue
+		}
+		project, ok := v.projects[channel]
+		if !ok {
+			return ErrProtocolForbidden
+		}
+		if _, err = v.ProjectMemberType(v.context, project, user); err != nil {
+			return ErrProtocolForbidden
+		}
+	}
 
-	go v.handleWebsocket(ws)
+	return
 }
 
-func (v *WebsocketAPIView) handleWebsocket(ws *websocket.Conn) {
-	// subscribe on all needed queues
-	// send all messages to client
-	// profit
+/*
+Forwards messages from subscribed channels to websocket and handles client
+requests until client disconnects (or stops answering pings), fails
+re-validation or patrol quits. Websocket is re-validated on every server
+ping and client ping request.
+*/
+func (v *WebsocketAPIView) handleWebsocket(ws *websocket.Conn, r *http.Request) {
+	channels := v.getsubs(v.user, r)
+	glog.V(2).Infof("realtime: websocket of %s subscribes to %v.", v.user, channels)
+
+	subs := newSubscriptions(models.NewRealtimeManager(v.context))
+	defer subs.Close()
+
+	for _, channel := range channels {
+		if err := subs.Add(channel); err != nil {
+			glog.Warningf("realtime: websocket of %s cannot subscribe to %s: %s.", v.user, channel, err)
+			break
+		}
+	}
+
+	// read loop, reads client requests and processes pongs
+	done := make(chan struct{})
+	defer close(done)
+	requests := make(chan *Request)
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		ws.SetReadDeadline(time.Now().Add(settings.REALTIME_PONG_WAIT))
+		ws.SetPongHandler(func(string) error {
+			return ws.SetReadDeadline(time.Now().Add(settings.REALTIME_PONG_WAIT))
+		})
+		for {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			request := &Request{}
+			if err = json.Unmarshal(data, request); err != nil {
+				request.Type = ""
+			}
+			select {
+			case requests <- request:
+			case <-done:
+				return
+			}
+		}
+	}()
 
-	glog.Infof("this is user %+v\n", v.user)
+	ticker := time.NewTicker(settings.REALTIME_PING_PERIOD)
+	defer ticker.Stop()
 
 	for {
-		time.Sleep(time.Second)
-		ws.WriteJSON(v.user)
+		select {
+		case <-closed:
+			return
+		case <-v.context.Quit:
+			ws.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+				time.Now().Add(settings.REALTIME_WRITE_WAIT),
+			)
+			return
+		case <-ticker.C:
+			if err := v.revalidate(subs, r); err != nil {
+				glog.V(2).Infof("realtime: closing websocket of %s: %s.", v.user, err)
+				closePolicyViolation(ws, err)
+				return
+			}
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(settings.REALTIME_WRITE_WAIT)); err != nil {
+				return
+			}
+		case err := <-subs.errs:
+			glog.Errorf("realtime: subscription of %s failed: %s.", v.user, err)
+			return
+		case request := <-requests:
+			if request.Type == PROTOCOL_PING {
+				if err := v.revalidate(subs, r); err != nil {
+					glog.V(2).Infof("realtime: closing websocket of %s: %s.", v.user, err)
+					closePolicyViolation(ws, err)
+					return
+				}
+			}
+			ws.SetWriteDeadline(time.Now().Add(settings.REALTIME_WRITE_WAIT))
+			if err := ws.WriteJSON(v.handleRequest(subs, request)); err != nil {
+				return
+			}
+		case message := <-subs.out:
+			ws.SetWriteDeadline(time.Now().Add(settings.REALTIME_WRITE_WAIT))
+			if err := ws.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		}
 	}
+}
 
+// handles client request and returns ack
+func (v *WebsocketAPIView) handleRequest(subs *subscriptions, request *Request) *Ack {
+	switch request.Type {
+	case PROTOCOL_PING:
+		return NewAck(request, "", nil)
+	case PROTOCOL_SUBSCRIBE, PROTOCOL_UNSUBSCRIBE:
+		channel, project, err := v.requestChannel(request)
+		if err != nil {
+			return NewAck(request, "", err)
+		}
+		if request.Type == PROTOCOL_SUBSCRIBE {
+			if err = subs.Add(channel); err == nil {
+				v.projects[channel] = project
+			}
+		} else {
+			if err = subs.Remove(channel); err == nil {
+				delete(v.projects, channel)
+			}
+		}
+		return NewAck(request, channel, err)
+	}
+	return NewAck(request, "", ErrProtocolInvalidRequest)
+}
+
+/*
+Returns channel and project of project or eventgroup in request. User must
+be member of project (of eventgroup), nonexisting objects are also forbidden
+so clients cannot probe them.
+*/
+func (v *WebsocketAPIView) requestChannel(request *Request) (channel string, project *models.Project, err error) {
+	pm := models.NewProjectManager(v.context)
+	project = pm.NewProject()
+
+	switch {
+	case request.EventGroupID != 0:
+		egm := models.NewEventGroupManager(v.context)
+		eventgroup := egm.NewEventGroup()
+		if err = egm.GetByID(eventgroup, request.EventGroupID); err != nil {
+			return "", nil, ErrProtocolForbidden
+		}
+		if err = pm.GetByID(project, eventgroup.ProjectID); err != nil {
+			return "", nil, ErrProtocolForbidden
+		}
+		channel = models.RealtimeEventGroupChannel(eventgroup.ID)
+	case request.ProjectID != 0:
+		if err = pm.GetByID(project, request.ProjectID); err != nil {
+			return "", nil, ErrProtocolForbidden
+		}
+		channel = models.RealtimeProjectChannel(project.ID)
+	default:
+		return "", nil, ErrProtocolInvalidRequest
+	}
+
+	if _, err = v.ProjectMemberType(v.context, project, v.user); err != nil {
+		return "", nil, ErrProtocolForbidden
+	}
+
+	return
+}
+
+// closes websocket with policy violation and error as reason
+func closePolicyViolation(ws *websocket.Conn, err error) {
+	ws.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()),
+		time.Now().Add(settings.REALTIME_WRITE_WAIT),
+	)
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/phonkee/patrol/rest/response"
 	"github.com/phonkee/patrol/rest/views"
 	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/settings"
 	"github.com/phonkee/patrol/views/mixins"
 )
 
@@ -24,6 +25,7 @@ type TeamListAPIView struct {
 	views.APIView
 
 	// mixins
+	mixins.AuditMixin
 	mixins.AuthUserMixin
 
 	context *context.Context
@@ -39,15 +41,6 @@ func (t *TeamListAPIView) Before(w http.ResponseWriter, r *http.Request) (err er
 		return
 	}
 
-	// check
-	switch r.Method {
-	case "POST":
-		if !t.user.IsSuperuser {
-			response.New(http.StatusForbidden).Write(w, r)
-			return views.ErrBreakRequest
-		}
-	}
-
 	return
 }
 
@@ -58,7 +51,7 @@ func (t *TeamListAPIView) OPTIONS(w http.ResponseWriter, r *http.Request) {
 
 	md := metadata.New("Teams")
 
-	if t.user.IsSuperuser {
+	if t.user.IsSuperuser || t.user.Permissions.Has(settings.PERMISSION_TEAMS_TEAM_ADD) {
 		createAction := md.ActionCreate()
 		createAction.Field("name").SetHelpText("team name").SetRequired(true).SetMax(200).SetMin(5)
 
@@ -112,6 +105,7 @@ func (t *TeamListAPIView) POST(w http.ResponseWriter, r *http.Request) {
 		response.New(http.StatusInternalServerError).Write(w, r)
 		return
 	}
+	t.Audit(r, t.user, models.AUDIT_ACTION_CREATE, nil, team, mixins.AuditTeam(team))
 
 	response.New(http.StatusCreated).Result(team).Write(w, r)
 }
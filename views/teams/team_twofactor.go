@@ -0,0 +1,81 @@
+package teams
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+/*
+	Factory function to create view
+*/
+func NewTeamTwoFactorAPIView() views.Viewer {
+	return &TeamTwoFactorAPIView{
+		team: models.NewTeam(),
+		user: models.NewUser(),
+	}
+}
+
+/*
+	Two-factor authentication requirement of team, only superuser can change
+	it. Members of team must use two-factor authentication to log in.
+*/
+type TeamTwoFactorAPIView struct {
+	views.APIView
+
+	// used mixins
+	mixins.AuditMixin
+	mixins.AuthUserMixin
+	mixins.TeamsTeamMixin
+
+	context *context.Context
+
+	// stored instances
+	team *models.Team
+	user *models.User
+}
+
+func (t *TeamTwoFactorAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	if err = t.GetAuthUser(t.user, w, r); err != nil {
+		return
+	}
+
+	if !t.user.IsSuperuser {
+		response.New(http.StatusForbidden).Write(w, r)
+		return views.ErrForbidden
+	}
+
+	if err = t.GetTeam(t.team, w, r); err != nil {
+		return
+	}
+
+	t.context = t.GetContext(r)
+	return
+}
+
+/*
+	Set whether team requires two-factor authentication
+*/
+func (t *TeamTwoFactorAPIView) PUT(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	serializer := &serializers.TeamsTeamTwoFactorSerializer{}
+	if err = t.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	before := *t.team
+	if err = serializer.Save(t.context, t.team); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+	t.Audit(r, t.user, models.AUDIT_ACTION_UPDATE, &before, t.team, mixins.AuditTeam(t.team))
+
+	response.New(http.StatusOK).Result(t.team).Write(w, r)
+}
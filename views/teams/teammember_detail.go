@@ -29,6 +29,7 @@ type TeamMemberDetailAPIView struct {
 	views.APIView
 
 	// used mixins
+	mixins.AuditMixin
 	mixins.AuthUserMixin
 	mixins.TeamsTeamMixin
 	mixins.TeamsTeamMemberMixin
@@ -110,6 +111,7 @@ func (t *TeamMemberDetailAPIView) DELETE(w http.ResponseWriter, r *http.Request)
 		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
 		return
 	}
+	t.Audit(r, t.user, models.AUDIT_ACTION_DELETE, t.teammember, nil, mixins.AuditTeam(t.team))
 
 	response.New(http.StatusOK).Write(w, r)
 }
@@ -139,10 +141,12 @@ func (t *TeamMemberDetailAPIView) POST(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	before := *t.teammember
 	if err = serializer.Update(t.context, t.teammember); err != nil {
 		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
 		return
 	}
+	t.Audit(r, t.user, models.AUDIT_ACTION_UPDATE, &before, t.teammember, mixins.AuditTeam(t.team))
 
 	response.New(http.StatusOK).Write(w, r)
 }
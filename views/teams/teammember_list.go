@@ -28,6 +28,7 @@ type TeamMemberListAPIView struct {
 	views.APIView
 
 	// userd mixins
+	mixins.AuditMixin
 	mixins.AuthUserMixin
 	mixins.TeamsTeamMixin
 	mixins.TeamsTeamMemberMixin
@@ -125,6 +126,7 @@ func (t *TeamMemberListAPIView) POST(w http.ResponseWriter, r *http.Request) {
 		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
 		return
 	}
+	t.Audit(r, t.user, models.AUDIT_ACTION_CREATE, nil, result, mixins.AuditTeam(t.team))
 
 	response.New(http.StatusCreated).Result(result).Write(w, r)
 }
@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewDeliveryListAPIView() views.Viewer {
+	return &DeliveryListAPIView{
+		project: models.NewProject(),
+		user:    models.NewUser(),
+		webhook: models.NewWebhook(),
+	}
+}
+
+/*
+Recent delivery attempts of webhook (latest first)
+
+	/api/projects/project/{project_id:[0-9]+}/webhook/{webhook_id:[0-9]+}/delivery/
+*/
+type DeliveryListAPIView struct {
+	views.APIView
+
+	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+	mixins.WebhookMixin
+
+	context *context.Context
+
+	project *models.Project
+	user    *models.User
+	webhook *models.Webhook
+}
+
+/*
+Before loads project, checks membership and loads webhook of project
+*/
+func (d *DeliveryListAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	d.context = d.GetContext(r)
+
+	if err = d.GetAuthUser(d.user, w, r); err != nil {
+		return
+	}
+
+	if err = d.GetProject(d.project, w, r); err != nil {
+		return
+	}
+
+	if _, err = d.GetMemberType(d.project, d.user, w, r); err != nil {
+		return
+	}
+
+	if err = d.GetWebhook(d.webhook, d.project, w, r); err != nil {
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve list of deliveries
+*/
+func (d *DeliveryListAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	manager := models.NewWebhookDeliveryManager(d.context)
+	paginator := manager.NewPaginatorFromRequest(r)
+	result := manager.NewWebhookDeliveryList()
+
+	if err := manager.FilterPaged(&result, paginator, manager.QueryFilterWebhook(d.webhook), manager.QueryFilterOrderLatest()); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Paginator(paginator).Result(result).Write(w, r)
+}
@@ -0,0 +1,104 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/views/mixins"
+	"github.com/phonkee/patrol/webhooks"
+)
+
+func NewDeliveryRedeliverAPIView() views.Viewer {
+	return &DeliveryRedeliverAPIView{
+		delivery: models.NewWebhookDelivery(),
+		project:  models.NewProject(),
+		user:     models.NewUser(),
+		webhook:  models.NewWebhook(),
+	}
+}
+
+/*
+Queues delivery again
+
+	/api/projects/project/{project_id:[0-9]+}/webhook/{webhook_id:[0-9]+}/delivery/{delivery_id:[0-9]+}/redeliver
+*/
+type DeliveryRedeliverAPIView struct {
+	views.APIView
+
+	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+	mixins.WebhookMixin
+
+	context *context.Context
+
+	delivery   *models.WebhookDelivery
+	membertype models.MemberType
+	project    *models.Project
+	user       *models.User
+	webhook    *models.Webhook
+}
+
+/*
+Before loads project, checks membership and loads delivery of webhook
+*/
+func (d *DeliveryRedeliverAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	d.context = d.GetContext(r)
+
+	if err = d.GetAuthUser(d.user, w, r); err != nil {
+		return
+	}
+
+	if err = d.GetProject(d.project, w, r); err != nil {
+		return
+	}
+
+	if d.membertype, err = d.GetMemberType(d.project, d.user, w, r); err != nil {
+		return
+	}
+
+	if err = d.GetWebhook(d.webhook, d.project, w, r); err != nil {
+		return
+	}
+
+	var pk types.PrimaryKey
+	if pk, err = rest.GetMuxVarPrimaryKey(r, "delivery_id"); err != nil {
+		err = views.ErrInvalidParam
+		response.New(http.StatusBadRequest).Error(err).Write(w, r)
+		return
+	}
+
+	manager := models.NewWebhookDeliveryManager(d.context)
+	if err = manager.GetByID(d.delivery, pk, manager.QueryFilterWebhook(d.webhook)); err != nil {
+		if err == models.ErrObjectDoesNotExists {
+			response.New(http.StatusNotFound).Write(w, r)
+		} else {
+			response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		}
+		return
+	}
+
+	return
+}
+
+/*
+Redeliver, only project admins can redeliver
+*/
+func (d *DeliveryRedeliverAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	if d.membertype != models.MEMBER_TYPE_ADMIN {
+		response.New(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	if err := webhooks.NewDispatcher(d.context).Redeliver(d.delivery); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusAccepted).Write(w, r)
+}
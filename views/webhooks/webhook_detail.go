@@ -0,0 +1,120 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/rest/response"
+	"github.com/phonkee/patrol/rest/views"
+	"github.com/phonkee/patrol/serializers"
+	"github.com/phonkee/patrol/views/mixins"
+)
+
+func NewWebhookDetailAPIView() views.Viewer {
+	return &WebhookDetailAPIView{
+		project: models.NewProject(),
+		user:    models.NewUser(),
+		webhook: models.NewWebhook(),
+	}
+}
+
+/*
+Webhook detail
+
+	/api/projects/project/{project_id:[0-9]+}/webhook/{webhook_id:[0-9]+}
+*/
+type WebhookDetailAPIView struct {
+	views.APIView
+
+	mixins.AuthUserMixin
+	mixins.ProjectMemberTypeMixin
+	mixins.ProjectsProjectMixin
+	mixins.WebhookMixin
+
+	context *context.Context
+
+	membertype models.MemberType
+	project    *models.Project
+	user       *models.User
+	webhook    *models.Webhook
+}
+
+/*
+Before loads project, checks membership and loads webhook of project
+*/
+func (a *WebhookDetailAPIView) Before(w http.ResponseWriter, r *http.Request) (err error) {
+	a.context = a.GetContext(r)
+
+	if err = a.GetAuthUser(a.user, w, r); err != nil {
+		return
+	}
+
+	if err = a.GetProject(a.project, w, r); err != nil {
+		return
+	}
+
+	if a.membertype, err = a.GetMemberType(a.project, a.user, w, r); err != nil {
+		return
+	}
+
+	if err = a.GetWebhook(a.webhook, a.project, w, r); err != nil {
+		return
+	}
+
+	return
+}
+
+/*
+Retrieve webhook
+*/
+func (a *WebhookDetailAPIView) GET(w http.ResponseWriter, r *http.Request) {
+	response.New(http.StatusOK).Result(a.webhook).Write(w, r)
+}
+
+/*
+Update webhook, only project admins can update webhooks
+*/
+func (a *WebhookDetailAPIView) POST(w http.ResponseWriter, r *http.Request) {
+	var err error
+
+	if a.membertype != models.MEMBER_TYPE_ADMIN {
+		response.New(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	serializer := &serializers.WebhooksWebhookSerializer{}
+	if err = a.context.Bind(serializer); err != nil {
+		response.New(http.StatusBadRequest).Write(w, r)
+		return
+	}
+
+	if vr := serializer.Validate(a.context); !vr.IsValid() {
+		response.New(http.StatusBadRequest).Error(vr).Write(w, r)
+		return
+	}
+
+	if err = serializer.Save(a.context, a.webhook); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Result(a.webhook).Write(w, r)
+}
+
+/*
+Delete webhook, only project admins can delete webhooks
+*/
+func (a *WebhookDetailAPIView) DELETE(w http.ResponseWriter, r *http.Request) {
+	if a.membertype != models.MEMBER_TYPE_ADMIN {
+		response.New(http.StatusForbidden).Write(w, r)
+		return
+	}
+
+	if err := a.webhook.Delete(a.context); err != nil {
+		response.New(http.StatusInternalServerError).Error(err).Write(w, r)
+		return
+	}
+
+	response.New(http.StatusOK).Write(w, r)
+}
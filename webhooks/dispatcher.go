@@ -0,0 +1,277 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/phonkee/ergoq"
+	"github.com/phonkee/patrol/context"
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/types"
+	"github.com/phonkee/patrol/utils"
+)
+
+var (
+	ErrTaskNotDue = errors.New("webhook task is not due yet")
+)
+
+/*
+Payload is posted as JSON to webhook url
+*/
+type Payload struct {
+	ID         string             `json:"id"`
+	Event      string             `json:"event"`
+	Timestamp  time.Time          `json:"timestamp"`
+	ProjectID  types.ForeignKey   `json:"project_id"`
+	EventGroup *models.EventGroup `json:"eventgroup,omitempty"`
+	Data       *models.Event      `json:"data,omitempty"`
+}
+
+/*
+Task is single delivery attempt stored in message queue. Tasks with URL are
+posted to personal webhooks of users, they are not signed and deliveries
+are not stored.
+*/
+type Task struct {
+	GUID      string           `json:"guid"`
+	WebhookID types.ForeignKey `json:"webhook_id"`
+	URL       string           `json:"url,omitempty"`
+	EventType string           `json:"event_type"`
+	Payload   string           `json:"payload"`
+	Attempt   int              `json:"attempt"`
+	NotBefore time.Time        `json:"not_before"`
+}
+
+// returns whether task should be delivered now
+func (t *Task) IsDue() bool {
+	return !time.Now().Before(t.NotBefore)
+}
+
+/*
+Returns delay before given attempt, first retry waits base delay and every
+other retry doubles it.
+*/
+func Backoff(attempt int) time.Duration {
+	if attempt <= 1 {
+		return 0
+	}
+	return settings.WEBHOOK_RETRY_BASE_DELAY << uint(attempt-2)
+}
+
+// returns new delivery guid
+func NewGUID() string {
+	return utils.NewUUID()
+}
+
+/*
+Dispatcher queues and delivers webhooks
+*/
+type Dispatcher struct {
+	context *context.Context
+	client  *http.Client
+}
+
+func NewDispatcher(context *context.Context, funcs ...func(*Dispatcher)) (dispatcher *Dispatcher) {
+	dispatcher = &Dispatcher{
+		context: context,
+		client:  utils.NewPublicHTTPClient(settings.WEBHOOK_REQUEST_TIMEOUT),
+	}
+	for _, f := range funcs {
+		f(dispatcher)
+	}
+	return
+}
+
+/*
+Queues delivery of event type to all webhooks of project subscribed to it.
+*/
+func (d *Dispatcher) Dispatch(projectID types.ForeignKey, eventType string, eventgroup *models.EventGroup, event *models.Event) (err error) {
+	manager := models.NewWebhookManager(d.context)
+	webhooks := manager.NewWebhookList()
+	if err = manager.FilterSubscribed(&webhooks, projectID, eventType); err != nil {
+		return
+	}
+
+	for _, webhook := range webhooks {
+		payload := &Payload{
+			ID:         NewGUID(),
+			Event:      eventType,
+			Timestamp:  utils.NowTruncated(),
+			ProjectID:  projectID,
+			EventGroup: eventgroup,
+			Data:       event,
+		}
+
+		var body []byte
+		if body, err = json.Marshal(payload); err != nil {
+			return
+		}
+
+		if err = d.Push(&Task{
+			GUID:      payload.ID,
+			WebhookID: webhook.ID.ToForeignKey(),
+			EventType: eventType,
+			Payload:   string(body),
+			Attempt:   1,
+		}); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+/*
+Queues delivery of payload to url (personal webhook of user)
+*/
+func (d *Dispatcher) DispatchURL(url string, eventType string, payload interface{}) (err error) {
+	var body []byte
+	if body, err = json.Marshal(payload); err != nil {
+		return
+	}
+	return d.Push(&Task{
+		GUID:      NewGUID(),
+		URL:       url,
+		EventType: eventType,
+		Payload:   string(body),
+		Attempt:   1,
+	})
+}
+
+/*
+Queues stored delivery again, redelivery keeps guid and starts attempts from
+beginning.
+*/
+func (d *Dispatcher) Redeliver(delivery *models.WebhookDelivery) error {
+	return d.Push(&Task{
+		GUID:      delivery.GUID,
+		WebhookID: delivery.WebhookID,
+		EventType: delivery.EventType,
+		Payload:   delivery.Payload,
+		Attempt:   1,
+	})
+}
+
+// pushes task to message queue
+func (d *Dispatcher) Push(task *Task) (err error) {
+	var body []byte
+	if body, err = json.Marshal(task); err != nil {
+		return
+	}
+	return d.context.Queue.Push(settings.WEBHOOK_QUEUE_ID, body)
+}
+
+// pops task from message queue, message must be acked by caller
+func (d *Dispatcher) Pop() (task *Task, message ergoq.QueueMessage, err error) {
+	if message, err = d.context.Queue.Pop(settings.WEBHOOK_QUEUE_ID); err != nil {
+		return
+	}
+	task = &Task{}
+	err = json.Unmarshal(message.Message(), task)
+	return
+}
+
+/*
+Delivers task, attempt is stored. Failed attempts are scheduled for retry
+until WEBHOOK_MAX_ATTEMPTS is reached. Tasks of removed or disabled webhooks
+are dropped.
+*/
+func (d *Dispatcher) Deliver(task *Task) (err error) {
+	if !task.IsDue() {
+		return ErrTaskNotDue
+	}
+
+	if task.URL != "" {
+		return d.deliverURL(task)
+	}
+
+	webhook := models.NewWebhook()
+	if err = models.NewWebhookManager(d.context).GetByID(webhook, task.WebhookID); err != nil {
+		if err == models.ErrObjectDoesNotExists {
+			glog.V(2).Infof("webhooks: webhook %d removed, delivery %s dropped.", task.WebhookID, task.GUID)
+			return nil
+		}
+		return
+	}
+	if !webhook.Enabled {
+		return nil
+	}
+
+	delivery := d.Send(webhook, task)
+	if err = delivery.Insert(d.context); err != nil {
+		return
+	}
+
+	if delivery.Success || task.Attempt >= settings.WEBHOOK_MAX_ATTEMPTS {
+		return
+	}
+
+	// schedule retry
+	task.Attempt++
+	task.NotBefore = time.Now().Add(Backoff(task.Attempt))
+	return d.Push(task)
+}
+
+// delivers task to personal webhook, failed attempts are retried
+func (d *Dispatcher) deliverURL(task *Task) (err error) {
+	delivery := d.Send(&models.Webhook{URL: task.URL}, task)
+	if delivery.Success || task.Attempt >= settings.WEBHOOK_MAX_ATTEMPTS {
+		return
+	}
+
+	glog.V(2).Infof("webhooks: delivery %s to %s failed (attempt %d).", task.GUID, task.URL, task.Attempt)
+	task.Attempt++
+	task.NotBefore = time.Now().Add(Backoff(task.Attempt))
+	return d.Push(task)
+}
+
+/*
+Posts signed payload to webhook url and returns delivery with result (not
+stored).
+*/
+func (d *Dispatcher) Send(webhook *models.Webhook, task *Task) (delivery *models.WebhookDelivery) {
+	delivery = models.NewWebhookDelivery(func(wd *models.WebhookDelivery) {
+		wd.WebhookID = webhook.ID.ToForeignKey()
+		wd.GUID = task.GUID
+		wd.EventType = task.EventType
+		wd.Payload = task.Payload
+		wd.Attempt = task.Attempt
+	})
+
+	body := []byte(task.Payload)
+	request, err := http.NewRequest(settings.HTTP_POST, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "patrol-webhooks/"+settings.VERSION)
+	request.Header.Set(settings.WEBHOOK_EVENT_HEADER_NAME, task.EventType)
+	request.Header.Set(settings.WEBHOOK_DELIVERY_HEADER_NAME, task.GUID)
+	if webhook.Secret != "" {
+		request.Header.Set(settings.WEBHOOK_SIGNATURE_HEADER_NAME, Sign(webhook.Secret, body))
+	}
+
+	start := time.Now()
+	response, err := d.client.Do(request)
+	delivery.Duration = int(time.Since(start) / time.Millisecond)
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	defer response.Body.Close()
+
+	responseBody, _ := ioutil.ReadAll(io.LimitReader(response.Body, models.MAX_WEBHOOK_DELIVERY_RESPONSE_LENGTH))
+	delivery.StatusCode = response.StatusCode
+	delivery.Response = string(responseBody)
+	delivery.Success = response.StatusCode >= 200 && response.StatusCode < 300
+
+	return
+}
@@ -0,0 +1,116 @@
+package webhooks
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/phonkee/patrol/models"
+	"github.com/phonkee/patrol/settings"
+	"github.com/phonkee/patrol/utils"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// test servers listen on loopback, which default client refuses
+func withClient(client *http.Client) func(*Dispatcher) {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+
+	Convey("Test sign and verify", t, func() {
+		body := []byte(`{"event":"event.created"}`)
+		signature := Sign("secret", body)
+		So(signature, ShouldStartWith, SIGNATURE_PREFIX)
+		So(Verify("secret", body, signature), ShouldBeTrue)
+		So(Verify("other", body, signature), ShouldBeFalse)
+		So(Verify("secret", []byte(`{}`), signature), ShouldBeFalse)
+	})
+
+	Convey("Test backoff", t, func() {
+		So(Backoff(1), ShouldEqual, 0)
+		So(Backoff(2), ShouldEqual, settings.WEBHOOK_RETRY_BASE_DELAY)
+		So(Backoff(3), ShouldEqual, 2*settings.WEBHOOK_RETRY_BASE_DELAY)
+		So(Backoff(5), ShouldEqual, 8*settings.WEBHOOK_RETRY_BASE_DELAY)
+	})
+
+	Convey("Test task is due", t, func() {
+		So((&Task{}).IsDue(), ShouldBeTrue)
+		So((&Task{NotBefore: time.Now().Add(time.Minute)}).IsDue(), ShouldBeFalse)
+	})
+
+	Convey("Test send signed payload", t, func() {
+		received := make(chan *http.Request, 1)
+		bodies := make(chan []byte, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			received <- r
+			bodies <- body
+			w.Write([]byte("ok"))
+		}))
+		defer server.Close()
+
+		webhook := models.NewWebhook(func(wh *models.Webhook) {
+			wh.ID = 1
+			wh.URL = server.URL
+			wh.Secret = "secret"
+		})
+		task := &Task{GUID: NewGUID(), EventType: models.WEBHOOK_EVENT_EVENT_CREATED, Payload: `{"id":1}`, Attempt: 2}
+
+		delivery := NewDispatcher(nil, withClient(server.Client())).Send(webhook, task)
+		So(delivery.Success, ShouldBeTrue)
+		So(delivery.StatusCode, ShouldEqual, http.StatusOK)
+		So(delivery.Response, ShouldEqual, "ok")
+		So(delivery.Attempt, ShouldEqual, 2)
+		So(delivery.GUID, ShouldEqual, task.GUID)
+
+		r := <-received
+		body := <-bodies
+		So(string(body), ShouldEqual, task.Payload)
+		So(r.Header.Get(settings.WEBHOOK_EVENT_HEADER_NAME), ShouldEqual, models.WEBHOOK_EVENT_EVENT_CREATED)
+		So(r.Header.Get(settings.WEBHOOK_DELIVERY_HEADER_NAME), ShouldEqual, task.GUID)
+		So(Verify("secret", body, r.Header.Get(settings.WEBHOOK_SIGNATURE_HEADER_NAME)), ShouldBeTrue)
+	})
+
+	Convey("Test send failed", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+
+		webhook := models.NewWebhook(func(wh *models.Webhook) {
+			wh.URL = server.URL
+		})
+		task := &Task{GUID: NewGUID(), Payload: `{}`, Attempt: 1}
+
+		dispatcher := NewDispatcher(nil, withClient(server.Client()))
+		delivery := dispatcher.Send(webhook, task)
+		So(delivery.Success, ShouldBeFalse)
+		So(delivery.StatusCode, ShouldEqual, http.StatusInternalServerError)
+
+		// unreachable server
+		server.Close()
+		delivery = dispatcher.Send(webhook, task)
+		So(delivery.Success, ShouldBeFalse)
+		So(delivery.Error, ShouldNotBeEmpty)
+	})
+
+	Convey("Test send to internal address is refused", t, func() {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+		}))
+		defer server.Close()
+
+		webhook := &models.Webhook{URL: server.URL}
+		task := &Task{GUID: NewGUID(), Payload: `{}`, Attempt: 1}
+
+		delivery := NewDispatcher(nil).Send(webhook, task)
+		So(delivery.Success, ShouldBeFalse)
+		So(delivery.Error, ShouldContainSubstring, utils.ErrAddressNotPublic.Error())
+		So(requests, ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,22 @@
+/*
+Package webhooks delivers project events to external urls.
+
+Every webhook delivery is pushed to message queue and delivered by webhook
+worker (patrol webhooks:worker). Failed deliveries are retried with
+exponential backoff and every attempt is stored as WebhookDelivery.
+Personal webhooks of users (notifications) use the same queue and worker,
+their attempts are not stored.
+
+Webhooks are never delivered to loopback, link-local or private addresses,
+url is checked when webhook is saved and address again when connecting.
+
+Payloads are signed with webhook secret, receiver should verify signature
+header before trusting payload, e.g.
+
+	body, _ := ioutil.ReadAll(r.Body)
+	if !webhooks.Verify(secret, body, r.Header.Get("X-Patrol-Signature")) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+*/
+package webhooks
@@ -0,0 +1,28 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	// prefix of signature that identifies hash function
+	SIGNATURE_PREFIX = "sha256="
+)
+
+/*
+Returns HMAC-SHA256 signature of body in form "sha256=<hex digest>"
+*/
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return SIGNATURE_PREFIX + hex.EncodeToString(mac.Sum(nil))
+}
+
+/*
+Returns whether signature of body is valid (constant time comparison)
+*/
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}